@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/G-Research/unicorn-history-server/internal/config"
+	"github.com/G-Research/unicorn-history-server/internal/database/postgres"
+	"github.com/G-Research/unicorn-history-server/internal/database/repository"
+	"github.com/G-Research/unicorn-history-server/internal/log"
+	"github.com/G-Research/unicorn-history-server/internal/rollup"
+)
+
+// RollupFrom is the start of the backfill of the rollups, in RFC 3339 format or as a date
+var RollupFrom string
+
+// rollupCmd represents the rollup command which is used to compute the hourly and daily rollups once
+var rollupCmd = &cobra.Command{
+	Use:   "rollup",
+	Short: "Compute the hourly and daily rollups.",
+	Long: `Compute the buckets of the hourly and daily rollups which ended since their watermarks,
+or backfill them from a point in time, including the buckets which were already computed.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.New(ConfigFile)
+		if err != nil {
+			return err
+		}
+
+		log.Init(&cfg.LogConfig)
+
+		var from time.Time
+		if RollupFrom != "" {
+			from, err = parseRollupFrom(RollupFrom)
+			if err != nil {
+				return err
+			}
+		}
+
+		ctx := context.Background()
+		pool, err := postgres.NewConnectionPool(ctx, &cfg.PostgresConfig)
+		if err != nil {
+			return fmt.Errorf("cannot parse Postgres connection config: %w", err)
+		}
+		defer pool.Close()
+		repo, err := repository.NewPostgresRepository(pool)
+		if err != nil {
+			return err
+		}
+
+		service := rollup.NewService(repo, cfg.RollupConfig)
+		if from.IsZero() {
+			return service.Update(ctx)
+		}
+		return service.Backfill(ctx, from)
+	},
+}
+
+// parseRollupFrom parses the start of a backfill, either in RFC 3339 format or as a date in UTC.
+func parseRollupFrom(from string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, from); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, from)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid start of the backfill %q: must be in RFC 3339 format or a date", from)
+	}
+	return t, nil
+}
+
+func newRollupCmd() *cobra.Command {
+	rollupCmd.Flags().StringVar(
+		&RollupFrom,
+		"from",
+		RollupFrom,
+		"backfill the rollups from this point in time, e.g. 2024-12-01 or 2024-12-01T00:00:00Z",
+	)
+	return rollupCmd
+}
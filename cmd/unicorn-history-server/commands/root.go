@@ -15,6 +15,8 @@ import (
 	"github.com/G-Research/unicorn-history-server/internal/database/repository"
 	"github.com/G-Research/unicorn-history-server/internal/health"
 	"github.com/G-Research/unicorn-history-server/internal/log"
+	"github.com/G-Research/unicorn-history-server/internal/retention"
+	"github.com/G-Research/unicorn-history-server/internal/rollup"
 	"github.com/G-Research/unicorn-history-server/internal/webservice"
 	"github.com/G-Research/unicorn-history-server/internal/yunikorn"
 )
@@ -70,9 +72,29 @@ func Run(ctx context.Context, cfg *config.Config) error {
 		func(err error) {},
 	)
 
+	if cfg.RollupConfig.Enabled {
+		rollupService := rollup.NewService(mainRepository, cfg.RollupConfig)
+		g.Add(
+			func() error {
+				return rollupService.Run(ctx)
+			},
+			func(err error) {},
+		)
+	}
+
+	if cfg.EventsConfig.Retention > 0 {
+		retentionService := retention.NewService(mainRepository, cfg.EventsConfig)
+		g.Add(
+			func() error {
+				return retentionService.Run(ctx)
+			},
+			func(err error) {},
+		)
+	}
+
 	healthService := health.New(info.Version, health.NewYunikornComponent(client), health.NewPostgresComponent(pool))
 
-	ws := webservice.NewWebService(cfg.UHSConfig, mainRepository, eventRepository, healthService)
+	ws := webservice.NewWebService(cfg.UHSConfig, cfg.ReportsConfig, mainRepository, eventRepository, healthService)
 	g.Add(
 		func() error {
 			return ws.Start(ctx)
@@ -92,5 +114,6 @@ func Run(ctx context.Context, cfg *config.Config) error {
 func New() *cobra.Command {
 	rootCmd.PersistentFlags().StringVarP(&ConfigFile, "config", "c", ConfigFile, "path to the configuration file")
 	rootCmd.AddCommand(newMigrateCmd())
+	rootCmd.AddCommand(newRollupCmd())
 	return rootCmd
 }
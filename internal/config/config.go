@@ -22,6 +22,12 @@ type Config struct {
 	YunikornConfig YunikornConfig
 	// LogConfig specifies the configuration for the logger.
 	LogConfig LogConfig
+	// ReportsConfig specifies the configuration for the usage reports.
+	ReportsConfig ReportsConfig
+	// RollupConfig specifies the configuration for the hourly and daily rollups.
+	RollupConfig RollupConfig
+	// EventsConfig specifies the configuration for the stored events.
+	EventsConfig EventsConfig
 }
 
 type UHSConfig struct {
@@ -111,6 +117,96 @@ func (c *YunikornConfig) Validate() error {
 	return nil
 }
 
+// ReportsConfig specifies the configuration for the usage reports.
+type ReportsConfig struct {
+	// Currency is the currency of the prices, e.g. USD.
+	Currency string
+	// VcoreHourPrice is the price of using one vcore for one hour.
+	VcoreHourPrice float64
+	// MemoryGiBHourPrice is the price of using one GiB of memory for one hour.
+	MemoryGiBHourPrice float64
+	// GPUHourPrice is the price of using one GPU for one hour.
+	GPUHourPrice float64
+	// GPUResource is the name of the resource which counts the GPUs of an allocation, e.g. nvidia.com/gpu.
+	GPUResource string
+}
+
+func (c *ReportsConfig) Validate() error {
+	var errorMessages []string
+	if c.VcoreHourPrice < 0 {
+		errorMessages = append(errorMessages, "vcore hour price must not be negative")
+	}
+	if c.MemoryGiBHourPrice < 0 {
+		errorMessages = append(errorMessages, "memory GiB hour price must not be negative")
+	}
+	if c.GPUHourPrice < 0 {
+		errorMessages = append(errorMessages, "GPU hour price must not be negative")
+	}
+	if len(errorMessages) > 0 {
+		return fmt.Errorf("reports config validation errors: %v", errorMessages)
+	}
+	return nil
+}
+
+// RollupConfig specifies the configuration for the hourly and daily rollups of the applications and the nodes.
+type RollupConfig struct {
+	// Enabled indicates whether the server computes the rollups in the background.
+	// The rollup command computes them regardless.
+	Enabled bool
+	// Interval specifies the interval at which the rollups are computed.
+	Interval time.Duration
+	// Lag specifies how long after its end a bucket is computed, so that late data is included.
+	Lag time.Duration
+	// Backfill specifies the period before now from which a rollup which was never computed starts.
+	Backfill time.Duration
+}
+
+func (c *RollupConfig) Validate() error {
+	var errorMessages []string
+	if c.Interval <= 0 {
+		errorMessages = append(errorMessages, "rollup interval must be positive")
+	}
+	if c.Lag < 0 {
+		errorMessages = append(errorMessages, "rollup lag must not be negative")
+	}
+	if c.Backfill < 0 {
+		errorMessages = append(errorMessages, "rollup backfill must not be negative")
+	}
+	if len(errorMessages) > 0 {
+		return fmt.Errorf("rollup config validation errors: %v", errorMessages)
+	}
+	return nil
+}
+
+// EventsConfig specifies the configuration for the events of the event stream, which are stored in the database.
+type EventsConfig struct {
+	// Retention specifies how long the events are kept. Older events are deleted periodically.
+	// A retention of 0 keeps the events forever, which is the default.
+	//
+	// The removal times of the allocations and the failure messages of the applications are read from the events,
+	// so the reports about periods older than the retention degrade once their events are deleted: the usage
+	// reports, the efficiency reports, the container timelines and the running applications of the node timelines
+	// count the allocations as running until their applications ended, and the diagnostics lose the messages.
+	// Recomputing the rollups of such periods overwrites them with the degraded values.
+	Retention time.Duration
+	// CleanupInterval specifies the interval at which the events older than the retention are deleted.
+	CleanupInterval time.Duration
+}
+
+func (c *EventsConfig) Validate() error {
+	var errorMessages []string
+	if c.Retention < 0 {
+		errorMessages = append(errorMessages, "events retention must not be negative")
+	}
+	if c.CleanupInterval <= 0 {
+		errorMessages = append(errorMessages, "events cleanup interval must be positive")
+	}
+	if len(errorMessages) > 0 {
+		return fmt.Errorf("events config validation errors: %v", errorMessages)
+	}
+	return nil
+}
+
 type LogConfig struct {
 	LogLevel   string
 	JSONFormat bool
@@ -173,11 +269,59 @@ func New(path string) (*Config, error) {
 		PoolMinConns:        k.Int("db_pool_min_conns"),
 	}
 
+	gpuResource := k.String("reports_gpu_resource")
+	if gpuResource == "" {
+		gpuResource = "nvidia.com/gpu"
+	}
+	reportsConfig := ReportsConfig{
+		Currency:           k.String("reports_currency"),
+		VcoreHourPrice:     k.Float64("reports_prices_vcore_hour"),
+		MemoryGiBHourPrice: k.Float64("reports_prices_memory_gib_hour"),
+		GPUHourPrice:       k.Float64("reports_prices_gpu_hour"),
+		GPUResource:        gpuResource,
+	}
+	if err := reportsConfig.Validate(); err != nil {
+		return nil, err
+	}
+
+	rollupConfig := RollupConfig{
+		Enabled:  !k.Exists("rollup_enabled") || k.Bool("rollup_enabled"),
+		Interval: k.Duration("rollup_interval"),
+		Lag:      k.Duration("rollup_lag"),
+		Backfill: k.Duration("rollup_backfill"),
+	}
+	if rollupConfig.Interval == 0 {
+		rollupConfig.Interval = 15 * time.Minute
+	}
+	if !k.Exists("rollup_lag") {
+		rollupConfig.Lag = 15 * time.Minute
+	}
+	if !k.Exists("rollup_backfill") {
+		rollupConfig.Backfill = 30 * 24 * time.Hour
+	}
+	if err := rollupConfig.Validate(); err != nil {
+		return nil, err
+	}
+
+	eventsConfig := EventsConfig{
+		Retention:       k.Duration("events_retention"),
+		CleanupInterval: k.Duration("events_cleanup_interval"),
+	}
+	if eventsConfig.CleanupInterval == 0 {
+		eventsConfig.CleanupInterval = time.Hour
+	}
+	if err := eventsConfig.Validate(); err != nil {
+		return nil, err
+	}
+
 	config := &Config{
 		UHSConfig:      uhsConfig,
 		YunikornConfig: yunikornConfig,
 		PostgresConfig: postgresConfig,
 		LogConfig:      logConfig,
+		ReportsConfig:  reportsConfig,
+		RollupConfig:   rollupConfig,
+		EventsConfig:   eventsConfig,
 	}
 	return config, nil
 }
@@ -60,6 +60,23 @@ func TestNew(t *testing.T) {
 					PoolMinConns:        1,
 					SSLMode:             "disable",
 				},
+				ReportsConfig: ReportsConfig{
+					Currency:           "USD",
+					VcoreHourPrice:     0.04,
+					MemoryGiBHourPrice: 0.005,
+					GPUHourPrice:       2.5,
+					GPUResource:        "nvidia.com/gpu",
+				},
+				RollupConfig: RollupConfig{
+					Enabled:  true,
+					Interval: 30 * time.Minute,
+					Lag:      15 * time.Minute,
+					Backfill: 30 * 24 * time.Hour,
+				},
+				EventsConfig: EventsConfig{
+					Retention:       7 * 24 * time.Hour,
+					CleanupInterval: time.Hour,
+				},
 			},
 			wantErr: false,
 		},
@@ -196,6 +213,107 @@ func TestPostgresConfigValidate(t *testing.T) {
 	}
 }
 
+func TestReportsConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  ReportsConfig
+		wantErr bool
+	}{
+		{
+			name:    "valid config",
+			config:  ReportsConfig{VcoreHourPrice: 0.04, MemoryGiBHourPrice: 0.005},
+			wantErr: false,
+		},
+		{
+			name:    "valid config - no prices",
+			config:  ReportsConfig{},
+			wantErr: false,
+		},
+		{
+			name:    "invalid config - negative price",
+			config:  ReportsConfig{GPUHourPrice: -1},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("ReportsConfig.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRollupConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  RollupConfig
+		wantErr bool
+	}{
+		{
+			name:    "valid config",
+			config:  RollupConfig{Enabled: true, Interval: 15 * time.Minute, Lag: 15 * time.Minute, Backfill: 24 * time.Hour},
+			wantErr: false,
+		},
+		{
+			name:    "invalid config - missing interval",
+			config:  RollupConfig{Lag: 15 * time.Minute},
+			wantErr: true,
+		},
+		{
+			name:    "invalid config - negative lag",
+			config:  RollupConfig{Interval: 15 * time.Minute, Lag: -time.Minute},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("RollupConfig.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEventsConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  EventsConfig
+		wantErr bool
+	}{
+		{
+			name:    "valid config",
+			config:  EventsConfig{Retention: 24 * time.Hour, CleanupInterval: time.Hour},
+			wantErr: false,
+		},
+		{
+			name:    "valid config - events kept forever",
+			config:  EventsConfig{CleanupInterval: time.Hour},
+			wantErr: false,
+		},
+		{
+			name:    "invalid config - negative retention",
+			config:  EventsConfig{Retention: -time.Hour, CleanupInterval: time.Hour},
+			wantErr: true,
+		},
+		{
+			name:    "invalid config - missing cleanup interval",
+			config:  EventsConfig{Retention: 24 * time.Hour},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("EventsConfig.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestYunikornConfigValidate(t *testing.T) {
 	tests := []struct {
 		name    string
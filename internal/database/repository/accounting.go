@@ -0,0 +1,248 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/G-Research/unicorn-history-server/internal/database/sql"
+	"github.com/G-Research/unicorn-history-server/internal/model"
+)
+
+type AccountingFilters struct {
+	FinishedStartTime *time.Time
+	FinishedEndTime   *time.Time
+	User              *string
+	Groups            []string
+	States            []string
+	// Queue is the full path of the queue (e.g. 'root.default').
+	Queue *string
+	// IncludeSubqueues also selects the records of the queues below Queue.
+	IncludeSubqueues bool
+	Partition        *string
+	// Sort are the keys by which the records are sorted, see AccountingSortFields.
+	Sort []SortKey
+	// After is the position after which the returned records start.
+	After  Cursor
+	Offset *int
+	Limit  *int
+}
+
+// accountingColumns are the columns of the accounting_records table in the order in which scanAccountingRecord scans them.
+var accountingColumns = []string{
+	"id",
+	"app_id",
+	"partition_id",
+	"partition",
+	"queue_id",
+	"queue_name",
+	`"user"`,
+	"groups",
+	"state",
+	"rejected_message",
+	"submission_time",
+	"start_time",
+	"finished_time",
+	"wait_time",
+	"run_time",
+	"peak_resource",
+	"total_resource",
+	"allocations",
+	"preemptions",
+	"recorded_at_nano",
+}
+
+func scanAccountingRecord(row pgx.Row, record *model.AccountingRecord) error {
+	var user, rejectedMessage *string
+	err := row.Scan(
+		&record.ID,
+		&record.ApplicationID,
+		&record.PartitionID,
+		&record.Partition,
+		&record.QueueID,
+		&record.QueueName,
+		&user,
+		&record.Groups,
+		&record.State,
+		&rejectedMessage,
+		&record.SubmissionTime,
+		&record.StartTime,
+		&record.FinishedTime,
+		&record.WaitTime,
+		&record.RunTime,
+		&record.PeakResource,
+		&record.TotalResource,
+		&record.Allocations,
+		&record.Preemptions,
+		&record.RecordedAtNano,
+	)
+	record.User = valueOrZero(user)
+	record.RejectedMessage = valueOrZero(rejectedMessage)
+	return err
+}
+
+// AccountingSortFields are the fields by which accounting records can be sorted.
+var AccountingSortFields = &SortFields[model.AccountingRecord]{
+	fields: map[string]sortField[model.AccountingRecord]{
+		"id":             {"id", func(r *model.AccountingRecord) any { return r.ID }},
+		"applicationId":  {"app_id", func(r *model.AccountingRecord) any { return r.ApplicationID }},
+		"submissionTime": {"submission_time", func(r *model.AccountingRecord) any { return r.SubmissionTime }},
+		"finishedTime":   {"finished_time", func(r *model.AccountingRecord) any { return r.FinishedTime }},
+		"waitTime":       {"wait_time", func(r *model.AccountingRecord) any { return r.WaitTime }},
+		"runTime":        {"run_time", func(r *model.AccountingRecord) any { return r.RunTime }},
+		"user":           {`COALESCE("user", '')`, func(r *model.AccountingRecord) any { return r.User }},
+		"queueName":      {"queue_name", func(r *model.AccountingRecord) any { return r.QueueName }},
+		"state":          {"state", func(r *model.AccountingRecord) any { return r.State }},
+		"preemptions":    {"preemptions", func(r *model.AccountingRecord) any { return r.Preemptions }},
+	},
+	resources: map[string]resourceSortField[model.AccountingRecord]{
+		"peakResource":  {"peak_resource", func(r *model.AccountingRecord) map[string]int64 { return r.PeakResource }},
+		"totalResource": {"total_resource", func(r *model.AccountingRecord) map[string]int64 { return r.TotalResource }},
+	},
+	defaultSort: []SortKey{{Field: "finishedTime", Descending: true}},
+	id:          "id",
+}
+
+// AccountingCursor returns the cursor which points to the position after the record in the records matching the filters.
+func (f AccountingFilters) AccountingCursor(record *model.AccountingRecord) Cursor {
+	return AccountingSortFields.Cursor(f.Sort, record)
+}
+
+// applyAccountingFilters adds accounting record filters to the sql query.
+func applyAccountingFilters(builder *sql.Builder, filters AccountingFilters) {
+	if filters.FinishedStartTime != nil {
+		builder.Where(sql.Cmp("finished_time", sql.OpGe, filters.FinishedStartTime.UnixMilli()))
+	}
+	if filters.FinishedEndTime != nil {
+		builder.Where(sql.Cmp("finished_time", sql.OpLe, filters.FinishedEndTime.UnixMilli()))
+	}
+	if filters.User != nil {
+		builder.Where(sql.Eq(`"user"`, *filters.User))
+	}
+	if len(filters.Groups) > 0 {
+		builder.Where(sql.Overlaps("groups", filters.Groups))
+	}
+	if len(filters.States) > 0 {
+		builder.Where(sql.In("state", filters.States))
+	}
+	if filters.Queue != nil {
+		if filters.IncludeSubqueues {
+			builder.Where(sql.Or(
+				sql.Eq("queue_name", *filters.Queue),
+				sql.Cmp("queue_name", sql.OpLike, escapeLike(*filters.Queue)+".%"),
+			))
+		} else {
+			builder.Where(sql.Eq("queue_name", *filters.Queue))
+		}
+	}
+	if filters.Partition != nil {
+		builder.Where(sql.Eq("partition", *filters.Partition))
+	}
+	applyPagination(builder, filters.After, filters.Limit, filters.Offset)
+}
+
+func accountingQuery(filters AccountingFilters) (*sql.Builder, error) {
+	queryBuilder := sql.NewBuilder().Select(accountingColumns...).From("accounting_records", "")
+	if err := AccountingSortFields.apply(queryBuilder, filters.Sort); err != nil {
+		return nil, err
+	}
+	applyAccountingFilters(queryBuilder, filters)
+	return queryBuilder, nil
+}
+
+// InsertAccountingRecord stores the accounting record of an application, unless the application was already recorded.
+// The preemptions of the record are the preempted allocations of the record or the preemption events
+// of the application, whichever are more. It returns true if the record was stored.
+func (s *PostgresRepository) InsertAccountingRecord(ctx context.Context, record *model.AccountingRecord) (bool, error) {
+	const q = `
+INSERT INTO accounting_records (
+	id, app_id, partition_id, partition, queue_id, queue_name, "user", groups, state, rejected_message,
+	submission_time, start_time, finished_time, wait_time, run_time, peak_resource, total_resource,
+	allocations, preemptions, recorded_at_nano
+)
+VALUES (
+	@id, @app_id, @partition_id, @partition, @queue_id, @queue_name, @user, @groups, @state, @rejected_message,
+	@submission_time, @start_time, @finished_time, @wait_time, @run_time, @peak_resource, @total_resource,
+	@allocations,
+	GREATEST(@preemptions::BIGINT, (
+		SELECT COUNT(*) FROM events
+		WHERE type = 'APP' AND object_id = @app_id AND change_detail = 'ALLOC_PREEMPT'
+			AND timestamp_nano >= @submission_time::BIGINT * 1000000
+	)),
+	@recorded_at_nano
+)
+ON CONFLICT (id) DO NOTHING
+RETURNING preemptions`
+
+	var preemptions int64
+	err := s.dbpool.QueryRow(ctx, q,
+		pgx.NamedArgs{
+			"id":               record.ID,
+			"app_id":           record.ApplicationID,
+			"partition_id":     record.PartitionID,
+			"partition":        record.Partition,
+			"queue_id":         record.QueueID,
+			"queue_name":       record.QueueName,
+			"user":             record.User,
+			"groups":           record.Groups,
+			"state":            record.State,
+			"rejected_message": record.RejectedMessage,
+			"submission_time":  record.SubmissionTime,
+			"start_time":       record.StartTime,
+			"finished_time":    record.FinishedTime,
+			"wait_time":        record.WaitTime,
+			"run_time":         record.RunTime,
+			"peak_resource":    record.PeakResource,
+			"total_resource":   record.TotalResource,
+			"allocations":      record.Allocations,
+			"preemptions":      record.Preemptions,
+			"recorded_at_nano": record.RecordedAtNano,
+		}).Scan(&preemptions)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("could not insert accounting record into DB: %v", err)
+	}
+	record.Preemptions = preemptions
+	return true, nil
+}
+
+// GetAccountingRecords returns the accounting records which match the filters.
+func (s *PostgresRepository) GetAccountingRecords(ctx context.Context, filters AccountingFilters) ([]*model.AccountingRecord, error) {
+	queryBuilder, err := accountingQuery(filters)
+	if err != nil {
+		return nil, err
+	}
+	query, args := queryBuilder.Build()
+	rows, err := s.dbpool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get accounting records from DB: %v", err)
+	}
+	defer rows.Close()
+
+	var records []*model.AccountingRecord
+	for rows.Next() {
+		var record model.AccountingRecord
+		if err := scanAccountingRecord(rows, &record); err != nil {
+			return nil, fmt.Errorf("could not scan accounting record from DB: %v", err)
+		}
+		records = append(records, &record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get accounting records from DB: %v", err)
+	}
+	return records, nil
+}
+
+// CountAccountingRecords returns the number of accounting records which match the filters, ignoring the pagination.
+func (s *PostgresRepository) CountAccountingRecords(ctx context.Context, filters AccountingFilters, estimated bool) (int64, error) {
+	queryBuilder, err := accountingQuery(filters)
+	if err != nil {
+		return 0, err
+	}
+	return s.count(ctx, queryBuilder, estimated)
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/G-Research/unicorn-history-server/internal/model"
+	"github.com/G-Research/unicorn-history-server/internal/util"
+)
+
+type AccountingIntTest struct {
+	suite.Suite
+	pool *pgxpool.Pool
+	repo *PostgresRepository
+	now  time.Time
+}
+
+func (as *AccountingIntTest) SetupSuite() {
+	require.NotNil(as.T(), as.pool)
+	repo, err := NewPostgresRepository(as.pool)
+	require.NoError(as.T(), err)
+	as.repo = repo
+
+	ctx := context.Background()
+	as.now = time.Now()
+	now := as.now
+
+	require.NoError(as.T(), repo.InsertEvent(ctx, &model.Event{
+		TimestampNano: now.UnixNano(),
+		Type:          "APP",
+		ObjectID:      "app-1",
+		ReferenceID:   "alloc-1",
+		ChangeType:    "REMOVE",
+		ChangeDetail:  "ALLOC_PREEMPT",
+	}))
+
+	records := []*model.AccountingRecord{
+		{
+			ID:             "1",
+			ApplicationID:  "app-1",
+			PartitionID:    "1",
+			Partition:      "default",
+			QueueName:      "root.ml.training",
+			User:           "alice",
+			Groups:         []string{"dev", "ml"},
+			State:          "Completed",
+			SubmissionTime: now.Add(-2 * time.Hour).UnixMilli(),
+			StartTime:      util.ToPtr(now.Add(-90 * time.Minute).UnixMilli()),
+			FinishedTime:   now.Add(-time.Hour).UnixMilli(),
+			WaitTime:       (30 * time.Minute).Milliseconds(),
+			RunTime:        (30 * time.Minute).Milliseconds(),
+			PeakResource:   map[string]int64{"vcore": 2000},
+			TotalResource:  map[string]int64{"vcore": 3600000},
+			Allocations:    2,
+			RecordedAtNano: now.UnixNano(),
+		},
+		{
+			ID:             "2",
+			ApplicationID:  "app-2",
+			PartitionID:    "1",
+			Partition:      "default",
+			QueueName:      "root.batch",
+			User:           "bob",
+			State:          "Failed",
+			SubmissionTime: now.Add(-time.Hour).UnixMilli(),
+			FinishedTime:   now.UnixMilli(),
+			WaitTime:       time.Hour.Milliseconds(),
+			RecordedAtNano: now.UnixNano(),
+		},
+	}
+	for _, record := range records {
+		inserted, err := repo.InsertAccountingRecord(ctx, record)
+		require.NoError(as.T(), err)
+		require.True(as.T(), inserted)
+	}
+}
+
+func (as *AccountingIntTest) TearDownSuite() {
+	as.pool.Close()
+}
+
+func (as *AccountingIntTest) TestInsertAccountingRecordOnlyOnce() {
+	ctx := context.Background()
+	inserted, err := as.repo.InsertAccountingRecord(ctx, &model.AccountingRecord{
+		ID:             "2",
+		ApplicationID:  "app-2",
+		PartitionID:    "1",
+		Partition:      "default",
+		QueueName:      "root.batch",
+		State:          "Completed",
+		RecordedAtNano: time.Now().UnixNano(),
+	})
+	require.NoError(as.T(), err)
+	assert.False(as.T(), inserted)
+
+	records, err := as.repo.GetAccountingRecords(ctx, AccountingFilters{User: util.ToPtr("bob")})
+	require.NoError(as.T(), err)
+	require.Len(as.T(), records, 1)
+	assert.Equal(as.T(), "Failed", records[0].State)
+}
+
+func (as *AccountingIntTest) TestAccountingRecordsAreImmutable() {
+	ctx := context.Background()
+	_, err := as.pool.Exec(ctx, "UPDATE accounting_records SET state = 'Completed' WHERE id = '2'")
+	require.Error(as.T(), err)
+	_, err = as.pool.Exec(ctx, "DELETE FROM accounting_records WHERE id = '2'")
+	require.Error(as.T(), err)
+}
+
+func (as *AccountingIntTest) TestGetAccountingRecords() {
+	ctx := context.Background()
+	tests := []struct {
+		name        string
+		filters     AccountingFilters
+		expectedIDs []string
+	}{
+		{
+			name:        "All records, most recently finished first",
+			filters:     AccountingFilters{},
+			expectedIDs: []string{"2", "1"},
+		},
+		{
+			name:        "By group",
+			filters:     AccountingFilters{Groups: []string{"ml"}},
+			expectedIDs: []string{"1"},
+		},
+		{
+			name:        "By queue including subqueues",
+			filters:     AccountingFilters{Queue: util.ToPtr("root.ml"), IncludeSubqueues: true},
+			expectedIDs: []string{"1"},
+		},
+		{
+			name:        "By state and finished time",
+			filters:     AccountingFilters{States: []string{"Failed"}, FinishedStartTime: util.ToPtr(as.now.Add(-time.Minute))},
+			expectedIDs: []string{"2"},
+		},
+		{
+			name:        "Sorted by peak vcore",
+			filters:     AccountingFilters{Sort: []SortKey{{Field: "peakResource.vcore", Descending: true}}},
+			expectedIDs: []string{"1", "2"},
+		},
+	}
+
+	for _, tt := range tests {
+		as.Run(tt.name, func() {
+			records, err := as.repo.GetAccountingRecords(ctx, tt.filters)
+			require.NoError(as.T(), err)
+			var ids []string
+			for _, record := range records {
+				ids = append(ids, record.ID)
+			}
+			assert.Equal(as.T(), tt.expectedIDs, ids)
+
+			count, err := as.repo.CountAccountingRecords(ctx, tt.filters, false)
+			require.NoError(as.T(), err)
+			assert.Equal(as.T(), int64(len(tt.expectedIDs)), count)
+		})
+	}
+}
+
+func (as *AccountingIntTest) TestPreemptionsAreCountedFromEvents() {
+	records, err := as.repo.GetAccountingRecords(context.Background(), AccountingFilters{User: util.ToPtr("alice")})
+	require.NoError(as.T(), err)
+	require.Len(as.T(), records, 1)
+	assert.Equal(as.T(), int64(1), records[0].Preemptions)
+	assert.Equal(as.T(), []string{"dev", "ml"}, records[0].Groups)
+	assert.Equal(as.T(), map[string]int64{"vcore": 3600000}, records[0].TotalResource)
+}
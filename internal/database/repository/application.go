@@ -9,7 +9,6 @@ import (
 
 	"github.com/G-Research/unicorn-history-server/internal/database/sql"
 	"github.com/G-Research/unicorn-history-server/internal/model"
-	"github.com/G-Research/unicorn-history-server/internal/util"
 )
 
 type ApplicationFilters struct {
@@ -17,34 +16,205 @@ type ApplicationFilters struct {
 	SubmissionEndTime   *time.Time
 	FinishedStartTime   *time.Time
 	FinishedEndTime     *time.Time
-	User                *string
-	Groups              []string
-	Offset              *int
-	Limit               *int
+	// RunningStartTime and RunningEndTime select the applications which were running at any time
+	// in the interval, i.e. which were submitted before its end and not finished before its start.
+	RunningStartTime *time.Time
+	RunningEndTime   *time.Time
+	User             *string
+	Groups           []string
+	States           []string
+	// Queue is the full path of the queue (e.g. 'root.default').
+	Queue *string
+	// IncludeSubqueues also selects the applications of the queues below Queue.
+	IncludeSubqueues    bool
+	Partition           *string
+	ApplicationIDPrefix *string
+	// Resources filter the applications by their resources, see ApplicationResourceFilterFields.
+	Resources []ResourceFilter
+	// Expression is a filter expression compiled with ApplicationFilterSchema.
+	Expression     sql.Expr
+	AsOf           *time.Time
+	IncludeDeleted bool
+	// Sort are the keys by which the applications are sorted, see ApplicationSortFields.
+	Sort []SortKey
+	// After is the position after which the returned applications start.
+	After  Cursor
+	Offset *int
+	Limit  *int
 }
 
-// applyApplicationFilters adds application filters to the sql query using positional arguments and
-// returns the arguments in the same order.
+// applicationColumns are the columns of the applications table in the order in which scanApplication scans them.
+var applicationColumns = []string{
+	"id",
+	"created_at_nano",
+	"deleted_at_nano",
+	"app_id",
+	"used_resource",
+	"max_used_resource",
+	"pending_resource",
+	"partition_id",
+	"partition",
+	"queue_id",
+	"queue_name",
+	"submission_time",
+	"finished_time",
+	"requests",
+	"allocations",
+	"state",
+	`"user"`,
+	"groups",
+	"rejected_message",
+	"state_log",
+	"place_holder_data",
+	"has_reserved",
+	"reservations",
+	"max_request_priority",
+	"preempted_resource",
+}
+
+// scanApplication scans a row which contains the applicationColumns into app.
+func scanApplication(row pgx.Row, app *model.Application) error {
+	return row.Scan(
+		&app.ID,
+		&app.CreatedAtNano,
+		&app.DeletedAtNano,
+		&app.ApplicationID,
+		&app.UsedResource,
+		&app.MaxUsedResource,
+		&app.PendingResource,
+		&app.PartitionID,
+		&app.Partition,
+		&app.QueueID,
+		&app.QueueName,
+		&app.SubmissionTime,
+		&app.FinishedTime,
+		&app.Requests,
+		&app.Allocations,
+		&app.State,
+		&app.User,
+		&app.Groups,
+		&app.RejectedMessage,
+		&app.StateLog,
+		&app.PlaceholderData,
+		&app.HasReserved,
+		&app.Reservations,
+		&app.MaxRequestPriority,
+		&app.PreemptedResource,
+	)
+}
+
+// applyApplicationFilters adds application filters to the sql query.
 func applyApplicationFilters(builder *sql.Builder, filters ApplicationFilters) {
 	if filters.SubmissionStartTime != nil {
-		builder.Conditionp("submission_time", ">=", filters.SubmissionStartTime.UnixMilli())
+		builder.Where(sql.Cmp("submission_time", sql.OpGe, filters.SubmissionStartTime.UnixMilli()))
 	}
 	if filters.SubmissionEndTime != nil {
-		builder.Conditionp("submission_time", "<=", filters.SubmissionEndTime.UnixMilli())
+		builder.Where(sql.Cmp("submission_time", sql.OpLe, filters.SubmissionEndTime.UnixMilli()))
 	}
 	if filters.FinishedStartTime != nil {
-		builder.Conditionp("finished_time", ">=", filters.FinishedStartTime.UnixMilli())
+		builder.Where(sql.Cmp("finished_time", sql.OpGe, filters.FinishedStartTime.UnixMilli()))
 	}
 	if filters.FinishedEndTime != nil {
-		builder.Conditionp("finished_time", "<=", filters.FinishedEndTime.UnixMilli())
+		builder.Where(sql.Cmp("finished_time", sql.OpLe, filters.FinishedEndTime.UnixMilli()))
 	}
 	if len(filters.Groups) > 0 {
-		builder.Conditionf("groups && ARRAY[%s]", util.SliceToCommaSeparated(filters.Groups, true))
+		builder.Where(sql.Overlaps("groups", filters.Groups))
+	}
+	if filters.RunningEndTime != nil {
+		builder.Where(sql.Cmp("submission_time", sql.OpLe, filters.RunningEndTime.UnixMilli()))
+	}
+	if filters.RunningStartTime != nil {
+		builder.Where(sql.Or(
+			sql.IsNull("finished_time"),
+			sql.Cmp("finished_time", sql.OpGe, filters.RunningStartTime.UnixMilli()),
+		))
 	}
 	if filters.User != nil {
-		builder.Conditionp("\"user\"", "=", *filters.User)
+		builder.Where(sql.Eq(`"user"`, *filters.User))
+	}
+	if len(filters.States) > 0 {
+		builder.Where(sql.In("state", filters.States))
+	}
+	if filters.Queue != nil {
+		if filters.IncludeSubqueues {
+			builder.Where(sql.Or(
+				sql.Eq("queue_name", *filters.Queue),
+				sql.Cmp("queue_name", sql.OpLike, escapeLike(*filters.Queue)+".%"),
+			))
+		} else {
+			builder.Where(sql.Eq("queue_name", *filters.Queue))
+		}
+	}
+	if filters.Partition != nil {
+		builder.Where(sql.Eq("partition", *filters.Partition))
+	}
+	if filters.ApplicationIDPrefix != nil {
+		builder.Where(sql.Cmp("app_id", sql.OpLike, escapeLike(*filters.ApplicationIDPrefix)+"%"))
+	}
+	if filters.Expression != nil {
+		builder.Where(filters.Expression)
 	}
-	applyLimitAndOffset(builder, filters.Limit, filters.Offset)
+	applyVersionsAsOf(builder, "applications", "application_versions", filters.AsOf)
+	applyTemporalFilters(builder, filters.AsOf, filters.IncludeDeleted)
+	applyPagination(builder, filters.After, filters.Limit, filters.Offset)
+}
+
+// ApplicationSortFields are the fields by which applications can be sorted.
+var ApplicationSortFields = &SortFields[model.Application]{
+	fields: map[string]sortField[model.Application]{
+		"id":               {"id", func(a *model.Application) any { return a.ID }},
+		"applicationID":    {"app_id", func(a *model.Application) any { return a.ApplicationID }},
+		"submissionTime":   {"COALESCE(submission_time, 0)", func(a *model.Application) any { return a.SubmissionTime }},
+		"finishedTime":     {"COALESCE(finished_time, 0)", func(a *model.Application) any { return valueOrZero(a.FinishedTime) }},
+		"user":             {`COALESCE("user", '')`, func(a *model.Application) any { return a.User }},
+		"queueName":        {"queue_name", func(a *model.Application) any { return a.QueueName }},
+		"partition":        {"partition", func(a *model.Application) any { return a.Partition }},
+		"applicationState": {"COALESCE(state, '')", func(a *model.Application) any { return a.State }},
+	},
+	resources: map[string]resourceSortField[model.Application]{
+		"usedResource":    {"used_resource", func(a *model.Application) map[string]int64 { return a.UsedResource }},
+		"maxUsedResource": {"max_used_resource", func(a *model.Application) map[string]int64 { return a.MaxUsedResource }},
+		"pendingResource": {"pending_resource", func(a *model.Application) map[string]int64 { return a.PendingResource }},
+	},
+	defaultSort: []SortKey{{Field: "submissionTime", Descending: true}},
+	id:          "id",
+}
+
+// ApplicationResourceFilterFields are the fields by which applications can be filtered with resource filters.
+var ApplicationResourceFilterFields = &ResourceFilterFields{
+	resources: map[string]string{
+		"usedResource":    "used_resource",
+		"maxUsedResource": "max_used_resource",
+		"pendingResource": "pending_resource",
+	},
+}
+
+// ApplicationFilterSchema defines the fields of the filter expressions of applications.
+var ApplicationFilterSchema = &FilterSchema{
+	fields: map[string]filterField{
+		"id":               {expression: "id", fieldType: filterTypeString},
+		"applicationID":    {expression: "app_id", fieldType: filterTypeString},
+		"user":             {expression: `"user"`, fieldType: filterTypeString},
+		"groups":           {expression: "groups", fieldType: filterTypeStringArray},
+		"queueName":        {expression: "queue_name", fieldType: filterTypeString},
+		"partition":        {expression: "partition", fieldType: filterTypeString},
+		"applicationState": {expression: "state", fieldType: filterTypeString},
+		"submissionTime":   {expression: "submission_time", fieldType: filterTypeTime},
+		"finishedTime":     {expression: "finished_time", fieldType: filterTypeTime},
+		"rejectedMessage":  {expression: "rejected_message", fieldType: filterTypeString},
+		"hasReserved":      {expression: "has_reserved", fieldType: filterTypeBool},
+	},
+	resources: map[string]string{
+		"usedResource":    "used_resource",
+		"maxUsedResource": "max_used_resource",
+		"pendingResource": "pending_resource",
+	},
+}
+
+// Cursor returns the cursor which points to the position after the application
+// in the list of applications matching the filters.
+func (f ApplicationFilters) Cursor(app *model.Application) Cursor {
+	return ApplicationSortFields.Cursor(f.Sort, app)
 }
 
 func (s *PostgresRepository) InsertApplication(ctx context.Context, app *model.Application) error {
@@ -74,7 +244,8 @@ INSERT INTO applications
 	place_holder_data,
 	has_reserved,
 	reservations,
-	max_request_priority
+	max_request_priority,
+	preempted_resource
 )
 VALUES
 (
@@ -101,7 +272,8 @@ VALUES
 	@place_holder_data,
 	@has_reserved,
 	@reservations,
-	@max_request_priority
+	@max_request_priority,
+	@preempted_resource
 )
 	`
 
@@ -133,86 +305,39 @@ VALUES
 			"has_reserved":         app.HasReserved,
 			"reservations":         app.Reservations,
 			"max_request_priority": app.MaxRequestPriority,
+			"preempted_resource":   app.PreemptedResource,
 		})
 	return err
 }
 
 func (s *PostgresRepository) GetApplicationByID(ctx context.Context, id string) (*model.Application, error) {
-	const q = `
-SELECT
-	id,
-	created_at_nano,
-	deleted_at_nano,
-	app_id,
-	used_resource,
-	max_used_resource,
-	pending_resource,
-	partition_id,
-	partition,
-	queue_id,
-	queue_name,
-	submission_time,
-	finished_time,
-	requests,
-	allocations,
-	state,
-	"user",
-	groups,
-	rejected_message,
-	state_log,
-	place_holder_data,
-	has_reserved,
-	reservations,
-	max_request_priority
-FROM
-	applications
-WHERE
-	id = @id
-ORDER BY id DESC
-LIMIT 1
-	`
+	query, args := sql.NewBuilder().
+		Select(applicationColumns...).
+		From("applications", "").
+		Where(sql.Eq("id", id)).
+		Build()
 
 	var app model.Application
-	row := s.dbpool.QueryRow(
-		ctx,
-		q,
-		pgx.NamedArgs{
-			"id": id,
-		},
-	)
-
-	if err := row.Scan(
-		&app.ID,
-		&app.CreatedAtNano,
-		&app.DeletedAtNano,
-		&app.ApplicationID,
-		&app.UsedResource,
-		&app.MaxUsedResource,
-		&app.PendingResource,
-		&app.PartitionID,
-		&app.Partition,
-		&app.QueueID,
-		&app.QueueName,
-		&app.SubmissionTime,
-		&app.FinishedTime,
-		&app.Requests,
-		&app.Allocations,
-		&app.State,
-		&app.User,
-		&app.Groups,
-		&app.RejectedMessage,
-		&app.StateLog,
-		&app.PlaceholderData,
-		&app.HasReserved,
-		&app.Reservations,
-		&app.MaxRequestPriority,
-	); err != nil {
+	if err := scanApplication(s.dbpool.QueryRow(ctx, query, args...), &app); err != nil {
 		return nil, err
 	}
 
 	return &app, nil
 }
 
+// GetApplicationRuns returns all runs of an application, which is identified either by its id,
+// or by its applicationID (which is not unique, if the application was resubmitted).
+// The runs are returned most recently submitted first, including the deleted ones.
+func (s *PostgresRepository) GetApplicationRuns(ctx context.Context, id string) ([]*model.Application, error) {
+	queryBuilder := sql.NewBuilder().
+		Select(applicationColumns...).
+		From("applications", "").
+		Where(sql.Raw("app_id = COALESCE((SELECT app_id FROM applications WHERE id = $1), $1)", id)).
+		OrderBy("submission_time", sql.OrderByDescending).
+		OrderBy("id", sql.OrderByDescending)
+	return s.queryApplications(ctx, queryBuilder)
+}
+
 func (s *PostgresRepository) DeleteApplicationsNotInIDs(ctx context.Context, ids []string, deletedAtNano int64) error {
 	const q = `
 UPDATE applications
@@ -249,7 +374,8 @@ SET
 	place_holder_data = @place_holder_data,
 	has_reserved = @has_reserved,
 	reservations = @reservations,
-	max_request_priority = @max_request_priority
+	max_request_priority = @max_request_priority,
+	preempted_resource = @preempted_resource
 WHERE id = @id
 	`
 
@@ -274,6 +400,7 @@ WHERE id = @id
 			"has_reserved":         app.HasReserved,
 			"reservations":         app.Reservations,
 			"max_request_priority": app.MaxRequestPriority,
+			"preempted_resource":   app.PreemptedResource,
 		},
 	)
 	if err != nil {
@@ -288,105 +415,92 @@ WHERE id = @id
 }
 
 func (s *PostgresRepository) GetAllApplications(ctx context.Context, filters ApplicationFilters) ([]*model.Application, error) {
-	queryBuilder := sql.NewBuilder().SelectAll("applications", "a").OrderBy("a.submission_time", sql.OrderByDescending)
-	applyApplicationFilters(queryBuilder, filters)
-
-	var apps []*model.Application
+	queryBuilder, err := allApplicationsQuery(filters)
+	if err != nil {
+		return nil, err
+	}
+	return s.queryApplications(ctx, queryBuilder)
+}
 
-	query := queryBuilder.Query()
-	args := queryBuilder.Args()
-	rows, err := s.dbpool.Query(ctx, query, args...)
+// CountAllApplications returns the number of applications which match the filters, ignoring the pagination.
+func (s *PostgresRepository) CountAllApplications(ctx context.Context, filters ApplicationFilters, estimated bool) (int64, error) {
+	queryBuilder, err := allApplicationsQuery(filters)
 	if err != nil {
-		return nil, fmt.Errorf("could not get applications from DB: %v", err)
+		return 0, err
 	}
-	defer rows.Close()
-	for rows.Next() {
-		var app model.Application
-		err := rows.Scan(
-			&app.ID,
-			&app.CreatedAtNano,
-			&app.DeletedAtNano,
-			&app.ApplicationID,
-			&app.UsedResource,
-			&app.MaxUsedResource,
-			&app.PendingResource,
-			&app.PartitionID,
-			&app.Partition,
-			&app.QueueID,
-			&app.QueueName,
-			&app.SubmissionTime,
-			&app.FinishedTime,
-			&app.Requests,
-			&app.Allocations,
-			&app.State,
-			&app.User,
-			&app.Groups,
-			&app.RejectedMessage,
-			&app.StateLog,
-			&app.PlaceholderData,
-			&app.HasReserved,
-			&app.Reservations,
-			&app.MaxRequestPriority,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("could not scan application from DB: %v", err)
-		}
-		apps = append(apps, &app)
+	return s.count(ctx, queryBuilder, estimated)
+}
+
+func allApplicationsQuery(filters ApplicationFilters) (*sql.Builder, error) {
+	queryBuilder := sql.NewBuilder().
+		Select(applicationColumns...).
+		From("applications", "")
+	if err := ApplicationSortFields.apply(queryBuilder, filters.Sort); err != nil {
+		return nil, err
 	}
-	return apps, nil
+	if err := ApplicationResourceFilterFields.apply(queryBuilder, filters.Resources); err != nil {
+		return nil, err
+	}
+	applyApplicationFilters(queryBuilder, filters)
+	return queryBuilder, nil
 }
 
 //nolint:all
 func (s *PostgresRepository) GetAppsPerPartitionPerQueue(ctx context.Context, partitionID, queueID string, filters ApplicationFilters) ([]*model.Application, error) {
+	queryBuilder, err := appsPerPartitionPerQueueQuery(partitionID, queueID, filters)
+	if err != nil {
+		return nil, err
+	}
+	return s.queryApplications(ctx, queryBuilder)
+}
+
+// CountAppsPerPartitionPerQueue returns the number of applications in the queue which match the filters, ignoring the pagination.
+func (s *PostgresRepository) CountAppsPerPartitionPerQueue(
+	ctx context.Context,
+	partitionID, queueID string,
+	filters ApplicationFilters,
+	estimated bool,
+) (int64, error) {
+	queryBuilder, err := appsPerPartitionPerQueueQuery(partitionID, queueID, filters)
+	if err != nil {
+		return 0, err
+	}
+	return s.count(ctx, queryBuilder, estimated)
+}
+
+func appsPerPartitionPerQueueQuery(partitionID, queueID string, filters ApplicationFilters) (*sql.Builder, error) {
 	queryBuilder := sql.NewBuilder().
-		SelectAll("applications", "").
-		Conditionp("queue_id", "=", queueID).
-		Conditionp("partition_id", "=", partitionID).
-		OrderBy("submission_time", sql.OrderByDescending)
+		Select(applicationColumns...).
+		From("applications", "").
+		Where(sql.Eq("queue_id", queueID), sql.Eq("partition_id", partitionID))
+	if err := ApplicationSortFields.apply(queryBuilder, filters.Sort); err != nil {
+		return nil, err
+	}
+	if err := ApplicationResourceFilterFields.apply(queryBuilder, filters.Resources); err != nil {
+		return nil, err
+	}
 	applyApplicationFilters(queryBuilder, filters)
+	return queryBuilder, nil
+}
 
-	var apps []*model.Application
-
-	query := queryBuilder.Query()
-	args := queryBuilder.Args()
+func (s *PostgresRepository) queryApplications(ctx context.Context, queryBuilder *sql.Builder) ([]*model.Application, error) {
+	query, args := queryBuilder.Build()
 	rows, err := s.dbpool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("could not get applications from DB: %v", err)
 	}
 	defer rows.Close()
+
+	var apps []*model.Application
 	for rows.Next() {
 		var app model.Application
-
-		err := rows.Scan(
-			&app.ID,
-			&app.CreatedAtNano,
-			&app.DeletedAtNano,
-			&app.ApplicationID,
-			&app.UsedResource,
-			&app.MaxUsedResource,
-			&app.PendingResource,
-			&app.PartitionID,
-			&app.Partition,
-			&app.QueueID,
-			&app.QueueName,
-			&app.SubmissionTime,
-			&app.FinishedTime,
-			&app.Requests,
-			&app.Allocations,
-			&app.State,
-			&app.User,
-			&app.Groups,
-			&app.RejectedMessage,
-			&app.StateLog,
-			&app.PlaceholderData,
-			&app.HasReserved,
-			&app.Reservations,
-			&app.MaxRequestPriority,
-		)
-		if err != nil {
+		if err := scanApplication(rows, &app); err != nil {
 			return nil, fmt.Errorf("could not scan application from DB: %v", err)
 		}
 		apps = append(apps, &app)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get applications from DB: %v", err)
+	}
 	return apps, nil
 }
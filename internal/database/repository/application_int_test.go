@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
+	"github.com/G-Research/unicorn-history-server/internal/database/sql"
 	"github.com/G-Research/unicorn-history-server/internal/model"
 	"github.com/G-Research/unicorn-history-server/internal/util"
 )
@@ -70,6 +71,65 @@ func (as *ApplicationIntTest) TestGetApplicationByID() {
 	}
 }
 
+func (as *ApplicationIntTest) TestGetApplicationRuns() {
+	ctx := context.Background()
+	tests := []struct {
+		name        string
+		id          string
+		expectedIDs []string
+	}{
+		{
+			name:        "Get runs by ID",
+			id:          "1",
+			expectedIDs: []string{"1"},
+		},
+		{
+			name:        "Get runs by applicationID",
+			id:          "app2",
+			expectedIDs: []string{"2"},
+		},
+		{
+			name: "Get runs of an application that does not exist",
+			id:   "100",
+		},
+	}
+
+	for _, tt := range tests {
+		as.Run(tt.name, func() {
+			runs, err := as.repo.GetApplicationRuns(ctx, tt.id)
+			require.NoError(as.T(), err)
+			var ids []string
+			for _, run := range runs {
+				ids = append(ids, run.ID)
+			}
+			assert.Equal(as.T(), tt.expectedIDs, ids)
+		})
+	}
+}
+
+func (as *ApplicationIntTest) TestCountApplicationsInQueueByState() {
+	ctx := context.Background()
+
+	counts, err := as.repo.CountApplicationsInQueueByState(ctx, "1")
+	require.NoError(as.T(), err)
+	assert.Equal(as.T(), map[string]int64{
+		si.EventRecord_APP_RUNNING.String():    2,
+		si.EventRecord_APP_COMPLETING.String(): 1,
+		si.EventRecord_APP_COMPLETED.String():  2,
+		si.EventRecord_APP_FAILED.String():     1,
+	}, counts)
+
+	counts, err = as.repo.CountApplicationsInQueueByState(ctx, "2")
+	require.NoError(as.T(), err)
+	assert.Empty(as.T(), counts)
+}
+
+func (as *ApplicationIntTest) compileFilter(expression string) sql.Expr {
+	expr, err := ApplicationFilterSchema.Compile(expression)
+	require.NoError(as.T(), err)
+	return expr
+}
+
 func (as *ApplicationIntTest) TestGetAllApplications() {
 	ctx := context.Background()
 	tests := []struct {
@@ -100,6 +160,20 @@ func (as *ApplicationIntTest) TestGetAllApplications() {
 			},
 			expected: 1,
 		},
+		{
+			name: "Filter by Expression",
+			filters: ApplicationFilters{
+				Expression: as.compileFilter("applicationState in (APP_FAILED, APP_COMPLETED) or user = user3"),
+			},
+			expected: 4,
+		},
+		{
+			name: "Filter by negated Expression",
+			filters: ApplicationFilters{
+				Expression: as.compileFilter(`not (user = "user1" or applicationState ~ "*_COMPLET*")`),
+			},
+			expected: 2,
+		},
 		{
 			name: "Filter By Limit",
 			filters: ApplicationFilters{
@@ -125,6 +199,51 @@ func (as *ApplicationIntTest) TestGetAllApplications() {
 			filters:  ApplicationFilters{Groups: []string{"group1", "group2"}, User: util.ToPtr("user1")},
 			expected: 2,
 		},
+		{
+			name: "Filter by States",
+			filters: ApplicationFilters{
+				States: []string{si.EventRecord_APP_COMPLETED.String(), si.EventRecord_APP_FAILED.String()},
+			},
+			expected: 3,
+		},
+		{
+			name:     "Filter by Queue",
+			filters:  ApplicationFilters{Queue: util.ToPtr("root.default")},
+			expected: 6,
+		},
+		{
+			name:     "Filter by parent Queue",
+			filters:  ApplicationFilters{Queue: util.ToPtr("root")},
+			expected: 0,
+		},
+		{
+			name:     "Filter by parent Queue including subqueues",
+			filters:  ApplicationFilters{Queue: util.ToPtr("root"), IncludeSubqueues: true},
+			expected: 6,
+		},
+		{
+			name:     "Filter by Partition",
+			filters:  ApplicationFilters{Partition: util.ToPtr("default")},
+			expected: 6,
+		},
+		{
+			name:     "Filter by Application ID prefix",
+			filters:  ApplicationFilters{ApplicationIDPrefix: util.ToPtr("app1")},
+			expected: 1,
+		},
+		{
+			name:     "Filter by Application ID prefix with wildcard",
+			filters:  ApplicationFilters{ApplicationIDPrefix: util.ToPtr("app_")},
+			expected: 0,
+		},
+		{
+			name: "Filter by Running Time Range",
+			filters: ApplicationFilters{
+				RunningStartTime: util.ToPtr(time.Now().Add(-4*time.Hour - 30*time.Minute)),
+				RunningEndTime:   util.ToPtr(time.Now().Add(-4 * time.Hour)),
+			},
+			expected: 2,
+		},
 		{
 			name:     "No Filters",
 			expected: 6,
@@ -266,6 +385,12 @@ func (as *ApplicationIntTest) TestDeleteApplicationsNotInIDs() {
 
 	for _, tt := range tests {
 		as.Run(tt.name, func() {
+			// restore the deleted applications, so that the other tests are not affected
+			as.T().Cleanup(func() {
+				_, err := as.repo.dbpool.Exec(ctx, `UPDATE applications SET deleted_at_nano = NULL`)
+				require.NoError(as.T(), err)
+			})
+
 			err := as.repo.DeleteApplicationsNotInIDs(ctx, tt.ids, deletedAtNano)
 			require.NoError(as.T(), err)
 
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/G-Research/unicorn-history-server/internal/model"
+)
+
+// diagnosticExamples is the number of example applicationIDs of a diagnostic group.
+const diagnosticExamples = 5
+
+// DiagnosticsFilters select the rejected and failed applications of a diagnostics report.
+type DiagnosticsFilters struct {
+	// Start and End select the applications which were rejected or failed during the period [Start, End).
+	Start time.Time
+	End   time.Time
+	// Bucket is the width of the time buckets of the trends.
+	Bucket    time.Duration
+	Partition *string
+	// Queue selects the applications in the subtree of a queue.
+	Queue *string
+	User  *string
+}
+
+// diagnosticsQuery groups the rejected and failed applications by their state, normalized message, queue and user.
+// The message of a failed application, which does not have a rejected message, is the most recent message of its
+// events which changed its state to failing, failed or rejected. The time when an application was rejected or failed
+// is its finished time, or its submission time if it has not finished.
+var diagnosticsQuery = `
+WITH diagnosed AS (
+	SELECT
+		a.app_id,
+		a.state,
+		a.queue_name,
+		COALESCE(a."user", '') AS "user",
+		COALESCE(a.finished_time, a.submission_time) AS failed_time,
+		normalize_message(COALESCE(NULLIF(a.rejected_message, ''), (
+			SELECT e.message
+			FROM events e
+			WHERE e.type = 'APP' AND e.object_id = a.app_id
+				AND e.change_detail IN ('APP_FAILING', 'APP_FAILED', 'APP_REJECT') AND COALESCE(e.message, '') <> ''
+			ORDER BY e.timestamp_nano DESC
+			LIMIT 1
+		), '')) AS message
+	FROM applications a
+	WHERE a.state IN ('Rejected', 'Failed')
+		AND COALESCE(a.finished_time, a.submission_time) >= @start
+		AND COALESCE(a.finished_time, a.submission_time) < @end
+		AND (@partition::TEXT IS NULL OR a.partition = @partition)
+		AND ` + queueSubtreeFilter("a.queue_name") + `
+		AND (@user::TEXT IS NULL OR a."user" = @user)
+),
+trends AS (
+	SELECT
+		b.state,
+		b.message,
+		b.queue_name,
+		b."user",
+		jsonb_agg(jsonb_build_object('bucket', b.bucket, 'count', b.count) ORDER BY b.bucket) AS trend
+	FROM (
+		SELECT state, message, queue_name, "user", (failed_time / @bucket::BIGINT) * @bucket::BIGINT AS bucket, COUNT(*) AS count
+		FROM diagnosed
+		GROUP BY 1, 2, 3, 4, 5
+	) b
+	GROUP BY 1, 2, 3, 4
+)
+SELECT
+	d.state,
+	d.message,
+	d.queue_name,
+	d."user",
+	COUNT(*),
+	MIN(d.failed_time),
+	MAX(d.failed_time),
+	(array_agg(d.app_id ORDER BY d.failed_time DESC, d.app_id))[1:@examples],
+	t.trend
+FROM diagnosed d
+JOIN trends t ON t.state = d.state AND t.message = d.message AND t.queue_name = d.queue_name AND t."user" = d."user"
+GROUP BY d.state, d.message, d.queue_name, d."user", t.trend
+ORDER BY 5 DESC, 1, 2, 3, 4`
+
+// GetDiagnostics returns the rejected and failed applications which match the filters, grouped by their state,
+// normalized message, queue and user, with the most frequent groups first. Deleted applications are included.
+func (s *PostgresRepository) GetDiagnostics(ctx context.Context, filters DiagnosticsFilters) ([]*model.DiagnosticGroup, error) {
+	if filters.Bucket < time.Millisecond {
+		return nil, fmt.Errorf("invalid bucket width %s", filters.Bucket)
+	}
+
+	args := pgx.NamedArgs{
+		"start":     filters.Start.UnixMilli(),
+		"end":       filters.End.UnixMilli(),
+		"bucket":    filters.Bucket.Milliseconds(),
+		"partition": filters.Partition,
+		"user":      filters.User,
+		"examples":  diagnosticExamples,
+	}
+	maps.Copy(args, queueFilterArgs(filters.Queue))
+
+	rows, err := s.dbpool.Query(ctx, diagnosticsQuery, args)
+	if err != nil {
+		return nil, fmt.Errorf("could not get diagnostics from DB: %v", err)
+	}
+	defer rows.Close()
+
+	var groups []*model.DiagnosticGroup
+	for rows.Next() {
+		var g model.DiagnosticGroup
+		if err := rows.Scan(
+			&g.State,
+			&g.Message,
+			&g.Queue,
+			&g.User,
+			&g.Applications,
+			&g.FirstSeen,
+			&g.LastSeen,
+			&g.ExampleIDs,
+			&g.Trend,
+		); err != nil {
+			return nil, fmt.Errorf("could not scan diagnostics from DB: %v", err)
+		}
+		groups = append(groups, &g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get diagnostics from DB: %v", err)
+	}
+	return groups, nil
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/G-Research/yunikorn-core/pkg/webservice/dao"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/G-Research/unicorn-history-server/internal/model"
+	"github.com/G-Research/unicorn-history-server/internal/util"
+)
+
+type DiagnosticsIntTest struct {
+	suite.Suite
+	pool  *pgxpool.Pool
+	repo  *PostgresRepository
+	start time.Time
+}
+
+func (ds *DiagnosticsIntTest) SetupSuite() {
+	require.NotNil(ds.T(), ds.pool)
+	repo, err := NewPostgresRepository(ds.pool)
+	require.NoError(ds.T(), err)
+	ds.repo = repo
+
+	ctx := context.Background()
+	ds.start = time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	start := ds.start
+
+	newApp := func(appID, queue, user, state, message string, finished time.Time) *model.Application {
+		return &model.Application{
+			Metadata: model.Metadata{CreatedAtNano: start.UnixNano()},
+			ApplicationDAOInfo: dao.ApplicationDAOInfo{
+				ID:              ulid.Make().String(),
+				ApplicationID:   appID,
+				PartitionID:     "1",
+				Partition:       "default",
+				QueueName:       queue,
+				User:            user,
+				State:           state,
+				RejectedMessage: message,
+				SubmissionTime:  start.UnixMilli(),
+				FinishedTime:    util.ToPtr(finished.UnixMilli()),
+			},
+		}
+	}
+	apps := []*model.Application{
+		newApp("app-1", "root.a", "alice", "Rejected",
+			"application app-1 rejected: queue root.a has reached max applications 10", start.Add(10*time.Minute)),
+		newApp("app-2", "root.a", "alice", "Rejected",
+			"application app-2 rejected:  queue root.a has reached max applications 12", start.Add(90*time.Minute)),
+		newApp("app-3", "root.b", "bob", "Failed", "", start.Add(20*time.Minute)),
+		newApp("app-4", "root.b", "bob", "Completed", "", start.Add(20*time.Minute)),
+	}
+	for _, app := range apps {
+		require.NoError(ds.T(), repo.InsertApplication(ctx, app))
+	}
+	require.NoError(ds.T(), repo.InsertEvent(ctx, &model.Event{
+		TimestampNano: start.Add(20 * time.Minute).UnixNano(),
+		Type:          "APP",
+		ObjectID:      "app-3",
+		ChangeType:    "SET",
+		ChangeDetail:  "APP_FAILED",
+		Message:       "container exited with code 137 on pod spark-abc123",
+	}))
+}
+
+func (ds *DiagnosticsIntTest) TearDownSuite() {
+	ds.pool.Close()
+}
+
+func (ds *DiagnosticsIntTest) TestGetDiagnostics() {
+	ctx := context.Background()
+	start := ds.start
+	filters := DiagnosticsFilters{Start: start, End: start.Add(2 * time.Hour), Bucket: time.Hour}
+	groups, err := ds.repo.GetDiagnostics(ctx, filters)
+	require.NoError(ds.T(), err)
+	assert.Equal(ds.T(), []*model.DiagnosticGroup{
+		{
+			State:        "Rejected",
+			Message:      "application app-<n> rejected: queue root.a has reached max applications <n>",
+			Queue:        "root.a",
+			User:         "alice",
+			Applications: 2,
+			FirstSeen:    start.Add(10 * time.Minute).UnixMilli(),
+			LastSeen:     start.Add(90 * time.Minute).UnixMilli(),
+			ExampleIDs:   []string{"app-2", "app-1"},
+			Trend: []model.DiagnosticTrendPoint{
+				{Bucket: start.UnixMilli(), Count: 1},
+				{Bucket: start.Add(time.Hour).UnixMilli(), Count: 1},
+			},
+		},
+		{
+			State:        "Failed",
+			Message:      "container exited with code <n> on pod <id>",
+			Queue:        "root.b",
+			User:         "bob",
+			Applications: 1,
+			FirstSeen:    start.Add(20 * time.Minute).UnixMilli(),
+			LastSeen:     start.Add(20 * time.Minute).UnixMilli(),
+			ExampleIDs:   []string{"app-3"},
+			Trend:        []model.DiagnosticTrendPoint{{Bucket: start.UnixMilli(), Count: 1}},
+		},
+	}, groups)
+
+	filters.User = util.ToPtr("bob")
+	filters.End = start.Add(15 * time.Minute)
+	groups, err = ds.repo.GetDiagnostics(ctx, filters)
+	require.NoError(ds.T(), err)
+	assert.Empty(ds.T(), groups)
+
+	_, err = ds.repo.GetDiagnostics(ctx, DiagnosticsFilters{Start: start, End: start.Add(time.Hour)})
+	require.Error(ds.T(), err)
+}
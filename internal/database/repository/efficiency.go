@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/G-Research/unicorn-history-server/internal/model"
+)
+
+// EfficiencyRankBy is the waste by which the applications, users and queues of an efficiency report are ranked.
+type EfficiencyRankBy string
+
+const (
+	// EfficiencyRankByIdle ranks by the resource-seconds during which the peak of an application was not allocated.
+	EfficiencyRankByIdle EfficiencyRankBy = "idle"
+	// EfficiencyRankByUnused ranks by the requested quantity which was never allocated at the same time.
+	EfficiencyRankByUnused EfficiencyRankBy = "unused"
+)
+
+// EfficiencyRankByValues are the wastes by which an efficiency report can be ranked.
+var EfficiencyRankByValues = []EfficiencyRankBy{
+	EfficiencyRankByIdle,
+	EfficiencyRankByUnused,
+}
+
+// EfficiencyFilters select the applications, the resource and the ranking of an efficiency report.
+type EfficiencyFilters struct {
+	// Start and End select the applications which were submitted during the period [Start, End).
+	Start     time.Time
+	End       time.Time
+	Partition *string
+	// Queue selects the applications in the subtree of a queue.
+	Queue    *string
+	User     *string
+	Resource string
+	RankBy   EfficiencyRankBy
+	// Limit is the number of applications, users and queues with the largest waste which are returned.
+	Limit int
+}
+
+// efficiencyQuery computes the efficiency of the applications submitted during the period [@start, @end) for
+// @resource, and rolls it up per user and per queue. The requested quantity of an application is the sum of its
+// allocations and of its asks which were not allocated, as the asks are kept after they were allocated. Its reserved
+// resource-seconds are its maximum used quantity during its runtime, from its first Running state until it finished,
+// was deleted or the end of the period. Its allocated
+// resource-seconds are the ones of its accounting record, or else the ones of its allocations, which are allocated
+// until they were removed (by the first REMOVE event of the allocation) or the application ended.
+// The @limit applications, users and queues with the largest waste are returned.
+var efficiencyQuery = `
+WITH apps AS (
+	SELECT
+		a.app_id,
+		a.queue_name,
+		COALESCE(a."user", '') AS "user",
+		(
+			SELECT COALESCE(SUM((alloc->'resource'->>@resource)::DOUBLE PRECISION), 0)
+			FROM jsonb_array_elements(COALESCE(a.allocations, '[]'::JSONB)) AS alloc
+		) + (
+			SELECT COALESCE(SUM((ask->'resource'->>@resource)::DOUBLE PRECISION), 0)
+			FROM jsonb_array_elements(COALESCE(a.requests, '[]'::JSONB)) AS ask
+			WHERE NOT COALESCE(a.allocations, '[]'::JSONB) @> jsonb_build_array(jsonb_build_object('allocationKey', ask->>'allocationKey'))
+		) AS requested,
+		COALESCE((a.max_used_resource->>@resource)::DOUBLE PRECISION, 0) AS max_used,
+		COALESCE(
+			(r.total_resource->>@resource)::DOUBLE PRECISION,
+			(
+				SELECT SUM(COALESCE((alloc->'resource'->>@resource)::DOUBLE PRECISION, 0)
+					* GREATEST(LEAST(COALESCE(removed.timestamp_nano, e.ended), e.ended) - al.start_nano, 0) / 1e9)
+				FROM jsonb_array_elements(COALESCE(a.allocations, '[]'::JSONB)) AS alloc
+				CROSS JOIN LATERAL (
+					SELECT COALESCE(NULLIF((alloc->>'allocationTime')::BIGINT, 0), a.submission_time * 1000000) AS start_nano
+				) al
+				CROSS JOIN LATERAL (
+					SELECT MIN(ev.timestamp_nano) AS timestamp_nano
+					FROM events ev
+					WHERE ev.type = 'APP' AND ev.object_id = a.app_id AND ev.reference_id = alloc->>'allocationKey'
+						AND ev.change_type = 'REMOVE' AND ev.timestamp_nano >= al.start_nano
+				) removed
+			),
+			0
+		) AS allocated_seconds,
+		COALESCE((a.max_used_resource->>@resource)::DOUBLE PRECISION, 0)
+			* GREATEST(e.ended - COALESCE(s.started, e.ended), 0) / 1e9 AS reserved_seconds
+	FROM applications a
+	LEFT JOIN accounting_records r ON r.id = a.id
+	CROSS JOIN LATERAL (
+		SELECT MIN((l->>'time')::BIGINT) * 1000000 AS started
+		FROM jsonb_array_elements(COALESCE(a.state_log, '[]'::JSONB)) AS l
+		WHERE l->>'applicationState' = 'Running'
+	) s
+	CROSS JOIN LATERAL (
+		SELECT COALESCE(a.finished_time * 1000000, a.deleted_at_nano, @end::BIGINT * 1000000) AS ended
+	) e
+	WHERE a.submission_time >= @start AND a.submission_time < @end
+		AND (@partition::TEXT IS NULL OR a.partition = @partition)
+		AND ` + queueSubtreeFilter("a.queue_name") + `
+		AND (@user::TEXT IS NULL OR a."user" = @user)
+),
+efficiencies AS (
+	SELECT 'application' AS level, app_id, queue_name, "user", 1 AS applications,
+		requested, max_used, allocated_seconds, reserved_seconds
+	FROM apps
+	UNION ALL
+	SELECT 'user', NULL, NULL, "user", COUNT(*),
+		SUM(requested), SUM(max_used), SUM(allocated_seconds), SUM(reserved_seconds)
+	FROM apps
+	GROUP BY "user"
+	UNION ALL
+	SELECT 'queue', NULL, queue_name, NULL, COUNT(*),
+		SUM(requested), SUM(max_used), SUM(allocated_seconds), SUM(reserved_seconds)
+	FROM apps
+	GROUP BY queue_name
+)
+SELECT app_id, queue_name, "user", applications, requested, max_used, allocated_seconds, reserved_seconds
+FROM (
+	SELECT
+		*,
+		ROW_NUMBER() OVER (
+			PARTITION BY level
+			ORDER BY
+				CASE @rank_by::TEXT
+					WHEN 'unused' THEN GREATEST(requested - max_used, 0)
+					ELSE GREATEST(reserved_seconds - allocated_seconds, 0)
+				END DESC,
+				app_id, queue_name, "user"
+		) AS rank
+	FROM efficiencies
+) ranked
+WHERE rank <= @limit
+ORDER BY level, rank`
+
+// GetEfficiency returns the efficiency of the applications which match the filters for the resource of the filters,
+// and rolled up per user and per queue, ranked by the waste of the filters. The applications have an application ID,
+// a queue and a user, the roll-ups per user only a user, and the roll-ups per queue only a queue.
+func (s *PostgresRepository) GetEfficiency(ctx context.Context, filters EfficiencyFilters) ([]*model.Efficiency, error) {
+	args := pgx.NamedArgs{
+		"start":     filters.Start.UnixMilli(),
+		"end":       filters.End.UnixMilli(),
+		"partition": filters.Partition,
+		"user":      filters.User,
+		"resource":  filters.Resource,
+		"rank_by":   string(filters.RankBy),
+		"limit":     filters.Limit,
+	}
+	maps.Copy(args, queueFilterArgs(filters.Queue))
+
+	rows, err := s.dbpool.Query(ctx, efficiencyQuery, args)
+	if err != nil {
+		return nil, fmt.Errorf("could not get efficiency from DB: %v", err)
+	}
+	defer rows.Close()
+
+	var efficiencies []*model.Efficiency
+	for rows.Next() {
+		var e model.Efficiency
+		if err := rows.Scan(
+			&e.ApplicationID,
+			&e.Queue,
+			&e.User,
+			&e.Applications,
+			&e.Requested,
+			&e.MaxUsed,
+			&e.AllocatedSeconds,
+			&e.ReservedSeconds,
+		); err != nil {
+			return nil, fmt.Errorf("could not scan efficiency from DB: %v", err)
+		}
+		e.ComputeRatios()
+		efficiencies = append(efficiencies, &e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get efficiency from DB: %v", err)
+	}
+	return efficiencies, nil
+}
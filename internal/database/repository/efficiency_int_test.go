@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/G-Research/yunikorn-core/pkg/webservice/dao"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/G-Research/unicorn-history-server/internal/model"
+	"github.com/G-Research/unicorn-history-server/internal/util"
+)
+
+type EfficiencyIntTest struct {
+	suite.Suite
+	pool  *pgxpool.Pool
+	repo  *PostgresRepository
+	start time.Time
+}
+
+func (es *EfficiencyIntTest) SetupSuite() {
+	require.NotNil(es.T(), es.pool)
+	repo, err := NewPostgresRepository(es.pool)
+	require.NoError(es.T(), err)
+	es.repo = repo
+
+	ctx := context.Background()
+	es.start = time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	start := es.start
+
+	newApp := func(appID, queue, user string, submitted time.Time, ran time.Duration) *model.Application {
+		return &model.Application{
+			Metadata: model.Metadata{CreatedAtNano: submitted.UnixNano()},
+			ApplicationDAOInfo: dao.ApplicationDAOInfo{
+				ID:             ulid.Make().String(),
+				ApplicationID:  appID,
+				PartitionID:    "1",
+				Partition:      "default",
+				QueueName:      queue,
+				SubmissionTime: submitted.UnixMilli(),
+				FinishedTime:   util.ToPtr(submitted.Add(ran).UnixMilli()),
+				User:           user,
+				State:          "Completed",
+				StateLog: []*dao.StateDAOInfo{
+					{Time: submitted.UnixMilli(), ApplicationState: "Running"},
+					{Time: submitted.Add(ran).UnixMilli(), ApplicationState: "Completed"},
+				},
+			},
+		}
+	}
+
+	// the first allocation is removed after an hour, its ask is kept, and the second ask is never allocated
+	alice := newApp("app-alice", "root.a", "alice", start, 2*time.Hour)
+	alice.MaxUsedResource = map[string]int64{"vcore": 2000}
+	alice.Allocations = []*dao.AllocationDAOInfo{
+		{AllocationKey: "alloc-1", AllocationTime: start.UnixNano(), ResourcePerAlloc: map[string]int64{"vcore": 2000}},
+	}
+	alice.Requests = []*dao.AllocationAskDAOInfo{
+		{AllocationKey: "alloc-1", ResourcePerAlloc: map[string]int64{"vcore": 2000}},
+		{AllocationKey: "ask-1", ResourcePerAlloc: map[string]int64{"vcore": 2000}},
+	}
+	// the allocated resource-seconds are read from the accounting record
+	bob := newApp("app-bob", "root.b", "bob", start, time.Hour)
+	bob.MaxUsedResource = map[string]int64{"vcore": 1000}
+	bob.Allocations = []*dao.AllocationDAOInfo{
+		{AllocationKey: "alloc-2", AllocationTime: start.UnixNano(), ResourcePerAlloc: map[string]int64{"vcore": 1000}},
+	}
+	// submitted before the period
+	early := newApp("app-early", "root.a", "alice", start.Add(-time.Hour), time.Hour)
+	early.MaxUsedResource = map[string]int64{"vcore": 8000}
+
+	for _, app := range []*model.Application{alice, bob, early} {
+		require.NoError(es.T(), repo.InsertApplication(ctx, app))
+	}
+	require.NoError(es.T(), repo.InsertEvent(ctx, &model.Event{
+		TimestampNano: start.Add(time.Hour).UnixNano(),
+		Type:          "APP",
+		ObjectID:      "app-alice",
+		ReferenceID:   "alloc-1",
+		ChangeType:    "REMOVE",
+		ChangeDetail:  "ALLOC_CANCEL",
+	}))
+	record := model.NewAccountingRecord(bob, start.Add(time.Hour).UnixNano())
+	record.TotalResource = map[string]int64{"vcore": 3600000}
+	inserted, err := repo.InsertAccountingRecord(ctx, record)
+	require.NoError(es.T(), err)
+	require.True(es.T(), inserted)
+}
+
+func (es *EfficiencyIntTest) TearDownSuite() {
+	es.pool.Close()
+}
+
+func (es *EfficiencyIntTest) TestGetEfficiency() {
+	ctx := context.Background()
+	filters := EfficiencyFilters{
+		Start:    es.start,
+		End:      es.start.Add(24 * time.Hour),
+		Resource: "vcore",
+		RankBy:   EfficiencyRankByIdle,
+		Limit:    10,
+	}
+	efficiencies, err := es.repo.GetEfficiency(ctx, filters)
+	require.NoError(es.T(), err)
+	report := model.NewEfficiencyReport(0, 0, "vcore", string(filters.RankBy), efficiencies)
+
+	require.Len(es.T(), report.Applications, 2)
+	alice := report.Applications[0]
+	assert.Equal(es.T(), util.ToPtr("app-alice"), alice.ApplicationID)
+	assert.Equal(es.T(), util.ToPtr("root.a"), alice.Queue)
+	assert.Equal(es.T(), util.ToPtr("alice"), alice.User)
+	assert.InDelta(es.T(), 4000, alice.Requested, 1e-6)
+	assert.InDelta(es.T(), 0.5, alice.MaxUsedRatio, 1e-6)
+	assert.InDelta(es.T(), 2000*3600, alice.AllocatedSeconds, 1e-6)
+	assert.InDelta(es.T(), 2000*7200, alice.ReservedSeconds, 1e-6)
+	assert.InDelta(es.T(), 0.5, alice.AllocatedRatio, 1e-6)
+	bob := report.Applications[1]
+	assert.Equal(es.T(), util.ToPtr("app-bob"), bob.ApplicationID)
+	assert.InDelta(es.T(), 1, bob.MaxUsedRatio, 1e-6)
+	assert.InDelta(es.T(), 1, bob.AllocatedRatio, 1e-6)
+	assert.Zero(es.T(), bob.IdleSeconds)
+
+	require.Len(es.T(), report.Users, 2)
+	assert.Equal(es.T(), util.ToPtr("alice"), report.Users[0].User)
+	assert.Nil(es.T(), report.Users[0].Queue)
+	assert.Equal(es.T(), int64(1), report.Users[0].Applications, "the application submitted before the period is excluded")
+	assert.Equal(es.T(), util.ToPtr("bob"), report.Users[1].User)
+	require.Len(es.T(), report.Queues, 2)
+	assert.Equal(es.T(), util.ToPtr("root.a"), report.Queues[0].Queue)
+	assert.Equal(es.T(), util.ToPtr("root.b"), report.Queues[1].Queue)
+
+	filters.Limit = 1
+	filters.RankBy = EfficiencyRankByUnused
+	filters.Queue = util.ToPtr("root")
+	efficiencies, err = es.repo.GetEfficiency(ctx, filters)
+	require.NoError(es.T(), err)
+	report = model.NewEfficiencyReport(0, 0, "vcore", string(filters.RankBy), efficiencies)
+	require.Len(es.T(), report.Applications, 1)
+	assert.Equal(es.T(), util.ToPtr("app-alice"), report.Applications[0].ApplicationID)
+	require.Len(es.T(), report.Users, 1)
+	require.Len(es.T(), report.Queues, 1)
+
+	filters.User = util.ToPtr("carol")
+	efficiencies, err = es.repo.GetEfficiency(ctx, filters)
+	require.NoError(es.T(), err)
+	require.Empty(es.T(), efficiencies)
+}
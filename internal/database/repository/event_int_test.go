@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/G-Research/unicorn-history-server/internal/model"
+	"github.com/G-Research/unicorn-history-server/internal/util"
+)
+
+type EventIntTest struct {
+	suite.Suite
+	pool *pgxpool.Pool
+	repo *PostgresRepository
+}
+
+func (es *EventIntTest) SetupSuite() {
+	require.NotNil(es.T(), es.pool)
+	repo, err := NewPostgresRepository(es.pool)
+	require.NoError(es.T(), err)
+	es.repo = repo
+}
+
+func (es *EventIntTest) TearDownSuite() {
+	es.pool.Close()
+}
+
+func (es *EventIntTest) TestInsertAndGetEvents() {
+	ctx := context.Background()
+	now := time.Now()
+	nodeID := ulid.Make().String()
+
+	events := []*model.Event{
+		{
+			TimestampNano: now.Add(-2 * time.Minute).UnixNano(),
+			Type:          "NODE",
+			ObjectID:      nodeID,
+			ChangeType:    "ADD",
+			ChangeDetail:  "DETAILS_NONE",
+			Resource:      map[string]int64{"memory": 1024},
+		},
+		{
+			TimestampNano: now.Add(-1 * time.Minute).UnixNano(),
+			Type:          "NODE",
+			ObjectID:      nodeID,
+			ChangeType:    "SET",
+			ChangeDetail:  "NODE_SCHEDULABLE",
+			Message:       "schedulable: false",
+		},
+		{
+			TimestampNano: now.UnixNano(),
+			Type:          "APP",
+			ObjectID:      nodeID,
+			ChangeType:    "ADD",
+			ChangeDetail:  "APP_NEW",
+		},
+	}
+	for _, event := range events {
+		require.NoError(es.T(), es.repo.InsertEvent(ctx, event))
+		assert.NotZero(es.T(), event.ID)
+	}
+
+	tests := []struct {
+		name     string
+		filters  EventFilters
+		expected []*model.Event
+	}{
+		{
+			name:     "Filter by type and object",
+			filters:  EventFilters{Type: util.ToPtr("NODE"), ObjectID: &nodeID},
+			expected: events[:2],
+		},
+		{
+			name:     "Filter by time range",
+			filters:  EventFilters{ObjectID: &nodeID, TimestampStart: util.ToPtr(now.Add(-90 * time.Second))},
+			expected: events[1:],
+		},
+		{
+			name:     "Limit",
+			filters:  EventFilters{ObjectID: &nodeID, Limit: util.ToPtr(1)},
+			expected: events[:1],
+		},
+		{
+			name:     "Limit to the newest events",
+			filters:  EventFilters{ObjectID: &nodeID, NewestFirst: true, Limit: util.ToPtr(2)},
+			expected: []*model.Event{events[2], events[1]},
+		},
+	}
+	for _, tt := range tests {
+		es.Run(tt.name, func() {
+			result, err := es.repo.GetEvents(ctx, tt.filters)
+			require.NoError(es.T(), err)
+			assert.Equal(es.T(), tt.expected, result)
+		})
+	}
+}
+
+func (es *EventIntTest) TestDeleteEventsBefore() {
+	ctx := context.Background()
+	now := time.Now()
+	objectID := ulid.Make().String()
+
+	old := &model.Event{
+		TimestampNano: now.Add(-48 * time.Hour).UnixNano(),
+		Type:          "APP",
+		ObjectID:      objectID,
+		ChangeType:    "ADD",
+		ChangeDetail:  "APP_NEW",
+	}
+	recent := &model.Event{
+		TimestampNano: now.UnixNano(),
+		Type:          "APP",
+		ObjectID:      objectID,
+		ChangeType:    "REMOVE",
+		ChangeDetail:  "DETAILS_NONE",
+	}
+	require.NoError(es.T(), es.repo.InsertEvent(ctx, old))
+	require.NoError(es.T(), es.repo.InsertEvent(ctx, recent))
+
+	deleted, err := es.repo.DeleteEventsBefore(ctx, now.Add(-24*time.Hour).UnixNano())
+	require.NoError(es.T(), err)
+	assert.GreaterOrEqual(es.T(), deleted, int64(1))
+
+	result, err := es.repo.GetEvents(ctx, EventFilters{ObjectID: &objectID})
+	require.NoError(es.T(), err)
+	assert.Equal(es.T(), []*model.Event{recent}, result)
+}
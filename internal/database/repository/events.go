@@ -4,15 +4,19 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/G-Research/yunikorn-scheduler-interface/lib/go/si"
+	"github.com/jackc/pgx/v5"
 
-	"github.com/G-Research/unicorn-history-server/internal/yunikorn/model"
+	"github.com/G-Research/unicorn-history-server/internal/database/sql"
+	"github.com/G-Research/unicorn-history-server/internal/model"
+	ykmodel "github.com/G-Research/unicorn-history-server/internal/yunikorn/model"
 )
 
 type EventRepository interface {
 	// Counts returns a map of event types to their counts.
-	Counts(ctx context.Context) (model.EventTypeCounts, error)
+	Counts(ctx context.Context) (ykmodel.EventTypeCounts, error)
 	// Record increments the count of the given event type.
 	Record(ctx context.Context, event *si.EventRecord) error
 }
@@ -21,22 +25,22 @@ type EventRepository interface {
 // TODO: This implementation is not resilient to crashes and will lose all data when the process is restarted.
 type InMemoryEventRepository struct {
 	mutex  sync.Mutex
-	counts model.EventTypeCounts
+	counts ykmodel.EventTypeCounts
 }
 
 func NewInMemoryEventRepository() *InMemoryEventRepository {
 	return &InMemoryEventRepository{
-		counts: make(model.EventTypeCounts),
+		counts: make(ykmodel.EventTypeCounts),
 	}
 }
 
-func (r *InMemoryEventRepository) Counts(ctx context.Context) (model.EventTypeCounts, error) {
+func (r *InMemoryEventRepository) Counts(ctx context.Context) (ykmodel.EventTypeCounts, error) {
 	// We must lock and make a copy of the original map to avoid
 	// "concurrent map read and map write" panics, if the caller
 	// of this func reads from the returned result of this func.
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
-	countsCopy := model.EventTypeCounts{}
+	countsCopy := ykmodel.EventTypeCounts{}
 	for k, v := range r.counts {
 		countsCopy[k] = v
 	}
@@ -60,3 +64,131 @@ func (r *InMemoryEventRepository) Record(ctx context.Context, event *si.EventRec
 func getKey(e *si.EventRecord) string {
 	return fmt.Sprintf("%s-%s", e.GetType().String(), e.GetEventChangeType().String())
 }
+
+type EventFilters struct {
+	Type           *string
+	ObjectID       *string
+	TimestampStart *time.Time
+	TimestampEnd   *time.Time
+	// NewestFirst sorts the events from the newest to the oldest, so that Limit keeps the newest events.
+	NewestFirst bool
+	Limit       *int
+}
+
+// InsertEvent stores an event of the YuniKorn event stream.
+func (s *PostgresRepository) InsertEvent(ctx context.Context, event *model.Event) error {
+	const q = `
+INSERT INTO events (
+	timestamp_nano,
+	type,
+	object_id,
+	reference_id,
+	change_type,
+	change_detail,
+	message,
+	resource
+) VALUES (
+	@timestamp_nano,
+	@type,
+	@object_id,
+	@reference_id,
+	@change_type,
+	@change_detail,
+	@message,
+	@resource
+) RETURNING id`
+
+	err := s.dbpool.QueryRow(ctx, q,
+		pgx.NamedArgs{
+			"timestamp_nano": event.TimestampNano,
+			"type":           event.Type,
+			"object_id":      event.ObjectID,
+			"reference_id":   event.ReferenceID,
+			"change_type":    event.ChangeType,
+			"change_detail":  event.ChangeDetail,
+			"message":        event.Message,
+			"resource":       event.Resource,
+		},
+	).Scan(&event.ID)
+	if err != nil {
+		return fmt.Errorf("could not insert event into DB: %v", err)
+	}
+	return nil
+}
+
+// GetEvents returns the stored events which match the filters, sorted from the oldest to the newest,
+// or from the newest to the oldest if NewestFirst is set.
+func (s *PostgresRepository) GetEvents(ctx context.Context, filters EventFilters) ([]*model.Event, error) {
+	direction := sql.OrderByAscending
+	if filters.NewestFirst {
+		direction = sql.OrderByDescending
+	}
+	queryBuilder := sql.NewBuilder().
+		Select(
+			"id",
+			"timestamp_nano",
+			"type",
+			"object_id",
+			"COALESCE(reference_id, '')",
+			"change_type",
+			"change_detail",
+			"COALESCE(message, '')",
+			"resource",
+		).
+		From("events", "").
+		OrderBy("timestamp_nano", direction).
+		OrderBy("id", direction)
+	if filters.Type != nil {
+		queryBuilder.Where(sql.Eq("type", *filters.Type))
+	}
+	if filters.ObjectID != nil {
+		queryBuilder.Where(sql.Eq("object_id", *filters.ObjectID))
+	}
+	if filters.TimestampStart != nil {
+		queryBuilder.Where(sql.Cmp("timestamp_nano", sql.OpGe, filters.TimestampStart.UnixNano()))
+	}
+	if filters.TimestampEnd != nil {
+		queryBuilder.Where(sql.Cmp("timestamp_nano", sql.OpLe, filters.TimestampEnd.UnixNano()))
+	}
+	applyLimitAndOffset(queryBuilder, filters.Limit, nil)
+
+	query, args := queryBuilder.Build()
+	rows, err := s.dbpool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get events from DB: %v", err)
+	}
+	defer rows.Close()
+
+	var events []*model.Event
+	for rows.Next() {
+		var event model.Event
+		if err := rows.Scan(
+			&event.ID,
+			&event.TimestampNano,
+			&event.Type,
+			&event.ObjectID,
+			&event.ReferenceID,
+			&event.ChangeType,
+			&event.ChangeDetail,
+			&event.Message,
+			&event.Resource,
+		); err != nil {
+			return nil, fmt.Errorf("could not scan event from DB: %v", err)
+		}
+		events = append(events, &event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get events from DB: %v", err)
+	}
+	return events, nil
+}
+
+// DeleteEventsBefore deletes the stored events which happened before the time, and returns the number of deleted events.
+func (s *PostgresRepository) DeleteEventsBefore(ctx context.Context, beforeNano int64) (int64, error) {
+	const q = `DELETE FROM events WHERE timestamp_nano < @before_nano`
+	result, err := s.dbpool.Exec(ctx, q, pgx.NamedArgs{"before_nano": beforeNano})
+	if err != nil {
+		return 0, fmt.Errorf("could not delete events from DB: %v", err)
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/G-Research/unicorn-history-server/internal/model"
+)
+
+// FairnessFilters select the period and the queues of a fairness report.
+type FairnessFilters struct {
+	Start     time.Time
+	End       time.Time
+	Partition *string
+	// Queue selects the queues in the subtree of a queue, and their applications.
+	Queue *string
+}
+
+// fairnessCapacities is the common table expression of the capacities of the partitions during the period
+// [@start, @end), which is the time-weighted average of the maximum resources of their root queues.
+const fairnessCapacities = `
+capacities AS (
+	SELECT c.partition_id, jsonb_object_agg(c.key, c.capacity) AS capacity
+	FROM (
+		SELECT
+			r.partition_id,
+			res.key,
+			SUM(res.value::DOUBLE PRECISION * (iv.to_nano - iv.from_nano)) / SUM(iv.to_nano - iv.from_nano) AS capacity
+		FROM queue_versions v
+		CROSS JOIN LATERAL jsonb_populate_record(NULL::queues, v.data) r
+		CROSS JOIN LATERAL (
+			SELECT
+				GREATEST(v.valid_from_nano, @start::BIGINT) AS from_nano,
+				LEAST(COALESCE(v.valid_to_nano, @end::BIGINT), @end::BIGINT) AS to_nano
+		) iv
+		CROSS JOIN LATERAL jsonb_each_text(r.max_resource) res
+		WHERE r.queue_name = 'root' AND r.deleted_at_nano IS NULL AND iv.to_nano > iv.from_nano
+		GROUP BY 1, 2
+	) c
+	GROUP BY 1
+)`
+
+// queueFairnessQuery computes how long the queues were starved, borrowing or capped during the period [@start, @end)
+// from the versions of the queues, and their average allocated resources. Only the resources of the guarantee of
+// a queue are compared to its guarantee, since a queue does not guarantee the resources which it does not list.
+var queueFairnessQuery = `
+WITH ` + fairnessCapacities + `,
+intervals AS (
+	SELECT
+		r.partition_id,
+		r.queue_name,
+		iv.to_nano - iv.from_nano AS duration,
+		r.allocated_resource,
+		EXISTS (
+			SELECT 1 FROM jsonb_each_text(r.guaranteed_resource) g
+			WHERE COALESCE((r.allocated_resource->>g.key)::BIGINT, 0) < g.value::BIGINT
+				AND COALESCE((r.pending_resource->>g.key)::BIGINT, 0) > 0
+		) AS starved,
+		EXISTS (
+			SELECT 1 FROM jsonb_each_text(r.guaranteed_resource) g
+			WHERE COALESCE((r.allocated_resource->>g.key)::BIGINT, 0) > g.value::BIGINT
+		) AS borrowing,
+		EXISTS (
+			SELECT 1 FROM jsonb_each_text(r.max_resource) m
+			WHERE m.value::BIGINT > 0 AND COALESCE((r.allocated_resource->>m.key)::BIGINT, 0) >= m.value::BIGINT
+		) AS capped
+	FROM queue_versions v
+	CROSS JOIN LATERAL jsonb_populate_record(NULL::queues, v.data) r
+	CROSS JOIN LATERAL (
+		SELECT
+			GREATEST(v.valid_from_nano, @start::BIGINT) AS from_nano,
+			LEAST(COALESCE(v.valid_to_nano, @end::BIGINT), @end::BIGINT) AS to_nano
+	) iv
+	WHERE r.deleted_at_nano IS NULL AND iv.to_nano > iv.from_nano
+		AND (@partition::TEXT IS NULL OR r.partition_id IN (SELECT id FROM partitions WHERE name = @partition))
+		AND ` + queueSubtreeFilter("r.queue_name") + `
+),
+averages AS (
+	SELECT a.partition_id, a.queue_name, jsonb_object_agg(a.key, a.average) AS average
+	FROM (
+		SELECT
+			i.partition_id,
+			i.queue_name,
+			res.key,
+			SUM(res.value::DOUBLE PRECISION * i.duration) / (@end::BIGINT - @start::BIGINT) AS average
+		FROM intervals i
+		CROSS JOIN LATERAL jsonb_each_text(i.allocated_resource) res
+		GROUP BY 1, 2, 3
+	) a
+	GROUP BY 1, 2
+)
+SELECT
+	s.partition_id,
+	s.queue_name,
+	s.observed,
+	s.starved,
+	s.borrowing,
+	s.capped,
+	a.average,
+	c.capacity
+FROM (
+	SELECT
+		partition_id,
+		queue_name,
+		SUM(duration) AS observed,
+		COALESCE(SUM(duration) FILTER (WHERE starved), 0) AS starved,
+		COALESCE(SUM(duration) FILTER (WHERE borrowing), 0) AS borrowing,
+		COALESCE(SUM(duration) FILTER (WHERE capped), 0) AS capped
+	FROM intervals
+	GROUP BY 1, 2
+) s
+LEFT JOIN averages a ON a.partition_id = s.partition_id AND a.queue_name = s.queue_name
+LEFT JOIN capacities c ON c.partition_id = s.partition_id
+ORDER BY s.partition_id, s.queue_name`
+
+// userSharesQuery computes the average allocated resources of the applications of the users
+// during the period [@start, @end). The resources allocated during the rolled up period [@rollup_start, @rollup_end)
+// are read from the usage rollups of the width @width_seconds, like in usageQuery, and the rest from the allocations.
+var userSharesQuery = `
+WITH ` + usageAllocations + `,
+` + fairnessCapacities + `,
+allocated AS (
+	SELECT
+		al.partition_id,
+		COALESCE(al."user", '') AS "user",
+		res.key,
+		res.value::DOUBLE PRECISION * (al.end_nano - al.start_nano
+			- GREATEST(LEAST(al.end_nano, @rollup_end) - GREATEST(al.start_nano, @rollup_start), 0)) AS nanos
+	FROM allocations al
+	CROSS JOIN LATERAL jsonb_each_text(al.resource) res
+	WHERE al.end_nano > al.start_nano
+	UNION ALL
+	SELECT r.partition_id, r."user", res.key, res.value::DOUBLE PRECISION * 1e9
+	FROM usage_rollups r
+	CROSS JOIN LATERAL jsonb_each_text(r.resource_seconds) res
+	WHERE r.width_seconds = @width_seconds AND r.bucket >= @rollup_start AND r.bucket < @rollup_end
+		AND (@partition::TEXT IS NULL OR r.partition = @partition)
+		AND ` + queueSubtreeFilter("r.queue_name") + `
+)
+SELECT u.partition_id, u."user", jsonb_object_agg(u.key, u.average), c.capacity
+FROM (
+	SELECT partition_id, "user", key, SUM(nanos) / (@end::BIGINT - @start::BIGINT) AS average
+	FROM allocated
+	GROUP BY 1, 2, 3
+) u
+LEFT JOIN capacities c ON c.partition_id = u.partition_id
+GROUP BY u.partition_id, u."user", c.capacity
+ORDER BY 1, 2`
+
+func (f FairnessFilters) namedArgs() pgx.NamedArgs {
+	args := pgx.NamedArgs{
+		"start":     f.Start.UnixNano(),
+		"end":       f.End.UnixNano(),
+		"partition": f.Partition,
+	}
+	maps.Copy(args, queueFilterArgs(f.Queue))
+	return args
+}
+
+// GetQueueFairness returns how long the queues which match the filters were starved, borrowing or capped
+// during the period of the filters, and their dominant resource share of the capacity of their partition.
+func (s *PostgresRepository) GetQueueFairness(ctx context.Context, filters FairnessFilters) ([]*model.QueueFairness, error) {
+	if !filters.End.After(filters.Start) {
+		return nil, fmt.Errorf("invalid period from %s to %s", filters.Start, filters.End)
+	}
+	rows, err := s.dbpool.Query(ctx, queueFairnessQuery, filters.namedArgs())
+	if err != nil {
+		return nil, fmt.Errorf("could not get queue fairness from DB: %v", err)
+	}
+	defer rows.Close()
+
+	var queues []*model.QueueFairness
+	for rows.Next() {
+		var q model.QueueFairness
+		var observed, starved, borrowing, capped int64
+		var average, capacity map[string]float64
+		if err := rows.Scan(&q.PartitionID, &q.Queue, &observed, &starved, &borrowing, &capped, &average, &capacity); err != nil {
+			return nil, fmt.Errorf("could not scan queue fairness from DB: %v", err)
+		}
+		q.ObservedTime = time.Duration(observed).Milliseconds()
+		q.StarvedTime = time.Duration(starved).Milliseconds()
+		q.BorrowingTime = time.Duration(borrowing).Milliseconds()
+		q.CappedTime = time.Duration(capped).Milliseconds()
+		if observed > 0 {
+			q.StarvedRatio = float64(starved) / float64(observed)
+			q.BorrowingRatio = float64(borrowing) / float64(observed)
+			q.CappedRatio = float64(capped) / float64(observed)
+		}
+		q.ResourceShare = model.NewResourceShare(average, capacity)
+		queues = append(queues, &q)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get queue fairness from DB: %v", err)
+	}
+	return queues, nil
+}
+
+// GetUserShares returns the dominant resource shares of the users, computed from the allocations of their
+// applications in the queues which match the filters during the period of the filters. The allocations during the
+// part of the period which is covered by the usage rollups are read from them.
+func (s *PostgresRepository) GetUserShares(ctx context.Context, filters FairnessFilters) ([]*model.UserShare, error) {
+	if !filters.End.After(filters.Start) {
+		return nil, fmt.Errorf("invalid period from %s to %s", filters.Start, filters.End)
+	}
+	width, rollupStart, rollupEnd, err := s.rolledUpPeriod(ctx, RollupUsage, time.Unix(0, 0), 0, filters.Start, filters.End)
+	if err != nil {
+		return nil, fmt.Errorf("could not get user shares from DB: %v", err)
+	}
+	if width == 0 {
+		rollupStart, rollupEnd = filters.End, filters.End
+	}
+	args := filters.namedArgs()
+	args["rollup_start"] = rollupStart.UnixNano()
+	args["rollup_end"] = rollupEnd.UnixNano()
+	args["width_seconds"] = int64(width / time.Second)
+
+	rows, err := s.dbpool.Query(ctx, userSharesQuery, args)
+	if err != nil {
+		return nil, fmt.Errorf("could not get user shares from DB: %v", err)
+	}
+	defer rows.Close()
+
+	var users []*model.UserShare
+	for rows.Next() {
+		var u model.UserShare
+		var average, capacity map[string]float64
+		if err := rows.Scan(&u.PartitionID, &u.User, &average, &capacity); err != nil {
+			return nil, fmt.Errorf("could not scan user shares from DB: %v", err)
+		}
+		u.ResourceShare = model.NewResourceShare(average, capacity)
+		users = append(users, &u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get user shares from DB: %v", err)
+	}
+	return users, nil
+}
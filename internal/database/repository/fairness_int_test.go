@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/G-Research/yunikorn-core/pkg/webservice/dao"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/G-Research/unicorn-history-server/internal/model"
+	"github.com/G-Research/unicorn-history-server/internal/util"
+)
+
+type FairnessIntTest struct {
+	suite.Suite
+	pool  *pgxpool.Pool
+	repo  *PostgresRepository
+	start time.Time
+}
+
+func (fs *FairnessIntTest) SetupSuite() {
+	require.NotNil(fs.T(), fs.pool)
+	repo, err := NewPostgresRepository(fs.pool)
+	require.NoError(fs.T(), err)
+	fs.repo = repo
+
+	ctx := context.Background()
+	fs.start = time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	start := fs.start
+
+	// the versions are recorded directly, since the versions of updated queues are valid from the time of the update
+	insertVersion := func(id string, from time.Time, to *time.Time, data map[string]any) {
+		data["id"] = id
+		data["partition_id"] = "1"
+		raw, err := json.Marshal(data)
+		require.NoError(fs.T(), err)
+		var validTo *int64
+		if to != nil {
+			validTo = util.ToPtr(to.UnixNano())
+		}
+		_, err = fs.pool.Exec(ctx,
+			"INSERT INTO queue_versions(object_id, valid_from_nano, valid_to_nano, data) VALUES ($1, $2, $3, $4)",
+			id, from.UnixNano(), validTo, raw)
+		require.NoError(fs.T(), err)
+	}
+
+	insertVersion("root", start.Add(-time.Hour), nil, map[string]any{
+		"queue_name":   "root",
+		"max_resource": map[string]int64{"vcore": 10000, "memory": 1000},
+	})
+	// starved for 15 minutes, then borrowing for 45 minutes, of which it is capped for 15 minutes
+	mlResources := map[string]any{
+		"queue_name":          "root.ml",
+		"guaranteed_resource": map[string]int64{"vcore": 4000},
+		"max_resource":        map[string]int64{"vcore": 8000},
+	}
+	withAllocation := func(allocated, pending map[string]int64) map[string]any {
+		data := map[string]any{"allocated_resource": allocated, "pending_resource": pending}
+		for k, v := range mlResources {
+			data[k] = v
+		}
+		return data
+	}
+	insertVersion("root.ml", start, util.ToPtr(start.Add(15*time.Minute)),
+		withAllocation(map[string]int64{"vcore": 2000}, map[string]int64{"vcore": 1000}))
+	insertVersion("root.ml", start.Add(15*time.Minute), util.ToPtr(start.Add(45*time.Minute)),
+		withAllocation(map[string]int64{"vcore": 6000, "memory": 100}, nil))
+	insertVersion("root.ml", start.Add(45*time.Minute), nil,
+		withAllocation(map[string]int64{"vcore": 8000, "memory": 100}, nil))
+	// without a guarantee it is neither starved nor borrowing, and it is deleted during the period
+	insertVersion("root.batch", start.Add(-2*time.Hour), util.ToPtr(start.Add(30*time.Minute)), map[string]any{
+		"queue_name":         "root.batch",
+		"allocated_resource": map[string]int64{"vcore": 1000},
+		"pending_resource":   map[string]int64{"vcore": 1000},
+	})
+	insertVersion("root.batch", start.Add(30*time.Minute), nil, map[string]any{
+		"queue_name":      "root.batch",
+		"deleted_at_nano": start.Add(30 * time.Minute).UnixNano(),
+	})
+
+	require.NoError(fs.T(), repo.InsertApplication(ctx, &model.Application{
+		Metadata: model.Metadata{CreatedAtNano: start.UnixNano()},
+		ApplicationDAOInfo: dao.ApplicationDAOInfo{
+			ID:             ulid.Make().String(),
+			ApplicationID:  "app-alice",
+			PartitionID:    "1",
+			Partition:      "default",
+			QueueName:      "root.ml",
+			SubmissionTime: start.Add(-time.Hour).UnixMilli(),
+			User:           "alice",
+			Allocations: []*dao.AllocationDAOInfo{
+				{
+					AllocationKey:    "alloc-1",
+					AllocationTime:   start.Add(30 * time.Minute).UnixNano(),
+					ResourcePerAlloc: map[string]int64{"vcore": 2000},
+				},
+			},
+		},
+	}))
+}
+
+func (fs *FairnessIntTest) TearDownSuite() {
+	fs.pool.Close()
+}
+
+func (fs *FairnessIntTest) TestGetQueueFairness() {
+	ctx := context.Background()
+	filters := FairnessFilters{Start: fs.start, End: fs.start.Add(time.Hour)}
+	queues, err := fs.repo.GetQueueFairness(ctx, filters)
+	require.NoError(fs.T(), err)
+	require.Len(fs.T(), queues, 3)
+
+	assert.Equal(fs.T(), "root", queues[0].Queue)
+	assert.Equal(fs.T(), time.Hour.Milliseconds(), queues[0].ObservedTime)
+	assert.Empty(fs.T(), queues[0].DominantResource)
+
+	batch := queues[1]
+	assert.Equal(fs.T(), "root.batch", batch.Queue)
+	assert.Equal(fs.T(), (30 * time.Minute).Milliseconds(), batch.ObservedTime)
+	assert.Zero(fs.T(), batch.StarvedTime)
+	assert.Zero(fs.T(), batch.BorrowingTime)
+	assert.Equal(fs.T(), "vcore", batch.DominantResource)
+	assert.InDelta(fs.T(), 0.05, batch.DominantShare, 1e-9)
+
+	ml := queues[2]
+	assert.Equal(fs.T(), "1", ml.PartitionID)
+	assert.Equal(fs.T(), "root.ml", ml.Queue)
+	assert.Equal(fs.T(), time.Hour.Milliseconds(), ml.ObservedTime)
+	assert.Equal(fs.T(), (15 * time.Minute).Milliseconds(), ml.StarvedTime)
+	assert.InDelta(fs.T(), 0.25, ml.StarvedRatio, 1e-9)
+	assert.Equal(fs.T(), (45 * time.Minute).Milliseconds(), ml.BorrowingTime)
+	assert.InDelta(fs.T(), 0.75, ml.BorrowingRatio, 1e-9)
+	assert.Equal(fs.T(), (15 * time.Minute).Milliseconds(), ml.CappedTime)
+	assert.InDelta(fs.T(), 0.25, ml.CappedRatio, 1e-9)
+	assert.InDelta(fs.T(), 5500, ml.AverageResource["vcore"], 1e-6)
+	assert.InDelta(fs.T(), 75, ml.AverageResource["memory"], 1e-6)
+	assert.Equal(fs.T(), "vcore", ml.DominantResource)
+	assert.InDelta(fs.T(), 0.55, ml.DominantShare, 1e-9)
+
+	filters.Queue = util.ToPtr("root.ml")
+	queues, err = fs.repo.GetQueueFairness(ctx, filters)
+	require.NoError(fs.T(), err)
+	require.Len(fs.T(), queues, 1)
+	assert.Equal(fs.T(), "root.ml", queues[0].Queue)
+	assert.InDelta(fs.T(), 0.55, queues[0].DominantShare, 1e-9)
+}
+
+func (fs *FairnessIntTest) TestGetUserShares() {
+	users, err := fs.repo.GetUserShares(context.Background(), FairnessFilters{Start: fs.start, End: fs.start.Add(time.Hour)})
+	require.NoError(fs.T(), err)
+	require.Len(fs.T(), users, 1)
+	assert.Equal(fs.T(), "1", users[0].PartitionID)
+	assert.Equal(fs.T(), "alice", users[0].User)
+	assert.InDelta(fs.T(), 1000, users[0].AverageResource["vcore"], 1e-6)
+	assert.Equal(fs.T(), "vcore", users[0].DominantResource)
+	assert.InDelta(fs.T(), 0.1, users[0].DominantShare, 1e-9)
+}
+
+func (fs *FairnessIntTest) TestInvalidPeriod() {
+	_, err := fs.repo.GetQueueFairness(context.Background(), FairnessFilters{Start: fs.start, End: fs.start})
+	require.Error(fs.T(), err)
+}
@@ -1,6 +1,13 @@
 package repository
 
 import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
 	"github.com/G-Research/unicorn-history-server/internal/database/sql"
 )
 
@@ -13,3 +20,76 @@ func applyLimitAndOffset(builder *sql.Builder, limit *int, offset *int) {
 		builder.Offset(*offset)
 	}
 }
+
+// applyTemporalFilters restricts the sql query to the rows which existed at the given point in time.
+// If asOf is nil, the current state is queried, so only rows which are not deleted are returned.
+// If includeDeleted is true, rows which were deleted (before asOf, if set) are returned as well.
+func applyTemporalFilters(builder *sql.Builder, asOf *time.Time, includeDeleted bool) {
+	if asOf != nil {
+		builder.Where(sql.Cmp("created_at_nano", sql.OpLe, asOf.UnixNano()))
+		if !includeDeleted {
+			// rows which are not deleted are treated as if they were deleted at the end of time
+			builder.Where(sql.Raw("COALESCE(deleted_at_nano, $1) > $2", int64(math.MaxInt64), asOf.UnixNano()))
+		}
+		return
+	}
+	if !includeDeleted {
+		builder.Where(sql.IsNull("deleted_at_nano"))
+	}
+}
+
+// applyVersionsAsOf makes the sql query read the rows of the table as they were at the given point in time,
+// from the version of each row which was valid then, rather than their current values.
+// The versions table keeps the versions of the rows of the table (see the row-versions migration).
+// If asOf is nil, the current rows are queried.
+func applyVersionsAsOf(builder *sql.Builder, table, versionsTable string, asOf *time.Time) {
+	if asOf == nil {
+		return
+	}
+	builder.FromSubquery(sql.Raw(
+		fmt.Sprintf(
+			"SELECT (jsonb_populate_record(NULL::%s, v.data)).* FROM %s v "+
+				"WHERE v.valid_from_nano <= $1 AND COALESCE(v.valid_to_nano, $2) > $1",
+			table, versionsTable,
+		),
+		asOf.UnixNano(), int64(math.MaxInt64),
+	), table)
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern, so the value is matched literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// escapeLike escapes the value, so it can be used as a literal part of a LIKE pattern.
+func escapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
+
+// queueSubtreeFilter returns the condition of a query which selects the rows whose queue, in the column, is in
+// the subtree of the queue @queue: the queue itself and the queues below it. All the rows are selected if @queue
+// is NULL. The query takes the named arguments of queueFilterArgs.
+func queueSubtreeFilter(column string) string {
+	return fmt.Sprintf("(@queue::TEXT IS NULL OR %[1]s = @queue OR %[1]s LIKE @queue_pattern)", column)
+}
+
+// queueSubtree is the condition of a query builder which selects the rows whose queue, in the column, is in
+// the subtree of the queue, like queueSubtreeFilter.
+func queueSubtree(column string, queue string) sql.Expr {
+	return sql.Or(
+		sql.Eq(column, queue),
+		sql.Cmp(column, sql.OpLike, escapeLike(queue)+".%"),
+	)
+}
+
+// queueFilterArgs returns the named arguments of queueSubtreeFilter for the full path of a queue,
+// or for no queue if it is nil.
+func queueFilterArgs(queue *string) pgx.NamedArgs {
+	var queuePattern *string
+	if queue != nil {
+		pattern := escapeLike(*queue) + ".%"
+		queuePattern = &pattern
+	}
+	return pgx.NamedArgs{
+		"queue":         queue,
+		"queue_pattern": queuePattern,
+	}
+}
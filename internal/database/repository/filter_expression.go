@@ -0,0 +1,577 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/G-Research/unicorn-history-server/internal/database/sql"
+)
+
+// A filter expression is a small language for ad-hoc filters of the list endpoints, e.g.
+//
+//	applicationState in (Failed,Rejected) and (user = "bob" or queueName ~ "root.ml.*")
+//
+// Grammar (keywords are case-insensitive):
+//
+//	expression := and { "or" and }
+//	and        := unary { "and" unary }
+//	unary      := "not" unary | "(" expression ")" | condition
+//	condition  := field operator value
+//	            | field [ "not" ] "in" "(" value { "," value } ")"
+//	            | field "is" [ "not" ] "null"
+//	operator   := "=" | "!=" | "<" | "<=" | ">" | ">=" | "~" | "!~"
+//	value      := word | "double-quoted" | 'single-quoted'
+//
+// '~' matches a glob pattern, in which '*' matches any characters and '?' matches a single character.
+// The fields and the operators which they support are defined by a FilterSchema.
+// Values are always passed to the database as arguments, never as literals.
+
+const (
+	// maxFilterExpressionLength is the maximum length of a filter expression.
+	maxFilterExpressionLength = 4096
+	// maxFilterExpressionDepth is the maximum nesting depth of parentheses and negations.
+	maxFilterExpressionDepth = 32
+)
+
+// FilterExpressionError is the error of an invalid filter expression.
+type FilterExpressionError struct {
+	// Position is the position in the expression (starting at 1) at which the error was detected.
+	Position int
+	Message  string
+}
+
+func (e *FilterExpressionError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Message, e.Position)
+}
+
+// filterFieldType is the type of a field of a filter expression, which defines how values are parsed and compared.
+type filterFieldType int
+
+const (
+	filterTypeString filterFieldType = iota
+	filterTypeInt
+	// filterTypeTime is a time in milliseconds since the epoch. Values are milliseconds or RFC 3339 times.
+	filterTypeTime
+	filterTypeBool
+	// filterTypeStringArray is an array of strings, '=' checks whether the array contains the value.
+	filterTypeStringArray
+	// filterTypeResource is a resource of a resource map, compared as a quantity with an optional unit.
+	filterTypeResource
+)
+
+func (t filterFieldType) operators() []string {
+	switch t {
+	case filterTypeString:
+		return []string{"=", "!=", "<", "<=", ">", ">=", "~", "!~", "in"}
+	case filterTypeBool, filterTypeStringArray:
+		return []string{"=", "!=", "in"}
+	default:
+		return []string{"=", "!=", "<", "<=", ">", ">=", "in"}
+	}
+}
+
+func (t filterFieldType) String() string {
+	switch t {
+	case filterTypeString:
+		return "string"
+	case filterTypeInt:
+		return "integer"
+	case filterTypeTime:
+		return "time"
+	case filterTypeBool:
+		return "boolean"
+	case filterTypeStringArray:
+		return "list of strings"
+	case filterTypeResource:
+		return "resource quantity"
+	default:
+		return "unknown"
+	}
+}
+
+// filterField is a field of a filter expression.
+type filterField struct {
+	// expression is the SQL expression of the field, which must not contain arguments.
+	expression string
+	fieldType  filterFieldType
+	// resource is the resource of a field of type filterTypeResource, which defines the unit of its quantities.
+	resource string
+}
+
+// FilterSchema defines the fields which can be used in the filter expressions of a list.
+type FilterSchema struct {
+	fields map[string]filterField
+	// resources maps the resource fields (e.g. 'usedResource') to the JSONB columns which contain a resource map.
+	// They are used as '<field>.<resource>', e.g. 'usedResource.memory'.
+	resources map[string]string
+	// attributes maps the attribute fields (e.g. 'attributes') to the JSONB columns which contain a map of strings.
+	// They are used as '<field>.<attribute>', e.g. 'attributes.instance-type'.
+	attributes map[string]string
+}
+
+// Names returns the names of the fields which can be used in filter expressions.
+func (s *FilterSchema) Names() []string {
+	names := make([]string, 0, len(s.fields)+len(s.resources)+len(s.attributes))
+	for name := range s.fields {
+		names = append(names, name)
+	}
+	for name := range s.resources {
+		names = append(names, name+".<resource>")
+	}
+	for name := range s.attributes {
+		names = append(names, name+".<attribute>")
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Compile parses the filter expression and compiles it into a SQL condition.
+// It returns a *FilterExpressionError if the expression is invalid.
+func (s *FilterSchema) Compile(expression string) (sql.Expr, error) {
+	if len(expression) > maxFilterExpressionLength {
+		return nil, &FilterExpressionError{
+			Position: maxFilterExpressionLength + 1,
+			Message:  fmt.Sprintf("filter expression is longer than %d characters", maxFilterExpressionLength),
+		}
+	}
+	tokens, err := tokenizeFilterExpression(expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{schema: s, tokens: tokens}
+	if p.peek().kind == filterTokenEOF {
+		return nil, p.errorf(p.peek(), "empty filter expression")
+	}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if token := p.peek(); token.kind != filterTokenEOF {
+		return nil, p.errorf(token, "unexpected %s, expected 'and', 'or' or the end of the expression", token)
+	}
+	return expr, nil
+}
+
+func (s *FilterSchema) field(name string) (filterField, bool) {
+	if field, ok := s.fields[name]; ok {
+		return field, true
+	}
+	prefix, key, ok := strings.Cut(name, ".")
+	if !ok || !resourceKeyRegexp.MatchString(key) {
+		return filterField{}, false
+	}
+	// the key is validated, so it can be used as a literal
+	if column, ok := s.resources[prefix]; ok {
+		return filterField{expression: resourceExpression(column, key), fieldType: filterTypeResource, resource: key}, true
+	}
+	if column, ok := s.attributes[prefix]; ok {
+		return filterField{expression: "(" + column + "->>'" + key + "')", fieldType: filterTypeString}, true
+	}
+	return filterField{}, false
+}
+
+type filterTokenKind int
+
+const (
+	filterTokenEOF filterTokenKind = iota
+	// filterTokenWord is an unquoted word: a field, a keyword or a value.
+	filterTokenWord
+	// filterTokenString is a quoted value.
+	filterTokenString
+	filterTokenOperator
+	filterTokenLeftParen
+	filterTokenRightParen
+	filterTokenComma
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string
+	// pos is the position of the token in the expression, starting at 1.
+	pos int
+}
+
+func (t filterToken) String() string {
+	switch t.kind {
+	case filterTokenEOF:
+		return "end of expression"
+	case filterTokenString:
+		return strconv.Quote(t.text)
+	default:
+		return "'" + t.text + "'"
+	}
+}
+
+// isKeyword checks whether the token is the (case-insensitive) keyword.
+func (t filterToken) isKeyword(keyword string) bool {
+	return t.kind == filterTokenWord && strings.EqualFold(t.text, keyword)
+}
+
+var filterKeywords = []string{"and", "or", "not", "in", "is", "null"}
+
+func (t filterToken) isAnyKeyword() bool {
+	for _, keyword := range filterKeywords {
+		if t.isKeyword(keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// isFilterWordRune checks whether r can be part of an unquoted word,
+// which includes the characters of field names, resource names, quantities, times and glob patterns.
+func isFilterWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_.-/*?:+", r)
+}
+
+func tokenizeFilterExpression(expression string) ([]filterToken, error) {
+	var tokens []filterToken
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		pos := i + 1
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, filterToken{kind: filterTokenLeftParen, text: "(", pos: pos})
+			i++
+		case r == ')':
+			tokens = append(tokens, filterToken{kind: filterTokenRightParen, text: ")", pos: pos})
+			i++
+		case r == ',':
+			tokens = append(tokens, filterToken{kind: filterTokenComma, text: ",", pos: pos})
+			i++
+		case r == '"' || r == '\'':
+			var value strings.Builder
+			j := i + 1
+			for ; j < len(runes) && runes[j] != r; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j++
+				}
+				value.WriteRune(runes[j])
+			}
+			if j == len(runes) {
+				return nil, &FilterExpressionError{Position: pos, Message: "unterminated string"}
+			}
+			tokens = append(tokens, filterToken{kind: filterTokenString, text: value.String(), pos: pos})
+			i = j + 1
+		case strings.ContainsRune("=!<>~", r):
+			op := string(r)
+			if i+1 < len(runes) && (r == '!' || r == '<' || r == '>') && (runes[i+1] == '=' || (r == '!' && runes[i+1] == '~')) {
+				op += string(runes[i+1])
+			}
+			if op == "!" {
+				return nil, &FilterExpressionError{Position: pos, Message: "invalid operator '!', expected '!=' or '!~'"}
+			}
+			tokens = append(tokens, filterToken{kind: filterTokenOperator, text: op, pos: pos})
+			i += len(op)
+		case isFilterWordRune(r):
+			j := i
+			for j < len(runes) && isFilterWordRune(runes[j]) {
+				j++
+			}
+			tokens = append(tokens, filterToken{kind: filterTokenWord, text: string(runes[i:j]), pos: pos})
+			i = j
+		default:
+			return nil, &FilterExpressionError{Position: pos, Message: fmt.Sprintf("unexpected character %q", r)}
+		}
+	}
+	return append(tokens, filterToken{kind: filterTokenEOF, pos: len(runes) + 1}), nil
+}
+
+// filterParser is a recursive descent parser of filter expressions, which compiles them into SQL conditions.
+type filterParser struct {
+	schema *FilterSchema
+	tokens []filterToken
+	next   int
+	depth  int
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.next]
+}
+
+func (p *filterParser) advance() filterToken {
+	token := p.tokens[p.next]
+	if token.kind != filterTokenEOF {
+		p.next++
+	}
+	return token
+}
+
+func (p *filterParser) errorf(token filterToken, format string, args ...any) error {
+	return &FilterExpressionError{Position: token.pos, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *filterParser) enter(token filterToken) error {
+	p.depth++
+	if p.depth > maxFilterExpressionDepth {
+		return p.errorf(token, "filter expression is nested deeper than %d levels", maxFilterExpressionDepth)
+	}
+	return nil
+}
+
+func (p *filterParser) parseOr() (sql.Expr, error) {
+	expr, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	exprs := []sql.Expr{expr}
+	for p.peek().isKeyword("or") {
+		p.advance()
+		expr, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+	}
+	if len(exprs) == 1 {
+		return exprs[0], nil
+	}
+	return sql.Or(exprs...), nil
+}
+
+func (p *filterParser) parseAnd() (sql.Expr, error) {
+	expr, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	exprs := []sql.Expr{expr}
+	for p.peek().isKeyword("and") {
+		p.advance()
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+	}
+	if len(exprs) == 1 {
+		return exprs[0], nil
+	}
+	return sql.And(exprs...), nil
+}
+
+func (p *filterParser) parseUnary() (sql.Expr, error) {
+	token := p.peek()
+	switch {
+	case token.isKeyword("not"):
+		p.advance()
+		if err := p.enter(token); err != nil {
+			return nil, err
+		}
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		p.depth--
+		return sql.Not(expr), nil
+	case token.kind == filterTokenLeftParen:
+		p.advance()
+		if err := p.enter(token); err != nil {
+			return nil, err
+		}
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.advance(); closing.kind != filterTokenRightParen {
+			return nil, p.errorf(closing, "unexpected %s, expected ')' to close '(' at position %d", closing, token.pos)
+		}
+		p.depth--
+		return expr, nil
+	default:
+		return p.parseCondition()
+	}
+}
+
+func (p *filterParser) parseCondition() (sql.Expr, error) {
+	token := p.advance()
+	if token.kind != filterTokenWord || token.isAnyKeyword() {
+		return nil, p.errorf(token, "unexpected %s, expected a field", token)
+	}
+	field, ok := p.schema.field(token.text)
+	if !ok {
+		return nil, p.errorf(token, "unknown field '%s', allowed fields are: %s", token.text, strings.Join(p.schema.Names(), ", "))
+	}
+
+	op := p.advance()
+	switch {
+	case op.isKeyword("is"):
+		negated := false
+		if p.peek().isKeyword("not") {
+			p.advance()
+			negated = true
+		}
+		if null := p.advance(); !null.isKeyword("null") {
+			return nil, p.errorf(null, "unexpected %s, expected 'null'", null)
+		}
+		if negated {
+			return sql.IsNotNull(field.expression), nil
+		}
+		return sql.IsNull(field.expression), nil
+	case op.isKeyword("not"):
+		if in := p.advance(); !in.isKeyword("in") {
+			return nil, p.errorf(in, "unexpected %s, expected 'in'", in)
+		}
+		expr, err := p.parseIn(token, field)
+		if err != nil {
+			return nil, err
+		}
+		return sql.Not(expr), nil
+	case op.isKeyword("in"):
+		return p.parseIn(token, field)
+	case op.kind == filterTokenOperator:
+		if !supportsOperator(field.fieldType, op.text) {
+			return nil, p.errorf(op, "operator '%s' is not supported by field '%s' of type %s, allowed operators are: %s",
+				op.text, token.text, field.fieldType, strings.Join(field.fieldType.operators(), " "))
+		}
+		value, err := p.parseValue(token, field)
+		if err != nil {
+			return nil, err
+		}
+		return compareFilterField(field, op.text, value), nil
+	default:
+		return nil, p.errorf(op, "unexpected %s, expected an operator after field '%s'", op, token.text)
+	}
+}
+
+func (p *filterParser) parseIn(fieldToken filterToken, field filterField) (sql.Expr, error) {
+	if open := p.advance(); open.kind != filterTokenLeftParen {
+		return nil, p.errorf(open, "unexpected %s, expected '(' after 'in'", open)
+	}
+	var values []any
+	for {
+		value, err := p.parseValue(fieldToken, field)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		token := p.advance()
+		if token.kind == filterTokenRightParen {
+			break
+		}
+		if token.kind != filterTokenComma {
+			return nil, p.errorf(token, "unexpected %s, expected ',' or ')'", token)
+		}
+	}
+
+	switch field.fieldType {
+	case filterTypeString:
+		return sql.In(field.expression, typedValues[string](values)), nil
+	case filterTypeBool:
+		return sql.In(field.expression, typedValues[bool](values)), nil
+	case filterTypeStringArray:
+		return sql.Overlaps(field.expression, typedValues[string](values)), nil
+	default:
+		return sql.In(field.expression, typedValues[int64](values)), nil
+	}
+}
+
+func (p *filterParser) parseValue(fieldToken filterToken, field filterField) (any, error) {
+	token := p.advance()
+	if token.kind != filterTokenString && (token.kind != filterTokenWord || token.isAnyKeyword()) {
+		return nil, p.errorf(token, "unexpected %s, expected a value", token)
+	}
+	value, err := parseFilterValue(field, token.text)
+	if err != nil {
+		return nil, p.errorf(token, "invalid value %s for field '%s' of type %s: %v", token, fieldToken.text, field.fieldType, err)
+	}
+	return value, nil
+}
+
+func parseFilterValue(field filterField, text string) (any, error) {
+	switch field.fieldType {
+	case filterTypeInt:
+		return strconv.ParseInt(text, 10, 64)
+	case filterTypeTime:
+		if millis, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return millis, nil
+		}
+		t, err := time.Parse(time.RFC3339, text)
+		if err != nil {
+			return nil, fmt.Errorf("expected milliseconds since the epoch or an RFC 3339 time")
+		}
+		return t.UnixMilli(), nil
+	case filterTypeBool:
+		switch strings.ToLower(text) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		default:
+			return nil, fmt.Errorf("expected true or false")
+		}
+	case filterTypeResource:
+		return parseResourceQuantity(field.resource, text)
+	default:
+		return text, nil
+	}
+}
+
+func supportsOperator(fieldType filterFieldType, op string) bool {
+	for _, supported := range fieldType.operators() {
+		if op == supported {
+			return true
+		}
+	}
+	return false
+}
+
+var filterComparisonOperators = map[string]sql.Operator{
+	"<":  sql.OpLt,
+	"<=": sql.OpLe,
+	">":  sql.OpGt,
+	">=": sql.OpGe,
+}
+
+func compareFilterField(field filterField, op string, value any) sql.Expr {
+	switch op {
+	case "=":
+		if field.fieldType == filterTypeStringArray {
+			return sql.Contains(field.expression, []string{value.(string)})
+		}
+		return sql.Eq(field.expression, value)
+	case "!=":
+		if field.fieldType == filterTypeStringArray {
+			return sql.Not(sql.Contains(field.expression, []string{value.(string)}))
+		}
+		// unlike '<>', rows in which the field is null are selected as well
+		return sql.Raw(field.expression+" IS DISTINCT FROM $1", value)
+	case "~":
+		return sql.Cmp(field.expression, sql.OpLike, globToLike(value.(string)))
+	case "!~":
+		return sql.Not(sql.Cmp(field.expression, sql.OpLike, globToLike(value.(string))))
+	default:
+		return sql.Cmp(field.expression, filterComparisonOperators[op], value)
+	}
+}
+
+// globToLike converts a glob pattern, in which '*' matches any characters and '?' matches a single character,
+// into a LIKE pattern.
+func globToLike(glob string) string {
+	var pattern strings.Builder
+	for _, r := range glob {
+		switch r {
+		case '*':
+			pattern.WriteByte('%')
+		case '?':
+			pattern.WriteByte('_')
+		default:
+			pattern.WriteString(escapeLike(string(r)))
+		}
+	}
+	return pattern.String()
+}
+
+func typedValues[T any](values []any) []T {
+	typed := make([]T, 0, len(values))
+	for _, value := range values {
+		typed = append(typed, value.(T))
+	}
+	return typed
+}
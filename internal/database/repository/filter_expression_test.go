@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/G-Research/unicorn-history-server/internal/database/sql"
+)
+
+func TestFilterSchemaCompile(t *testing.T) {
+	tests := []struct {
+		name          string
+		expression    string
+		expectedQuery string
+		expectedArgs  []any
+	}{
+		{
+			name:          "Equality with bare word",
+			expression:    "applicationState = Failed",
+			expectedQuery: "SELECT * FROM applications WHERE state = $1",
+			expectedArgs:  []any{"Failed"},
+		},
+		{
+			name:       "In and nested or with glob",
+			expression: `applicationState in (Failed,Rejected) and (user = "bob" or queueName ~ "root.ml.*")`,
+			expectedQuery: "SELECT * FROM applications WHERE " +
+				`(state = ANY($1) AND ("user" = $2 OR queue_name LIKE $3))`,
+			expectedArgs: []any{[]string{"Failed", "Rejected"}, "bob", "root.ml.%"},
+		},
+		{
+			name:          "Keywords are case-insensitive and precedence of and over or",
+			expression:    "user = a OR user = b AND NOT applicationState = Running",
+			expectedQuery: `SELECT * FROM applications WHERE ("user" = $1 OR ("user" = $2 AND NOT (state = $3)))`,
+			expectedArgs:  []any{"a", "b", "Running"},
+		},
+		{
+			name:          "Not equal selects nulls",
+			expression:    "user != 'bob'",
+			expectedQuery: `SELECT * FROM applications WHERE "user" IS DISTINCT FROM $1`,
+			expectedArgs:  []any{"bob"},
+		},
+		{
+			name:          "Glob escapes LIKE wildcards",
+			expression:    `applicationID !~ "app_1%?"`,
+			expectedQuery: "SELECT * FROM applications WHERE NOT (app_id LIKE $1)",
+			expectedArgs:  []any{`app\_1\%_`},
+		},
+		{
+			name:          "Not in and is null",
+			expression:    "partition not in (a, b) or finishedTime is null",
+			expectedQuery: "SELECT * FROM applications WHERE (NOT (partition = ANY($1)) OR finished_time IS NULL)",
+			expectedArgs:  []any{[]string{"a", "b"}},
+		},
+		{
+			name:          "Time as millis and RFC 3339",
+			expression:    "submissionTime >= 1000 and submissionTime < 2024-01-01T00:00:00Z",
+			expectedQuery: "SELECT * FROM applications WHERE (submission_time >= $1 AND submission_time < $2)",
+			expectedArgs:  []any{int64(1000), int64(1704067200000)},
+		},
+		{
+			name:          "String array",
+			expression:    "groups = dev and groups in (ops, sre)",
+			expectedQuery: "SELECT * FROM applications WHERE (groups @> $1 AND groups && $2)",
+			expectedArgs:  []any{[]string{"dev"}, []string{"ops", "sre"}},
+		},
+		{
+			name:       "Resource with unit",
+			expression: "usedResource.memory > 1Gi and usedResource.vcore <= 500m",
+			expectedQuery: "SELECT * FROM applications WHERE (COALESCE((used_resource->>'memory')::BIGINT, 0) > $1 AND " +
+				"COALESCE((used_resource->>'vcore')::BIGINT, 0) <= $2)",
+			expectedArgs: []any{int64(1 << 30), int64(500)},
+		},
+		{
+			name:          "Boolean and escaped quote",
+			expression:    `hasReserved = true and rejectedMessage = "say \"no\""`,
+			expectedQuery: "SELECT * FROM applications WHERE (has_reserved = $1 AND rejected_message = $2)",
+			expectedArgs:  []any{true, `say "no"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := ApplicationFilterSchema.Compile(tt.expression)
+			require.NoError(t, err)
+			query, args := sql.NewBuilder().SelectAll("applications", "").Where(expr).Build()
+			assert.Equal(t, tt.expectedQuery, query)
+			assert.Equal(t, tt.expectedArgs, args)
+		})
+	}
+}
+
+func TestFilterSchemaCompileAttributes(t *testing.T) {
+	expr, err := NodeFilterSchema.Compile(`attributes.instance-type = "m5.large" and available.nvidia.com/gpu > 0`)
+	require.NoError(t, err)
+	query, args := sql.NewBuilder().SelectAll("nodes", "").Where(expr).Build()
+	assert.Equal(t, "SELECT * FROM nodes WHERE ((attributes->>'instance-type') = $1 AND "+
+		"COALESCE((available->>'nvidia.com/gpu')::BIGINT, 0) > $2)", query)
+	assert.Equal(t, []any{"m5.large", int64(0)}, args)
+}
+
+func TestFilterSchemaCompileErrors(t *testing.T) {
+	tests := []struct {
+		name             string
+		expression       string
+		expectedPosition int
+		expectedMessage  string
+	}{
+		{"Empty", "  ", 3, "empty filter expression"},
+		{"Unknown field", "owner = bob", 1, "unknown field 'owner'"},
+		{"Missing operator", "user bob", 6, "expected an operator after field 'user'"},
+		{"Missing value", "user =", 7, "expected a value"},
+		{"Keyword as value", "user = and", 8, "expected a value"},
+		{"Unclosed parenthesis", "(user = bob", 12, "expected ')' to close '(' at position 1"},
+		{"Trailing token", "user = bob )", 12, "unexpected ')'"},
+		{"Unterminated string", `user = "bob`, 8, "unterminated string"},
+		{"Invalid character", "user = bob;", 11, "unexpected character ';'"},
+		{"Invalid operator", "user ! bob", 6, "invalid operator '!'"},
+		{"Unsupported operator", "hasReserved > true", 13, "operator '>' is not supported by field 'hasReserved'"},
+		{"Invalid integer", "submissionTime > yesterday", 18, "invalid value 'yesterday' for field 'submissionTime'"},
+		{"Invalid quantity", "usedResource.memory > 1GB", 23, "invalid value '1GB'"},
+		{"Invalid in list", "applicationState in (a b)", 24, "expected ',' or ')'"},
+		{"Is without null", "applicationState is empty", 21, "expected 'null'"},
+		{"Too deep", strings.Repeat("(", 40) + "user = bob" + strings.Repeat(")", 40), 33, "nested deeper than 32 levels"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ApplicationFilterSchema.Compile(tt.expression)
+			var filterErr *FilterExpressionError
+			require.True(t, errors.As(err, &filterErr), "expected FilterExpressionError, got %v", err)
+			assert.Equal(t, tt.expectedPosition, filterErr.Position)
+			assert.Contains(t, filterErr.Message, tt.expectedMessage)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/G-Research/unicorn-history-server/internal/model"
+)
+
+// DemandFilters select the queue and the resource of a demand series, and its buckets.
+type DemandFilters struct {
+	// Start and End are the period of the series. It is divided into buckets of width Step from Start.
+	Start     time.Time
+	End       time.Time
+	Step      time.Duration
+	Partition string
+	// Queue is the full path of the queue. The root queue is the demand of the whole partition.
+	Queue    string
+	Resource string
+}
+
+// demandSeriesQuery computes the time-weighted averages of the allocated and pending quantities of @resource
+// of a queue, and of its capacity, in the buckets of width @step of the period [@start, @end), from the versions
+// of the queue. The capacity is the maximum of the queue, or the capacity of the partition if it has no maximum.
+// The averages of a bucket which no version of the queue overlaps are NULL.
+const demandSeriesQuery = `
+WITH buckets AS (
+	SELECT t FROM generate_series(@start::BIGINT, @end::BIGINT - 1, @step::BIGINT) AS t
+),
+versions AS (
+	SELECT
+		v.valid_from_nano,
+		COALESCE(v.valid_to_nano, @end::BIGINT) AS valid_to_nano,
+		COALESCE((r.allocated_resource->>@resource)::DOUBLE PRECISION, 0) AS allocated,
+		COALESCE((r.pending_resource->>@resource)::DOUBLE PRECISION, 0) AS pending,
+		(r.max_resource->>@resource)::DOUBLE PRECISION AS capacity
+	FROM queue_versions v
+	CROSS JOIN LATERAL jsonb_populate_record(NULL::queues, v.data) r
+	WHERE r.queue_name = @queue AND r.deleted_at_nano IS NULL
+		AND r.partition_id IN (SELECT id FROM partitions WHERE name = @partition)
+		AND v.valid_from_nano < @end::BIGINT AND COALESCE(v.valid_to_nano, @end::BIGINT) > @start::BIGINT
+),
+partition_capacity AS (
+	SELECT MAX((capacity->>@resource)::DOUBLE PRECISION) AS capacity
+	FROM partitions
+	WHERE name = @partition AND deleted_at_nano IS NULL
+)
+SELECT
+	b.t,
+	SUM(v.allocated * o.duration) / NULLIF(SUM(o.duration), 0),
+	SUM(v.pending * o.duration) / NULLIF(SUM(o.duration), 0),
+	COALESCE(
+		SUM(v.capacity * o.duration) / NULLIF(SUM(o.duration) FILTER (WHERE v.capacity IS NOT NULL), 0),
+		(SELECT capacity FROM partition_capacity)
+	)
+FROM buckets b
+LEFT JOIN versions v ON v.valid_from_nano < b.t + @step::BIGINT AND v.valid_to_nano > b.t
+LEFT JOIN LATERAL (
+	SELECT (LEAST(v.valid_to_nano, b.t + @step::BIGINT) - GREATEST(v.valid_from_nano, b.t))::DOUBLE PRECISION AS duration
+) o ON TRUE
+GROUP BY b.t
+ORDER BY b.t`
+
+// GetDemandSeries returns the average allocated and pending quantities of the resource of the queue of the filters,
+// and its capacity, in every bucket of the period of the filters.
+func (s *PostgresRepository) GetDemandSeries(ctx context.Context, filters DemandFilters) ([]*model.DemandPoint, error) {
+	if !filters.End.After(filters.Start) {
+		return nil, fmt.Errorf("invalid period from %s to %s", filters.Start, filters.End)
+	}
+	if filters.Step <= 0 {
+		return nil, fmt.Errorf("invalid step %s", filters.Step)
+	}
+	rows, err := s.dbpool.Query(ctx, demandSeriesQuery, pgx.NamedArgs{
+		"start":     filters.Start.UnixNano(),
+		"end":       filters.End.UnixNano(),
+		"step":      filters.Step.Nanoseconds(),
+		"partition": filters.Partition,
+		"queue":     filters.Queue,
+		"resource":  filters.Resource,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get demand series from DB: %v", err)
+	}
+	defer rows.Close()
+
+	var points []*model.DemandPoint
+	for rows.Next() {
+		var p model.DemandPoint
+		var timestamp int64
+		if err := rows.Scan(&timestamp, &p.Allocated, &p.Pending, &p.Capacity); err != nil {
+			return nil, fmt.Errorf("could not scan demand series from DB: %v", err)
+		}
+		p.Timestamp = time.Duration(timestamp).Milliseconds()
+		points = append(points, &p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get demand series from DB: %v", err)
+	}
+	return points, nil
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/G-Research/yunikorn-core/pkg/webservice/dao"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/G-Research/unicorn-history-server/internal/model"
+	"github.com/G-Research/unicorn-history-server/internal/util"
+)
+
+type ForecastIntTest struct {
+	suite.Suite
+	pool  *pgxpool.Pool
+	repo  *PostgresRepository
+	start time.Time
+}
+
+func (fs *ForecastIntTest) SetupSuite() {
+	require.NotNil(fs.T(), fs.pool)
+	repo, err := NewPostgresRepository(fs.pool)
+	require.NoError(fs.T(), err)
+	fs.repo = repo
+
+	ctx := context.Background()
+	fs.start = time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	start := fs.start
+
+	require.NoError(fs.T(), repo.InsertPartition(ctx, &model.Partition{
+		Metadata: model.Metadata{CreatedAtNano: start.Add(-24 * time.Hour).UnixNano()},
+		PartitionInfo: dao.PartitionInfo{
+			ID:        "1",
+			Name:      "default",
+			ClusterID: "cluster1",
+			State:     "Active",
+			Capacity:  dao.PartitionCapacity{Capacity: map[string]int64{"vcore": 20000}},
+		},
+	}))
+
+	// the versions are recorded directly, since the versions of updated queues are valid from the time of the update
+	insertVersion := func(id string, from time.Time, to *time.Time, data map[string]any) {
+		data["id"] = id
+		data["partition_id"] = "1"
+		raw, err := json.Marshal(data)
+		require.NoError(fs.T(), err)
+		var validTo *int64
+		if to != nil {
+			validTo = util.ToPtr(to.UnixNano())
+		}
+		_, err = fs.pool.Exec(ctx,
+			"INSERT INTO queue_versions(object_id, valid_from_nano, valid_to_nano, data) VALUES ($1, $2, $3, $4)",
+			id, from.UnixNano(), validTo, raw)
+		require.NoError(fs.T(), err)
+	}
+
+	insertVersion("root", start.Add(-time.Hour), util.ToPtr(start.Add(30*time.Minute)), map[string]any{
+		"queue_name":         "root",
+		"allocated_resource": map[string]int64{"vcore": 4000},
+		"pending_resource":   map[string]int64{"vcore": 2000},
+		"max_resource":       map[string]int64{"vcore": 10000},
+	})
+	// without a maximum, the capacity of the queue is the capacity of the partition
+	insertVersion("root", start.Add(30*time.Minute), nil, map[string]any{
+		"queue_name":         "root",
+		"allocated_resource": map[string]int64{"vcore": 6000},
+	})
+	insertVersion("root.a", start.Add(-time.Hour), nil, map[string]any{
+		"queue_name":         "root.a",
+		"allocated_resource": map[string]int64{"vcore": 1000},
+	})
+}
+
+func (fs *ForecastIntTest) TearDownSuite() {
+	fs.pool.Close()
+}
+
+func (fs *ForecastIntTest) TestGetDemandSeries() {
+	ctx := context.Background()
+	filters := DemandFilters{
+		Start:     fs.start.Add(-2 * time.Hour),
+		End:       fs.start.Add(2 * time.Hour),
+		Step:      time.Hour,
+		Partition: "default",
+		Queue:     "root",
+		Resource:  "vcore",
+	}
+	points, err := fs.repo.GetDemandSeries(ctx, filters)
+	require.NoError(fs.T(), err)
+	assert.Equal(fs.T(), []*model.DemandPoint{
+		{Timestamp: fs.start.Add(-2 * time.Hour).UnixMilli(), Capacity: util.ToPtr(20000.0)},
+		{
+			Timestamp: fs.start.Add(-time.Hour).UnixMilli(),
+			Allocated: util.ToPtr(4000.0),
+			Pending:   util.ToPtr(2000.0),
+			Capacity:  util.ToPtr(10000.0),
+		},
+		{
+			Timestamp: fs.start.UnixMilli(),
+			Allocated: util.ToPtr(5000.0),
+			Pending:   util.ToPtr(1000.0),
+			Capacity:  util.ToPtr(10000.0),
+		},
+		{
+			Timestamp: fs.start.Add(time.Hour).UnixMilli(),
+			Allocated: util.ToPtr(6000.0),
+			Pending:   util.ToPtr(0.0),
+			Capacity:  util.ToPtr(20000.0),
+		},
+	}, points)
+
+	filters.Queue = "root.a"
+	filters.Resource = "memory"
+	points, err = fs.repo.GetDemandSeries(ctx, filters)
+	require.NoError(fs.T(), err)
+	require.Len(fs.T(), points, 4)
+	assert.Nil(fs.T(), points[0].Allocated)
+	assert.Equal(fs.T(), util.ToPtr(0.0), points[1].Allocated, "the queue does not allocate the resource")
+	assert.Nil(fs.T(), points[1].Capacity, "neither the queue nor the partition have a capacity of the resource")
+
+	filters.Partition = "other"
+	points, err = fs.repo.GetDemandSeries(ctx, filters)
+	require.NoError(fs.T(), err)
+	require.Len(fs.T(), points, 4)
+	for _, point := range points {
+		assert.Nil(fs.T(), point.Allocated)
+	}
+
+	_, err = fs.repo.GetDemandSeries(ctx, DemandFilters{Start: fs.start, End: fs.start, Step: time.Hour})
+	require.Error(fs.T(), err)
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/G-Research/unicorn-history-server/internal/model"
+)
+
+// GangFilters select the gang-scheduled applications of a gang scheduling report.
+type GangFilters struct {
+	// Start and End select the applications which were submitted during the period [Start, End).
+	Start     time.Time
+	End       time.Time
+	Partition *string
+	// Queue selects the applications in the subtree of a queue.
+	Queue *string
+	User  *string
+}
+
+// gangStatsQuery aggregates the placeholder data of the applications submitted during the period [@start, @end)
+// per queue and task group. The replacement time of a current allocation which replaced a placeholder is the time
+// from its request time, which is the time of the allocation of the placeholder, until its allocation time.
+var gangStatsQuery = `
+WITH gang_applications AS (
+	SELECT a.id, a.queue_name, a.place_holder_data, a.allocations
+	FROM applications a
+	WHERE jsonb_typeof(a.place_holder_data) = 'array' AND jsonb_array_length(a.place_holder_data) > 0
+		AND a.submission_time >= @start AND a.submission_time < @end
+		AND (@partition::TEXT IS NULL OR a.partition = @partition)
+		AND ` + queueSubtreeFilter("a.queue_name") + `
+		AND (@user::TEXT IS NULL OR a."user" = @user)
+),
+task_groups AS (
+	SELECT
+		a.queue_name,
+		COALESCE(ph->>'taskGroupName', '') AS task_group,
+		COUNT(DISTINCT a.id) AS applications,
+		SUM(COALESCE((ph->>'count')::BIGINT, 0)) AS placeholders,
+		SUM(COALESCE((ph->>'replaced')::BIGINT, 0)) AS replaced,
+		SUM(COALESCE((ph->>'timedout')::BIGINT, 0)) AS timed_out
+	FROM gang_applications a
+	CROSS JOIN LATERAL jsonb_array_elements(a.place_holder_data) AS ph
+	GROUP BY 1, 2
+),
+replacements AS (
+	SELECT
+		r.queue_name,
+		r.task_group,
+		percentile_cont(ARRAY[0.5, 0.9, 0.99]) WITHIN GROUP (ORDER BY r.replacement_time) AS percentiles,
+		MAX(r.replacement_time) AS max
+	FROM (
+		SELECT
+			a.queue_name,
+			COALESCE(al->>'taskGroupName', '') AS task_group,
+			((al->>'allocationTime')::BIGINT - (al->>'requestTime')::BIGINT) / 1000000 AS replacement_time
+		FROM gang_applications a
+		CROSS JOIN LATERAL jsonb_array_elements(COALESCE(a.allocations, '[]'::JSONB)) AS al
+		WHERE COALESCE((al->>'placeholderUsed')::BOOLEAN, FALSE)
+			AND al->>'allocationTime' IS NOT NULL AND al->>'requestTime' IS NOT NULL
+	) r
+	GROUP BY 1, 2
+)
+SELECT t.queue_name, t.task_group, t.applications, t.placeholders, t.replaced, t.timed_out, r.percentiles, r.max
+FROM task_groups t
+LEFT JOIN replacements r ON r.queue_name = t.queue_name AND r.task_group = t.task_group
+ORDER BY 1, 2`
+
+// GetGangStats returns the placeholders of the task groups of the gang-scheduled applications which match the filters,
+// per queue and task group. Deleted applications are included.
+func (s *PostgresRepository) GetGangStats(ctx context.Context, filters GangFilters) ([]*model.TaskGroupStats, error) {
+	args := pgx.NamedArgs{
+		"start":     filters.Start.UnixMilli(),
+		"end":       filters.End.UnixMilli(),
+		"partition": filters.Partition,
+		"user":      filters.User,
+	}
+	maps.Copy(args, queueFilterArgs(filters.Queue))
+
+	rows, err := s.dbpool.Query(ctx, gangStatsQuery, args)
+	if err != nil {
+		return nil, fmt.Errorf("could not get gang statistics from DB: %v", err)
+	}
+	defer rows.Close()
+
+	var stats []*model.TaskGroupStats
+	for rows.Next() {
+		var st model.TaskGroupStats
+		var percentiles []float64
+		var maximum *int64
+		if err := rows.Scan(
+			&st.Queue,
+			&st.TaskGroupName,
+			&st.Applications,
+			&st.Placeholders,
+			&st.Replaced,
+			&st.TimedOut,
+			&percentiles,
+			&maximum,
+		); err != nil {
+			return nil, fmt.Errorf("could not scan gang statistics from DB: %v", err)
+		}
+		if st.Placeholders > 0 {
+			st.TimeoutRate = float64(st.TimedOut) / float64(st.Placeholders)
+		}
+		st.ReplacementTime = newPercentiles(percentiles, maximum)
+		stats = append(stats, &st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get gang statistics from DB: %v", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/G-Research/yunikorn-core/pkg/webservice/dao"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/G-Research/unicorn-history-server/internal/model"
+	"github.com/G-Research/unicorn-history-server/internal/util"
+)
+
+type GangsIntTest struct {
+	suite.Suite
+	pool  *pgxpool.Pool
+	repo  *PostgresRepository
+	start time.Time
+}
+
+func (gs *GangsIntTest) SetupSuite() {
+	require.NotNil(gs.T(), gs.pool)
+	repo, err := NewPostgresRepository(gs.pool)
+	require.NoError(gs.T(), err)
+	gs.repo = repo
+
+	ctx := context.Background()
+	gs.start = time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	start := gs.start
+
+	newApp := func(queue, user string, placeholders []*dao.PlaceholderDAOInfo, replacementTimes ...time.Duration) *model.Application {
+		app := &model.Application{
+			Metadata: model.Metadata{CreatedAtNano: start.UnixNano()},
+			ApplicationDAOInfo: dao.ApplicationDAOInfo{
+				ID:              ulid.Make().String(),
+				ApplicationID:   ulid.Make().String(),
+				PartitionID:     "1",
+				Partition:       "default",
+				QueueName:       queue,
+				User:            user,
+				SubmissionTime:  start.UnixMilli(),
+				PlaceholderData: placeholders,
+			},
+		}
+		for i, d := range replacementTimes {
+			app.Allocations = append(app.Allocations, &dao.AllocationDAOInfo{
+				AllocationKey:   ulid.Make().String(),
+				TaskGroupName:   "executor",
+				PlaceholderUsed: true,
+				RequestTime:     start.Add(time.Duration(i) * time.Minute).UnixNano(),
+				AllocationTime:  start.Add(time.Duration(i)*time.Minute + d).UnixNano(),
+			})
+		}
+		return app
+	}
+
+	apps := []*model.Application{
+		newApp("root.spark", "alice", []*dao.PlaceholderDAOInfo{
+			{TaskGroupName: "driver", Count: 1, Replaced: 1},
+			{TaskGroupName: "executor", Count: 4, Replaced: 2, TimedOut: 2},
+		}, 2*time.Second, 4*time.Second),
+		newApp("root.spark", "bob", []*dao.PlaceholderDAOInfo{
+			{TaskGroupName: "executor", Count: 4, Replaced: 4},
+		}, 6*time.Second),
+		// not gang-scheduled
+		newApp("root.spark", "bob", nil),
+	}
+	for _, app := range apps {
+		require.NoError(gs.T(), repo.InsertApplication(ctx, app))
+	}
+}
+
+func (gs *GangsIntTest) TearDownSuite() {
+	gs.pool.Close()
+}
+
+func (gs *GangsIntTest) TestGetGangStats() {
+	ctx := context.Background()
+	filters := GangFilters{Start: gs.start, End: gs.start.Add(time.Hour)}
+	stats, err := gs.repo.GetGangStats(ctx, filters)
+	require.NoError(gs.T(), err)
+	require.Len(gs.T(), stats, 2)
+
+	driver := stats[0]
+	assert.Equal(gs.T(), util.ToPtr("root.spark"), driver.Queue)
+	assert.Equal(gs.T(), "driver", driver.TaskGroupName)
+	assert.Equal(gs.T(), int64(1), driver.Applications)
+	assert.Equal(gs.T(), int64(1), driver.Placeholders)
+	assert.Nil(gs.T(), driver.ReplacementTime)
+
+	executor := stats[1]
+	assert.Equal(gs.T(), "executor", executor.TaskGroupName)
+	assert.Equal(gs.T(), int64(2), executor.Applications)
+	assert.Equal(gs.T(), int64(8), executor.Placeholders)
+	assert.Equal(gs.T(), int64(6), executor.Replaced)
+	assert.Equal(gs.T(), int64(2), executor.TimedOut)
+	assert.InDelta(gs.T(), 0.25, executor.TimeoutRate, 1e-9)
+	assertPercentiles(gs.T(), &model.Percentiles{P50: 4000, P90: 5600, P99: 5960, Max: 6000}, executor.ReplacementTime)
+
+	filters.User = util.ToPtr("bob")
+	stats, err = gs.repo.GetGangStats(ctx, filters)
+	require.NoError(gs.T(), err)
+	require.Len(gs.T(), stats, 1)
+	assert.Equal(gs.T(), int64(4), stats[0].Replaced)
+
+	filters.User = nil
+	filters.Queue = util.ToPtr("root.batch")
+	stats, err = gs.repo.GetGangStats(ctx, filters)
+	require.NoError(gs.T(), err)
+	assert.Empty(gs.T(), stats)
+}
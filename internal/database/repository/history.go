@@ -11,25 +11,122 @@ import (
 	"github.com/G-Research/unicorn-history-server/internal/model"
 )
 
+const (
+	appHistoryType       = "application"
+	containerHistoryType = "container"
+)
+
+// HistoryAgg is the aggregation of the history entries in a time bucket of a downsampled history.
+type HistoryAgg string
+
+const (
+	HistoryAggAvg  HistoryAgg = "avg"
+	HistoryAggMin  HistoryAgg = "min"
+	HistoryAggMax  HistoryAgg = "max"
+	HistoryAggLast HistoryAgg = "last"
+)
+
+// HistoryAggValues are the aggregations of a downsampled history.
+var HistoryAggValues = []HistoryAgg{
+	HistoryAggAvg,
+	HistoryAggMin,
+	HistoryAggMax,
+	HistoryAggLast,
+}
+
+// historyAggExpressions maps the aggregations of a downsampled history to their expressions
+// over the history entries h and over the rollups r.
+var historyAggExpressions = map[HistoryAgg]struct {
+	entries string
+	rollups string
+}{
+	HistoryAggAvg: {entries: "AVG(h.total_number)", rollups: "SUM(r.sum)::NUMERIC / SUM(r.count)"},
+	HistoryAggMin: {entries: "MIN(h.total_number)", rollups: "MIN(r.min)"},
+	HistoryAggMax: {entries: "MAX(h.total_number)", rollups: "MAX(r.max)"},
+	HistoryAggLast: {
+		entries: "(array_agg(h.total_number ORDER BY h.timestamp DESC))[1]",
+		rollups: "(array_agg(r.last ORDER BY r.last_timestamp DESC))[1]",
+	},
+}
+
 type HistoryFilters struct {
 	TimestampStart *time.Time
 	TimestampEnd   *time.Time
-	Offset         *int
-	Limit          *int
+	// Step is the width of the time buckets of a downsampled history, see GetApplicationsHistorySeries.
+	Step time.Duration
+	// Agg is the aggregation of the history entries in a time bucket of a downsampled history.
+	Agg HistoryAgg
+	// Sort are the keys by which the history entries are sorted, see AppHistorySortFields and ContainerHistorySortFields.
+	Sort []SortKey
+	// After is the position after which the returned history entries start.
+	After  Cursor
+	Offset *int
+	Limit  *int
 }
 
 func applyHistoryFilters(builder *sql.Builder, filters HistoryFilters) {
 	if filters.TimestampStart != nil {
-		builder.Conditionp("timestamp", ">=", filters.TimestampStart.UnixNano())
+		builder.Where(sql.Cmp("timestamp", sql.OpGe, filters.TimestampStart.UnixNano()))
 	}
 	if filters.TimestampEnd != nil {
-		builder.Conditionp("timestamp", "<=", filters.TimestampEnd.UnixNano())
+		builder.Where(sql.Cmp("timestamp", sql.OpLe, filters.TimestampEnd.UnixNano()))
+	}
+	applyPagination(builder, filters.After, filters.Limit, filters.Offset)
+}
+
+// AppHistorySortFields are the fields by which the applications history can be sorted.
+var AppHistorySortFields = &SortFields[model.AppHistory]{
+	fields: map[string]sortField[model.AppHistory]{
+		"id":                {"id", func(h *model.AppHistory) any { return h.ID }},
+		"timestamp":         {"timestamp", func(h *model.AppHistory) any { return h.Timestamp }},
+		"totalApplications": {"total_number", func(h *model.AppHistory) any { return int64Value(h.TotalApplications) }},
+	},
+	defaultSort: []SortKey{{Field: "timestamp", Descending: true}},
+	id:          "id",
+}
+
+// ContainerHistorySortFields are the fields by which the containers history can be sorted.
+var ContainerHistorySortFields = &SortFields[model.ContainerHistory]{
+	fields: map[string]sortField[model.ContainerHistory]{
+		"id":              {"id", func(h *model.ContainerHistory) any { return h.ID }},
+		"timestamp":       {"timestamp", func(h *model.ContainerHistory) any { return h.Timestamp }},
+		"totalContainers": {"total_number", func(h *model.ContainerHistory) any { return int64Value(h.TotalContainers) }},
+	},
+	defaultSort: []SortKey{{Field: "timestamp", Descending: true}},
+	id:          "id",
+}
+
+// AppHistoryCursor returns the cursor which points to the position after the entry
+// in the applications history matching the filters.
+func (f HistoryFilters) AppHistoryCursor(appHistory *model.AppHistory) Cursor {
+	return AppHistorySortFields.Cursor(f.Sort, appHistory)
+}
+
+// ContainerHistoryCursor returns the cursor which points to the position after the entry
+// in the containers history matching the filters.
+func (f HistoryFilters) ContainerHistoryCursor(containerHistory *model.ContainerHistory) Cursor {
+	return ContainerHistorySortFields.Cursor(f.Sort, containerHistory)
+}
+
+func historyQuery(historyType string, filters HistoryFilters) (*sql.Builder, error) {
+	queryBuilder := sql.NewBuilder().
+		Select("id", "created_at_nano", "deleted_at_nano", "total_number", "timestamp").
+		From("history", "").
+		Where(sql.Eq("history_type", historyType))
+	var err error
+	if historyType == appHistoryType {
+		err = AppHistorySortFields.apply(queryBuilder, filters.Sort)
+	} else {
+		err = ContainerHistorySortFields.apply(queryBuilder, filters.Sort)
+	}
+	if err != nil {
+		return nil, err
 	}
-	applyLimitAndOffset(builder, filters.Limit, filters.Offset)
+	applyHistoryFilters(queryBuilder, filters)
+	return queryBuilder, nil
 }
 
 func (r *PostgresRepository) InsertAppHistory(ctx context.Context, appHistory *model.AppHistory) error {
-	const appHistoryType = "application"
 	const q = `
 INSERT INTO history (
 	 id, 
@@ -63,7 +160,6 @@ INSERT INTO history (
 }
 
 func (r *PostgresRepository) InsertContainerHistory(ctx context.Context, containerHistory *model.ContainerHistory) error {
-	const containerHistoryType = "container"
 	const q = `
 INSERT INTO history (
 	id,
@@ -98,16 +194,14 @@ INSERT INTO history (
 }
 
 func (r *PostgresRepository) GetApplicationsHistory(ctx context.Context, filters HistoryFilters) ([]*model.AppHistory, error) {
-	queryBuilder := sql.NewBuilder().
-		SelectAll("history", "").
-		Conditionp("history_type", "=", "application").
-		OrderBy("timestamp", sql.OrderByDescending)
-	applyHistoryFilters(queryBuilder, filters)
 
 	var apps []*model.AppHistory
 
-	query := queryBuilder.Query()
-	args := queryBuilder.Args()
+	queryBuilder, err := historyQuery(appHistoryType, filters)
+	if err != nil {
+		return nil, err
+	}
+	query, args := queryBuilder.Build()
 	rows, err := r.dbpool.Query(ctx, query, args...)
 
 	if err != nil {
@@ -117,26 +211,27 @@ func (r *PostgresRepository) GetApplicationsHistory(ctx context.Context, filters
 
 	for rows.Next() {
 		var app model.AppHistory
-		err := rows.Scan(&app.ID, &app.CreatedAtNano, &app.DeletedAtNano, nil, &app.TotalApplications, &app.Timestamp)
+		err := rows.Scan(&app.ID, &app.CreatedAtNano, &app.DeletedAtNano, &app.TotalApplications, &app.Timestamp)
 		if err != nil {
 			return nil, fmt.Errorf("could not scan applications history from DB: %v", err)
 		}
 		apps = append(apps, &app)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get applications history from DB: %v", err)
+	}
 	return apps, nil
 }
 
 func (r *PostgresRepository) GetContainersHistory(ctx context.Context, filters HistoryFilters) ([]*model.ContainerHistory, error) {
-	queryBuilder := sql.NewBuilder().
-		SelectAll("history", "").
-		Conditionp("history_type", "=", "container").
-		OrderBy("timestamp", sql.OrderByDescending)
-	applyHistoryFilters(queryBuilder, filters)
 
 	var containers []*model.ContainerHistory
 
-	query := queryBuilder.Query()
-	args := queryBuilder.Args()
+	queryBuilder, err := historyQuery(containerHistoryType, filters)
+	if err != nil {
+		return nil, err
+	}
+	query, args := queryBuilder.Build()
 	rows, err := r.dbpool.Query(ctx, query, args...)
 
 	if err != nil {
@@ -146,11 +241,166 @@ func (r *PostgresRepository) GetContainersHistory(ctx context.Context, filters H
 
 	for rows.Next() {
 		var container model.ContainerHistory
-		err := rows.Scan(&container.ID, &container.CreatedAtNano, &container.DeletedAtNano, nil, &container.TotalContainers, &container.Timestamp)
+		err := rows.Scan(&container.ID, &container.CreatedAtNano, &container.DeletedAtNano, &container.TotalContainers, &container.Timestamp)
 		if err != nil {
 			return nil, fmt.Errorf("could not scan contaienrs history from DB: %v", err)
 		}
 		containers = append(containers, &container)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get container history from DB: %v", err)
+	}
 	return containers, nil
 }
+
+// CountApplicationsHistory returns the number of applications history entries which match the filters, ignoring the pagination.
+func (r *PostgresRepository) CountApplicationsHistory(ctx context.Context, filters HistoryFilters, estimated bool) (int64, error) {
+	queryBuilder, err := historyQuery(appHistoryType, filters)
+	if err != nil {
+		return 0, err
+	}
+	return r.count(ctx, queryBuilder, estimated)
+}
+
+// CountContainersHistory returns the number of containers history entries which match the filters, ignoring the pagination.
+func (r *PostgresRepository) CountContainersHistory(ctx context.Context, filters HistoryFilters, estimated bool) (int64, error) {
+	queryBuilder, err := historyQuery(containerHistoryType, filters)
+	if err != nil {
+		return 0, err
+	}
+	return r.count(ctx, queryBuilder, estimated)
+}
+
+// historySeriesQuery aggregates the history entries of the type in every bucket of the step in seconds, which starts
+// from the bucket of @start until @end. The buckets without entries have a null value. The first argument is the
+// aggregation, the second one its source, either the history entries or the rollups of a width,
+// and the third one the timestamp of the source.
+const historySeriesQuery = `
+WITH buckets AS (
+	SELECT generate_series(history_bucket(@start::BIGINT, @step::BIGINT), @end::BIGINT - 1, @step::BIGINT * 1000000000) AS bucket
+),
+aggregates AS (
+	SELECT history_bucket(%[3]s, @step) AS bucket, %[1]s AS value
+	FROM %[2]s
+	WHERE history_type = @history_type AND %[3]s >= history_bucket(@start, @step) AND %[3]s < @end
+	GROUP BY 1
+)
+SELECT b.bucket, a.value::DOUBLE PRECISION
+FROM buckets b
+LEFT JOIN aggregates a ON a.bucket = b.bucket
+ORDER BY b.bucket`
+
+// getHistorySeries downsamples the history of the type over the period of the filters to the buckets of their step,
+// with their timestamps aligned to the step. The buckets which are covered by the history rollups of a width which
+// divides the step are read from them, and the buckets before and after them from the history entries.
+func (r *PostgresRepository) getHistorySeries(
+	ctx context.Context,
+	historyType string,
+	filters HistoryFilters,
+) ([]*model.HistoryPoint, error) {
+	if filters.TimestampStart == nil || filters.TimestampEnd == nil {
+		return nil, fmt.Errorf("the period of a downsampled history must be set")
+	}
+	if filters.Step < time.Second || filters.Step%time.Second != 0 {
+		return nil, fmt.Errorf("invalid history step %s", filters.Step)
+	}
+	agg := filters.Agg
+	if agg == "" {
+		agg = HistoryAggAvg
+	}
+	expressions, ok := historyAggExpressions[agg]
+	if !ok {
+		return nil, fmt.Errorf("cannot aggregate history by %q", agg)
+	}
+
+	// the start of the first bucket
+	start := time.Unix(0, filters.TimestampStart.UnixNano()-filters.TimestampStart.UnixNano()%filters.Step.Nanoseconds())
+	entriesQuery := fmt.Sprintf(historySeriesQuery, expressions.entries, "history h", "h.timestamp")
+	width, from, to, err := r.rolledUpPeriod(ctx, RollupHistory, start, filters.Step, start, *filters.TimestampEnd)
+	if err != nil {
+		return nil, err
+	}
+	if width == 0 {
+		return r.getHistoryPoints(ctx, entriesQuery, historyType, start, *filters.TimestampEnd, filters.Step)
+	}
+
+	// the width is an integer, so it can be used as a literal
+	source := fmt.Sprintf("(SELECT * FROM history_rollups WHERE width_seconds = %d) r", int64(width/time.Second))
+	var points []*model.HistoryPoint
+	for _, part := range []struct {
+		start time.Time
+		end   time.Time
+		query string
+	}{
+		{start: start, end: from, query: entriesQuery},
+		{start: from, end: to, query: fmt.Sprintf(historySeriesQuery, expressions.rollups, source, "r.bucket")},
+		{start: to, end: *filters.TimestampEnd, query: entriesQuery},
+	} {
+		if !part.start.Before(part.end) {
+			continue
+		}
+		partPoints, err := r.getHistoryPoints(ctx, part.query, historyType, part.start, part.end, filters.Step)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, partPoints...)
+	}
+	return points, nil
+}
+
+// getHistoryPoints returns the points of the history of the type in the buckets of the step from the bucket of
+// the start until the end, which are aggregated by the query.
+func (r *PostgresRepository) getHistoryPoints(
+	ctx context.Context,
+	query string,
+	historyType string,
+	start time.Time,
+	end time.Time,
+	step time.Duration,
+) ([]*model.HistoryPoint, error) {
+	rows, err := r.dbpool.Query(ctx, query, pgx.NamedArgs{
+		"history_type": historyType,
+		"start":        start.UnixNano(),
+		"end":          end.UnixNano(),
+		"step":         int64(step / time.Second),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []*model.HistoryPoint
+	for rows.Next() {
+		var p model.HistoryPoint
+		if err := rows.Scan(&p.Timestamp, &p.Value); err != nil {
+			return nil, err
+		}
+		points = append(points, &p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+// GetApplicationsHistorySeries returns the applications history over the period of the filters downsampled
+// to the buckets of their step, with the entries of a bucket aggregated by their aggregation, the average by default.
+// The pagination of the filters does not apply.
+func (r *PostgresRepository) GetApplicationsHistorySeries(ctx context.Context, filters HistoryFilters) ([]*model.HistoryPoint, error) {
+	points, err := r.getHistorySeries(ctx, appHistoryType, filters)
+	if err != nil {
+		return nil, fmt.Errorf("could not get applications history from DB: %v", err)
+	}
+	return points, nil
+}
+
+// GetContainersHistorySeries returns the containers history over the period of the filters downsampled
+// to the buckets of their step, with the entries of a bucket aggregated by their aggregation, the average by default.
+// The pagination of the filters does not apply.
+func (r *PostgresRepository) GetContainersHistorySeries(ctx context.Context, filters HistoryFilters) ([]*model.HistoryPoint, error) {
+	points, err := r.getHistorySeries(ctx, containerHistoryType, filters)
+	if err != nil {
+		return nil, fmt.Errorf("could not get containers history from DB: %v", err)
+	}
+	return points, nil
+}
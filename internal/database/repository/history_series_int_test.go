@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/G-Research/yunikorn-core/pkg/webservice/dao"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/G-Research/unicorn-history-server/internal/model"
+	"github.com/G-Research/unicorn-history-server/internal/util"
+)
+
+type HistorySeriesIntTest struct {
+	suite.Suite
+	pool *pgxpool.Pool
+	repo *PostgresRepository
+	base time.Time
+}
+
+func (hs *HistorySeriesIntTest) SetupSuite() {
+	ctx := context.Background()
+	require.NotNil(hs.T(), hs.pool)
+	repo, err := NewPostgresRepository(hs.pool)
+	require.NoError(hs.T(), err)
+	hs.repo = repo
+	hs.base = time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	entries := map[time.Duration]int{
+		10 * time.Minute:             2,
+		20 * time.Minute:             4,
+		70 * time.Minute:             6,
+		3*time.Hour + 10*time.Minute: 8,
+	}
+	for offset, total := range entries {
+		timestamp := hs.base.Add(offset).UnixNano()
+		err := hs.repo.InsertAppHistory(ctx, &model.AppHistory{
+			Metadata: model.Metadata{CreatedAtNano: timestamp},
+			ID:       ulid.Make().String(),
+			ApplicationHistoryDAOInfo: dao.ApplicationHistoryDAOInfo{
+				TotalApplications: strconv.Itoa(total),
+				Timestamp:         timestamp,
+			},
+		})
+		require.NoError(hs.T(), err)
+	}
+
+	// the hourly rollups cover the first two hours only, the following buckets are read from the history
+	now := hs.base.Add(48 * time.Hour).UnixNano()
+	require.NoError(hs.T(), hs.repo.ComputeRollup(ctx, RollupHistory, time.Hour, hs.base, hs.base.Add(2*time.Hour), now))
+	require.NoError(hs.T(), hs.repo.ComputeRollup(ctx, RollupHistory, 24*time.Hour, hs.base, hs.base.Add(24*time.Hour), now))
+}
+
+func (hs *HistorySeriesIntTest) TearDownSuite() {
+	hs.pool.Close()
+}
+
+func (hs *HistorySeriesIntTest) TestGetApplicationsHistorySeries() {
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		start    time.Duration
+		end      time.Duration
+		step     time.Duration
+		agg      HistoryAgg
+		expected []*float64
+	}{
+		{
+			name:     "Hourly average from the rollups and the history",
+			end:      4 * time.Hour,
+			step:     time.Hour,
+			expected: []*float64{util.ToPtr(3.0), util.ToPtr(6.0), nil, util.ToPtr(8.0)},
+		},
+		{
+			name:     "Half-hourly maximum from the history",
+			end:      4 * time.Hour,
+			step:     30 * time.Minute,
+			agg:      HistoryAggMax,
+			expected: []*float64{util.ToPtr(4.0), nil, util.ToPtr(6.0), nil, nil, nil, util.ToPtr(8.0), nil},
+		},
+		{
+			name:     "Daily last value from the rollups",
+			end:      24 * time.Hour,
+			step:     24 * time.Hour,
+			agg:      HistoryAggLast,
+			expected: []*float64{util.ToPtr(8.0)},
+		},
+		{
+			name:     "Hourly average from the history after the rollups",
+			start:    2 * time.Hour,
+			end:      4 * time.Hour,
+			step:     time.Hour,
+			expected: []*float64{nil, util.ToPtr(8.0)},
+		},
+		{
+			name:     "Buckets aligned to the step",
+			start:    time.Hour,
+			end:      4 * time.Hour,
+			step:     2 * time.Hour,
+			agg:      HistoryAggMin,
+			expected: []*float64{util.ToPtr(2.0), util.ToPtr(8.0)},
+		},
+	}
+
+	for _, tt := range tests {
+		hs.Run(tt.name, func() {
+			points, err := hs.repo.GetApplicationsHistorySeries(ctx, HistoryFilters{
+				TimestampStart: util.ToPtr(hs.base.Add(tt.start)),
+				TimestampEnd:   util.ToPtr(hs.base.Add(tt.end)),
+				Step:           tt.step,
+				Agg:            tt.agg,
+			})
+			require.NoError(hs.T(), err)
+			require.Len(hs.T(), points, len(tt.expected))
+			for i, point := range points {
+				require.Equal(hs.T(), hs.base.Add(tt.start).Truncate(tt.step).Add(time.Duration(i)*tt.step).UnixNano(), point.Timestamp)
+				require.Equal(hs.T(), tt.expected[i], point.Value, i)
+			}
+		})
+	}
+}
+
+func (hs *HistorySeriesIntTest) TestGetContainersHistorySeries() {
+	ctx := context.Background()
+	points, err := hs.repo.GetContainersHistorySeries(ctx, HistoryFilters{
+		TimestampStart: util.ToPtr(hs.base),
+		TimestampEnd:   util.ToPtr(hs.base.Add(2 * time.Hour)),
+		Step:           time.Hour,
+	})
+	require.NoError(hs.T(), err)
+	require.Len(hs.T(), points, 2)
+	for _, point := range points {
+		require.Nil(hs.T(), point.Value)
+	}
+
+	_, err = hs.repo.GetContainersHistorySeries(ctx, HistoryFilters{
+		TimestampStart: util.ToPtr(hs.base),
+		TimestampEnd:   util.ToPtr(hs.base.Add(2 * time.Hour)),
+		Step:           1500 * time.Millisecond,
+	})
+	require.Error(hs.T(), err)
+}
@@ -12,6 +12,7 @@ package repository
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	model "github.com/G-Research/unicorn-history-server/internal/model"
 	gomock "go.uber.org/mock/gomock"
@@ -40,6 +41,154 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
+// CloseDeletedReservations mocks base method.
+func (m *MockRepository) CloseDeletedReservations(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseDeletedReservations", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseDeletedReservations indicates an expected call of CloseDeletedReservations.
+func (mr *MockRepositoryMockRecorder) CloseDeletedReservations(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseDeletedReservations", reflect.TypeOf((*MockRepository)(nil).CloseDeletedReservations), arg0, arg1)
+}
+
+// ComputeRollup mocks base method.
+func (m *MockRepository) ComputeRollup(arg0 context.Context, arg1 Rollup, arg2 time.Duration, arg3, arg4 time.Time, arg5 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ComputeRollup", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ComputeRollup indicates an expected call of ComputeRollup.
+func (mr *MockRepositoryMockRecorder) ComputeRollup(arg0, arg1, arg2, arg3, arg4, arg5 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ComputeRollup", reflect.TypeOf((*MockRepository)(nil).ComputeRollup), arg0, arg1, arg2, arg3, arg4, arg5)
+}
+
+// CountAccountingRecords mocks base method.
+func (m *MockRepository) CountAccountingRecords(arg0 context.Context, arg1 AccountingFilters, arg2 bool) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountAccountingRecords", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountAccountingRecords indicates an expected call of CountAccountingRecords.
+func (mr *MockRepositoryMockRecorder) CountAccountingRecords(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountAccountingRecords", reflect.TypeOf((*MockRepository)(nil).CountAccountingRecords), arg0, arg1, arg2)
+}
+
+// CountAllApplications mocks base method.
+func (m *MockRepository) CountAllApplications(arg0 context.Context, arg1 ApplicationFilters, arg2 bool) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountAllApplications", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountAllApplications indicates an expected call of CountAllApplications.
+func (mr *MockRepositoryMockRecorder) CountAllApplications(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountAllApplications", reflect.TypeOf((*MockRepository)(nil).CountAllApplications), arg0, arg1, arg2)
+}
+
+// CountAllPartitions mocks base method.
+func (m *MockRepository) CountAllPartitions(arg0 context.Context, arg1 PartitionFilters, arg2 bool) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountAllPartitions", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountAllPartitions indicates an expected call of CountAllPartitions.
+func (mr *MockRepositoryMockRecorder) CountAllPartitions(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountAllPartitions", reflect.TypeOf((*MockRepository)(nil).CountAllPartitions), arg0, arg1, arg2)
+}
+
+// CountApplicationsHistory mocks base method.
+func (m *MockRepository) CountApplicationsHistory(arg0 context.Context, arg1 HistoryFilters, arg2 bool) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountApplicationsHistory", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountApplicationsHistory indicates an expected call of CountApplicationsHistory.
+func (mr *MockRepositoryMockRecorder) CountApplicationsHistory(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountApplicationsHistory", reflect.TypeOf((*MockRepository)(nil).CountApplicationsHistory), arg0, arg1, arg2)
+}
+
+// CountApplicationsInQueueByState mocks base method.
+func (m *MockRepository) CountApplicationsInQueueByState(arg0 context.Context, arg1 string) (map[string]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountApplicationsInQueueByState", arg0, arg1)
+	ret0, _ := ret[0].(map[string]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountApplicationsInQueueByState indicates an expected call of CountApplicationsInQueueByState.
+func (mr *MockRepositoryMockRecorder) CountApplicationsInQueueByState(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountApplicationsInQueueByState", reflect.TypeOf((*MockRepository)(nil).CountApplicationsInQueueByState), arg0, arg1)
+}
+
+// CountAppsPerPartitionPerQueue mocks base method.
+func (m *MockRepository) CountAppsPerPartitionPerQueue(arg0 context.Context, arg1, arg2 string, arg3 ApplicationFilters, arg4 bool) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountAppsPerPartitionPerQueue", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountAppsPerPartitionPerQueue indicates an expected call of CountAppsPerPartitionPerQueue.
+func (mr *MockRepositoryMockRecorder) CountAppsPerPartitionPerQueue(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountAppsPerPartitionPerQueue", reflect.TypeOf((*MockRepository)(nil).CountAppsPerPartitionPerQueue), arg0, arg1, arg2, arg3, arg4)
+}
+
+// CountContainersHistory mocks base method.
+func (m *MockRepository) CountContainersHistory(arg0 context.Context, arg1 HistoryFilters, arg2 bool) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountContainersHistory", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountContainersHistory indicates an expected call of CountContainersHistory.
+func (mr *MockRepositoryMockRecorder) CountContainersHistory(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountContainersHistory", reflect.TypeOf((*MockRepository)(nil).CountContainersHistory), arg0, arg1, arg2)
+}
+
+// CountNodesPerPartition mocks base method.
+func (m *MockRepository) CountNodesPerPartition(arg0 context.Context, arg1 string, arg2 NodeFilters, arg3 bool) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountNodesPerPartition", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountNodesPerPartition indicates an expected call of CountNodesPerPartition.
+func (mr *MockRepositoryMockRecorder) CountNodesPerPartition(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountNodesPerPartition", reflect.TypeOf((*MockRepository)(nil).CountNodesPerPartition), arg0, arg1, arg2, arg3)
+}
+
 // DeleteApplicationsNotInIDs mocks base method.
 func (m *MockRepository) DeleteApplicationsNotInIDs(arg0 context.Context, arg1 []string, arg2 int64) error {
 	m.ctrl.T.Helper()
@@ -54,6 +203,21 @@ func (mr *MockRepositoryMockRecorder) DeleteApplicationsNotInIDs(arg0, arg1, arg
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteApplicationsNotInIDs", reflect.TypeOf((*MockRepository)(nil).DeleteApplicationsNotInIDs), arg0, arg1, arg2)
 }
 
+// DeleteEventsBefore mocks base method.
+func (m *MockRepository) DeleteEventsBefore(arg0 context.Context, arg1 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteEventsBefore", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteEventsBefore indicates an expected call of DeleteEventsBefore.
+func (mr *MockRepositoryMockRecorder) DeleteEventsBefore(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEventsBefore", reflect.TypeOf((*MockRepository)(nil).DeleteEventsBefore), arg0, arg1)
+}
+
 // DeleteNodesNotInIDs mocks base method.
 func (m *MockRepository) DeleteNodesNotInIDs(arg0 context.Context, arg1 []string, arg2 int64) error {
 	m.ctrl.T.Helper()
@@ -96,6 +260,21 @@ func (mr *MockRepositoryMockRecorder) DeleteQueuesNotInIDs(arg0, arg1, arg2 any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteQueuesNotInIDs", reflect.TypeOf((*MockRepository)(nil).DeleteQueuesNotInIDs), arg0, arg1, arg2)
 }
 
+// GetAccountingRecords mocks base method.
+func (m *MockRepository) GetAccountingRecords(arg0 context.Context, arg1 AccountingFilters) ([]*model.AccountingRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountingRecords", arg0, arg1)
+	ret0, _ := ret[0].([]*model.AccountingRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountingRecords indicates an expected call of GetAccountingRecords.
+func (mr *MockRepositoryMockRecorder) GetAccountingRecords(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountingRecords", reflect.TypeOf((*MockRepository)(nil).GetAccountingRecords), arg0, arg1)
+}
+
 // GetAllApplications mocks base method.
 func (m *MockRepository) GetAllApplications(arg0 context.Context, arg1 ApplicationFilters) ([]*model.Application, error) {
 	m.ctrl.T.Helper()
@@ -156,6 +335,51 @@ func (mr *MockRepositoryMockRecorder) GetApplicationByID(arg0, arg1 any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApplicationByID", reflect.TypeOf((*MockRepository)(nil).GetApplicationByID), arg0, arg1)
 }
 
+// GetApplicationPreemptions mocks base method.
+func (m *MockRepository) GetApplicationPreemptions(arg0 context.Context, arg1 string) ([]*model.Preemption, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetApplicationPreemptions", arg0, arg1)
+	ret0, _ := ret[0].([]*model.Preemption)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetApplicationPreemptions indicates an expected call of GetApplicationPreemptions.
+func (mr *MockRepositoryMockRecorder) GetApplicationPreemptions(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApplicationPreemptions", reflect.TypeOf((*MockRepository)(nil).GetApplicationPreemptions), arg0, arg1)
+}
+
+// GetApplicationRuns mocks base method.
+func (m *MockRepository) GetApplicationRuns(arg0 context.Context, arg1 string) ([]*model.Application, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetApplicationRuns", arg0, arg1)
+	ret0, _ := ret[0].([]*model.Application)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetApplicationRuns indicates an expected call of GetApplicationRuns.
+func (mr *MockRepositoryMockRecorder) GetApplicationRuns(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApplicationRuns", reflect.TypeOf((*MockRepository)(nil).GetApplicationRuns), arg0, arg1)
+}
+
+// GetApplicationVersions mocks base method.
+func (m *MockRepository) GetApplicationVersions(arg0 context.Context, arg1 string) ([]*model.ApplicationVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetApplicationVersions", arg0, arg1)
+	ret0, _ := ret[0].([]*model.ApplicationVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetApplicationVersions indicates an expected call of GetApplicationVersions.
+func (mr *MockRepositoryMockRecorder) GetApplicationVersions(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApplicationVersions", reflect.TypeOf((*MockRepository)(nil).GetApplicationVersions), arg0, arg1)
+}
+
 // GetApplicationsHistory mocks base method.
 func (m *MockRepository) GetApplicationsHistory(arg0 context.Context, arg1 HistoryFilters) ([]*model.AppHistory, error) {
 	m.ctrl.T.Helper()
@@ -171,6 +395,36 @@ func (mr *MockRepositoryMockRecorder) GetApplicationsHistory(arg0, arg1 any) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApplicationsHistory", reflect.TypeOf((*MockRepository)(nil).GetApplicationsHistory), arg0, arg1)
 }
 
+// GetApplicationsHistorySeries mocks base method.
+func (m *MockRepository) GetApplicationsHistorySeries(arg0 context.Context, arg1 HistoryFilters) ([]*model.HistoryPoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetApplicationsHistorySeries", arg0, arg1)
+	ret0, _ := ret[0].([]*model.HistoryPoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetApplicationsHistorySeries indicates an expected call of GetApplicationsHistorySeries.
+func (mr *MockRepositoryMockRecorder) GetApplicationsHistorySeries(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApplicationsHistorySeries", reflect.TypeOf((*MockRepository)(nil).GetApplicationsHistorySeries), arg0, arg1)
+}
+
+// GetApplicationsTimeline mocks base method.
+func (m *MockRepository) GetApplicationsTimeline(arg0 context.Context, arg1 TimelineFilters) ([]*model.TimelinePoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetApplicationsTimeline", arg0, arg1)
+	ret0, _ := ret[0].([]*model.TimelinePoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetApplicationsTimeline indicates an expected call of GetApplicationsTimeline.
+func (mr *MockRepositoryMockRecorder) GetApplicationsTimeline(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApplicationsTimeline", reflect.TypeOf((*MockRepository)(nil).GetApplicationsTimeline), arg0, arg1)
+}
+
 // GetAppsPerPartitionPerQueue mocks base method.
 func (m *MockRepository) GetAppsPerPartitionPerQueue(arg0 context.Context, arg1, arg2 string, arg3 ApplicationFilters) ([]*model.Application, error) {
 	m.ctrl.T.Helper()
@@ -201,6 +455,141 @@ func (mr *MockRepositoryMockRecorder) GetContainersHistory(arg0, arg1 any) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContainersHistory", reflect.TypeOf((*MockRepository)(nil).GetContainersHistory), arg0, arg1)
 }
 
+// GetContainersHistorySeries mocks base method.
+func (m *MockRepository) GetContainersHistorySeries(arg0 context.Context, arg1 HistoryFilters) ([]*model.HistoryPoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetContainersHistorySeries", arg0, arg1)
+	ret0, _ := ret[0].([]*model.HistoryPoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetContainersHistorySeries indicates an expected call of GetContainersHistorySeries.
+func (mr *MockRepositoryMockRecorder) GetContainersHistorySeries(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContainersHistorySeries", reflect.TypeOf((*MockRepository)(nil).GetContainersHistorySeries), arg0, arg1)
+}
+
+// GetContainersTimeline mocks base method.
+func (m *MockRepository) GetContainersTimeline(arg0 context.Context, arg1 TimelineFilters) ([]*model.TimelinePoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetContainersTimeline", arg0, arg1)
+	ret0, _ := ret[0].([]*model.TimelinePoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetContainersTimeline indicates an expected call of GetContainersTimeline.
+func (mr *MockRepositoryMockRecorder) GetContainersTimeline(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContainersTimeline", reflect.TypeOf((*MockRepository)(nil).GetContainersTimeline), arg0, arg1)
+}
+
+// GetDemandSeries mocks base method.
+func (m *MockRepository) GetDemandSeries(arg0 context.Context, arg1 DemandFilters) ([]*model.DemandPoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDemandSeries", arg0, arg1)
+	ret0, _ := ret[0].([]*model.DemandPoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDemandSeries indicates an expected call of GetDemandSeries.
+func (mr *MockRepositoryMockRecorder) GetDemandSeries(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDemandSeries", reflect.TypeOf((*MockRepository)(nil).GetDemandSeries), arg0, arg1)
+}
+
+// GetDiagnostics mocks base method.
+func (m *MockRepository) GetDiagnostics(arg0 context.Context, arg1 DiagnosticsFilters) ([]*model.DiagnosticGroup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDiagnostics", arg0, arg1)
+	ret0, _ := ret[0].([]*model.DiagnosticGroup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDiagnostics indicates an expected call of GetDiagnostics.
+func (mr *MockRepositoryMockRecorder) GetDiagnostics(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDiagnostics", reflect.TypeOf((*MockRepository)(nil).GetDiagnostics), arg0, arg1)
+}
+
+// GetEfficiency mocks base method.
+func (m *MockRepository) GetEfficiency(arg0 context.Context, arg1 EfficiencyFilters) ([]*model.Efficiency, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEfficiency", arg0, arg1)
+	ret0, _ := ret[0].([]*model.Efficiency)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEfficiency indicates an expected call of GetEfficiency.
+func (mr *MockRepositoryMockRecorder) GetEfficiency(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEfficiency", reflect.TypeOf((*MockRepository)(nil).GetEfficiency), arg0, arg1)
+}
+
+// GetEvents mocks base method.
+func (m *MockRepository) GetEvents(arg0 context.Context, arg1 EventFilters) ([]*model.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEvents", arg0, arg1)
+	ret0, _ := ret[0].([]*model.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEvents indicates an expected call of GetEvents.
+func (mr *MockRepositoryMockRecorder) GetEvents(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEvents", reflect.TypeOf((*MockRepository)(nil).GetEvents), arg0, arg1)
+}
+
+// GetGangStats mocks base method.
+func (m *MockRepository) GetGangStats(arg0 context.Context, arg1 GangFilters) ([]*model.TaskGroupStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGangStats", arg0, arg1)
+	ret0, _ := ret[0].([]*model.TaskGroupStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGangStats indicates an expected call of GetGangStats.
+func (mr *MockRepositoryMockRecorder) GetGangStats(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGangStats", reflect.TypeOf((*MockRepository)(nil).GetGangStats), arg0, arg1)
+}
+
+// GetLongLivedReservations mocks base method.
+func (m *MockRepository) GetLongLivedReservations(arg0 context.Context, arg1 ReservationFilters) ([]*model.Reservation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLongLivedReservations", arg0, arg1)
+	ret0, _ := ret[0].([]*model.Reservation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLongLivedReservations indicates an expected call of GetLongLivedReservations.
+func (mr *MockRepositoryMockRecorder) GetLongLivedReservations(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLongLivedReservations", reflect.TypeOf((*MockRepository)(nil).GetLongLivedReservations), arg0, arg1)
+}
+
+// GetNodeAllocationPeriods mocks base method.
+func (m *MockRepository) GetNodeAllocationPeriods(arg0 context.Context, arg1, arg2 string) ([]*model.AllocationPeriod, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNodeAllocationPeriods", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*model.AllocationPeriod)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNodeAllocationPeriods indicates an expected call of GetNodeAllocationPeriods.
+func (mr *MockRepositoryMockRecorder) GetNodeAllocationPeriods(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNodeAllocationPeriods", reflect.TypeOf((*MockRepository)(nil).GetNodeAllocationPeriods), arg0, arg1, arg2)
+}
+
 // GetNodeByID mocks base method.
 func (m *MockRepository) GetNodeByID(arg0 context.Context, arg1 string) (*model.Node, error) {
 	m.ctrl.T.Helper()
@@ -216,6 +605,36 @@ func (mr *MockRepositoryMockRecorder) GetNodeByID(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNodeByID", reflect.TypeOf((*MockRepository)(nil).GetNodeByID), arg0, arg1)
 }
 
+// GetNodeUtilization mocks base method.
+func (m *MockRepository) GetNodeUtilization(arg0 context.Context, arg1 NodeUtilizationFilters) ([]*model.NodeUtilization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNodeUtilization", arg0, arg1)
+	ret0, _ := ret[0].([]*model.NodeUtilization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNodeUtilization indicates an expected call of GetNodeUtilization.
+func (mr *MockRepositoryMockRecorder) GetNodeUtilization(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNodeUtilization", reflect.TypeOf((*MockRepository)(nil).GetNodeUtilization), arg0, arg1)
+}
+
+// GetNodeVersions mocks base method.
+func (m *MockRepository) GetNodeVersions(arg0 context.Context, arg1 string) ([]*model.NodeVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNodeVersions", arg0, arg1)
+	ret0, _ := ret[0].([]*model.NodeVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNodeVersions indicates an expected call of GetNodeVersions.
+func (mr *MockRepositoryMockRecorder) GetNodeVersions(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNodeVersions", reflect.TypeOf((*MockRepository)(nil).GetNodeVersions), arg0, arg1)
+}
+
 // GetNodesPerPartition mocks base method.
 func (m *MockRepository) GetNodesPerPartition(arg0 context.Context, arg1 string, arg2 NodeFilters) ([]*model.Node, error) {
 	m.ctrl.T.Helper()
@@ -246,6 +665,21 @@ func (mr *MockRepositoryMockRecorder) GetPartitionByID(arg0, arg1 any) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPartitionByID", reflect.TypeOf((*MockRepository)(nil).GetPartitionByID), arg0, arg1)
 }
 
+// GetPreemptionStats mocks base method.
+func (m *MockRepository) GetPreemptionStats(arg0 context.Context, arg1 PreemptionFilters) ([]*model.PreemptionStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPreemptionStats", arg0, arg1)
+	ret0, _ := ret[0].([]*model.PreemptionStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPreemptionStats indicates an expected call of GetPreemptionStats.
+func (mr *MockRepositoryMockRecorder) GetPreemptionStats(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPreemptionStats", reflect.TypeOf((*MockRepository)(nil).GetPreemptionStats), arg0, arg1)
+}
+
 // GetQueue mocks base method.
 func (m *MockRepository) GetQueue(arg0 context.Context, arg1 string) (*model.Queue, error) {
 	m.ctrl.T.Helper()
@@ -261,19 +695,139 @@ func (mr *MockRepositoryMockRecorder) GetQueue(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQueue", reflect.TypeOf((*MockRepository)(nil).GetQueue), arg0, arg1)
 }
 
+// GetQueueFairness mocks base method.
+func (m *MockRepository) GetQueueFairness(arg0 context.Context, arg1 FairnessFilters) ([]*model.QueueFairness, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetQueueFairness", arg0, arg1)
+	ret0, _ := ret[0].([]*model.QueueFairness)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetQueueFairness indicates an expected call of GetQueueFairness.
+func (mr *MockRepositoryMockRecorder) GetQueueFairness(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQueueFairness", reflect.TypeOf((*MockRepository)(nil).GetQueueFairness), arg0, arg1)
+}
+
+// GetQueueSubtree mocks base method.
+func (m *MockRepository) GetQueueSubtree(arg0 context.Context, arg1, arg2 string, arg3 int, arg4 QueueFilters) ([]*model.Queue, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetQueueSubtree", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].([]*model.Queue)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetQueueSubtree indicates an expected call of GetQueueSubtree.
+func (mr *MockRepositoryMockRecorder) GetQueueSubtree(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQueueSubtree", reflect.TypeOf((*MockRepository)(nil).GetQueueSubtree), arg0, arg1, arg2, arg3, arg4)
+}
+
+// GetQueueVersions mocks base method.
+func (m *MockRepository) GetQueueVersions(arg0 context.Context, arg1 string) ([]*model.QueueVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetQueueVersions", arg0, arg1)
+	ret0, _ := ret[0].([]*model.QueueVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetQueueVersions indicates an expected call of GetQueueVersions.
+func (mr *MockRepositoryMockRecorder) GetQueueVersions(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQueueVersions", reflect.TypeOf((*MockRepository)(nil).GetQueueVersions), arg0, arg1)
+}
+
 // GetQueuesInPartition mocks base method.
-func (m *MockRepository) GetQueuesInPartition(arg0 context.Context, arg1 string) ([]*model.Queue, error) {
+func (m *MockRepository) GetQueuesInPartition(arg0 context.Context, arg1 string, arg2 QueueFilters) ([]*model.Queue, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetQueuesInPartition", arg0, arg1)
+	ret := m.ctrl.Call(m, "GetQueuesInPartition", arg0, arg1, arg2)
 	ret0, _ := ret[0].([]*model.Queue)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetQueuesInPartition indicates an expected call of GetQueuesInPartition.
-func (mr *MockRepositoryMockRecorder) GetQueuesInPartition(arg0, arg1 any) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetQueuesInPartition(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQueuesInPartition", reflect.TypeOf((*MockRepository)(nil).GetQueuesInPartition), arg0, arg1, arg2)
+}
+
+// GetRollupWatermark mocks base method.
+func (m *MockRepository) GetRollupWatermark(arg0 context.Context, arg1 Rollup, arg2 time.Duration) (*model.RollupWatermark, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRollupWatermark", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*model.RollupWatermark)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRollupWatermark indicates an expected call of GetRollupWatermark.
+func (mr *MockRepositoryMockRecorder) GetRollupWatermark(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRollupWatermark", reflect.TypeOf((*MockRepository)(nil).GetRollupWatermark), arg0, arg1, arg2)
+}
+
+// GetUsage mocks base method.
+func (m *MockRepository) GetUsage(arg0 context.Context, arg1 UsageFilters) ([]*model.Usage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsage", arg0, arg1)
+	ret0, _ := ret[0].([]*model.Usage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsage indicates an expected call of GetUsage.
+func (mr *MockRepositoryMockRecorder) GetUsage(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsage", reflect.TypeOf((*MockRepository)(nil).GetUsage), arg0, arg1)
+}
+
+// GetUserShares mocks base method.
+func (m *MockRepository) GetUserShares(arg0 context.Context, arg1 FairnessFilters) ([]*model.UserShare, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserShares", arg0, arg1)
+	ret0, _ := ret[0].([]*model.UserShare)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserShares indicates an expected call of GetUserShares.
+func (mr *MockRepositoryMockRecorder) GetUserShares(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserShares", reflect.TypeOf((*MockRepository)(nil).GetUserShares), arg0, arg1)
+}
+
+// GetWaitTimes mocks base method.
+func (m *MockRepository) GetWaitTimes(arg0 context.Context, arg1 WaitTimeFilters) ([]*model.WaitTimeStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWaitTimes", arg0, arg1)
+	ret0, _ := ret[0].([]*model.WaitTimeStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWaitTimes indicates an expected call of GetWaitTimes.
+func (mr *MockRepositoryMockRecorder) GetWaitTimes(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWaitTimes", reflect.TypeOf((*MockRepository)(nil).GetWaitTimes), arg0, arg1)
+}
+
+// InsertAccountingRecord mocks base method.
+func (m *MockRepository) InsertAccountingRecord(arg0 context.Context, arg1 *model.AccountingRecord) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertAccountingRecord", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertAccountingRecord indicates an expected call of InsertAccountingRecord.
+func (mr *MockRepositoryMockRecorder) InsertAccountingRecord(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQueuesInPartition", reflect.TypeOf((*MockRepository)(nil).GetQueuesInPartition), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertAccountingRecord", reflect.TypeOf((*MockRepository)(nil).InsertAccountingRecord), arg0, arg1)
 }
 
 // InsertAppHistory mocks base method.
@@ -318,6 +872,20 @@ func (mr *MockRepositoryMockRecorder) InsertContainerHistory(arg0, arg1 any) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertContainerHistory", reflect.TypeOf((*MockRepository)(nil).InsertContainerHistory), arg0, arg1)
 }
 
+// InsertEvent mocks base method.
+func (m *MockRepository) InsertEvent(arg0 context.Context, arg1 *model.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertEvent", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertEvent indicates an expected call of InsertEvent.
+func (mr *MockRepositoryMockRecorder) InsertEvent(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertEvent", reflect.TypeOf((*MockRepository)(nil).InsertEvent), arg0, arg1)
+}
+
 // InsertNode mocks base method.
 func (m *MockRepository) InsertNode(arg0 context.Context, arg1 *model.Node) error {
 	m.ctrl.T.Helper()
@@ -346,6 +914,20 @@ func (mr *MockRepositoryMockRecorder) InsertPartition(arg0, arg1 any) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertPartition", reflect.TypeOf((*MockRepository)(nil).InsertPartition), arg0, arg1)
 }
 
+// InsertPreemption mocks base method.
+func (m *MockRepository) InsertPreemption(arg0 context.Context, arg1 *model.Preemption) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertPreemption", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertPreemption indicates an expected call of InsertPreemption.
+func (mr *MockRepositoryMockRecorder) InsertPreemption(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertPreemption", reflect.TypeOf((*MockRepository)(nil).InsertPreemption), arg0, arg1)
+}
+
 // InsertQueue mocks base method.
 func (m *MockRepository) InsertQueue(arg0 context.Context, arg1 *model.Queue) error {
 	m.ctrl.T.Helper()
@@ -360,6 +942,21 @@ func (mr *MockRepositoryMockRecorder) InsertQueue(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertQueue", reflect.TypeOf((*MockRepository)(nil).InsertQueue), arg0, arg1)
 }
 
+// Search mocks base method.
+func (m *MockRepository) Search(arg0 context.Context, arg1 string, arg2 int) (*model.SearchResults, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*model.SearchResults)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockRepositoryMockRecorder) Search(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockRepository)(nil).Search), arg0, arg1, arg2)
+}
+
 // UpdateApplication mocks base method.
 func (m *MockRepository) UpdateApplication(arg0 context.Context, arg1 *model.Application) error {
 	m.ctrl.T.Helper()
@@ -374,6 +971,20 @@ func (mr *MockRepositoryMockRecorder) UpdateApplication(arg0, arg1 any) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateApplication", reflect.TypeOf((*MockRepository)(nil).UpdateApplication), arg0, arg1)
 }
 
+// UpdateApplicationReservations mocks base method.
+func (m *MockRepository) UpdateApplicationReservations(arg0 context.Context, arg1 *model.Application, arg2 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateApplicationReservations", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateApplicationReservations indicates an expected call of UpdateApplicationReservations.
+func (mr *MockRepositoryMockRecorder) UpdateApplicationReservations(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateApplicationReservations", reflect.TypeOf((*MockRepository)(nil).UpdateApplicationReservations), arg0, arg1, arg2)
+}
+
 // UpdateNode mocks base method.
 func (m *MockRepository) UpdateNode(arg0 context.Context, arg1 *model.Node) error {
 	m.ctrl.T.Helper()
@@ -388,6 +999,20 @@ func (mr *MockRepositoryMockRecorder) UpdateNode(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateNode", reflect.TypeOf((*MockRepository)(nil).UpdateNode), arg0, arg1)
 }
 
+// UpdateNodeReservations mocks base method.
+func (m *MockRepository) UpdateNodeReservations(arg0 context.Context, arg1, arg2 string, arg3 []string, arg4 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateNodeReservations", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateNodeReservations indicates an expected call of UpdateNodeReservations.
+func (mr *MockRepositoryMockRecorder) UpdateNodeReservations(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateNodeReservations", reflect.TypeOf((*MockRepository)(nil).UpdateNodeReservations), arg0, arg1, arg2, arg3, arg4)
+}
+
 // UpdatePartition mocks base method.
 func (m *MockRepository) UpdatePartition(arg0 context.Context, arg1 *model.Partition) error {
 	m.ctrl.T.Helper()
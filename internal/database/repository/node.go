@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 
@@ -17,27 +19,146 @@ type NodeFilters struct {
 	RackName    *string
 	Schedulable *bool
 	IsReserved  *bool
-	Offset      *int
-	Limit       *int
+	// Resources filter the nodes by their resources and attributes, see NodeResourceFilterFields.
+	Resources []ResourceFilter
+	// Expression is a filter expression compiled with NodeFilterSchema.
+	Expression     sql.Expr
+	AsOf           *time.Time
+	IncludeDeleted bool
+	// Sort are the keys by which the nodes are sorted, see NodeSortFields.
+	Sort []SortKey
+	// After is the position after which the returned nodes start.
+	After  Cursor
+	Offset *int
+	Limit  *int
+}
+
+// nodeColumns are the columns of the nodes table in the order in which scanNode scans them.
+var nodeColumns = []string{
+	"id",
+	"created_at_nano",
+	"deleted_at_nano",
+	"node_id",
+	"partition_id",
+	"host_name",
+	"rack_name",
+	"attributes",
+	"capacity",
+	"allocated",
+	"occupied",
+	"available",
+	"utilized",
+	"allocations",
+	"schedulable",
+	"is_reserved",
+	"reservations",
+}
+
+// scanNode scans a row which contains the nodeColumns into node.
+func scanNode(row pgx.Row, node *model.Node) error {
+	return row.Scan(
+		&node.ID,
+		&node.CreatedAtNano,
+		&node.DeletedAtNano,
+		&node.NodeID,
+		&node.PartitionID,
+		&node.HostName,
+		&node.RackName,
+		&node.Attributes,
+		&node.Capacity,
+		&node.Allocated,
+		&node.Occupied,
+		&node.Available,
+		&node.Utilized,
+		&node.Allocations,
+		&node.Schedulable,
+		&node.IsReserved,
+		&node.Reservations,
+	)
 }
 
 func applyNodeFilters(builder *sql.Builder, filters NodeFilters) {
 	if filters.NodeId != nil {
-		builder.Conditionp("node_id", "=", *filters.NodeId)
+		builder.Where(sql.Eq("node_id", *filters.NodeId))
 	}
 	if filters.HostName != nil {
-		builder.Conditionp("host_name", "=", *filters.HostName)
+		builder.Where(sql.Eq("host_name", *filters.HostName))
 	}
 	if filters.RackName != nil {
-		builder.Conditionp("rack_name", "=", *filters.RackName)
+		builder.Where(sql.Eq("rack_name", *filters.RackName))
 	}
 	if filters.Schedulable != nil {
-		builder.Conditionp("schedulable", "=", *filters.Schedulable)
+		builder.Where(sql.Eq("schedulable", *filters.Schedulable))
 	}
 	if filters.IsReserved != nil {
-		builder.Conditionp("is_reserved", "=", *filters.IsReserved)
+		builder.Where(sql.Eq("is_reserved", *filters.IsReserved))
+	}
+	if filters.Expression != nil {
+		builder.Where(filters.Expression)
 	}
-	applyLimitAndOffset(builder, filters.Limit, filters.Offset)
+	applyVersionsAsOf(builder, "nodes", "node_versions", filters.AsOf)
+	applyTemporalFilters(builder, filters.AsOf, filters.IncludeDeleted)
+	applyPagination(builder, filters.After, filters.Limit, filters.Offset)
+}
+
+// NodeSortFields are the fields by which nodes can be sorted.
+var NodeSortFields = &SortFields[model.Node]{
+	fields: map[string]sortField[model.Node]{
+		"id":       {"id", func(n *model.Node) any { return n.ID }},
+		"nodeID":   {"node_id", func(n *model.Node) any { return n.NodeID }},
+		"hostName": {"host_name", func(n *model.Node) any { return n.HostName }},
+		"rackName": {"COALESCE(rack_name, '')", func(n *model.Node) any { return n.RackName }},
+	},
+	resources: map[string]resourceSortField[model.Node]{
+		"capacity":  {"capacity", func(n *model.Node) map[string]int64 { return n.Capacity }},
+		"allocated": {"allocated", func(n *model.Node) map[string]int64 { return n.Allocated }},
+		"occupied":  {"occupied", func(n *model.Node) map[string]int64 { return n.Occupied }},
+		"available": {"available", func(n *model.Node) map[string]int64 { return n.Available }},
+		"utilized":  {"utilized", func(n *model.Node) map[string]int64 { return n.Utilized }},
+	},
+	defaultSort: []SortKey{{Field: "nodeID", Descending: true}},
+	id:          "id",
+}
+
+// NodeResourceFilterFields are the fields by which nodes can be filtered with resource filters.
+var NodeResourceFilterFields = &ResourceFilterFields{
+	resources: map[string]string{
+		"capacity":  "capacity",
+		"allocated": "allocated",
+		"occupied":  "occupied",
+		"available": "available",
+		"utilized":  "utilized",
+	},
+	attributes: map[string]string{
+		"attributes": "attributes",
+	},
+}
+
+// NodeFilterSchema defines the fields of the filter expressions of nodes.
+var NodeFilterSchema = &FilterSchema{
+	fields: map[string]filterField{
+		"id":          {expression: "id", fieldType: filterTypeString},
+		"nodeID":      {expression: "node_id", fieldType: filterTypeString},
+		"hostName":    {expression: "host_name", fieldType: filterTypeString},
+		"rackName":    {expression: "rack_name", fieldType: filterTypeString},
+		"schedulable": {expression: "schedulable", fieldType: filterTypeBool},
+		"isReserved":  {expression: "is_reserved", fieldType: filterTypeBool},
+	},
+	resources: map[string]string{
+		"capacity":  "capacity",
+		"allocated": "allocated",
+		"occupied":  "occupied",
+		"available": "available",
+		"utilized":  "utilized",
+	},
+	attributes: map[string]string{
+		"attributes": "attributes",
+	},
+}
+
+// Cursor returns the cursor which points to the position after the node in the list of nodes matching the filters.
+func (f NodeFilters) Cursor(node *model.Node) Cursor {
+	return NodeSortFields.Cursor(f.Sort, node)
 }
 
 func (s *PostgresRepository) InsertNode(ctx context.Context, node *model.Node) error {
@@ -159,28 +280,17 @@ WHERE id = @id`
 }
 
 func (s *PostgresRepository) GetNodeByID(ctx context.Context, id string) (*model.Node, error) {
-	const q = `SELECT * FROM nodes WHERE id = @id ORDER BY id DESC LIMIT 1`
+	query, args := sql.NewBuilder().
+		Select(nodeColumns...).
+		From("nodes", "").
+		Where(sql.Eq("id", id)).
+		Build()
+
 	var node model.Node
-	row := s.dbpool.QueryRow(ctx, q, pgx.NamedArgs{"id": id})
-	if err := row.Scan(
-		&node.ID,
-		&node.CreatedAtNano,
-		&node.DeletedAtNano,
-		&node.NodeID,
-		&node.PartitionID,
-		&node.HostName,
-		&node.RackName,
-		&node.Attributes,
-		&node.Capacity,
-		&node.Allocated,
-		&node.Occupied,
-		&node.Available,
-		&node.Utilized,
-		&node.Allocations,
-		&node.Schedulable,
-		&node.IsReserved,
-		&node.Reservations,
-	); err != nil {
+	if err := scanNode(s.dbpool.QueryRow(ctx, query, args...), &node); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("node %q %w", id, ErrNotFound)
+		}
 		return nil, fmt.Errorf("could not get node from DB: %v", err)
 	}
 	return &node, nil
@@ -203,16 +313,13 @@ WHERE deleted_at_nano IS NULL AND NOT (id = ANY(@ids))`
 }
 
 func (s *PostgresRepository) GetNodesPerPartition(ctx context.Context, partitionID string, filters NodeFilters) ([]*model.Node, error) {
-	queryBuilder := sql.NewBuilder().
-		SelectAll("nodes", "").
-		Conditionp("partition_id", "=", partitionID).
-		OrderBy("node_id", sql.OrderByDescending)
-	applyNodeFilters(queryBuilder, filters)
-
 	var nodes []*model.Node
 
-	query := queryBuilder.Query()
-	args := queryBuilder.Args()
+	queryBuilder, err := nodesPerPartitionQuery(partitionID, filters)
+	if err != nil {
+		return nil, err
+	}
+	query, args := queryBuilder.Build()
 	rows, err := s.dbpool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("could not get nodes from DB: %v", err)
@@ -220,29 +327,87 @@ func (s *PostgresRepository) GetNodesPerPartition(ctx context.Context, partition
 	defer rows.Close()
 	for rows.Next() {
 		var n model.Node
-		err := rows.Scan(
-			&n.ID,
-			&n.CreatedAtNano,
-			&n.DeletedAtNano,
-			&n.NodeID,
-			&n.PartitionID,
-			&n.HostName,
-			&n.RackName,
-			&n.Attributes,
-			&n.Capacity,
-			&n.Allocated,
-			&n.Occupied,
-			&n.Available,
-			&n.Utilized,
-			&n.Allocations,
-			&n.Schedulable,
-			&n.IsReserved,
-			&n.Reservations,
-		)
-		if err != nil {
+		if err := scanNode(rows, &n); err != nil {
 			return nil, fmt.Errorf("could not scan node: %v", err)
 		}
 		nodes = append(nodes, &n)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get nodes from DB: %v", err)
+	}
 	return nodes, nil
 }
+
+// CountNodesPerPartition returns the number of nodes in the partition which match the filters, ignoring the pagination.
+func (s *PostgresRepository) CountNodesPerPartition(ctx context.Context, partitionID string, filters NodeFilters, estimated bool) (int64, error) {
+	queryBuilder, err := nodesPerPartitionQuery(partitionID, filters)
+	if err != nil {
+		return 0, err
+	}
+	return s.count(ctx, queryBuilder, estimated)
+}
+
+func nodesPerPartitionQuery(partitionID string, filters NodeFilters) (*sql.Builder, error) {
+	queryBuilder := sql.NewBuilder().
+		Select(nodeColumns...).
+		From("nodes", "").
+		Where(sql.Eq("partition_id", partitionID))
+	if err := NodeSortFields.apply(queryBuilder, filters.Sort); err != nil {
+		return nil, err
+	}
+	if err := NodeResourceFilterFields.apply(queryBuilder, filters.Resources); err != nil {
+		return nil, err
+	}
+	applyNodeFilters(queryBuilder, filters)
+	return queryBuilder, nil
+}
+
+// nodeAllocationPeriodsQuery selects the allocations of the applications of the partition which were placed on
+// the node. An allocation is placed from its allocation time until it was removed (by the first REMOVE event of
+// the allocation) or its application finished or was deleted, whichever happened first.
+// The containment condition lets the applications with an allocation on the node be found by their index.
+const nodeAllocationPeriodsQuery = `
+SELECT a.app_id, alloc->>'allocationKey', al.start_nano, LEAST(removed.timestamp_nano, e.ended)
+FROM applications a
+CROSS JOIN LATERAL jsonb_array_elements(COALESCE(a.allocations, '[]'::JSONB)) AS alloc
+CROSS JOIN LATERAL (
+	SELECT COALESCE(NULLIF((alloc->>'allocationTime')::BIGINT, 0), a.submission_time * 1000000) AS start_nano
+) al
+CROSS JOIN LATERAL (
+	SELECT COALESCE(a.finished_time * 1000000, a.deleted_at_nano) AS ended
+) e
+CROSS JOIN LATERAL (
+	SELECT MIN(ev.timestamp_nano) AS timestamp_nano
+	FROM events ev
+	WHERE ev.type = 'APP' AND ev.object_id = a.app_id AND ev.reference_id = alloc->>'allocationKey'
+		AND ev.change_type = 'REMOVE' AND ev.timestamp_nano >= al.start_nano
+) removed
+WHERE a.partition_id = @partition_id AND alloc->>'nodeId' = @node_id
+	AND a.allocations @> jsonb_build_array(jsonb_build_object('nodeId', @node_id::TEXT))
+ORDER BY al.start_nano, a.app_id`
+
+// GetNodeAllocationPeriods returns the periods during which the allocations of the applications were placed on the
+// node of the partition, sorted by their start.
+func (s *PostgresRepository) GetNodeAllocationPeriods(ctx context.Context, partitionID, nodeID string) ([]*model.AllocationPeriod, error) {
+	rows, err := s.dbpool.Query(ctx, nodeAllocationPeriodsQuery, pgx.NamedArgs{
+		"partition_id": partitionID,
+		"node_id":      nodeID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get node allocation periods from DB: %v", err)
+	}
+	defer rows.Close()
+
+	var periods []*model.AllocationPeriod
+	for rows.Next() {
+		var p model.AllocationPeriod
+		if err := rows.Scan(&p.ApplicationID, &p.AllocationKey, &p.StartNano, &p.EndNano); err != nil {
+			return nil, fmt.Errorf("could not scan node allocation period from DB: %v", err)
+		}
+		periods = append(periods, &p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get node allocation periods from DB: %v", err)
+	}
+	return periods, nil
+}
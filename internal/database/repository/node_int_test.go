@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
+	"github.com/G-Research/unicorn-history-server/internal/database/sql"
 	"github.com/G-Research/unicorn-history-server/internal/model"
 
 	"github.com/G-Research/unicorn-history-server/internal/util"
@@ -115,6 +116,54 @@ func (ns *NodeIntTest) TestGetNodesPerPartition() {
 			},
 			expected: 1,
 		},
+		{
+			name:        "Filter by available memory",
+			partitionID: partitionID,
+			filters: NodeFilters{
+				Resources: []ResourceFilter{{Field: "available", Key: "memory", Operator: sql.OpGe, Value: "64Gi"}},
+			},
+			expected: 1,
+		},
+		{
+			name:        "Filter by available vcore in cores",
+			partitionID: partitionID,
+			filters: NodeFilters{
+				Resources: []ResourceFilter{{Field: "available", Key: "vcore", Operator: sql.OpGe, Value: "8"}},
+			},
+			expected: 2,
+		},
+		{
+			name:        "Filter by extended resource",
+			partitionID: partitionID,
+			filters: NodeFilters{
+				Resources: []ResourceFilter{{Field: "available", Key: "nvidia.com/gpu", Operator: sql.OpGt, Value: "0"}},
+			},
+			expected: 1,
+		},
+		{
+			name:        "Filter by missing resource",
+			partitionID: partitionID,
+			filters: NodeFilters{
+				Resources: []ResourceFilter{{Field: "available", Key: "nvidia.com/gpu", Operator: sql.OpEq, Value: "0"}},
+			},
+			expected: 3,
+		},
+		{
+			name:        "Filter by attribute",
+			partitionID: partitionID,
+			filters: NodeFilters{
+				Resources: []ResourceFilter{{Field: "attributes", Key: "instance-type", Operator: sql.OpEq, Value: "m5.large"}},
+			},
+			expected: 1,
+		},
+		{
+			name:        "Filter by attribute not equal",
+			partitionID: partitionID,
+			filters: NodeFilters{
+				Resources: []ResourceFilter{{Field: "attributes", Key: "instance-type", Operator: sql.OpNe, Value: "m5.large"}},
+			},
+			expected: 3,
+		},
 		{
 			name:        "No Filters",
 			partitionID: partitionID,
@@ -131,6 +180,58 @@ func (ns *NodeIntTest) TestGetNodesPerPartition() {
 	}
 }
 
+func (ns *NodeIntTest) TestGetNodeAllocationPeriods() {
+	ctx := context.Background()
+	partition := ulid.Make().String()
+
+	apps := []*model.Application{
+		{
+			ApplicationDAOInfo: dao.ApplicationDAOInfo{
+				ID:             ulid.Make().String(),
+				ApplicationID:  "app-running",
+				PartitionID:    partition,
+				SubmissionTime: 1,
+				Allocations: []*dao.AllocationDAOInfo{
+					{AllocationKey: "alloc-1", NodeID: "node-periods", AllocationTime: 100},
+					{AllocationKey: "alloc-2", NodeID: "node-periods", AllocationTime: 200},
+					{AllocationKey: "alloc-3", NodeID: "other-node", AllocationTime: 200},
+				},
+			},
+		},
+		{
+			ApplicationDAOInfo: dao.ApplicationDAOInfo{
+				ID:             ulid.Make().String(),
+				ApplicationID:  "app-finished",
+				PartitionID:    partition,
+				SubmissionTime: 1,
+				FinishedTime:   util.ToPtr(int64(1)),
+				Allocations: []*dao.AllocationDAOInfo{
+					{AllocationKey: "alloc-4", NodeID: "node-periods", AllocationTime: 150},
+				},
+			},
+		},
+	}
+	for _, app := range apps {
+		require.NoError(ns.T(), ns.repo.InsertApplication(ctx, app))
+	}
+	require.NoError(ns.T(), ns.repo.InsertEvent(ctx, &model.Event{
+		TimestampNano: 300,
+		Type:          "APP",
+		ObjectID:      "app-running",
+		ReferenceID:   "alloc-1",
+		ChangeType:    "REMOVE",
+		ChangeDetail:  "ALLOC_CANCEL",
+	}))
+
+	periods, err := ns.repo.GetNodeAllocationPeriods(ctx, partition, "node-periods")
+	require.NoError(ns.T(), err)
+	require.Equal(ns.T(), []*model.AllocationPeriod{
+		{ApplicationID: "app-running", AllocationKey: "alloc-1", StartNano: 100, EndNano: util.ToPtr(int64(300))},
+		{ApplicationID: "app-finished", AllocationKey: "alloc-4", StartNano: 150, EndNano: util.ToPtr(int64(1000000))},
+		{ApplicationID: "app-running", AllocationKey: "alloc-2", StartNano: 200},
+	}, periods)
+}
+
 func seedNodes(ctx context.Context, t *testing.T, repo *PostgresRepository) {
 	t.Helper()
 
@@ -149,6 +250,8 @@ func seedNodes(ctx context.Context, t *testing.T, repo *PostgresRepository) {
 				RackName:    "rack1",
 				Schedulable: true,
 				IsReserved:  true,
+				Available:   map[string]int64{"memory": 128 << 30, "vcore": 16000},
+				Attributes:  map[string]string{"instance-type": "m5.large"},
 			},
 		},
 		{
@@ -163,6 +266,8 @@ func seedNodes(ctx context.Context, t *testing.T, repo *PostgresRepository) {
 				RackName:    "rack2",
 				Schedulable: false,
 				IsReserved:  true,
+				Available:   map[string]int64{"memory": 32 << 30, "vcore": 8000, "nvidia.com/gpu": 2},
+				Attributes:  map[string]string{"instance-type": "p3.2xlarge"},
 			},
 		},
 		{
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/G-Research/unicorn-history-server/internal/database/sql"
+)
+
+// Cursor is the position in a sorted list after which the next page starts.
+// It contains the values of the sort keys of the last row of the previous page.
+type Cursor []any
+
+// Encode encodes the cursor into an opaque string, which can be used in URLs.
+func (c Cursor) Encode() string {
+	data, err := json.Marshal([]any(c))
+	if err != nil {
+		// the cursor only contains strings and numbers, which can always be marshaled
+		panic(fmt.Sprintf("could not encode cursor: %v", err))
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor decodes a cursor which was encoded with Cursor.Encode.
+func DecodeCursor(s string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %v", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var values []any
+	if err := decoder.Decode(&values); err != nil {
+		return nil, fmt.Errorf("invalid cursor: %v", err)
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("invalid cursor: no values")
+	}
+	cursor := make(Cursor, 0, len(values))
+	for _, v := range values {
+		switch v := v.(type) {
+		case string:
+			cursor = append(cursor, v)
+		case json.Number:
+			if i, err := v.Int64(); err == nil {
+				cursor = append(cursor, i)
+				continue
+			}
+			f, err := v.Float64()
+			if err != nil {
+				return nil, fmt.Errorf("invalid cursor: %v", err)
+			}
+			cursor = append(cursor, f)
+		default:
+			return nil, fmt.Errorf("invalid cursor: unexpected value %v", v)
+		}
+	}
+	return cursor, nil
+}
+
+// applyPagination adds the keyset position, limit and offset to the sql query.
+func applyPagination(builder *sql.Builder, after Cursor, limit *int, offset *int) {
+	if len(after) > 0 {
+		builder.SeekAfter(after...)
+	}
+	applyLimitAndOffset(builder, limit, offset)
+}
+
+// count returns the number of rows matching the query.
+// If estimated is true, the number is estimated by the query planner instead of counting the rows,
+// which is much faster for large tables.
+func (s *PostgresRepository) count(ctx context.Context, builder *sql.Builder, estimated bool) (int64, error) {
+	query, args := builder.BuildCount()
+	if !estimated {
+		var count int64
+		if err := s.dbpool.QueryRow(ctx, query, args...).Scan(&count); err != nil {
+			return 0, fmt.Errorf("could not count rows in DB: %v", err)
+		}
+		return count, nil
+	}
+
+	var plans []struct {
+		Plan explainPlan `json:"Plan"`
+	}
+	if err := s.dbpool.QueryRow(ctx, "EXPLAIN (FORMAT JSON) "+query, args...).Scan(&plans); err != nil {
+		return 0, fmt.Errorf("could not estimate row count in DB: %v", err)
+	}
+	if len(plans) == 0 {
+		return 0, fmt.Errorf("could not estimate row count in DB: empty plan")
+	}
+	// the top plan node aggregates the rows, the estimated number of counted rows is the one of its input
+	plan := plans[0].Plan
+	if len(plan.Plans) > 0 {
+		plan = plan.Plans[0]
+	}
+	return int64(plan.PlanRows), nil
+}
+
+type explainPlan struct {
+	PlanRows float64       `json:"Plan Rows"`
+	Plans    []explainPlan `json:"Plans"`
+}
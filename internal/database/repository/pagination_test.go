@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor Cursor
+	}{
+		{"Strings", Cursor{"node-1", "01J5Z"}},
+		{"Numbers and strings", Cursor{int64(1625097600000000000), "app-1"}},
+		{"Floats", Cursor{0.5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, err := DecodeCursor(tt.cursor.Encode())
+			require.NoError(t, err)
+			assert.Equal(t, tt.cursor, decoded)
+		})
+	}
+}
+
+func TestDecodeInvalidCursor(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"Not base64", "not base64!"},
+		{"Not JSON", base64.RawURLEncoding.EncodeToString([]byte("{"))},
+		{"Empty", base64.RawURLEncoding.EncodeToString([]byte("[]"))},
+		{"Object value", base64.RawURLEncoding.EncodeToString([]byte(`[{"a":1}]`))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeCursor(tt.cursor)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -17,27 +17,119 @@ type PartitionFilters struct {
 	Name                         *string
 	ClusterID                    *string
 	State                        *string
-	Offset                       *int
-	Limit                        *int
+	AsOf                         *time.Time
+	IncludeDeleted               bool
+	// Expression is a filter expression compiled with PartitionFilterSchema.
+	Expression sql.Expr
+	// Sort are the keys by which the partitions are sorted, see PartitionSortFields.
+	Sort []SortKey
+	// After is the position after which the returned partitions start.
+	After  Cursor
+	Offset *int
+	Limit  *int
+}
+
+// partitionColumns are the columns of the partitions table in the order in which scanPartition scans them.
+var partitionColumns = []string{
+	"id",
+	"created_at_nano",
+	"deleted_at_nano",
+	"cluster_id",
+	"name",
+	"capacity",
+	"used_capacity",
+	"utilization",
+	"total_nodes",
+	"applications",
+	"total_containers",
+	"state",
+	"last_state_transition_time",
+}
+
+// scanPartition scans a row which contains the partitionColumns into p.
+func scanPartition(row pgx.Row, p *model.Partition) error {
+	return row.Scan(
+		&p.ID,
+		&p.CreatedAtNano,
+		&p.DeletedAtNano,
+		&p.ClusterID,
+		&p.Name,
+		&p.Capacity.Capacity,
+		&p.Capacity.UsedCapacity,
+		&p.Capacity.Utilization,
+		&p.TotalNodes,
+		&p.Applications,
+		&p.TotalContainers,
+		&p.State,
+		&p.LastStateTransitionTime,
+	)
 }
 
 func applyPartitionFilters(builder *sql.Builder, filters PartitionFilters) {
 	if filters.LastStateTransitionTimeStart != nil {
-		builder.Conditionp("last_state_transition_time", ">=", filters.LastStateTransitionTimeStart.UnixMilli())
+		builder.Where(sql.Cmp("last_state_transition_time", sql.OpGe, filters.LastStateTransitionTimeStart.UnixMilli()))
 	}
 	if filters.LastStateTransitionTimeEnd != nil {
-		builder.Conditionp("last_state_transition_time", "<=", filters.LastStateTransitionTimeEnd.UnixMilli())
+		builder.Where(sql.Cmp("last_state_transition_time", sql.OpLe, filters.LastStateTransitionTimeEnd.UnixMilli()))
 	}
 	if filters.Name != nil {
-		builder.Conditionp("name", "=", *filters.Name)
+		builder.Where(sql.Eq("name", *filters.Name))
 	}
 	if filters.ClusterID != nil {
-		builder.Conditionp("cluster_id", "=", *filters.ClusterID)
+		builder.Where(sql.Eq("cluster_id", *filters.ClusterID))
 	}
 	if filters.State != nil {
-		builder.Conditionp("state", "=", *filters.State)
+		builder.Where(sql.Eq("state", *filters.State))
+	}
+	if filters.Expression != nil {
+		builder.Where(filters.Expression)
 	}
-	applyLimitAndOffset(builder, filters.Limit, filters.Offset)
+	applyTemporalFilters(builder, filters.AsOf, filters.IncludeDeleted)
+	applyPagination(builder, filters.After, filters.Limit, filters.Offset)
+}
+
+// PartitionFilterSchema defines the fields of the filter expressions of partitions.
+var PartitionFilterSchema = &FilterSchema{
+	fields: map[string]filterField{
+		"id":                      {expression: "id", fieldType: filterTypeString},
+		"name":                    {expression: "name", fieldType: filterTypeString},
+		"clusterId":               {expression: "cluster_id", fieldType: filterTypeString},
+		"state":                   {expression: "state", fieldType: filterTypeString},
+		"lastStateTransitionTime": {expression: "last_state_transition_time", fieldType: filterTypeTime},
+		"totalNodes":              {expression: "total_nodes", fieldType: filterTypeInt},
+		"totalContainers":         {expression: "total_containers", fieldType: filterTypeInt},
+	},
+	resources: map[string]string{
+		"capacity":     "capacity",
+		"usedCapacity": "used_capacity",
+		"utilization":  "utilization",
+	},
+}
+
+// PartitionSortFields are the fields by which partitions can be sorted.
+var PartitionSortFields = &SortFields[model.Partition]{
+	fields: map[string]sortField[model.Partition]{
+		"id":                      {"id", func(p *model.Partition) any { return p.ID }},
+		"name":                    {"name", func(p *model.Partition) any { return p.Name }},
+		"clusterId":               {"cluster_id", func(p *model.Partition) any { return p.ClusterID }},
+		"state":                   {"COALESCE(state, '')", func(p *model.Partition) any { return p.State }},
+		"lastStateTransitionTime": {"COALESCE(last_state_transition_time, 0)", func(p *model.Partition) any { return p.LastStateTransitionTime }},
+		"totalNodes":              {"COALESCE(total_nodes, 0)", func(p *model.Partition) any { return int64(p.TotalNodes) }},
+		"totalContainers":         {"COALESCE(total_containers, 0)", func(p *model.Partition) any { return int64(p.TotalContainers) }},
+	},
+	resources: map[string]resourceSortField[model.Partition]{
+		"capacity":     {"capacity", func(p *model.Partition) map[string]int64 { return p.Capacity.Capacity }},
+		"usedCapacity": {"used_capacity", func(p *model.Partition) map[string]int64 { return p.Capacity.UsedCapacity }},
+		"utilization":  {"utilization", func(p *model.Partition) map[string]int64 { return p.Capacity.Utilization }},
+	},
+	defaultSort: []SortKey{{Field: "id", Descending: true}},
+	id:          "id",
+}
+
+// Cursor returns the cursor which points to the position after the partition
+// in the list of partitions matching the filters.
+func (f PartitionFilters) Cursor(partition *model.Partition) Cursor {
+	return PartitionSortFields.Cursor(f.Sort, partition)
 }
 
 func (r *PostgresRepository) InsertPartition(ctx context.Context, partition *model.Partition) error {
@@ -96,13 +188,11 @@ INSERT INTO partitions (
 }
 
 func (s *PostgresRepository) GetAllPartitions(ctx context.Context, filters PartitionFilters) ([]*model.Partition, error) {
-	queryBuilder := sql.NewBuilder().
-		SelectAll("partitions", "").
-		OrderBy("id", sql.OrderByDescending)
-	applyPartitionFilters(queryBuilder, filters)
-
-	query := queryBuilder.Query()
-	args := queryBuilder.Args()
+	queryBuilder, err := allPartitionsQuery(filters)
+	if err != nil {
+		return nil, err
+	}
+	query, args := queryBuilder.Build()
 	rows, err := s.dbpool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("could not get partitions from DB: %v", err)
@@ -112,21 +202,7 @@ func (s *PostgresRepository) GetAllPartitions(ctx context.Context, filters Parti
 	var partitions []*model.Partition
 	for rows.Next() {
 		var p model.Partition
-		if err := rows.Scan(
-			&p.ID,
-			&p.CreatedAtNano,
-			&p.DeletedAtNano,
-			&p.ClusterID,
-			&p.Name,
-			&p.Capacity.Capacity,
-			&p.Capacity.UsedCapacity,
-			&p.Capacity.Utilization,
-			&p.TotalNodes,
-			&p.Applications,
-			&p.TotalContainers,
-			&p.State,
-			&p.LastStateTransitionTime,
-		); err != nil {
+		if err := scanPartition(rows, &p); err != nil {
 			return nil, fmt.Errorf("could not scan partition from DB: %v", err)
 		}
 		partitions = append(partitions, &p)
@@ -138,6 +214,26 @@ func (s *PostgresRepository) GetAllPartitions(ctx context.Context, filters Parti
 	return partitions, nil
 }
 
+// CountAllPartitions returns the number of partitions which match the filters, ignoring the pagination.
+func (s *PostgresRepository) CountAllPartitions(ctx context.Context, filters PartitionFilters, estimated bool) (int64, error) {
+	queryBuilder, err := allPartitionsQuery(filters)
+	if err != nil {
+		return 0, err
+	}
+	return s.count(ctx, queryBuilder, estimated)
+}
+
+func allPartitionsQuery(filters PartitionFilters) (*sql.Builder, error) {
+	queryBuilder := sql.NewBuilder().
+		Select(partitionColumns...).
+		From("partitions", "")
+	if err := PartitionSortFields.apply(queryBuilder, filters.Sort); err != nil {
+		return nil, err
+	}
+	applyPartitionFilters(queryBuilder, filters)
+	return queryBuilder, nil
+}
+
 func (r *PostgresRepository) UpdatePartition(ctx context.Context, partition *model.Partition) error {
 	const q = `
 UPDATE partitions
@@ -204,41 +300,14 @@ WHERE deleted_at_nano IS NULL AND NOT (id = ANY(@ids))`
 }
 
 func (s *PostgresRepository) GetPartitionByID(ctx context.Context, id string) (*model.Partition, error) {
-	const q = `
-SELECT
-	id,
-	created_at_nano,
-	deleted_at_nano,
-	cluster_id,
-	name,
-	capacity,
-	used_capacity,
-	utilization,
-	total_nodes,
-	applications,
-	total_containers,
-	state,
-	last_state_transition_time
-FROM partitions
-WHERE id = @id`
+	query, args := sql.NewBuilder().
+		Select(partitionColumns...).
+		From("partitions", "").
+		Where(sql.Eq("id", id)).
+		Build()
 
-	row := s.dbpool.QueryRow(ctx, q, pgx.NamedArgs{"id": id})
 	var p model.Partition
-	if err := row.Scan(
-		&p.ID,
-		&p.CreatedAtNano,
-		&p.DeletedAtNano,
-		&p.ClusterID,
-		&p.Name,
-		&p.Capacity.Capacity,
-		&p.Capacity.UsedCapacity,
-		&p.Capacity.Utilization,
-		&p.TotalNodes,
-		&p.Applications,
-		&p.TotalContainers,
-		&p.State,
-		&p.LastStateTransitionTime,
-	); err != nil {
+	if err := scanPartition(s.dbpool.QueryRow(ctx, query, args...), &p); err != nil {
 		return nil, fmt.Errorf("could not get partition from DB: %v", err)
 	}
 
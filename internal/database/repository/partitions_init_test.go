@@ -158,6 +158,19 @@ func (ps *PartitionIntTest) TestGetAllPartitions() {
 				LastStateTransitionTime: now.Add(-4 * time.Hour).UnixMilli(),
 			},
 		},
+		{
+			Metadata: model.Metadata{
+				CreatedAtNano: now.Add(-2 * time.Hour).UnixNano(),
+				DeletedAtNano: util.ToPtr(now.Add(-30 * time.Minute).UnixNano()),
+			},
+			PartitionInfo: dao.PartitionInfo{
+				ID:                      "5",
+				Name:                    "deleted",
+				ClusterID:               "cluster1",
+				State:                   "Active",
+				LastStateTransitionTime: now.Add(-2 * time.Hour).UnixMilli(),
+			},
+		},
 	}
 	for _, p := range partitions {
 		err := ps.repo.InsertPartition(ctx, p)
@@ -206,6 +219,21 @@ func (ps *PartitionIntTest) TestGetAllPartitions() {
 			},
 			expected: 1,
 		},
+		{
+			name: "After cursor",
+			filters: PartitionFilters{
+				After: Cursor{"3"},
+			},
+			expected: 2,
+		},
+		{
+			name: "After cursor with limit",
+			filters: PartitionFilters{
+				After: Cursor{"4"},
+				Limit: util.ToPtr(1),
+			},
+			expected: 1,
+		},
 		{
 			name: "Filter By State",
 			filters: PartitionFilters{
@@ -213,6 +241,35 @@ func (ps *PartitionIntTest) TestGetAllPartitions() {
 			},
 			expected: 3,
 		},
+		{
+			name: "Include deleted",
+			filters: PartitionFilters{
+				IncludeDeleted: true,
+			},
+			expected: 5,
+		},
+		{
+			name: "As of before the deletion",
+			filters: PartitionFilters{
+				AsOf: util.ToPtr(now.Add(-1 * time.Hour)),
+			},
+			expected: 1,
+		},
+		{
+			name: "As of after the deletion including deleted",
+			filters: PartitionFilters{
+				AsOf:           util.ToPtr(now.Add(-10 * time.Minute)),
+				IncludeDeleted: true,
+			},
+			expected: 1,
+		},
+		{
+			name: "As of before the creation",
+			filters: PartitionFilters{
+				AsOf: util.ToPtr(now.Add(-3 * time.Hour)),
+			},
+			expected: 0,
+		},
 		{
 			name:     "No filters",
 			expected: 4,
@@ -224,6 +281,12 @@ func (ps *PartitionIntTest) TestGetAllPartitions() {
 			nodes, err := ps.repo.GetAllPartitions(ctx, tt.filters)
 			require.NoError(ps.T(), err)
 			require.Len(ps.T(), nodes, tt.expected)
+
+			if tt.filters.After == nil && tt.filters.Limit == nil && tt.filters.Offset == nil {
+				count, err := ps.repo.CountAllPartitions(ctx, tt.filters, false)
+				require.NoError(ps.T(), err)
+				require.Equal(ps.T(), int64(tt.expected), count)
+			}
 		})
 	}
 	ps.clearPartitionsTable(ctx)
@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/G-Research/unicorn-history-server/internal/model"
+)
+
+// PreemptionGroupBy is the field by which the preemptions are aggregated.
+type PreemptionGroupBy string
+
+const (
+	PreemptionGroupByQueue PreemptionGroupBy = "queue"
+	PreemptionGroupByUser  PreemptionGroupBy = "user"
+)
+
+// preemptionGroupByKeys maps the fields by which the preemptions can be aggregated to their key expressions.
+var preemptionGroupByKeys = map[PreemptionGroupBy]string{
+	PreemptionGroupByQueue: "queue_name",
+	PreemptionGroupByUser:  `COALESCE("user", '')`,
+}
+
+// PreemptionFilters select the preemptions of a preemption report.
+type PreemptionFilters struct {
+	// Start and End select the preemptions during the period [Start, End).
+	Start     time.Time
+	End       time.Time
+	GroupBy   PreemptionGroupBy
+	Partition *string
+	// Queue selects the preemptions in the subtree of a queue.
+	Queue *string
+	User  *string
+}
+
+// preemptionColumns are the columns of the preemptions table in the order in which scanPreemption scans them.
+const preemptionColumns = `
+	id,
+	timestamp_nano,
+	source,
+	app_id,
+	allocation_key,
+	partition,
+	queue_name,
+	COALESCE("user", ''),
+	resource,
+	preemptor_app_id,
+	preemptor_allocation_key`
+
+func scanPreemption(row pgx.Row, p *model.Preemption) error {
+	return row.Scan(
+		&p.ID,
+		&p.TimestampNano,
+		&p.Source,
+		&p.ApplicationID,
+		&p.AllocationKey,
+		&p.Partition,
+		&p.QueueName,
+		&p.User,
+		&p.Resource,
+		&p.PreemptorApplicationID,
+		&p.PreemptorAllocationKey,
+	)
+}
+
+// InsertPreemption records the preemption of an allocation, and infers its preemptor from the most recent ask in the
+// partition which triggered preemption. An allocation is recorded only once, but a preemption recorded during a sync
+// takes the timestamp of the event of the allocation, and a missing preemptor is inferred again.
+func (s *PostgresRepository) InsertPreemption(ctx context.Context, p *model.Preemption) error {
+	const q = `
+INSERT INTO preemptions (
+	timestamp_nano,
+	source,
+	app_id,
+	allocation_key,
+	partition,
+	queue_name,
+	"user",
+	resource,
+	preemptor_app_id,
+	preemptor_allocation_key
+)
+SELECT
+	@timestamp_nano::BIGINT,
+	@source::TEXT,
+	@app_id::TEXT,
+	@allocation_key::TEXT,
+	@partition::TEXT,
+	@queue_name::TEXT,
+	@user::TEXT,
+	@resource::JSONB,
+	preemptor.app_id,
+	preemptor.allocation_key
+FROM (SELECT 1) one
+LEFT JOIN LATERAL (
+	SELECT a.app_id, ask->>'allocationKey' AS allocation_key
+	FROM applications a
+	CROSS JOIN LATERAL jsonb_array_elements(COALESCE(a.requests, '[]'::JSONB)) AS ask
+	WHERE a.partition = @partition::TEXT AND a.app_id <> @app_id::TEXT AND a.deleted_at_nano IS NULL
+		AND (ask->>'triggeredPreemption')::BOOLEAN
+	ORDER BY (ask->>'requestTime')::BIGINT DESC NULLS LAST
+	LIMIT 1
+) preemptor ON TRUE
+ON CONFLICT (app_id, allocation_key) DO UPDATE SET
+	timestamp_nano = CASE WHEN preemptions.source = 'sync' AND EXCLUDED.source = 'event'
+		THEN EXCLUDED.timestamp_nano ELSE preemptions.timestamp_nano END,
+	source = CASE WHEN preemptions.source = 'sync' AND EXCLUDED.source = 'event'
+		THEN EXCLUDED.source ELSE preemptions.source END,
+	preemptor_app_id = COALESCE(preemptions.preemptor_app_id, EXCLUDED.preemptor_app_id),
+	preemptor_allocation_key = COALESCE(preemptions.preemptor_allocation_key, EXCLUDED.preemptor_allocation_key)
+WHERE (preemptions.source = 'sync' AND EXCLUDED.source = 'event')
+	OR (preemptions.preemptor_app_id IS NULL AND EXCLUDED.preemptor_app_id IS NOT NULL)`
+
+	_, err := s.dbpool.Exec(ctx, q, pgx.NamedArgs{
+		"timestamp_nano": p.TimestampNano,
+		"source":         p.Source,
+		"app_id":         p.ApplicationID,
+		"allocation_key": p.AllocationKey,
+		"partition":      p.Partition,
+		"queue_name":     p.QueueName,
+		"user":           p.User,
+		"resource":       p.Resource,
+	})
+	if err != nil {
+		return fmt.Errorf("could not insert preemption into DB: %v", err)
+	}
+	return nil
+}
+
+// GetApplicationPreemptions returns the preemptions of the allocations of an application and the preemptions which it
+// triggered, sorted from the oldest to the newest. The application is identified either by its id or its applicationID.
+func (s *PostgresRepository) GetApplicationPreemptions(ctx context.Context, id string) ([]*model.Preemption, error) {
+	q := `
+WITH application AS (
+	SELECT COALESCE((SELECT app_id FROM applications WHERE id = @id), @id) AS app_id
+)
+SELECT` + preemptionColumns + `
+FROM preemptions
+WHERE app_id = (SELECT app_id FROM application) OR preemptor_app_id = (SELECT app_id FROM application)
+ORDER BY timestamp_nano, id`
+
+	rows, err := s.dbpool.Query(ctx, q, pgx.NamedArgs{"id": id})
+	if err != nil {
+		return nil, fmt.Errorf("could not get preemptions from DB: %v", err)
+	}
+	defer rows.Close()
+
+	var preemptions []*model.Preemption
+	for rows.Next() {
+		var p model.Preemption
+		if err := scanPreemption(rows, &p); err != nil {
+			return nil, fmt.Errorf("could not scan preemption from DB: %v", err)
+		}
+		preemptions = append(preemptions, &p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get preemptions from DB: %v", err)
+	}
+	return preemptions, nil
+}
+
+// preemptionStatsQuery aggregates the preemptions during the period [@start, @end) by the key expression
+// which is the first argument, sorted by the number of preemptions.
+var preemptionStatsQuery = `
+WITH selected AS (
+	SELECT %s AS key, app_id, resource
+	FROM preemptions
+	WHERE timestamp_nano >= @start AND timestamp_nano < @end
+		AND (@partition::TEXT IS NULL OR partition = @partition)
+		AND ` + queueSubtreeFilter("queue_name") + `
+		AND (@user::TEXT IS NULL OR "user" = @user)
+),
+resources AS (
+	SELECT r.key, jsonb_object_agg(r.name, r.total) AS resource
+	FROM (
+		SELECT selected.key, res.key AS name, SUM(res.value::BIGINT) AS total
+		FROM selected
+		CROSS JOIN LATERAL jsonb_each_text(selected.resource) res
+		GROUP BY 1, 2
+	) r
+	GROUP BY r.key
+)
+SELECT s.key, COUNT(*), COUNT(DISTINCT s.app_id), r.resource
+FROM selected s
+LEFT JOIN resources r ON r.key = s.key
+GROUP BY s.key, r.resource
+ORDER BY 2 DESC, 1`
+
+// GetPreemptionStats returns the preemptions which match the filters, aggregated by the field of the filters.
+func (s *PostgresRepository) GetPreemptionStats(ctx context.Context, filters PreemptionFilters) ([]*model.PreemptionStats, error) {
+	key, ok := preemptionGroupByKeys[filters.GroupBy]
+	if !ok {
+		return nil, fmt.Errorf("cannot group preemptions by %q", filters.GroupBy)
+	}
+
+	args := pgx.NamedArgs{
+		"start":     filters.Start.UnixNano(),
+		"end":       filters.End.UnixNano(),
+		"partition": filters.Partition,
+		"user":      filters.User,
+	}
+	maps.Copy(args, queueFilterArgs(filters.Queue))
+
+	rows, err := s.dbpool.Query(ctx, fmt.Sprintf(preemptionStatsQuery, key), args)
+	if err != nil {
+		return nil, fmt.Errorf("could not get preemption statistics from DB: %v", err)
+	}
+	defer rows.Close()
+
+	var stats []*model.PreemptionStats
+	for rows.Next() {
+		var st model.PreemptionStats
+		if err := rows.Scan(&st.Key, &st.Preemptions, &st.Applications, &st.Resource); err != nil {
+			return nil, fmt.Errorf("could not scan preemption statistics from DB: %v", err)
+		}
+		stats = append(stats, &st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get preemption statistics from DB: %v", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/G-Research/yunikorn-core/pkg/common/resources"
+	"github.com/G-Research/yunikorn-core/pkg/webservice/dao"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/G-Research/unicorn-history-server/internal/model"
+	"github.com/G-Research/unicorn-history-server/internal/util"
+)
+
+type PreemptionsIntTest struct {
+	suite.Suite
+	pool     *pgxpool.Pool
+	repo     *PostgresRepository
+	start    time.Time
+	victimID string
+}
+
+func (ps *PreemptionsIntTest) SetupSuite() {
+	require.NotNil(ps.T(), ps.pool)
+	repo, err := NewPostgresRepository(ps.pool)
+	require.NoError(ps.T(), err)
+	ps.repo = repo
+
+	ctx := context.Background()
+	ps.start = time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	start := ps.start
+
+	victim := &model.Application{
+		Metadata: model.Metadata{CreatedAtNano: start.UnixNano()},
+		ApplicationDAOInfo: dao.ApplicationDAOInfo{
+			ID:            ulid.Make().String(),
+			ApplicationID: "app-victim",
+			PartitionID:   "1",
+			Partition:     "default",
+			QueueName:     "root.batch",
+			User:          "bob",
+			PreemptedResource: &resources.TrackedResource{
+				TrackedResourceMap: map[string]*resources.Resource{
+					"m5.large": {Resources: map[string]resources.Quantity{"vcore": 60000}},
+				},
+			},
+		},
+	}
+	ps.victimID = victim.ID
+	preemptor := &model.Application{
+		Metadata: model.Metadata{CreatedAtNano: start.UnixNano()},
+		ApplicationDAOInfo: dao.ApplicationDAOInfo{
+			ID:            ulid.Make().String(),
+			ApplicationID: "app-preemptor",
+			PartitionID:   "1",
+			Partition:     "default",
+			QueueName:     "root.ml",
+			User:          "alice",
+			Requests: []*dao.AllocationAskDAOInfo{
+				{AllocationKey: "ask-1", RequestTime: start.UnixNano(), TriggeredPreemption: true},
+			},
+		},
+	}
+	for _, app := range []*model.Application{victim, preemptor} {
+		require.NoError(ps.T(), repo.InsertApplication(ctx, app))
+	}
+
+	preemptions := []*model.Preemption{
+		// recorded during a sync first, and then from the event of the allocation
+		model.NewPreemption(victim, "alloc-1", map[string]int64{"vcore": 1000}, start.Add(time.Hour).UnixNano(), model.PreemptionSourceSync),
+		model.NewPreemption(victim, "alloc-1", map[string]int64{"vcore": 1000}, start.Add(time.Minute).UnixNano(), model.PreemptionSourceEvent),
+		model.NewPreemption(victim, "alloc-2", map[string]int64{"vcore": 500}, start.Add(2*time.Minute).UnixNano(), model.PreemptionSourceEvent),
+		// a later sync does not change the event
+		model.NewPreemption(victim, "alloc-2", map[string]int64{"vcore": 500}, start.Add(time.Hour).UnixNano(), model.PreemptionSourceSync),
+	}
+	for _, p := range preemptions {
+		require.NoError(ps.T(), repo.InsertPreemption(ctx, p))
+	}
+}
+
+func (ps *PreemptionsIntTest) TearDownSuite() {
+	ps.pool.Close()
+}
+
+func (ps *PreemptionsIntTest) TestGetApplicationPreemptions() {
+	ctx := context.Background()
+	for _, id := range []string{ps.victimID, "app-victim", "app-preemptor"} {
+		preemptions, err := ps.repo.GetApplicationPreemptions(ctx, id)
+		require.NoError(ps.T(), err)
+		require.Len(ps.T(), preemptions, 2, id)
+
+		first := preemptions[0]
+		assert.Equal(ps.T(), "app-victim", first.ApplicationID)
+		assert.Equal(ps.T(), "alloc-1", first.AllocationKey)
+		assert.Equal(ps.T(), model.PreemptionSourceEvent, first.Source)
+		assert.Equal(ps.T(), ps.start.Add(time.Minute).UnixNano(), first.TimestampNano)
+		assert.Equal(ps.T(), "root.batch", first.QueueName)
+		assert.Equal(ps.T(), "bob", first.User)
+		assert.Equal(ps.T(), map[string]int64{"vcore": 1000}, first.Resource)
+		assert.Equal(ps.T(), util.ToPtr("app-preemptor"), first.PreemptorApplicationID)
+		assert.Equal(ps.T(), util.ToPtr("ask-1"), first.PreemptorAllocationKey)
+
+		assert.Equal(ps.T(), "alloc-2", preemptions[1].AllocationKey)
+		assert.Equal(ps.T(), ps.start.Add(2*time.Minute).UnixNano(), preemptions[1].TimestampNano)
+	}
+
+	preemptions, err := ps.repo.GetApplicationPreemptions(ctx, "unknown")
+	require.NoError(ps.T(), err)
+	assert.Empty(ps.T(), preemptions)
+}
+
+func (ps *PreemptionsIntTest) TestPreemptedResourceIsStored() {
+	app, err := ps.repo.GetApplicationByID(context.Background(), ps.victimID)
+	require.NoError(ps.T(), err)
+	require.NotNil(ps.T(), app.PreemptedResource)
+	assert.Equal(ps.T(), resources.Quantity(60000), app.PreemptedResource.TrackedResourceMap["m5.large"].Resources["vcore"])
+}
+
+func (ps *PreemptionsIntTest) TestGetPreemptionStats() {
+	ctx := context.Background()
+	filters := PreemptionFilters{Start: ps.start, End: ps.start.Add(time.Hour), GroupBy: PreemptionGroupByQueue}
+	stats, err := ps.repo.GetPreemptionStats(ctx, filters)
+	require.NoError(ps.T(), err)
+	assert.Equal(ps.T(), []*model.PreemptionStats{
+		{Key: "root.batch", Preemptions: 2, Applications: 1, Resource: map[string]int64{"vcore": 1500}},
+	}, stats)
+
+	filters.GroupBy = PreemptionGroupByUser
+	filters.End = ps.start.Add(90 * time.Second)
+	stats, err = ps.repo.GetPreemptionStats(ctx, filters)
+	require.NoError(ps.T(), err)
+	assert.Equal(ps.T(), []*model.PreemptionStats{
+		{Key: "bob", Preemptions: 1, Applications: 1, Resource: map[string]int64{"vcore": 1000}},
+	}, stats)
+
+	filters.User = util.ToPtr("alice")
+	stats, err = ps.repo.GetPreemptionStats(ctx, filters)
+	require.NoError(ps.T(), err)
+	assert.Empty(ps.T(), stats)
+
+	_, err = ps.repo.GetPreemptionStats(ctx, PreemptionFilters{GroupBy: "node"})
+	require.Error(ps.T(), err)
+}
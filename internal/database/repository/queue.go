@@ -2,13 +2,83 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 
+	"github.com/G-Research/unicorn-history-server/internal/database/sql"
 	"github.com/G-Research/unicorn-history-server/internal/model"
 )
 
+type QueueFilters struct {
+	AsOf           *time.Time
+	IncludeDeleted bool
+}
+
+// queueColumns are the columns of the queues table in the order in which scanQueue scans them.
+var queueColumns = []string{
+	"id",
+	"created_at_nano",
+	"deleted_at_nano",
+	"queue_name",
+	"parent_id",
+	"parent",
+	"status",
+	"partition_id",
+	"pending_resource",
+	"max_resource",
+	"guaranteed_resource",
+	"allocated_resource",
+	"preempting_resource",
+	"head_room",
+	"is_leaf",
+	"is_managed",
+	"properties",
+	"template_info",
+	"abs_used_capacity",
+	"max_running_apps",
+	"running_apps",
+	"current_priority",
+	"allocating_accepted_apps",
+}
+
+// scanQueue scans a row which contains the queueColumns into queue.
+func scanQueue(row pgx.Row, queue *model.Queue) error {
+	return row.Scan(
+		&queue.ID,
+		&queue.CreatedAtNano,
+		&queue.DeletedAtNano,
+		&queue.QueueName,
+		&queue.ParentID,
+		&queue.Parent,
+		&queue.Status,
+		&queue.PartitionID,
+		&queue.PendingResource,
+		&queue.MaxResource,
+		&queue.GuaranteedResource,
+		&queue.AllocatedResource,
+		&queue.PreemptingResource,
+		&queue.HeadRoom,
+		&queue.IsLeaf,
+		&queue.IsManaged,
+		&queue.Properties,
+		&queue.TemplateInfo,
+		&queue.AbsUsedCapacity,
+		&queue.MaxRunningApps,
+		&queue.RunningApps,
+		&queue.CurrentPriority,
+		&queue.AllocatingAcceptedApps,
+	)
+}
+
+func applyQueueFilters(builder *sql.Builder, filters QueueFilters) {
+	applyVersionsAsOf(builder, "queues", "queue_versions", filters.AsOf)
+	applyTemporalFilters(builder, filters.AsOf, filters.IncludeDeleted)
+}
+
 func (s *PostgresRepository) InsertQueue(ctx context.Context, q *model.Queue) error {
 	insertSQL := `INSERT INTO queues (
 		id, created_at_nano, queue_name, parent_id, parent, status, partition_id, pending_resource, max_resource,
@@ -111,35 +181,12 @@ func (s *PostgresRepository) UpdateQueue(ctx context.Context, queue *model.Queue
 }
 
 func (s *PostgresRepository) GetAllQueues(ctx context.Context) ([]*model.Queue, error) {
-	const q = `
-SELECT
-    id,
-    created_at_nano,
-    deleted_at_nano,
-    queue_name,
-	parent_id,
-    parent,
-    status,
-    partition_id,
-    pending_resource,
-    max_resource,
-    guaranteed_resource,
-    allocated_resource,
-    preempting_resource,
-    head_room,
-    is_leaf,
-    is_managed,
-    properties,
-    template_info,
-    abs_used_capacity,
-    max_running_apps,
-    running_apps,
-    current_priority,
-    allocating_accepted_apps
-FROM queues
-ORDER BY id DESC
-		`
-	rows, err := s.dbpool.Query(ctx, q)
+	query, args := sql.NewBuilder().
+		Select(queueColumns...).
+		From("queues", "").
+		OrderBy("id", sql.OrderByDescending).
+		Build()
+	rows, err := s.dbpool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("could not get queues from DB: %v", err)
 	}
@@ -148,32 +195,7 @@ ORDER BY id DESC
 	var queues []*model.Queue
 	for rows.Next() {
 		var q model.Queue
-		err = rows.Scan(
-			&q.ID,
-			&q.CreatedAtNano,
-			&q.DeletedAtNano,
-			&q.QueueName,
-			&q.ParentID,
-			&q.Parent,
-			&q.Status,
-			&q.PartitionID,
-			&q.PendingResource,
-			&q.MaxResource,
-			&q.GuaranteedResource,
-			&q.AllocatedResource,
-			&q.PreemptingResource,
-			&q.HeadRoom,
-			&q.IsLeaf,
-			&q.IsManaged,
-			&q.Properties,
-			&q.TemplateInfo,
-			&q.AbsUsedCapacity,
-			&q.MaxRunningApps,
-			&q.RunningApps,
-			&q.CurrentPriority,
-			&q.AllocatingAcceptedApps,
-		)
-		if err != nil {
+		if err := scanQueue(rows, &q); err != nil {
 			return nil, fmt.Errorf("could not scan queue from DB: %v", err)
 		}
 		queues = append(queues, &q)
@@ -182,142 +204,42 @@ ORDER BY id DESC
 }
 
 func (s *PostgresRepository) GetQueue(ctx context.Context, queueID string) (*model.Queue, error) {
-	const q = `
-SELECT
-    id,
-    created_at_nano,
-    deleted_at_nano,
-    queue_name,
-	parent_id,
-    parent,
-    status,
-    partition_id,
-    pending_resource,
-    max_resource,
-    guaranteed_resource,
-    allocated_resource,
-    preempting_resource,
-    head_room,
-    is_leaf,
-    is_managed,
-    properties,
-    template_info,
-    abs_used_capacity,
-    max_running_apps,
-    running_apps,
-    current_priority,
-    allocating_accepted_apps
-FROM queues
-WHERE id = @id
-ORDER BY id DESC
-LIMIT 1
-`
+	query, args := sql.NewBuilder().
+		Select(queueColumns...).
+		From("queues", "").
+		Where(sql.Eq("id", queueID)).
+		Build()
+
 	var queue model.Queue
-	err := s.dbpool.QueryRow(
-		ctx,
-		q,
-		&pgx.NamedArgs{
-			"id": queueID,
-		},
-	).Scan(
-		&queue.ID,
-		&queue.CreatedAtNano,
-		&queue.DeletedAtNano,
-		&queue.QueueName,
-		&queue.ParentID,
-		&queue.Parent,
-		&queue.Status,
-		&queue.PartitionID,
-		&queue.PendingResource,
-		&queue.MaxResource,
-		&queue.GuaranteedResource,
-		&queue.AllocatedResource,
-		&queue.PreemptingResource,
-		&queue.HeadRoom,
-		&queue.IsLeaf,
-		&queue.IsManaged,
-		&queue.Properties,
-		&queue.TemplateInfo,
-		&queue.AbsUsedCapacity,
-		&queue.MaxRunningApps,
-		&queue.RunningApps,
-		&queue.CurrentPriority,
-		&queue.AllocatingAcceptedApps,
-	)
-	if err != nil {
+	if err := scanQueue(s.dbpool.QueryRow(ctx, query, args...), &queue); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("queue %q %w", queueID, ErrNotFound)
+		}
 		return nil, fmt.Errorf("could not get queue from DB: %v", err)
 	}
 
 	return &queue, nil
 }
 
-func (s *PostgresRepository) GetQueuesInPartition(ctx context.Context, partitionID string) ([]*model.Queue, error) {
-	const q = `
-SELECT
-    id,
-    created_at_nano,
-    deleted_at_nano,
-    queue_name,
-    status,
-    partition_id,
-    parent,
-    pending_resource,
-    max_resource,
-    guaranteed_resource,
-    allocated_resource,
-    preempting_resource,
-    head_room,
-    is_leaf,
-    is_managed,
-    properties,
-	parent_id,
-    template_info,
-    abs_used_capacity,
-    max_running_apps,
-    running_apps,
-    current_priority,
-    allocating_accepted_apps
-FROM queues
-WHERE partition_id = @partition_id
-ORDER BY id DESC
-`
-	var queues []*model.Queue
-	rows, err := s.dbpool.Query(
-		ctx,
-		q,
-		&pgx.NamedArgs{"partition_id": partitionID},
-	)
+func (s *PostgresRepository) GetQueuesInPartition(ctx context.Context, partitionID string, filters QueueFilters) ([]*model.Queue, error) {
+	queryBuilder := sql.NewBuilder().
+		Select(queueColumns...).
+		From("queues", "").
+		Where(sql.Eq("partition_id", partitionID)).
+		OrderBy("id", sql.OrderByDescending)
+	applyQueueFilters(queryBuilder, filters)
+
+	query, args := queryBuilder.Build()
+	rows, err := s.dbpool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("could not get queue from DB: %v", err)
 	}
+	defer rows.Close()
 
+	var queues []*model.Queue
 	for rows.Next() {
 		var queue model.Queue
-		if err := rows.Scan(
-			&queue.ID,
-			&queue.CreatedAtNano,
-			&queue.DeletedAtNano,
-			&queue.QueueName,
-			&queue.Status,
-			&queue.PartitionID,
-			&queue.Parent,
-			&queue.PendingResource,
-			&queue.MaxResource,
-			&queue.GuaranteedResource,
-			&queue.AllocatedResource,
-			&queue.PreemptingResource,
-			&queue.HeadRoom,
-			&queue.IsLeaf,
-			&queue.IsManaged,
-			&queue.Properties,
-			&queue.ParentID,
-			&queue.TemplateInfo,
-			&queue.AbsUsedCapacity,
-			&queue.MaxRunningApps,
-			&queue.RunningApps,
-			&queue.CurrentPriority,
-			&queue.AllocatingAcceptedApps,
-		); err != nil {
+		if err := scanQueue(rows, &queue); err != nil {
 			return nil, fmt.Errorf("could not get queue from DB: %v", err)
 		}
 
@@ -331,6 +253,82 @@ ORDER BY id DESC
 	return queues, nil
 }
 
+// GetQueueSubtree returns the queue with the path (e.g. 'root.eng.ml') in the partition,
+// and its descendants up to depth levels below it. A depth of 0 returns only the queue.
+func (s *PostgresRepository) GetQueueSubtree(
+	ctx context.Context,
+	partitionID, queuePath string,
+	depth int,
+	filters QueueFilters,
+) ([]*model.Queue, error) {
+	queryBuilder := sql.NewBuilder().
+		Select(queueColumns...).
+		From("queues", "").
+		Where(
+			sql.Eq("partition_id", partitionID),
+			sql.Or(
+				sql.Eq("queue_name", queuePath),
+				sql.Cmp("queue_name", sql.OpLike, escapeLike(queuePath)+".%"),
+			),
+			// the depth of a queue is the number of dots in its path
+			sql.Raw(
+				"length(queue_name) - length(replace(queue_name, '.', '')) <= $1",
+				strings.Count(queuePath, ".")+depth,
+			),
+		).
+		OrderBy("queue_name", sql.OrderByAscending)
+	applyQueueFilters(queryBuilder, filters)
+
+	query, args := queryBuilder.Build()
+	rows, err := s.dbpool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get queues from DB: %v", err)
+	}
+	defer rows.Close()
+
+	var queues []*model.Queue
+	for rows.Next() {
+		var queue model.Queue
+		if err := scanQueue(rows, &queue); err != nil {
+			return nil, fmt.Errorf("could not scan queue from DB: %v", err)
+		}
+		queues = append(queues, &queue)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get queues from DB: %v", err)
+	}
+	return queues, nil
+}
+
+// CountApplicationsInQueueByState returns the number of current (not deleted) applications in the queue per state.
+func (s *PostgresRepository) CountApplicationsInQueueByState(ctx context.Context, queueID string) (map[string]int64, error) {
+	const q = `
+SELECT COALESCE(state, ''), COUNT(*)
+FROM applications
+WHERE queue_id = @queue_id AND deleted_at_nano IS NULL
+GROUP BY 1`
+
+	rows, err := s.dbpool.Query(ctx, q, pgx.NamedArgs{"queue_id": queueID})
+	if err != nil {
+		return nil, fmt.Errorf("could not count applications in queue from DB: %v", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var state string
+		var count int64
+		if err := rows.Scan(&state, &count); err != nil {
+			return nil, fmt.Errorf("could not scan application count from DB: %v", err)
+		}
+		counts[state] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not count applications in queue from DB: %v", err)
+	}
+	return counts, nil
+}
+
 func (s *PostgresRepository) DeleteQueuesNotInIDs(ctx context.Context, ids []string, deletedAtNano int64) error {
 	const q = `
 UPDATE queues
@@ -74,7 +74,7 @@ func (qs *QueueIntTest) TestGetQueuesInPartition() {
 
 	for _, tt := range tests {
 		qs.Run(tt.name, func() {
-			queues, err := qs.repo.GetQueuesInPartition(ctx, tt.partitionID)
+			queues, err := qs.repo.GetQueuesInPartition(ctx, tt.partitionID, QueueFilters{})
 			require.NoError(qs.T(), err)
 			assert.Len(qs.T(), queues, tt.expectedTotalQueues)
 		})
@@ -119,6 +119,77 @@ func (qs *QueueIntTest) TestGetQueue() {
 	}
 }
 
+func (qs *QueueIntTest) TestGetQueueSubtree() {
+	ctx := context.Background()
+	tests := []struct {
+		name          string
+		partitionID   string
+		queuePath     string
+		depth         int
+		expectedNames []string
+	}{
+		{
+			name:          "Only the queue",
+			partitionID:   "1",
+			queuePath:     "root.org",
+			depth:         0,
+			expectedNames: []string{"root.org"},
+		},
+		{
+			name:          "Queue with children",
+			partitionID:   "1",
+			queuePath:     "root.org",
+			depth:         1,
+			expectedNames: []string{"root.org", "root.org.eng", "root.org.sales"},
+		},
+		{
+			name:        "Queue with all descendants",
+			partitionID: "1",
+			queuePath:   "root.org",
+			depth:       10,
+			expectedNames: []string{
+				"root.org",
+				"root.org.eng",
+				"root.org.eng.prod",
+				"root.org.eng.test",
+				"root.org.sales",
+				"root.org.sales.prod",
+				"root.org.sales.test",
+			},
+		},
+		{
+			name:          "Queue of another partition",
+			partitionID:   "2",
+			queuePath:     "root",
+			depth:         1,
+			expectedNames: []string{"root", "root.child", "root.child2"},
+		},
+		{
+			name:        "Non-existent queue",
+			partitionID: "1",
+			queuePath:   "root.org.marketing",
+			depth:       1,
+		},
+	}
+
+	for _, tt := range tests {
+		qs.Run(tt.name, func() {
+			queues, err := qs.repo.GetQueueSubtree(ctx, tt.partitionID, tt.queuePath, tt.depth, QueueFilters{})
+			require.NoError(qs.T(), err)
+			var names []string
+			for _, q := range queues {
+				names = append(names, q.QueueName)
+			}
+			assert.Equal(qs.T(), tt.expectedNames, names)
+		})
+	}
+}
+
+func (qs *QueueIntTest) TestGetQueueNotFound() {
+	_, err := qs.repo.GetQueue(context.Background(), "99")
+	require.ErrorIs(qs.T(), err, ErrNotFound)
+}
+
 func (qs *QueueIntTest) TestDeleteQueues() {
 	ctx := context.Background()
 	tests := []struct {
@@ -140,7 +211,13 @@ func (qs *QueueIntTest) TestDeleteQueues() {
 
 	for _, tt := range tests {
 		qs.Run(tt.name, func() {
-			queues, err := qs.repo.GetQueuesInPartition(ctx, tt.partitionID)
+			// restore the deleted queues, so that the other tests are not affected
+			qs.T().Cleanup(func() {
+				_, err := qs.repo.dbpool.Exec(ctx, `UPDATE queues SET deleted_at_nano = NULL`)
+				require.NoError(qs.T(), err)
+			})
+
+			queues, err := qs.repo.GetQueuesInPartition(ctx, tt.partitionID, QueueFilters{})
 			require.NoError(qs.T(), err)
 			now := time.Now()
 			timestamp := now.UnixNano()
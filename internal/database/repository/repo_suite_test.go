@@ -62,6 +62,70 @@ func (ts *RepositorySuite) TestSubSuites() {
 		pool := database.CloneDB(t, ts.tp, ts.pool)
 		suite.Run(t, &PartitionIntTest{pool: pool})
 	})
+	ts.T().Run("VersionIntTest", func(t *testing.T) {
+		pool := database.CloneDB(t, ts.tp, ts.pool)
+		suite.Run(t, &VersionIntTest{pool: pool})
+	})
+	ts.T().Run("EventIntTest", func(t *testing.T) {
+		pool := database.CloneDB(t, ts.tp, ts.pool)
+		suite.Run(t, &EventIntTest{pool: pool})
+	})
+	ts.T().Run("SearchIntTest", func(t *testing.T) {
+		pool := database.CloneDB(t, ts.tp, ts.pool)
+		suite.Run(t, &SearchIntTest{pool: pool})
+	})
+	ts.T().Run("UsageIntTest", func(t *testing.T) {
+		pool := database.CloneDB(t, ts.tp, ts.pool)
+		suite.Run(t, &UsageIntTest{pool: pool})
+	})
+	ts.T().Run("AccountingIntTest", func(t *testing.T) {
+		pool := database.CloneDB(t, ts.tp, ts.pool)
+		suite.Run(t, &AccountingIntTest{pool: pool})
+	})
+	ts.T().Run("WaitTimesIntTest", func(t *testing.T) {
+		pool := database.CloneDB(t, ts.tp, ts.pool)
+		suite.Run(t, &WaitTimesIntTest{pool: pool})
+	})
+	ts.T().Run("FairnessIntTest", func(t *testing.T) {
+		pool := database.CloneDB(t, ts.tp, ts.pool)
+		suite.Run(t, &FairnessIntTest{pool: pool})
+	})
+	ts.T().Run("PreemptionsIntTest", func(t *testing.T) {
+		pool := database.CloneDB(t, ts.tp, ts.pool)
+		suite.Run(t, &PreemptionsIntTest{pool: pool})
+	})
+	ts.T().Run("GangsIntTest", func(t *testing.T) {
+		pool := database.CloneDB(t, ts.tp, ts.pool)
+		suite.Run(t, &GangsIntTest{pool: pool})
+	})
+	ts.T().Run("DiagnosticsIntTest", func(t *testing.T) {
+		pool := database.CloneDB(t, ts.tp, ts.pool)
+		suite.Run(t, &DiagnosticsIntTest{pool: pool})
+	})
+	ts.T().Run("ReservationsIntTest", func(t *testing.T) {
+		pool := database.CloneDB(t, ts.tp, ts.pool)
+		suite.Run(t, &ReservationsIntTest{pool: pool})
+	})
+	ts.T().Run("TimelineIntTest", func(t *testing.T) {
+		pool := database.CloneDB(t, ts.tp, ts.pool)
+		suite.Run(t, &TimelineIntTest{pool: pool})
+	})
+	ts.T().Run("HistorySeriesIntTest", func(t *testing.T) {
+		pool := database.CloneDB(t, ts.tp, ts.pool)
+		suite.Run(t, &HistorySeriesIntTest{pool: pool})
+	})
+	ts.T().Run("RollupsIntTest", func(t *testing.T) {
+		pool := database.CloneDB(t, ts.tp, ts.pool)
+		suite.Run(t, &RollupsIntTest{pool: pool})
+	})
+	ts.T().Run("ForecastIntTest", func(t *testing.T) {
+		pool := database.CloneDB(t, ts.tp, ts.pool)
+		suite.Run(t, &ForecastIntTest{pool: pool})
+	})
+	ts.T().Run("EfficiencyIntTest", func(t *testing.T) {
+		pool := database.CloneDB(t, ts.tp, ts.pool)
+		suite.Run(t, &EfficiencyIntTest{pool: pool})
+	})
 }
 
 func TestRepositoryIntegration(t *testing.T) {
@@ -2,36 +2,83 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/G-Research/unicorn-history-server/internal/model"
 )
 
+// ErrNotFound is returned if the requested object does not exist.
+var ErrNotFound = errors.New("not found")
+
 //go:generate mockgen -destination=mock_repository.go -package=repository github.com/G-Research/unicorn-history-server/internal/database/repository Repository
 type Repository interface {
 	InsertApplication(ctx context.Context, app *model.Application) error
 	UpdateApplication(ctx context.Context, app *model.Application) error
 	GetApplicationByID(ctx context.Context, id string) (*model.Application, error)
+	GetApplicationRuns(ctx context.Context, id string) ([]*model.Application, error)
 	DeleteApplicationsNotInIDs(ctx context.Context, ids []string, deletedAtNano int64) error
 	GetAllApplications(ctx context.Context, filters ApplicationFilters) ([]*model.Application, error)
+	CountAllApplications(ctx context.Context, filters ApplicationFilters, estimated bool) (int64, error)
 	GetAppsPerPartitionPerQueue(ctx context.Context, partitionID, queueID string, filters ApplicationFilters) ([]*model.Application, error)
+	CountAppsPerPartitionPerQueue(ctx context.Context, partitionID, queueID string, filters ApplicationFilters, estimated bool) (int64, error)
 	InsertAppHistory(ctx context.Context, appHistory *model.AppHistory) error
 	InsertContainerHistory(ctx context.Context, containerHistory *model.ContainerHistory) error
 	GetApplicationsHistory(ctx context.Context, filters HistoryFilters) ([]*model.AppHistory, error)
 	GetContainersHistory(ctx context.Context, filters HistoryFilters) ([]*model.ContainerHistory, error)
+	CountApplicationsHistory(ctx context.Context, filters HistoryFilters, estimated bool) (int64, error)
+	CountContainersHistory(ctx context.Context, filters HistoryFilters, estimated bool) (int64, error)
+	GetApplicationsTimeline(ctx context.Context, filters TimelineFilters) ([]*model.TimelinePoint, error)
+	GetContainersTimeline(ctx context.Context, filters TimelineFilters) ([]*model.TimelinePoint, error)
+	GetApplicationsHistorySeries(ctx context.Context, filters HistoryFilters) ([]*model.HistoryPoint, error)
+	GetContainersHistorySeries(ctx context.Context, filters HistoryFilters) ([]*model.HistoryPoint, error)
 	InsertNode(ctx context.Context, node *model.Node) error
 	UpdateNode(ctx context.Context, node *model.Node) error
 	GetNodeByID(ctx context.Context, id string) (*model.Node, error)
+	GetNodeAllocationPeriods(ctx context.Context, partitionID, nodeID string) ([]*model.AllocationPeriod, error)
 	DeleteNodesNotInIDs(ctx context.Context, ids []string, deletedAtNano int64) error
 	GetNodesPerPartition(ctx context.Context, partitionID string, filters NodeFilters) ([]*model.Node, error)
+	CountNodesPerPartition(ctx context.Context, partitionID string, filters NodeFilters, estimated bool) (int64, error)
 	InsertPartition(ctx context.Context, partition *model.Partition) error
 	UpdatePartition(ctx context.Context, partition *model.Partition) error
 	GetAllPartitions(ctx context.Context, filters PartitionFilters) ([]*model.Partition, error)
+	CountAllPartitions(ctx context.Context, filters PartitionFilters, estimated bool) (int64, error)
 	GetPartitionByID(ctx context.Context, id string) (*model.Partition, error)
 	DeletePartitionsNotInIDs(ctx context.Context, ids []string, deletedatNano int64) error
 	InsertQueue(ctx context.Context, q *model.Queue) error
 	GetQueue(ctx context.Context, queueID string) (*model.Queue, error)
 	UpdateQueue(ctx context.Context, queue *model.Queue) error
 	GetAllQueues(ctx context.Context) ([]*model.Queue, error)
-	GetQueuesInPartition(ctx context.Context, partitionID string) ([]*model.Queue, error)
+	GetQueuesInPartition(ctx context.Context, partitionID string, filters QueueFilters) ([]*model.Queue, error)
+	GetQueueSubtree(ctx context.Context, partitionID, queuePath string, depth int, filters QueueFilters) ([]*model.Queue, error)
+	CountApplicationsInQueueByState(ctx context.Context, queueID string) (map[string]int64, error)
 	DeleteQueuesNotInIDs(ctx context.Context, ids []string, deletedAtNano int64) error
+	GetApplicationVersions(ctx context.Context, id string) ([]*model.ApplicationVersion, error)
+	GetQueueVersions(ctx context.Context, id string) ([]*model.QueueVersion, error)
+	GetNodeVersions(ctx context.Context, id string) ([]*model.NodeVersion, error)
+	InsertEvent(ctx context.Context, event *model.Event) error
+	GetEvents(ctx context.Context, filters EventFilters) ([]*model.Event, error)
+	DeleteEventsBefore(ctx context.Context, beforeNano int64) (int64, error)
+	Search(ctx context.Context, query string, limit int) (*model.SearchResults, error)
+	GetUsage(ctx context.Context, filters UsageFilters) ([]*model.Usage, error)
+	InsertAccountingRecord(ctx context.Context, record *model.AccountingRecord) (bool, error)
+	GetAccountingRecords(ctx context.Context, filters AccountingFilters) ([]*model.AccountingRecord, error)
+	CountAccountingRecords(ctx context.Context, filters AccountingFilters, estimated bool) (int64, error)
+	GetWaitTimes(ctx context.Context, filters WaitTimeFilters) ([]*model.WaitTimeStats, error)
+	GetQueueFairness(ctx context.Context, filters FairnessFilters) ([]*model.QueueFairness, error)
+	GetUserShares(ctx context.Context, filters FairnessFilters) ([]*model.UserShare, error)
+	InsertPreemption(ctx context.Context, p *model.Preemption) error
+	GetApplicationPreemptions(ctx context.Context, id string) ([]*model.Preemption, error)
+	GetPreemptionStats(ctx context.Context, filters PreemptionFilters) ([]*model.PreemptionStats, error)
+	GetGangStats(ctx context.Context, filters GangFilters) ([]*model.TaskGroupStats, error)
+	GetDiagnostics(ctx context.Context, filters DiagnosticsFilters) ([]*model.DiagnosticGroup, error)
+	UpdateApplicationReservations(ctx context.Context, app *model.Application, nowNano int64) error
+	UpdateNodeReservations(ctx context.Context, partitionID string, nodeID string, reservations []string, nowNano int64) error
+	CloseDeletedReservations(ctx context.Context, nowNano int64) error
+	GetLongLivedReservations(ctx context.Context, filters ReservationFilters) ([]*model.Reservation, error)
+	ComputeRollup(ctx context.Context, rollup Rollup, width time.Duration, from time.Time, to time.Time, nowNano int64) error
+	GetRollupWatermark(ctx context.Context, rollup Rollup, width time.Duration) (*model.RollupWatermark, error)
+	GetNodeUtilization(ctx context.Context, filters NodeUtilizationFilters) ([]*model.NodeUtilization, error)
+	GetDemandSeries(ctx context.Context, filters DemandFilters) ([]*model.DemandPoint, error)
+	GetEfficiency(ctx context.Context, filters EfficiencyFilters) ([]*model.Efficiency, error)
 }
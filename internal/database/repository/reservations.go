@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/G-Research/unicorn-history-server/internal/model"
+)
+
+// ReservationFilters select the reservations of a reservation report.
+type ReservationFilters struct {
+	// Start and End select the reservations during the period [Start, End).
+	Start time.Time
+	End   time.Time
+	// MinDuration selects the reservations which lasted at least as long, until End if they had not ended yet.
+	MinDuration time.Duration
+	Partition   *string
+	// Queue selects the reservations of the applications in the subtree of a queue.
+	Queue *string
+	User  *string
+}
+
+// reservationAllocated is true if the ask of the reservation r was allocated.
+const reservationAllocated = `
+	EXISTS (
+		SELECT 1
+		FROM applications a
+		CROSS JOIN LATERAL jsonb_array_elements(COALESCE(a.allocations, '[]'::JSONB)) AS al
+		WHERE a.app_id = r.app_id AND a.partition_id = r.partition_id AND al->>'allocationKey' = r.allocation_key
+	)`
+
+// reservationOutcome is the outcome of the reservation r when it ends. It is allocated if its ask was allocated,
+// cancelled if its ask was cancelled or timed out, or if its application is gone, and unreserved otherwise.
+// The REMOVE event of the ask is stored before the reservation ends, so that its detail is known.
+const reservationOutcome = `
+	CASE
+		WHEN ` + reservationAllocated + ` THEN 'allocated'
+		WHEN NOT EXISTS (
+			SELECT 1
+			FROM applications a
+			WHERE a.app_id = r.app_id AND a.partition_id = r.partition_id AND a.deleted_at_nano IS NULL
+		) OR EXISTS (
+			SELECT 1
+			FROM events e
+			WHERE e.type = 'APP' AND e.object_id = r.app_id AND e.reference_id = r.allocation_key
+				AND e.change_type = 'REMOVE' AND e.change_detail IN ('REQUEST_CANCEL', 'REQUEST_TIMEOUT')
+		) THEN 'cancelled'
+		ELSE 'unreserved'
+	END`
+
+// UpdateApplicationReservations records the current reservations of an application: a reservation which is not
+// recorded yet starts, and a recorded reservation which is gone ends. All the reservations of a deleted application end.
+// The application must be updated before its reservations, so that their outcomes are known. The outcome of
+// a reservation which ended while its ask was still pending becomes allocated if its ask was allocated since.
+func (s *PostgresRepository) UpdateApplicationReservations(ctx context.Context, app *model.Application, nowNano int64) error {
+	var keys []model.ReservationKey
+	if app.DeletedAtNano == nil {
+		keys = model.ApplicationReservationKeys(app.ApplicationID, app.Reservations)
+	}
+	const q = `
+WITH reserved AS (
+	SELECT * FROM unnest(@node_ids::TEXT[], @allocation_keys::TEXT[]) AS c(node_id, allocation_key)
+),
+closed AS (
+	UPDATE reservations r
+	SET end_time_nano = @now, outcome = ` + reservationOutcome + `
+	WHERE r.end_time_nano IS NULL AND r.partition_id = @partition_id AND r.app_id = @app_id
+		AND NOT EXISTS (SELECT 1 FROM reserved c WHERE c.node_id = r.node_id AND c.allocation_key = r.allocation_key)
+),
+allocated AS (
+	UPDATE reservations r
+	SET outcome = 'allocated'
+	WHERE r.outcome = 'unreserved' AND r.partition_id = @partition_id AND r.app_id = @app_id AND ` + reservationAllocated + `
+)
+INSERT INTO reservations (partition_id, app_id, allocation_key, node_id, start_time_nano)
+SELECT @partition_id, @app_id, c.allocation_key, c.node_id, @now
+FROM reserved c
+ON CONFLICT (partition_id, app_id, allocation_key, node_id) WHERE end_time_nano IS NULL DO NOTHING`
+
+	nodeIDs := make([]string, 0, len(keys))
+	allocationKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		nodeIDs = append(nodeIDs, key.NodeID)
+		allocationKeys = append(allocationKeys, key.AllocationKey)
+	}
+	_, err := s.dbpool.Exec(ctx, q, pgx.NamedArgs{
+		"partition_id":    app.PartitionID,
+		"app_id":          app.ApplicationID,
+		"node_ids":        nodeIDs,
+		"allocation_keys": allocationKeys,
+		"now":             nowNano,
+	})
+	if err != nil {
+		return fmt.Errorf("could not update reservations of application %s into DB: %v", app.ApplicationID, err)
+	}
+	return nil
+}
+
+// UpdateNodeReservations records the current reservations of a node, which are keyed by application and
+// allocation key: a reservation which is not recorded yet starts, and a recorded reservation which is gone ends.
+func (s *PostgresRepository) UpdateNodeReservations(
+	ctx context.Context,
+	partitionID string,
+	nodeID string,
+	reservations []string,
+	nowNano int64,
+) error {
+	const q = `
+WITH reserved AS (
+	SELECT * FROM unnest(@app_ids::TEXT[], @allocation_keys::TEXT[]) AS c(app_id, allocation_key)
+),
+closed AS (
+	UPDATE reservations r
+	SET end_time_nano = @now, outcome = ` + reservationOutcome + `
+	WHERE r.end_time_nano IS NULL AND r.partition_id = @partition_id AND r.node_id = @node_id
+		AND NOT EXISTS (SELECT 1 FROM reserved c WHERE c.app_id = r.app_id AND c.allocation_key = r.allocation_key)
+)
+INSERT INTO reservations (partition_id, app_id, allocation_key, node_id, start_time_nano)
+SELECT @partition_id, c.app_id, c.allocation_key, @node_id, @now
+FROM reserved c
+ON CONFLICT (partition_id, app_id, allocation_key, node_id) WHERE end_time_nano IS NULL DO NOTHING`
+
+	keys := model.NodeReservationKeys(nodeID, reservations)
+	appIDs := make([]string, 0, len(keys))
+	allocationKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		appIDs = append(appIDs, key.ApplicationID)
+		allocationKeys = append(allocationKeys, key.AllocationKey)
+	}
+	_, err := s.dbpool.Exec(ctx, q, pgx.NamedArgs{
+		"partition_id":    partitionID,
+		"node_id":         nodeID,
+		"app_ids":         appIDs,
+		"allocation_keys": allocationKeys,
+		"now":             nowNano,
+	})
+	if err != nil {
+		return fmt.Errorf("could not update reservations of node %s into DB: %v", nodeID, err)
+	}
+	return nil
+}
+
+// CloseDeletedReservations ends the reservations of the applications and the nodes which were deleted,
+// whose reservations are not updated anymore.
+func (s *PostgresRepository) CloseDeletedReservations(ctx context.Context, nowNano int64) error {
+	const q = `
+UPDATE reservations r
+SET end_time_nano = @now, outcome = ` + reservationOutcome + `
+WHERE r.end_time_nano IS NULL AND (
+	NOT EXISTS (
+		SELECT 1 FROM applications a
+		WHERE a.app_id = r.app_id AND a.partition_id = r.partition_id AND a.deleted_at_nano IS NULL
+	)
+	OR NOT EXISTS (SELECT 1 FROM nodes n WHERE n.node_id = r.node_id AND n.deleted_at_nano IS NULL)
+)`
+
+	_, err := s.dbpool.Exec(ctx, q, pgx.NamedArgs{"now": nowNano})
+	if err != nil {
+		return fmt.Errorf("could not close reservations of deleted applications and nodes in DB: %v", err)
+	}
+	return nil
+}
+
+// longLivedReservationsQuery selects the reservations during the period [@start, @end) which lasted at least
+// @min_duration nanoseconds until their end, or until @end if they had not ended yet. The queue and the user
+// of a reservation are those of the most recent application with its applicationID.
+var longLivedReservationsQuery = `
+SELECT
+	r.id,
+	r.partition_id,
+	r.app_id,
+	r.allocation_key,
+	r.node_id,
+	app.queue_name,
+	app."user",
+	r.start_time_nano,
+	r.end_time_nano,
+	r.outcome,
+	(LEAST(COALESCE(r.end_time_nano, @end), @end) - r.start_time_nano) / 1000000 AS duration
+FROM reservations r
+LEFT JOIN LATERAL (
+	SELECT a.queue_name, a.partition, a."user"
+	FROM applications a
+	WHERE a.app_id = r.app_id AND a.partition_id = r.partition_id
+	ORDER BY a.created_at_nano DESC
+	LIMIT 1
+) app ON TRUE
+WHERE r.start_time_nano < @end AND (r.end_time_nano IS NULL OR r.end_time_nano >= @start)
+	AND LEAST(COALESCE(r.end_time_nano, @end), @end) - r.start_time_nano >= @min_duration
+	AND (@partition::TEXT IS NULL OR app.partition = @partition)
+	AND ` + queueSubtreeFilter("app.queue_name") + `
+	AND (@user::TEXT IS NULL OR app."user" = @user)
+ORDER BY duration DESC, r.id`
+
+// GetLongLivedReservations returns the reservations which match the filters, the longest first.
+func (s *PostgresRepository) GetLongLivedReservations(ctx context.Context, filters ReservationFilters) ([]*model.Reservation, error) {
+	args := pgx.NamedArgs{
+		"start":        filters.Start.UnixNano(),
+		"end":          filters.End.UnixNano(),
+		"min_duration": filters.MinDuration.Nanoseconds(),
+		"partition":    filters.Partition,
+		"user":         filters.User,
+	}
+	maps.Copy(args, queueFilterArgs(filters.Queue))
+
+	rows, err := s.dbpool.Query(ctx, longLivedReservationsQuery, args)
+	if err != nil {
+		return nil, fmt.Errorf("could not get reservations from DB: %v", err)
+	}
+	defer rows.Close()
+
+	var reservations []*model.Reservation
+	for rows.Next() {
+		var r model.Reservation
+		if err := rows.Scan(
+			&r.ID,
+			&r.PartitionID,
+			&r.ApplicationID,
+			&r.AllocationKey,
+			&r.NodeID,
+			&r.Queue,
+			&r.User,
+			&r.StartTimeNano,
+			&r.EndTimeNano,
+			&r.Outcome,
+			&r.Duration,
+		); err != nil {
+			return nil, fmt.Errorf("could not scan reservation from DB: %v", err)
+		}
+		reservations = append(reservations, &r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get reservations from DB: %v", err)
+	}
+	return reservations, nil
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/G-Research/yunikorn-core/pkg/webservice/dao"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/G-Research/unicorn-history-server/internal/model"
+	"github.com/G-Research/unicorn-history-server/internal/util"
+)
+
+type ReservationsIntTest struct {
+	suite.Suite
+	pool  *pgxpool.Pool
+	repo  *PostgresRepository
+	start time.Time
+}
+
+func (rs *ReservationsIntTest) newApplication(appID, queue, user string, requests ...string) *model.Application {
+	app := &model.Application{
+		Metadata: model.Metadata{CreatedAtNano: rs.start.UnixNano()},
+		ApplicationDAOInfo: dao.ApplicationDAOInfo{
+			ID:            ulid.Make().String(),
+			ApplicationID: appID,
+			PartitionID:   "1",
+			Partition:     "default",
+			QueueName:     queue,
+			User:          user,
+			State:         "Running",
+		},
+	}
+	for _, allocationKey := range requests {
+		app.Requests = append(app.Requests, &dao.AllocationAskDAOInfo{AllocationKey: allocationKey})
+	}
+	return app
+}
+
+func (rs *ReservationsIntTest) SetupSuite() {
+	require.NotNil(rs.T(), rs.pool)
+	repo, err := NewPostgresRepository(rs.pool)
+	require.NoError(rs.T(), err)
+	rs.repo = repo
+
+	ctx := context.Background()
+	rs.start = time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	start := rs.start
+
+	for _, nodeID := range []string{"node-1", "node-2"} {
+		node := &model.Node{
+			Metadata:    model.Metadata{CreatedAtNano: start.UnixNano()},
+			NodeDAOInfo: dao.NodeDAOInfo{ID: ulid.Make().String(), NodeID: nodeID, PartitionID: "1", HostName: nodeID},
+		}
+		require.NoError(rs.T(), repo.InsertNode(ctx, node))
+	}
+	app1 := rs.newApplication("app-1", "root.a", "alice", "alloc-1")
+	app1.Reservations = []string{"node-1|alloc-1"}
+	app2 := rs.newApplication("app-2", "root.b", "bob")
+	for _, app := range []*model.Application{app1, app2} {
+		require.NoError(rs.T(), repo.InsertApplication(ctx, app))
+	}
+
+	// app-1 reserves node-1, and app-2 reserves it as well until its ask is cancelled
+	require.NoError(rs.T(), repo.UpdateApplicationReservations(ctx, app1, start.UnixNano()))
+	require.NoError(rs.T(), repo.UpdateNodeReservations(ctx, "1", "node-1", []string{"app-1|alloc-1", "app-2|alloc-2"},
+		start.Add(time.Minute).UnixNano()))
+	require.NoError(rs.T(), repo.InsertEvent(ctx, &model.Event{
+		TimestampNano: start.Add(10 * time.Minute).UnixNano(),
+		Type:          "APP",
+		ObjectID:      "app-2",
+		ReferenceID:   "alloc-2",
+		ChangeType:    "REMOVE",
+		ChangeDetail:  "REQUEST_CANCEL",
+	}))
+	require.NoError(rs.T(), repo.UpdateNodeReservations(ctx, "1", "node-1", []string{"app-1|alloc-1"},
+		start.Add(10*time.Minute).UnixNano()))
+	// app-1 unreserves node-1 while its ask is pending, and its ask is allocated later
+	app1.Reservations = nil
+	require.NoError(rs.T(), repo.UpdateApplicationReservations(ctx, app1, start.Add(2*time.Hour).UnixNano()))
+	app1.Requests = nil
+	app1.Allocations = []*dao.AllocationDAOInfo{{AllocationKey: "alloc-1", NodeID: "node-2"}}
+	require.NoError(rs.T(), repo.UpdateApplication(ctx, app1))
+	require.NoError(rs.T(), repo.UpdateApplicationReservations(ctx, app1, start.Add(3*time.Hour).UnixNano()))
+}
+
+func (rs *ReservationsIntTest) TearDownSuite() {
+	rs.pool.Close()
+}
+
+func (rs *ReservationsIntTest) TestGetLongLivedReservations() {
+	ctx := context.Background()
+	start := rs.start
+
+	filters := ReservationFilters{Start: start, End: start.Add(4 * time.Hour), MinDuration: 30 * time.Minute}
+	reservations, err := rs.repo.GetLongLivedReservations(ctx, filters)
+	require.NoError(rs.T(), err)
+	require.Len(rs.T(), reservations, 1)
+	first := reservations[0]
+	assert.Equal(rs.T(), "app-1", first.ApplicationID)
+	assert.Equal(rs.T(), "alloc-1", first.AllocationKey)
+	assert.Equal(rs.T(), "node-1", first.NodeID)
+	assert.Equal(rs.T(), util.ToPtr("root.a"), first.Queue)
+	assert.Equal(rs.T(), util.ToPtr("alice"), first.User)
+	assert.Equal(rs.T(), start.UnixNano(), first.StartTimeNano)
+	assert.Equal(rs.T(), util.ToPtr(start.Add(2*time.Hour).UnixNano()), first.EndTimeNano)
+	// the ask was allocated after the reservation ended
+	assert.Equal(rs.T(), util.ToPtr(model.ReservationOutcomeAllocated), first.Outcome)
+	assert.Equal(rs.T(), (2 * time.Hour).Milliseconds(), first.Duration)
+
+	filters.MinDuration = 5 * time.Minute
+	reservations, err = rs.repo.GetLongLivedReservations(ctx, filters)
+	require.NoError(rs.T(), err)
+	require.Len(rs.T(), reservations, 2)
+	second := reservations[1]
+	assert.Equal(rs.T(), "app-2", second.ApplicationID)
+	assert.Equal(rs.T(), start.Add(time.Minute).UnixNano(), second.StartTimeNano)
+	assert.Equal(rs.T(), util.ToPtr(model.ReservationOutcomeCancelled), second.Outcome)
+	assert.Equal(rs.T(), (9 * time.Minute).Milliseconds(), second.Duration)
+
+	filters.Queue = util.ToPtr("root.b")
+	reservations, err = rs.repo.GetLongLivedReservations(ctx, filters)
+	require.NoError(rs.T(), err)
+	require.Len(rs.T(), reservations, 1)
+	assert.Equal(rs.T(), "app-2", reservations[0].ApplicationID)
+
+	// the reservations are measured until the end of the period
+	filters = ReservationFilters{Start: start, End: start.Add(30 * time.Minute), MinDuration: 15 * time.Minute}
+	reservations, err = rs.repo.GetLongLivedReservations(ctx, filters)
+	require.NoError(rs.T(), err)
+	require.Len(rs.T(), reservations, 1)
+	assert.Equal(rs.T(), (30 * time.Minute).Milliseconds(), reservations[0].Duration)
+
+	filters.User = util.ToPtr("bob")
+	reservations, err = rs.repo.GetLongLivedReservations(ctx, filters)
+	require.NoError(rs.T(), err)
+	assert.Empty(rs.T(), reservations)
+}
+
+func (rs *ReservationsIntTest) TestCloseDeletedReservations() {
+	ctx := context.Background()
+	start := rs.start
+
+	app := rs.newApplication("app-3", "root.c", "carol", "alloc-3")
+	app.Reservations = []string{"node-2|alloc-3"}
+	require.NoError(rs.T(), rs.repo.InsertApplication(ctx, app))
+	require.NoError(rs.T(), rs.repo.UpdateApplicationReservations(ctx, app, start.UnixNano()))
+
+	filters := ReservationFilters{Start: start, End: start.Add(time.Hour), User: util.ToPtr("carol")}
+	require.NoError(rs.T(), rs.repo.CloseDeletedReservations(ctx, start.Add(10*time.Minute).UnixNano()))
+	reservations, err := rs.repo.GetLongLivedReservations(ctx, filters)
+	require.NoError(rs.T(), err)
+	require.Len(rs.T(), reservations, 1)
+	assert.Nil(rs.T(), reservations[0].EndTimeNano)
+
+	app.DeletedAtNano = util.ToPtr(start.Add(20 * time.Minute).UnixNano())
+	require.NoError(rs.T(), rs.repo.UpdateApplication(ctx, app))
+	require.NoError(rs.T(), rs.repo.CloseDeletedReservations(ctx, start.Add(30*time.Minute).UnixNano()))
+	reservations, err = rs.repo.GetLongLivedReservations(ctx, filters)
+	require.NoError(rs.T(), err)
+	require.Len(rs.T(), reservations, 1)
+	assert.Equal(rs.T(), util.ToPtr(start.Add(30*time.Minute).UnixNano()), reservations[0].EndTimeNano)
+	assert.Equal(rs.T(), util.ToPtr(model.ReservationOutcomeCancelled), reservations[0].Outcome)
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/G-Research/yunikorn-core/pkg/common/resources"
+
+	"github.com/G-Research/unicorn-history-server/internal/database/sql"
+)
+
+// ResourceFilter selects the rows by a resource or an attribute, e.g. 'available.memory>=64Gi',
+// 'usedResource.nvidia.com/gpu>0' or 'attributes.instance-type=m5.large'.
+type ResourceFilter struct {
+	// Field is the resource field (e.g. 'available') or the attribute field (e.g. 'attributes').
+	Field string
+	// Key is the resource (e.g. 'memory') or the attribute (e.g. 'instance-type').
+	Key      string
+	Operator sql.Operator
+	// Value is a resource quantity with an optional unit (e.g. '64Gi' or '500m' for vcore), or an attribute value.
+	Value string
+}
+
+func (f ResourceFilter) String() string {
+	return f.Field + "." + f.Key + string(f.Operator) + f.Value
+}
+
+// ResourceFilterFields is the allow-list of the fields by which a list can be filtered with resource filters.
+type ResourceFilterFields struct {
+	// resources maps the resource fields of the API to the JSONB columns which contain a resource map.
+	resources map[string]string
+	// attributes maps the attribute fields of the API to the JSONB columns which contain a map of strings.
+	attributes map[string]string
+}
+
+// Names returns the names of the fields which can be filtered, as '<field>.<key>'.
+func (f *ResourceFilterFields) Names() []string {
+	names := make([]string, 0, len(f.resources)+len(f.attributes))
+	for name := range f.resources {
+		names = append(names, name+".<resource>")
+	}
+	for name := range f.attributes {
+		names = append(names, name+".<attribute>")
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks that the list can be filtered by the resource filters.
+func (f *ResourceFilterFields) Validate(filters []ResourceFilter) error {
+	for _, filter := range filters {
+		if _, err := f.condition(filter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply adds the conditions of the resource filters to the sql query.
+func (f *ResourceFilterFields) apply(builder *sql.Builder, filters []ResourceFilter) error {
+	for _, filter := range filters {
+		condition, err := f.condition(filter)
+		if err != nil {
+			return err
+		}
+		builder.Where(condition)
+	}
+	return nil
+}
+
+func (f *ResourceFilterFields) condition(filter ResourceFilter) (sql.Expr, error) {
+	if column, ok := f.resources[filter.Field]; ok {
+		return resourceCondition(column, filter)
+	}
+	if column, ok := f.attributes[filter.Field]; ok {
+		return attributeCondition(column, filter)
+	}
+	return nil, fmt.Errorf("cannot filter by %q, allowed fields are: %s", filter.Field+"."+filter.Key, strings.Join(f.Names(), ", "))
+}
+
+// resourceCondition compares a resource of a resource column to the quantity of the filter.
+// Missing resources are compared as 0, like they are sorted.
+func resourceCondition(column string, filter ResourceFilter) (sql.Expr, error) {
+	if !resourceKeyRegexp.MatchString(filter.Key) {
+		return nil, fmt.Errorf("invalid resource filter %q: invalid resource %q", filter, filter.Key)
+	}
+	quantity, err := parseResourceQuantity(filter.Key, filter.Value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource filter %q: %v", filter, err)
+	}
+	if !isResourceOperator(filter.Operator) {
+		return nil, fmt.Errorf("invalid resource filter %q: unsupported operator %q", filter, filter.Operator)
+	}
+	// the resource key is validated, so it can be used as a literal, which matches the expression indexes
+	condition := sql.Cmp(resourceExpression(column, filter.Key), filter.Operator, quantity)
+	if compare(0, filter.Operator, quantity) {
+		return condition, nil
+	}
+	// rows without the resource cannot match, so the GIN index of the column can be used to find the rows which have it
+	return sql.And(sql.Raw(column+" ? $1", filter.Key), condition), nil
+}
+
+// attributeCondition checks whether an attribute of an attributes column equals the value of the filter.
+func attributeCondition(column string, filter ResourceFilter) (sql.Expr, error) {
+	if filter.Key == "" {
+		return nil, fmt.Errorf("invalid attribute filter %q: empty attribute", filter)
+	}
+	// containment can use the GIN index of the column
+	contains := sql.Raw(column+" @> $1", map[string]string{filter.Key: filter.Value})
+	switch filter.Operator {
+	case sql.OpEq:
+		return contains, nil
+	case sql.OpNe:
+		return sql.Not(contains), nil
+	default:
+		return nil, fmt.Errorf("invalid attribute filter %q: unsupported operator %q, allowed are = and !=", filter, filter.Operator)
+	}
+}
+
+// parseResourceQuantity parses a quantity like YuniKorn parses the quantities of its configuration:
+// vcore is measured in millicores, so '2' is 2000 and '500m' is 500.
+func parseResourceQuantity(resource, value string) (int64, error) {
+	var quantity resources.Quantity
+	var err error
+	if resource == "vcore" {
+		quantity, err = resources.ParseVCore(value)
+	} else {
+		quantity, err = resources.ParseQuantity(value)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid quantity %q: %v", value, err)
+	}
+	return int64(quantity), nil
+}
+
+func isResourceOperator(op sql.Operator) bool {
+	switch op {
+	case sql.OpEq, sql.OpNe, sql.OpLt, sql.OpLe, sql.OpGt, sql.OpGe:
+		return true
+	default:
+		return false
+	}
+}
+
+// compare evaluates 'lhs op rhs'.
+func compare(lhs int64, op sql.Operator, rhs int64) bool {
+	switch op {
+	case sql.OpEq:
+		return lhs == rhs
+	case sql.OpNe:
+		return lhs != rhs
+	case sql.OpLt:
+		return lhs < rhs
+	case sql.OpLe:
+		return lhs <= rhs
+	case sql.OpGt:
+		return lhs > rhs
+	case sql.OpGe:
+		return lhs >= rhs
+	default:
+		return false
+	}
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/G-Research/unicorn-history-server/internal/database/sql"
+)
+
+func TestResourceFilterFieldsApply(t *testing.T) {
+	tests := []struct {
+		name          string
+		filters       []ResourceFilter
+		expectedQuery string
+		expectedArgs  []any
+		expectedErr   bool
+	}{
+		{
+			name:          "No filters",
+			expectedQuery: "SELECT * FROM nodes",
+		},
+		{
+			name:    "Binary unit",
+			filters: []ResourceFilter{{Field: "available", Key: "memory", Operator: sql.OpGe, Value: "64Gi"}},
+			expectedQuery: "SELECT * FROM nodes WHERE (available ? $1 AND " +
+				"COALESCE((available->>'memory')::BIGINT, 0) >= $2)",
+			expectedArgs: []any{"memory", int64(64 << 30)},
+		},
+		{
+			name:          "Vcore in cores",
+			filters:       []ResourceFilter{{Field: "capacity", Key: "vcore", Operator: sql.OpLt, Value: "2"}},
+			expectedQuery: "SELECT * FROM nodes WHERE COALESCE((capacity->>'vcore')::BIGINT, 0) < $1",
+			expectedArgs:  []any{int64(2000)},
+		},
+		{
+			name:          "Vcore in millicores",
+			filters:       []ResourceFilter{{Field: "capacity", Key: "vcore", Operator: sql.OpLe, Value: "500m"}},
+			expectedQuery: "SELECT * FROM nodes WHERE COALESCE((capacity->>'vcore')::BIGINT, 0) <= $1",
+			expectedArgs:  []any{int64(500)},
+		},
+		{
+			name: "Resource with dots and slash and attribute",
+			filters: []ResourceFilter{
+				{Field: "allocated", Key: "nvidia.com/gpu", Operator: sql.OpGt, Value: "0"},
+				{Field: "attributes", Key: "instance-type", Operator: sql.OpEq, Value: "m5.large"},
+			},
+			expectedQuery: "SELECT * FROM nodes WHERE (allocated ? $1 AND " +
+				"COALESCE((allocated->>'nvidia.com/gpu')::BIGINT, 0) > $2) AND attributes @> $3",
+			expectedArgs: []any{"nvidia.com/gpu", int64(0), map[string]string{"instance-type": "m5.large"}},
+		},
+		{
+			name:          "Attribute not equal",
+			filters:       []ResourceFilter{{Field: "attributes", Key: "zone", Operator: sql.OpNe, Value: "a"}},
+			expectedQuery: "SELECT * FROM nodes WHERE NOT (attributes @> $1)",
+			expectedArgs:  []any{map[string]string{"zone": "a"}},
+		},
+		{
+			name:        "Unknown field",
+			filters:     []ResourceFilter{{Field: "usedResource", Key: "memory", Operator: sql.OpGt, Value: "1"}},
+			expectedErr: true,
+		},
+		{
+			name:        "Invalid quantity",
+			filters:     []ResourceFilter{{Field: "available", Key: "memory", Operator: sql.OpGt, Value: "64GB"}},
+			expectedErr: true,
+		},
+		{
+			name:        "Millicores of other resources",
+			filters:     []ResourceFilter{{Field: "available", Key: "memory", Operator: sql.OpGt, Value: "500m"}},
+			expectedErr: true,
+		},
+		{
+			name:        "Invalid resource key",
+			filters:     []ResourceFilter{{Field: "available", Key: "memory')::BIGINT; --", Operator: sql.OpGt, Value: "1"}},
+			expectedErr: true,
+		},
+		{
+			name:        "Ordering attributes",
+			filters:     []ResourceFilter{{Field: "attributes", Key: "zone", Operator: sql.OpGt, Value: "a"}},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := sql.NewBuilder().SelectAll("nodes", "")
+			err := NodeResourceFilterFields.apply(builder, tt.filters)
+			if tt.expectedErr {
+				require.Error(t, err)
+				require.Error(t, NodeResourceFilterFields.Validate(tt.filters))
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, NodeResourceFilterFields.Validate(tt.filters))
+			query, args := builder.Build()
+			assert.Equal(t, tt.expectedQuery, query)
+			assert.Equal(t, tt.expectedArgs, args)
+		})
+	}
+}
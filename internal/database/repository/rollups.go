@@ -0,0 +1,422 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/G-Research/unicorn-history-server/internal/model"
+)
+
+// Rollup is a materialized aggregate of the history, the applications or the nodes, which is computed incrementally
+// for the buckets of every width of RollupWidths. A reader reads the rollups during the period which is covered by
+// their watermark (see rolledUpPeriod), and the rows they are computed from during the rest of its period.
+//
+// The wait time, efficiency and preemption reports, and the usage grouped by application, are computed from the rows
+// of the applications and the preemptions, since they need the distributions of or the values for the single
+// applications and preemptions, which the rollups do not keep. The fairness of the queues and the demand forecasts are computed from the
+// versions of the queues, which are not rolled up.
+type Rollup string
+
+const (
+	// RollupHistory keeps the count, sum, minimum, maximum and last value of the total numbers of the applications
+	// and containers history, which the downsampled histories read.
+	RollupHistory Rollup = "history"
+	// RollupUsage keeps the resources used by the allocations of the applications per partition, queue, user
+	// and groups, which the usage reports and the shares of the users of the fairness reports read.
+	RollupUsage Rollup = "usage"
+	// RollupApplicationStates keeps the numbers of pending, running and completed applications per partition, queue
+	// and user, which the applications timelines read.
+	RollupApplicationStates Rollup = "application_states"
+	// RollupNodeUtilization keeps the capacity of every node and the resources which were allocated on it,
+	// which the node utilization reports read.
+	RollupNodeUtilization Rollup = "node_utilization"
+)
+
+// Rollups are the rollups which are computed.
+var Rollups = []Rollup{
+	RollupHistory,
+	RollupUsage,
+	RollupApplicationStates,
+	RollupNodeUtilization,
+}
+
+// RollupWidths are the widths of the buckets of the rollups, the widest first.
+var RollupWidths = []time.Duration{24 * time.Hour, time.Hour}
+
+// rollupAllocated sums the resources of the allocations a multiplied by the seconds they were allocated
+// during every bucket b, grouped by the key and the resource.
+const rollupAllocated = `
+	SELECT
+		b.bucket,
+		%[1]s AS key,
+		r.key AS resource,
+		SUM((LEAST(a.end_nano, b.bucket + @width::BIGINT) - GREATEST(a.start_nano, b.bucket)) / 1e9::DOUBLE PRECISION
+			* r.value::DOUBLE PRECISION) AS seconds
+	FROM allocations a
+	JOIN buckets b ON b.bucket < a.end_nano AND b.bucket + @width::BIGINT > a.start_nano
+	LEFT JOIN LATERAL jsonb_each_text(COALESCE(a.resource, '{}'::JSONB)) AS r(key, value) ON TRUE
+	WHERE %[1]s IS NOT NULL
+	GROUP BY b.bucket, %[1]s, r.key`
+
+// rollupDefinition is the table of a rollup, and the common table expressions which compute its rows
+// for the buckets, ending with the computed rows.
+type rollupDefinition struct {
+	table    string
+	computed string
+	// keys and values are the columns of the rows, besides their width and their bucket.
+	keys   []string
+	values []string
+}
+
+var rollupDefinitions = map[Rollup]rollupDefinition{
+	RollupHistory: {
+		table: "history_rollups",
+		computed: `
+computed AS (
+	SELECT
+		b.bucket,
+		h.history_type,
+		COUNT(*) AS count,
+		SUM(h.total_number) AS sum,
+		MIN(h.total_number) AS min,
+		MAX(h.total_number) AS max,
+		(array_agg(h.total_number ORDER BY h.timestamp DESC))[1] AS last,
+		MAX(h.timestamp) AS last_timestamp
+	FROM buckets b
+	JOIN history h ON h.timestamp >= b.bucket AND h.timestamp < b.bucket + @width::BIGINT
+	GROUP BY b.bucket, h.history_type
+)`,
+		keys:   []string{"history_type"},
+		values: []string{"count", "sum", "min", "max", "last", "last_timestamp"},
+	},
+	RollupUsage: {
+		table: "usage_rollups",
+		computed: usageAllocations + `,
+allocated AS (` + fmt.Sprintf(rollupAllocated, "a.id") + `
+),
+computed AS (
+	SELECT
+		u.bucket,
+		u.partition_id,
+		MIN(u.partition) AS partition,
+		u.queue_name,
+		u."user",
+		u.groups,
+		COALESCE(jsonb_object_agg(u.resource, u.seconds) FILTER (WHERE u.resource IS NOT NULL), '{}'::JSONB)
+			AS resource_seconds
+	FROM (
+		SELECT
+			al.bucket,
+			a.partition_id,
+			MIN(a.partition) AS partition,
+			a.queue_name,
+			COALESCE(a."user", '') AS "user",
+			COALESCE(a.groups, '{}') AS groups,
+			al.resource,
+			SUM(al.seconds) AS seconds
+		FROM allocated al
+		JOIN applications a ON a.id = al.key
+		GROUP BY al.bucket, a.partition_id, a.queue_name, COALESCE(a."user", ''), COALESCE(a.groups, '{}'), al.resource
+	) u
+	GROUP BY u.bucket, u.partition_id, u.queue_name, u."user", u.groups
+)`,
+		keys:   []string{"partition_id", "queue_name", `"user"`, "groups"},
+		values: []string{"partition", "resource_seconds"},
+	},
+	RollupApplicationStates: {
+		table: "application_state_rollups",
+		computed: applicationLifecycles + `,
+computed AS (
+	SELECT
+		b.bucket,
+		l.partition,
+		l.queue_name,
+		l."user",
+		COUNT(*) FILTER (WHERE l.submitted <= b.bucket AND (l.started IS NULL OR l.started > b.bucket)
+			AND (l.ended IS NULL OR l.ended > b.bucket)) AS pending,
+		COUNT(*) FILTER (WHERE l.started <= b.bucket AND (l.ended IS NULL OR l.ended > b.bucket)) AS running,
+		COUNT(*) FILTER (WHERE l.completed AND l.ended >= b.bucket AND l.ended < b.bucket + @width::BIGINT) AS completed
+	FROM buckets b
+	JOIN lifecycles l ON l.submitted < b.bucket + @width::BIGINT AND (l.ended IS NULL OR l.ended >= b.bucket)
+	GROUP BY b.bucket, l.partition, l.queue_name, l."user"
+)`,
+		keys:   []string{"partition", "queue_name", `"user"`},
+		values: []string{"pending", "running", "completed"},
+	},
+	RollupNodeUtilization: {
+		table: "node_utilization_rollups",
+		computed: usageAllocations + `,
+allocated AS (` + fmt.Sprintf(rollupAllocated, "a.node_id") + `
+),
+computed AS (
+	SELECT
+		b.bucket,
+		n.node_id,
+		COALESCE(p.name, n.partition_id) AS partition,
+		COALESCE(capacity.value, n.capacity, '{}'::JSONB) AS capacity,
+		COALESCE((
+			SELECT jsonb_object_agg(al.resource, al.seconds)
+			FROM allocated al
+			WHERE al.bucket = b.bucket AND al.key = n.node_id AND al.resource IS NOT NULL
+		), '{}'::JSONB) AS allocated_seconds
+	FROM buckets b
+	JOIN nodes n ON n.created_at_nano < b.bucket + @width::BIGINT AND (n.deleted_at_nano IS NULL OR n.deleted_at_nano > b.bucket)
+	LEFT JOIN partitions p ON p.id = n.partition_id
+	LEFT JOIN LATERAL (
+		SELECT NULLIF(v.data->'capacity', 'null'::JSONB) AS value
+		FROM node_versions v
+		WHERE v.object_id = n.id AND v.valid_from_nano < b.bucket + @width::BIGINT
+		ORDER BY v.valid_from_nano DESC
+		LIMIT 1
+	) capacity ON TRUE
+)`,
+		keys:   []string{"node_id"},
+		values: []string{"partition", "capacity", "allocated_seconds"},
+	},
+}
+
+// rollupQuery replaces the rows of a rollup of the width @width_seconds in the buckets of the period [@from, @to)
+// by the computed rows, and extends the period covered by the rollup with it. The arguments are the common table
+// expressions of the computed rows, the table of the rollup, its key columns, its key columns in the table r
+// and in the computed rows c, its value columns, its value columns in the computed rows and their updates.
+const rollupQuery = `
+WITH buckets AS (
+	SELECT generate_series(@from::BIGINT, @to::BIGINT - 1, @width::BIGINT) AS bucket
+),
+%[1]s,
+deleted AS (
+	DELETE FROM %[2]s r
+	WHERE r.width_seconds = @width_seconds AND r.bucket >= @from AND r.bucket < @to
+		AND NOT EXISTS (SELECT 1 FROM computed c WHERE (c.bucket, %[5]s) = (r.bucket, %[4]s))
+),
+upserted AS (
+	INSERT INTO %[2]s (width_seconds, bucket, %[3]s, %[6]s)
+	SELECT @width_seconds, c.bucket, %[5]s, %[7]s
+	FROM computed c
+	ON CONFLICT (width_seconds, bucket, %[3]s) DO UPDATE SET %[8]s
+)
+INSERT INTO rollup_watermarks (rollup, width_seconds, start_nano, watermark_nano, updated_at_nano)
+VALUES (@rollup, @width_seconds, @from, @to, @now)
+ON CONFLICT (rollup, width_seconds) DO UPDATE SET
+	start_nano = LEAST(rollup_watermarks.start_nano, EXCLUDED.start_nano),
+	watermark_nano = GREATEST(rollup_watermarks.watermark_nano, EXCLUDED.watermark_nano),
+	updated_at_nano = EXCLUDED.updated_at_nano`
+
+func (d rollupDefinition) query() string {
+	qualify := func(alias string, columns []string) string {
+		qualified := make([]string, 0, len(columns))
+		for _, column := range columns {
+			qualified = append(qualified, alias+"."+column)
+		}
+		return strings.Join(qualified, ", ")
+	}
+	updates := make([]string, 0, len(d.values))
+	for _, column := range d.values {
+		updates = append(updates, column+" = EXCLUDED."+column)
+	}
+	return fmt.Sprintf(
+		rollupQuery,
+		d.computed,
+		d.table,
+		strings.Join(d.keys, ", "),
+		qualify("r", d.keys),
+		qualify("c", d.keys),
+		strings.Join(d.values, ", "),
+		qualify("c", d.values),
+		strings.Join(updates, ", "),
+	)
+}
+
+// ComputeRollup computes the buckets of the rollup of the width in the period [from, to), which must be aligned
+// to the width, and extends the period covered by the rollup with it. The period must overlap or adjoin the period
+// which is already covered, so that the covered period has no gaps.
+func (s *PostgresRepository) ComputeRollup(
+	ctx context.Context,
+	rollup Rollup,
+	width time.Duration,
+	from time.Time,
+	to time.Time,
+	nowNano int64,
+) error {
+	definition, ok := rollupDefinitions[rollup]
+	if !ok {
+		return fmt.Errorf("unknown rollup %q", rollup)
+	}
+	if !slices.Contains(RollupWidths, width) {
+		return fmt.Errorf("invalid width %s of rollup %s", width, rollup)
+	}
+	if from.UnixNano()%width.Nanoseconds() != 0 || to.UnixNano()%width.Nanoseconds() != 0 || !from.Before(to) {
+		return fmt.Errorf("invalid period [%s, %s) of rollup %s of width %s", from, to, rollup, width)
+	}
+
+	_, err := s.dbpool.Exec(ctx, definition.query(), pgx.NamedArgs{
+		"rollup":        string(rollup),
+		"width":         width.Nanoseconds(),
+		"width_seconds": int64(width / time.Second),
+		"from":          from.UnixNano(),
+		"to":            to.UnixNano(),
+		"now":           nowNano,
+		// the allocations and the lifecycles of all the applications during the period
+		"start":         from.UnixNano(),
+		"end":           to.UnixNano(),
+		"rollup_start":  to.UnixNano(),
+		"rollup_end":    to.UnixNano(),
+		"partition":     nil,
+		"queue":         nil,
+		"queue_pattern": nil,
+		"user":          nil,
+	})
+	if err != nil {
+		return fmt.Errorf("could not compute rollup %s of width %s into DB: %v", rollup, width, err)
+	}
+	return nil
+}
+
+// GetRollupWatermark returns the period which is covered by the rollup of the width, or nil if it was never computed.
+func (s *PostgresRepository) GetRollupWatermark(ctx context.Context, rollup Rollup, width time.Duration) (*model.RollupWatermark, error) {
+	const q = `
+SELECT rollup, width_seconds, start_nano, watermark_nano, updated_at_nano
+FROM rollup_watermarks
+WHERE rollup = @rollup AND width_seconds = @width_seconds`
+
+	var watermark model.RollupWatermark
+	err := s.dbpool.QueryRow(ctx, q, pgx.NamedArgs{
+		"rollup":        string(rollup),
+		"width_seconds": int64(width / time.Second),
+	}).Scan(
+		&watermark.Rollup,
+		&watermark.WidthSeconds,
+		&watermark.StartNano,
+		&watermark.WatermarkNano,
+		&watermark.UpdatedAtNano,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not get watermark of rollup %s from DB: %v", rollup, err)
+	}
+	return &watermark, nil
+}
+
+// rolledUpPeriod returns the width of the rollup whose buckets cover the longest part [from, to) of the period
+// [start, end), with from and to on the grid of the step from the origin. The step is the width if it is 0,
+// and the widths which do not divide it, or whose buckets are not aligned to the origin, are skipped.
+// The width is 0 if no part of the period is covered.
+func (s *PostgresRepository) rolledUpPeriod(
+	ctx context.Context,
+	rollup Rollup,
+	origin time.Time,
+	step time.Duration,
+	start time.Time,
+	end time.Time,
+) (time.Duration, time.Time, time.Time, error) {
+	var width time.Duration
+	var from, to time.Time
+	for _, w := range RollupWidths {
+		gridStep := step
+		if gridStep == 0 {
+			gridStep = w
+		}
+		if gridStep%w != 0 || origin.UnixNano()%w.Nanoseconds() != 0 {
+			continue
+		}
+		watermark, err := s.GetRollupWatermark(ctx, rollup, w)
+		if err != nil {
+			return 0, time.Time{}, time.Time{}, err
+		}
+		if watermark == nil {
+			continue
+		}
+		first := max(start.UnixNano(), watermark.StartNano) - origin.UnixNano()
+		last := min(end.UnixNano(), watermark.WatermarkNano) - origin.UnixNano()
+		if first < 0 || last < first {
+			continue
+		}
+		first = (first + gridStep.Nanoseconds() - 1) / gridStep.Nanoseconds() * gridStep.Nanoseconds()
+		last = last / gridStep.Nanoseconds() * gridStep.Nanoseconds()
+		if last-first > to.Sub(from).Nanoseconds() {
+			width, from, to = w, origin.Add(time.Duration(first)), origin.Add(time.Duration(last))
+		}
+	}
+	return width, from, to, nil
+}
+
+// nodeUtilizationQuery computes the utilization of the nodes in the buckets of the node utilization rollups
+// of the width @width_seconds during the period [@start, @end). The utilization of a resource is the ratio of its
+// allocated resource seconds over its capacity multiplied by the seconds the node existed.
+const nodeUtilizationQuery = `
+WITH rollups AS (
+	SELECT *
+	FROM node_utilization_rollups r
+	WHERE r.width_seconds = @width_seconds AND r.bucket >= @start AND r.bucket < @end
+		AND (@partition::TEXT IS NULL OR r.partition = @partition)
+),
+utilization AS (
+	SELECT
+		r.node_id,
+		c.key,
+		SUM(COALESCE((r.allocated_seconds->>c.key)::DOUBLE PRECISION, 0))
+			/ SUM(c.value::DOUBLE PRECISION * r.width_seconds) AS ratio
+	FROM rollups r
+	CROSS JOIN LATERAL jsonb_each_text(r.capacity) AS c(key, value)
+	GROUP BY r.node_id, c.key
+	HAVING SUM(c.value::DOUBLE PRECISION) > 0
+)
+SELECT
+	r.node_id,
+	MIN(r.partition),
+	SUM(r.width_seconds) / 3600::DOUBLE PRECISION,
+	COALESCE((SELECT jsonb_object_agg(u.key, u.ratio) FROM utilization u WHERE u.node_id = r.node_id), '{}'::JSONB)
+FROM rollups r
+GROUP BY r.node_id
+ORDER BY MIN(r.partition), r.node_id`
+
+// NodeUtilizationFilters select the nodes and the period of a node utilization report.
+type NodeUtilizationFilters struct {
+	Start     time.Time
+	End       time.Time
+	Partition *string
+}
+
+// GetNodeUtilization returns the utilization of the nodes during the part of the period of the filters
+// which is covered by the node utilization rollups.
+func (s *PostgresRepository) GetNodeUtilization(ctx context.Context, filters NodeUtilizationFilters) ([]*model.NodeUtilization, error) {
+	width, from, to, err := s.rolledUpPeriod(ctx, RollupNodeUtilization, time.Unix(0, 0), 0, filters.Start, filters.End)
+	if err != nil {
+		return nil, fmt.Errorf("could not get node utilization from DB: %v", err)
+	}
+	if width == 0 {
+		return nil, nil
+	}
+
+	rows, err := s.dbpool.Query(ctx, nodeUtilizationQuery, pgx.NamedArgs{
+		"width_seconds": int64(width / time.Second),
+		"start":         from.UnixNano(),
+		"end":           to.UnixNano(),
+		"partition":     filters.Partition,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get node utilization from DB: %v", err)
+	}
+	defer rows.Close()
+
+	var nodes []*model.NodeUtilization
+	for rows.Next() {
+		var n model.NodeUtilization
+		if err := rows.Scan(&n.NodeID, &n.Partition, &n.Hours, &n.Utilization); err != nil {
+			return nil, fmt.Errorf("could not scan node utilization from DB: %v", err)
+		}
+		nodes = append(nodes, &n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get node utilization from DB: %v", err)
+	}
+	return nodes, nil
+}
@@ -0,0 +1,274 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/G-Research/yunikorn-core/pkg/webservice/dao"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/G-Research/unicorn-history-server/internal/model"
+	"github.com/G-Research/unicorn-history-server/internal/util"
+)
+
+type RollupsIntTest struct {
+	suite.Suite
+	pool  *pgxpool.Pool
+	repo  *PostgresRepository
+	start time.Time
+}
+
+func (rs *RollupsIntTest) SetupSuite() {
+	require.NotNil(rs.T(), rs.pool)
+	repo, err := NewPostgresRepository(rs.pool)
+	require.NoError(rs.T(), err)
+	rs.repo = repo
+
+	ctx := context.Background()
+	rs.start = time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	start := rs.start
+	const gib = 1 << 30
+
+	require.NoError(rs.T(), repo.InsertPartition(ctx, &model.Partition{
+		Metadata:      model.Metadata{CreatedAtNano: start.Add(-24 * time.Hour).UnixNano()},
+		PartitionInfo: dao.PartitionInfo{ID: "1", Name: "default", ClusterID: "cluster1", State: "Active"},
+	}))
+	for nodeID, vcore := range map[string]int64{"node-1": 8000, "node-2": 4000} {
+		require.NoError(rs.T(), repo.InsertNode(ctx, &model.Node{
+			Metadata: model.Metadata{CreatedAtNano: start.Add(-24 * time.Hour).UnixNano()},
+			NodeDAOInfo: dao.NodeDAOInfo{
+				ID:          ulid.Make().String(),
+				NodeID:      nodeID,
+				PartitionID: "1",
+				HostName:    nodeID,
+				Capacity:    map[string]int64{"vcore": vcore},
+			},
+		}))
+	}
+
+	apps := []*model.Application{
+		{
+			// running: one allocation removed during the first hour, one from the second hour
+			Metadata: model.Metadata{CreatedAtNano: start.UnixNano()},
+			ApplicationDAOInfo: dao.ApplicationDAOInfo{
+				ID:             ulid.Make().String(),
+				ApplicationID:  "app-alice",
+				PartitionID:    "1",
+				Partition:      "default",
+				QueueName:      "root.ml.training",
+				SubmissionTime: start.Add(-time.Hour).UnixMilli(),
+				User:           "alice",
+				Groups:         []string{"dev", "ml"},
+				State:          "Running",
+				StateLog: []*dao.StateDAOInfo{
+					{Time: start.Add(-30 * time.Minute).UnixMilli(), ApplicationState: "Running"},
+				},
+				Allocations: []*dao.AllocationDAOInfo{
+					{
+						AllocationKey:    "alloc-1",
+						NodeID:           "node-1",
+						AllocationTime:   start.Add(-30 * time.Minute).UnixNano(),
+						ResourcePerAlloc: map[string]int64{"vcore": 2000, "memory": 4 * gib},
+					},
+					{
+						AllocationKey:    "alloc-2",
+						NodeID:           "node-1",
+						AllocationTime:   start.Add(time.Hour).UnixNano(),
+						ResourcePerAlloc: map[string]int64{"vcore": 1000, "nvidia.com/gpu": 1},
+					},
+				},
+			},
+		},
+		{
+			// completed at the end of the first hour
+			Metadata: model.Metadata{CreatedAtNano: start.UnixNano()},
+			ApplicationDAOInfo: dao.ApplicationDAOInfo{
+				ID:             ulid.Make().String(),
+				ApplicationID:  "app-bob",
+				PartitionID:    "1",
+				Partition:      "default",
+				QueueName:      "root.batch",
+				SubmissionTime: start.UnixMilli(),
+				FinishedTime:   util.ToPtr(start.Add(time.Hour).UnixMilli()),
+				User:           "bob",
+				State:          "Completed",
+				StateLog: []*dao.StateDAOInfo{
+					{Time: start.Add(10 * time.Minute).UnixMilli(), ApplicationState: "Running"},
+					{Time: start.Add(time.Hour).UnixMilli(), ApplicationState: "Completed"},
+				},
+				Allocations: []*dao.AllocationDAOInfo{
+					{
+						AllocationKey:    "alloc-3",
+						NodeID:           "node-2",
+						AllocationTime:   start.UnixNano(),
+						ResourcePerAlloc: map[string]int64{"vcore": 4000},
+					},
+				},
+			},
+		},
+	}
+	for _, app := range apps {
+		require.NoError(rs.T(), repo.InsertApplication(ctx, app))
+	}
+	require.NoError(rs.T(), repo.InsertEvent(ctx, &model.Event{
+		TimestampNano: start.Add(30 * time.Minute).UnixNano(),
+		Type:          "APP",
+		ObjectID:      "app-alice",
+		ReferenceID:   "alloc-1",
+		ChangeType:    "REMOVE",
+		ChangeDetail:  "ALLOC_CANCEL",
+	}))
+}
+
+func (rs *RollupsIntTest) TearDownSuite() {
+	rs.pool.Close()
+}
+
+func (rs *RollupsIntTest) TestComputeRollup() {
+	ctx := context.Background()
+	now := rs.start.Add(3 * time.Hour).UnixNano()
+
+	watermark, err := rs.repo.GetRollupWatermark(ctx, RollupUsage, 24*time.Hour)
+	require.NoError(rs.T(), err)
+	require.Nil(rs.T(), watermark)
+
+	err = rs.repo.ComputeRollup(ctx, RollupUsage, 24*time.Hour, rs.start.Add(time.Hour), rs.start.Add(25*time.Hour), now)
+	require.Error(rs.T(), err, "the period is not aligned to the width")
+	err = rs.repo.ComputeRollup(ctx, RollupUsage, 2*time.Hour, rs.start, rs.start.Add(2*time.Hour), now)
+	require.Error(rs.T(), err, "the width is not a width of the rollups")
+
+	// the covered period is extended, and computing a bucket again replaces its rows
+	for _, from := range []time.Time{rs.start.Add(24 * time.Hour), rs.start, rs.start} {
+		err = rs.repo.ComputeRollup(ctx, RollupUsage, 24*time.Hour, from, from.Add(24*time.Hour), now)
+		require.NoError(rs.T(), err)
+	}
+	watermark, err = rs.repo.GetRollupWatermark(ctx, RollupUsage, 24*time.Hour)
+	require.NoError(rs.T(), err)
+	require.NotNil(rs.T(), watermark)
+	assert.Equal(rs.T(), rs.start.UnixNano(), watermark.StartNano)
+	assert.Equal(rs.T(), rs.start.Add(48*time.Hour).UnixNano(), watermark.WatermarkNano)
+	assert.Equal(rs.T(), now, watermark.UpdatedAtNano)
+
+	var rows int
+	err = rs.pool.QueryRow(ctx, `SELECT COUNT(*) FROM usage_rollups WHERE width_seconds = 86400`).Scan(&rows)
+	require.NoError(rs.T(), err)
+	assert.Equal(rs.T(), 3, rows, "the queues of alice and bob were used on the first day, the queue of alice on the second day")
+}
+
+func (rs *RollupsIntTest) TestGetUsageFromRollups() {
+	ctx := context.Background()
+	filters := UsageFilters{
+		Start:       rs.start.Add(-30 * time.Minute),
+		End:         rs.start.Add(150 * time.Minute),
+		GPUResource: "nvidia.com/gpu",
+	}
+	expected := make(map[UsageGroupBy][]*model.Usage)
+	for _, groupBy := range UsageGroupByValues {
+		filters.GroupBy = groupBy
+		usage, err := rs.repo.GetUsage(ctx, filters)
+		require.NoError(rs.T(), err)
+		require.NotEmpty(rs.T(), usage)
+		expected[groupBy] = usage
+	}
+
+	// bob ran entirely during the rolled up hours, so his usage is only read from the rollups
+	err := rs.repo.ComputeRollup(ctx, RollupUsage, time.Hour, rs.start, rs.start.Add(2*time.Hour), rs.start.UnixNano())
+	require.NoError(rs.T(), err)
+
+	for _, groupBy := range UsageGroupByValues {
+		filters.GroupBy = groupBy
+		usage, err := rs.repo.GetUsage(ctx, filters)
+		require.NoError(rs.T(), err)
+		require.Len(rs.T(), usage, len(expected[groupBy]), groupBy)
+		for i, e := range expected[groupBy] {
+			assert.Equal(rs.T(), e.Key, usage[i].Key, groupBy)
+			assert.Equal(rs.T(), e.Applications, usage[i].Applications, groupBy)
+			assert.InDelta(rs.T(), e.VcoreSeconds, usage[i].VcoreSeconds, 1e-6, groupBy)
+			assert.InDelta(rs.T(), e.MemoryGiBHours, usage[i].MemoryGiBHours, 1e-6, groupBy)
+			assert.InDelta(rs.T(), e.GPUHours, usage[i].GPUHours, 1e-6, groupBy)
+		}
+	}
+}
+
+func (rs *RollupsIntTest) TestGetUserSharesFromRollups() {
+	ctx := context.Background()
+	filters := FairnessFilters{
+		Start: rs.start.Add(-30 * time.Minute),
+		End:   rs.start.Add(150 * time.Minute),
+	}
+	// the shares are first computed from the allocations only
+	_, err := rs.pool.Exec(ctx, "DELETE FROM rollup_watermarks WHERE rollup = 'usage'")
+	require.NoError(rs.T(), err)
+	expected, err := rs.repo.GetUserShares(ctx, filters)
+	require.NoError(rs.T(), err)
+	require.Len(rs.T(), expected, 2)
+
+	err = rs.repo.ComputeRollup(ctx, RollupUsage, time.Hour, rs.start, rs.start.Add(2*time.Hour), rs.start.UnixNano())
+	require.NoError(rs.T(), err)
+
+	users, err := rs.repo.GetUserShares(ctx, filters)
+	require.NoError(rs.T(), err)
+	require.Len(rs.T(), users, len(expected))
+	for i := range expected {
+		assert.Equal(rs.T(), expected[i].User, users[i].User)
+		require.Len(rs.T(), users[i].AverageResource, len(expected[i].AverageResource))
+		for resource, average := range expected[i].AverageResource {
+			assert.InDelta(rs.T(), average, users[i].AverageResource[resource], 1e-6)
+		}
+	}
+}
+
+func (rs *RollupsIntTest) TestGetApplicationsTimelineFromRollups() {
+	ctx := context.Background()
+	filters := TimelineFilters{
+		Start: rs.start.Add(-time.Hour),
+		End:   rs.start.Add(3 * time.Hour),
+		Step:  time.Hour,
+		Queue: util.ToPtr("root"),
+	}
+	expected, err := rs.repo.GetApplicationsTimeline(ctx, filters)
+	require.NoError(rs.T(), err)
+
+	err = rs.repo.ComputeRollup(ctx, RollupApplicationStates, time.Hour, rs.start, rs.start.Add(2*time.Hour), rs.start.UnixNano())
+	require.NoError(rs.T(), err)
+
+	points, err := rs.repo.GetApplicationsTimeline(ctx, filters)
+	require.NoError(rs.T(), err)
+	assert.Equal(rs.T(), expected, points)
+}
+
+func (rs *RollupsIntTest) TestGetNodeUtilization() {
+	ctx := context.Background()
+	filters := NodeUtilizationFilters{Start: rs.start, End: rs.start.Add(2 * time.Hour)}
+
+	nodes, err := rs.repo.GetNodeUtilization(ctx, filters)
+	require.NoError(rs.T(), err)
+	require.Empty(rs.T(), nodes, "the rollups were not computed yet")
+
+	err = rs.repo.ComputeRollup(ctx, RollupNodeUtilization, time.Hour, rs.start, rs.start.Add(2*time.Hour), rs.start.UnixNano())
+	require.NoError(rs.T(), err)
+
+	nodes, err = rs.repo.GetNodeUtilization(ctx, filters)
+	require.NoError(rs.T(), err)
+	require.Len(rs.T(), nodes, 2)
+	assert.Equal(rs.T(), "node-1", nodes[0].NodeID)
+	assert.Equal(rs.T(), "default", nodes[0].Partition)
+	assert.InDelta(rs.T(), 2, nodes[0].Hours, 1e-9)
+	// 2 vcores during 30 minutes and 1 vcore during 1 hour out of 8 vcores during 2 hours
+	assert.InDelta(rs.T(), 0.125, nodes[0].Utilization["vcore"], 1e-9)
+	assert.Equal(rs.T(), "node-2", nodes[1].NodeID)
+	// 4 vcores during 1 hour out of 4 vcores during 2 hours
+	assert.InDelta(rs.T(), 0.5, nodes[1].Utilization["vcore"], 1e-9)
+
+	nodes, err = rs.repo.GetNodeUtilization(ctx, NodeUtilizationFilters{
+		Start:     rs.start,
+		End:       rs.start.Add(2 * time.Hour),
+		Partition: util.ToPtr("other"),
+	})
+	require.NoError(rs.T(), err)
+	require.Empty(rs.T(), nodes)
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/G-Research/unicorn-history-server/internal/model"
+)
+
+// The search queries select the rows in which any of the searched fields contains the query (ILIKE),
+// or contains a word which is similar to it (pg_trgm word similarity, '<%'). Both can use the trigram indexes.
+// Each row is ranked by its best matching field: the mean of the word similarity,
+// which is high if the query is a fragment of the field, and the similarity, which is high if the whole field matches.
+// Deleted rows are searched as well, since they are part of the history.
+
+const searchApplicationsQuery = `
+SELECT a.id, a.app_id, a.partition_id, a.deleted_at_nano IS NOT NULL, m.field, m.value, m.score
+FROM applications a
+CROSS JOIN LATERAL (
+	SELECT f.field, f.value, ((word_similarity(@query, f.value) + similarity(@query, f.value)) / 2)::DOUBLE PRECISION AS score
+	FROM (VALUES
+		('applicationId', a.app_id),
+		('user', a."user"),
+		('queueName', a.queue_name),
+		('rejectedMessage', a.rejected_message)
+	) AS f(field, value)
+	WHERE f.value ILIKE @pattern OR @query <% f.value
+	ORDER BY score DESC
+	LIMIT 1
+) m
+WHERE a.app_id ILIKE @pattern OR @query <% a.app_id
+	OR a."user" ILIKE @pattern OR @query <% a."user"
+	OR a.queue_name ILIKE @pattern OR @query <% a.queue_name
+	OR a.rejected_message ILIKE @pattern OR @query <% a.rejected_message
+ORDER BY m.score DESC, a.id DESC
+LIMIT @limit`
+
+const searchQueuesQuery = `
+SELECT q.id, q.queue_name, q.partition_id, q.deleted_at_nano IS NOT NULL, 'queueName', q.queue_name,
+	((word_similarity(@query, q.queue_name) + similarity(@query, q.queue_name)) / 2)::DOUBLE PRECISION AS score
+FROM queues q
+WHERE q.queue_name ILIKE @pattern OR @query <% q.queue_name
+ORDER BY score DESC, q.id DESC
+LIMIT @limit`
+
+const searchNodesQuery = `
+SELECT n.id, n.node_id, n.partition_id, n.deleted_at_nano IS NOT NULL, m.field, m.value, m.score
+FROM nodes n
+CROSS JOIN LATERAL (
+	SELECT f.field, f.value, ((word_similarity(@query, f.value) + similarity(@query, f.value)) / 2)::DOUBLE PRECISION AS score
+	FROM (
+		VALUES ('nodeId', n.node_id), ('hostName', n.host_name), ('rackName', n.rack_name)
+		UNION ALL
+		SELECT 'attributes.' || a.key, a.value FROM jsonb_each_text(n.attributes) a
+	) AS f(field, value)
+	WHERE f.value ILIKE @pattern OR @query <% f.value
+	ORDER BY score DESC
+	LIMIT 1
+) m
+WHERE n.node_id ILIKE @pattern OR @query <% n.node_id
+	OR n.host_name ILIKE @pattern OR @query <% n.host_name
+	OR n.rack_name ILIKE @pattern OR @query <% n.rack_name
+	OR n.attributes::TEXT ILIKE @pattern OR @query <% n.attributes::TEXT
+ORDER BY m.score DESC, n.id DESC
+LIMIT @limit`
+
+// Search returns the applications, queues and nodes which match the query, at most limit of each type.
+func (s *PostgresRepository) Search(ctx context.Context, query string, limit int) (*model.SearchResults, error) {
+	results := &model.SearchResults{Query: query}
+	var err error
+	results.Applications, err = s.search(ctx, searchApplicationsQuery, model.SearchResultTypeApplication, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	results.Queues, err = s.search(ctx, searchQueuesQuery, model.SearchResultTypeQueue, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	results.Nodes, err = s.search(ctx, searchNodesQuery, model.SearchResultTypeNode, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (s *PostgresRepository) search(
+	ctx context.Context,
+	q string,
+	resultType model.SearchResultType,
+	query string,
+	limit int,
+) ([]*model.SearchResult, error) {
+	rows, err := s.dbpool.Query(ctx, q, pgx.NamedArgs{
+		"query":   query,
+		"pattern": "%" + escapeLike(query) + "%",
+		"limit":   limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not search %ss in DB: %v", resultType, err)
+	}
+	defer rows.Close()
+
+	results := []*model.SearchResult{}
+	for rows.Next() {
+		result := model.SearchResult{Type: resultType}
+		if err := rows.Scan(
+			&result.ID,
+			&result.Name,
+			&result.PartitionID,
+			&result.Deleted,
+			&result.MatchedField,
+			&result.MatchedValue,
+			&result.Score,
+		); err != nil {
+			return nil, fmt.Errorf("could not scan %s search result from DB: %v", resultType, err)
+		}
+		results = append(results, &result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not search %ss in DB: %v", resultType, err)
+	}
+	return results, nil
+}
@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/G-Research/yunikorn-core/pkg/webservice/dao"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/G-Research/unicorn-history-server/internal/model"
+	"github.com/G-Research/unicorn-history-server/internal/util"
+)
+
+type SearchIntTest struct {
+	suite.Suite
+	pool *pgxpool.Pool
+	repo *PostgresRepository
+}
+
+func (ss *SearchIntTest) SetupSuite() {
+	require.NotNil(ss.T(), ss.pool)
+	repo, err := NewPostgresRepository(ss.pool)
+	require.NoError(ss.T(), err)
+	ss.repo = repo
+
+	ctx := context.Background()
+	now := time.Now()
+
+	apps := []*model.Application{
+		{
+			Metadata: model.Metadata{CreatedAtNano: now.UnixNano()},
+			ApplicationDAOInfo: dao.ApplicationDAOInfo{
+				ID:             ulid.Make().String(),
+				ApplicationID:  "spark-pi-2024",
+				PartitionID:    "1",
+				Partition:      "default",
+				QueueID:        util.ToPtr("1"),
+				QueueName:      "root.ml.training",
+				SubmissionTime: now.UnixMilli(),
+				User:           "alice",
+			},
+		},
+		{
+			Metadata: model.Metadata{CreatedAtNano: now.UnixNano(), DeletedAtNano: util.ToPtr(now.UnixNano())},
+			ApplicationDAOInfo: dao.ApplicationDAOInfo{
+				ID:              ulid.Make().String(),
+				ApplicationID:   "flink-job",
+				PartitionID:     "1",
+				Partition:       "default",
+				QueueID:         util.ToPtr("1"),
+				QueueName:       "root.batch",
+				SubmissionTime:  now.UnixMilli(),
+				User:            "bob",
+				RejectedMessage: "application rejected: maximum quota exceeded",
+			},
+		},
+		{
+			Metadata: model.Metadata{CreatedAtNano: now.UnixNano()},
+			ApplicationDAOInfo: dao.ApplicationDAOInfo{
+				ID:             ulid.Make().String(),
+				ApplicationID:  "nightly",
+				PartitionID:    "1",
+				Partition:      "default",
+				QueueID:        util.ToPtr("1"),
+				QueueName:      "root.batch",
+				SubmissionTime: now.UnixMilli(),
+				User:           "carol",
+			},
+		},
+		{
+			Metadata: model.Metadata{CreatedAtNano: now.UnixNano()},
+			ApplicationDAOInfo: dao.ApplicationDAOInfo{
+				ID:             ulid.Make().String(),
+				ApplicationID:  "nightly-backfill-2024-12-01",
+				PartitionID:    "1",
+				Partition:      "default",
+				QueueID:        util.ToPtr("1"),
+				QueueName:      "root.batch",
+				SubmissionTime: now.UnixMilli(),
+				User:           "carol",
+			},
+		},
+	}
+	for _, app := range apps {
+		require.NoError(ss.T(), repo.InsertApplication(ctx, app))
+	}
+
+	queue := &model.Queue{
+		Metadata: model.Metadata{CreatedAtNano: now.UnixNano()},
+		PartitionQueueDAOInfo: dao.PartitionQueueDAOInfo{
+			ID:          ulid.Make().String(),
+			PartitionID: "1",
+			QueueName:   "root.ml.training",
+		},
+	}
+	require.NoError(ss.T(), repo.InsertQueue(ctx, queue))
+
+	node := &model.Node{
+		Metadata: model.Metadata{CreatedAtNano: now.UnixNano()},
+		NodeDAOInfo: dao.NodeDAOInfo{
+			ID:          ulid.Make().String(),
+			NodeID:      "worker-17",
+			PartitionID: "1",
+			HostName:    "ip-10-0-0-17.ec2.internal",
+			Attributes:  map[string]string{"instance-type": "p3.2xlarge"},
+		},
+	}
+	require.NoError(ss.T(), repo.InsertNode(ctx, node))
+}
+
+func (ss *SearchIntTest) TearDownSuite() {
+	ss.pool.Close()
+}
+
+func (ss *SearchIntTest) TestSearch() {
+	ctx := context.Background()
+	tests := []struct {
+		name         string
+		query        string
+		resultType   model.SearchResultType
+		expectedName string
+		matchedField string
+		deleted      bool
+	}{
+		{
+			name:         "Fragment of application ID",
+			query:        "spark-pi",
+			resultType:   model.SearchResultTypeApplication,
+			expectedName: "spark-pi-2024",
+			matchedField: "applicationId",
+		},
+		{
+			name:         "Fragment of rejection message of a deleted application",
+			query:        "QUOTA",
+			resultType:   model.SearchResultTypeApplication,
+			expectedName: "flink-job",
+			matchedField: "rejectedMessage",
+			deleted:      true,
+		},
+		{
+			name:         "Exact match is ranked first",
+			query:        "nightly",
+			resultType:   model.SearchResultTypeApplication,
+			expectedName: "nightly",
+			matchedField: "applicationId",
+		},
+		{
+			name:         "Queue",
+			query:        "ml.train",
+			resultType:   model.SearchResultTypeQueue,
+			expectedName: "root.ml.training",
+			matchedField: "queueName",
+		},
+		{
+			name:         "Host name",
+			query:        "10-0-0-17",
+			resultType:   model.SearchResultTypeNode,
+			expectedName: "worker-17",
+			matchedField: "hostName",
+		},
+		{
+			name:         "Node attribute",
+			query:        "p3.2xl",
+			resultType:   model.SearchResultTypeNode,
+			expectedName: "worker-17",
+			matchedField: "attributes.instance-type",
+		},
+	}
+
+	for _, tt := range tests {
+		ss.Run(tt.name, func() {
+			results, err := ss.repo.Search(ctx, tt.query, 10)
+			require.NoError(ss.T(), err)
+			assert.Equal(ss.T(), tt.query, results.Query)
+
+			var found []*model.SearchResult
+			switch tt.resultType {
+			case model.SearchResultTypeApplication:
+				found = results.Applications
+			case model.SearchResultTypeQueue:
+				found = results.Queues
+			case model.SearchResultTypeNode:
+				found = results.Nodes
+			}
+			require.NotEmpty(ss.T(), found)
+			assert.Equal(ss.T(), tt.resultType, found[0].Type)
+			assert.Equal(ss.T(), tt.expectedName, found[0].Name)
+			assert.Equal(ss.T(), tt.matchedField, found[0].MatchedField)
+			assert.Equal(ss.T(), tt.deleted, found[0].Deleted)
+			assert.Equal(ss.T(), "1", found[0].PartitionID)
+			for i := 1; i < len(found); i++ {
+				assert.GreaterOrEqual(ss.T(), found[i-1].Score, found[i].Score)
+			}
+		})
+	}
+}
+
+func (ss *SearchIntTest) TestSearchNoResults() {
+	results, err := ss.repo.Search(context.Background(), "does-not-exist-anywhere", 10)
+	require.NoError(ss.T(), err)
+	assert.Empty(ss.T(), results.Applications)
+	assert.Empty(ss.T(), results.Queues)
+	assert.Empty(ss.T(), results.Nodes)
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/G-Research/unicorn-history-server/internal/database/sql"
+)
+
+// SortKey is a field by which a list is sorted.
+type SortKey struct {
+	Field      string
+	Descending bool
+}
+
+func (k SortKey) direction() sql.OrderDirection {
+	if k.Descending {
+		return sql.OrderByDescending
+	}
+	return sql.OrderByAscending
+}
+
+// sortField maps a field of the API to the SQL expression by which the rows are sorted,
+// and to the value of the field of an item, which is stored in the cursor.
+// The expression must not be nullable, otherwise keyset pagination skips rows.
+type sortField[T any] struct {
+	expression string
+	value      func(*T) any
+}
+
+// resourceSortField is a resource field (e.g. 'usedResource'), which is sorted by one of its resource keys
+// (e.g. 'usedResource.memory'). Missing resources are sorted as 0.
+type resourceSortField[T any] struct {
+	column string
+	value  func(*T) map[string]int64
+}
+
+// resourceKeyRegexp matches the valid resource keys, e.g. 'memory', 'vcore' or 'nvidia.com/gpu'.
+var resourceKeyRegexp = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9_./-]*[A-Za-z0-9])?$`)
+
+// SortFields is the allow-list of the fields by which a list of T can be sorted.
+type SortFields[T any] struct {
+	fields    map[string]sortField[T]
+	resources map[string]resourceSortField[T]
+	// defaultSort is used if no sort keys are requested.
+	defaultSort []SortKey
+	// id is the field which identifies an item uniquely. It is added as the last sort key to make the order deterministic.
+	id string
+}
+
+// Names returns the names of the fields by which the list can be sorted.
+// Resource fields are returned as '<field>.<resource>'.
+func (s *SortFields[T]) Names() []string {
+	names := make([]string, 0, len(s.fields)+len(s.resources))
+	for name := range s.fields {
+		names = append(names, name)
+	}
+	for name := range s.resources {
+		names = append(names, name+".<resource>")
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks that the list can be sorted by the sort keys,
+// and that the cursor contains a value for every sort key of the resulting order.
+func (s *SortFields[T]) Validate(sortKeys []SortKey, after Cursor) error {
+	for _, key := range sortKeys {
+		if _, err := s.field(key.Field); err != nil {
+			return err
+		}
+	}
+	if after != nil && len(after) != len(s.keys(sortKeys)) {
+		return fmt.Errorf("cursor does not match the sort order")
+	}
+	return nil
+}
+
+// apply adds the ORDER BY clause of the sort keys to the sql query.
+func (s *SortFields[T]) apply(builder *sql.Builder, sortKeys []SortKey) error {
+	for _, key := range s.keys(sortKeys) {
+		field, err := s.field(key.Field)
+		if err != nil {
+			return err
+		}
+		builder.OrderBy(field.expression, key.direction())
+	}
+	return nil
+}
+
+// Cursor returns the cursor which points to the position after the item in a list sorted by the sort keys.
+// The sort keys must be valid.
+func (s *SortFields[T]) Cursor(sortKeys []SortKey, item *T) Cursor {
+	keys := s.keys(sortKeys)
+	cursor := make(Cursor, 0, len(keys))
+	for _, key := range keys {
+		field, err := s.field(key.Field)
+		if err != nil {
+			return nil
+		}
+		cursor = append(cursor, field.value(item))
+	}
+	return cursor
+}
+
+// keys returns the sort keys of the resulting order: the requested (or default) sort keys followed by the id.
+func (s *SortFields[T]) keys(sortKeys []SortKey) []SortKey {
+	if len(sortKeys) == 0 {
+		sortKeys = s.defaultSort
+	}
+	for _, key := range sortKeys {
+		if key.Field == s.id {
+			return sortKeys
+		}
+	}
+	keys := make([]SortKey, 0, len(sortKeys)+1)
+	keys = append(keys, sortKeys...)
+	return append(keys, SortKey{Field: s.id, Descending: true})
+}
+
+func (s *SortFields[T]) field(name string) (sortField[T], error) {
+	if field, ok := s.fields[name]; ok {
+		return field, nil
+	}
+	prefix, resource, ok := strings.Cut(name, ".")
+	if !ok {
+		return sortField[T]{}, fmt.Errorf("cannot sort by %q, allowed fields are: %s", name, strings.Join(s.Names(), ", "))
+	}
+	resourceField, ok := s.resources[prefix]
+	if !ok {
+		return sortField[T]{}, fmt.Errorf("cannot sort by %q, allowed fields are: %s", name, strings.Join(s.Names(), ", "))
+	}
+	if !resourceKeyRegexp.MatchString(resource) {
+		return sortField[T]{}, fmt.Errorf("cannot sort by %q: invalid resource %q", name, resource)
+	}
+	return sortField[T]{
+		// the resource key is validated, so it can be used as a literal
+		expression: resourceExpression(resourceField.column, resource),
+		value: func(item *T) any {
+			return resourceField.value(item)[resource]
+		},
+	}, nil
+}
+
+// resourceExpression returns the SQL expression of a resource in a JSONB resource column.
+// The expression matches the expression indexes of the resource columns.
+func resourceExpression(column, resource string) string {
+	return "COALESCE((" + column + "->>'" + resource + "')::BIGINT, 0)"
+}
+
+// int64Value converts a string which contains a number (like the totals of the history) to the int64 value of the cursor.
+func int64Value(s string) any {
+	i, _ := strconv.ParseInt(s, 10, 64)
+	return i
+}
+
+// valueOrZero returns the value of a nullable field, or the zero value if it is null.
+func valueOrZero[V any](v *V) V {
+	if v == nil {
+		var zero V
+		return zero
+	}
+	return *v
+}
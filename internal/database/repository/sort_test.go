@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/G-Research/yunikorn-core/pkg/webservice/dao"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/G-Research/unicorn-history-server/internal/database/sql"
+	"github.com/G-Research/unicorn-history-server/internal/model"
+)
+
+func TestSortFieldsApply(t *testing.T) {
+	tests := []struct {
+		name          string
+		sortKeys      []SortKey
+		expectedQuery string
+		expectedErr   bool
+	}{
+		{
+			name:          "Default sort",
+			expectedQuery: "SELECT * FROM applications ORDER BY COALESCE(submission_time, 0) DESC, id DESC",
+		},
+		{
+			name:     "Multiple keys",
+			sortKeys: []SortKey{{Field: "submissionTime", Descending: true}, {Field: "user"}},
+			expectedQuery: "SELECT * FROM applications " +
+				`ORDER BY COALESCE(submission_time, 0) DESC, COALESCE("user", '') ASC, id DESC`,
+		},
+		{
+			name:          "Id key is not repeated",
+			sortKeys:      []SortKey{{Field: "id"}},
+			expectedQuery: "SELECT * FROM applications ORDER BY id ASC",
+		},
+		{
+			name:     "Resource key",
+			sortKeys: []SortKey{{Field: "usedResource.memory", Descending: true}},
+			expectedQuery: "SELECT * FROM applications " +
+				"ORDER BY COALESCE((used_resource->>'memory')::BIGINT, 0) DESC, id DESC",
+		},
+		{
+			name:        "Unknown field",
+			sortKeys:    []SortKey{{Field: "requests"}},
+			expectedErr: true,
+		},
+		{
+			name:        "Unknown resource field",
+			sortKeys:    []SortKey{{Field: "requests.memory"}},
+			expectedErr: true,
+		},
+		{
+			name:        "Invalid resource key",
+			sortKeys:    []SortKey{{Field: "usedResource.memory')::BIGINT; --"}},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := sql.NewBuilder().SelectAll("applications", "")
+			err := ApplicationSortFields.apply(builder, tt.sortKeys)
+			if tt.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			query, _ := builder.Build()
+			assert.Equal(t, tt.expectedQuery, query)
+		})
+	}
+}
+
+func TestSortFieldsValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		sortKeys    []SortKey
+		after       Cursor
+		expectedErr bool
+	}{
+		{name: "Default sort"},
+		{name: "Default sort with cursor", after: Cursor{"node-1", "id-1"}},
+		{name: "Resource key with cursor", sortKeys: []SortKey{{Field: "available.nvidia.com/gpu"}}, after: Cursor{int64(1), "id-1"}},
+		{name: "Unknown field", sortKeys: []SortKey{{Field: "attributes"}}, expectedErr: true},
+		{
+			name:        "Cursor of another sort order",
+			sortKeys:    []SortKey{{Field: "hostName"}, {Field: "rackName"}},
+			after:       Cursor{"node-1", "id-1"},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NodeSortFields.Validate(tt.sortKeys, tt.after)
+			assert.Equal(t, tt.expectedErr, err != nil)
+		})
+	}
+}
+
+func TestSortFieldsCursor(t *testing.T) {
+	app := &model.Application{
+		ApplicationDAOInfo: dao.ApplicationDAOInfo{
+			ID:             "id-1",
+			SubmissionTime: 1000,
+			User:           "user-1",
+			UsedResource:   map[string]int64{"memory": 1024},
+		},
+	}
+
+	assert.Equal(t, Cursor{int64(1000), "id-1"}, ApplicationSortFields.Cursor(nil, app))
+	assert.Equal(t,
+		Cursor{"user-1", int64(1024), int64(0), "id-1"},
+		ApplicationSortFields.Cursor([]SortKey{{Field: "user"}, {Field: "usedResource.memory"}, {Field: "finishedTime"}}, app),
+	)
+	assert.Nil(t, ApplicationSortFields.Cursor([]SortKey{{Field: "unknown"}}, app))
+}
+
+func TestSortFieldsNames(t *testing.T) {
+	assert.Equal(t,
+		[]string{"id", "timestamp", "totalApplications"},
+		AppHistorySortFields.Names(),
+	)
+	assert.Contains(t, NodeSortFields.Names(), "available.<resource>")
+}
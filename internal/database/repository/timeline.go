@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/G-Research/unicorn-history-server/internal/model"
+)
+
+// TimelineFilters select the applications and the points of a timeline of applications or containers.
+type TimelineFilters struct {
+	// Start and End are the period [Start, End) of the timeline, which has a point every Step from Start.
+	Start     time.Time
+	End       time.Time
+	Step      time.Duration
+	Partition *string
+	// Queue selects the applications in the subtree of a queue.
+	Queue *string
+	User  *string
+}
+
+func (f TimelineFilters) namedArgs() (pgx.NamedArgs, error) {
+	if f.Step <= 0 {
+		return nil, fmt.Errorf("invalid timeline step %s", f.Step)
+	}
+	args := pgx.NamedArgs{
+		"start":     f.Start.UnixNano(),
+		"end":       f.End.UnixNano(),
+		"step":      f.Step.Nanoseconds(),
+		"partition": f.Partition,
+		"user":      f.User,
+	}
+	maps.Copy(args, queueFilterArgs(f.Queue))
+	return args, nil
+}
+
+// timelineApplicationsFilter selects the applications a which match the filters of a timeline.
+var timelineApplicationsFilter = `
+	(@partition::TEXT IS NULL OR a.partition = @partition)
+	AND ` + queueSubtreeFilter("a.queue_name") + `
+	AND (@user::TEXT IS NULL OR a."user" = @user)`
+
+// timelineQuery counts the pending and running lifecycles l at every point of the timeline, and the lifecycles which
+// completed from the point until the next one. A lifecycle is pending from its submission until it started running,
+// and running until it ended. The argument is the common table expression of the lifecycles.
+const timelineQuery = `
+WITH points AS (
+	SELECT generate_series(@start::BIGINT, @end::BIGINT - 1, @step::BIGINT) AS t
+),
+%s
+SELECT
+	p.t,
+	COUNT(l.submitted) FILTER (WHERE l.submitted <= p.t AND (l.started IS NULL OR l.started > p.t)
+		AND (l.ended IS NULL OR l.ended > p.t)),
+	COUNT(l.submitted) FILTER (WHERE l.started <= p.t AND (l.ended IS NULL OR l.ended > p.t)),
+	COUNT(l.submitted) FILTER (WHERE l.completed AND l.ended >= p.t AND l.ended < p.t + @step)
+FROM points p
+LEFT JOIN lifecycles l ON l.submitted < p.t + @step AND (l.ended IS NULL OR l.ended >= p.t)
+GROUP BY p.t
+ORDER BY p.t`
+
+// applicationLifecycles are the lifecycles of the applications. An application starts running at the first
+// Running state of its state log, and ends when it finished or, if it never finished, when it was deleted.
+// It completed if it ended in the Completed state.
+var applicationLifecycles = `
+lifecycles AS (
+	SELECT
+		a.submission_time * 1000000 AS submitted,
+		(
+			SELECT MIN((s->>'time')::BIGINT) * 1000000
+			FROM jsonb_array_elements(COALESCE(a.state_log, '[]'::JSONB)) AS s
+			WHERE s->>'applicationState' = 'Running'
+		) AS started,
+		COALESCE(a.finished_time * 1000000, a.deleted_at_nano) AS ended,
+		a.state = 'Completed' AS completed,
+		a.partition,
+		a.queue_name,
+		COALESCE(a."user", '') AS "user"
+	FROM applications a
+	WHERE a.submission_time * 1000000 < @end
+		AND (COALESCE(a.finished_time * 1000000, a.deleted_at_nano) IS NULL
+			OR COALESCE(a.finished_time * 1000000, a.deleted_at_nano) >= @start)
+		AND ` + timelineApplicationsFilter + `
+)`
+
+// containerLifecycles are the lifecycles of the allocations and the pending asks of the applications.
+// An allocation is pending from its request time until its allocation time, and running until it was removed
+// (by the first REMOVE event of the allocation), or until its application ended, when it completed.
+// The asks are kept after they were allocated, so the asks of the allocations are skipped. Any other ask is pending
+// until it was allocated, cancelled or timed out (by the first REMOVE event of the ask), or until its application ended.
+var containerLifecycles = `
+lifecycles AS (
+	SELECT
+		COALESCE(NULLIF((al->>'requestTime')::BIGINT, 0), NULLIF((al->>'allocationTime')::BIGINT, 0),
+			a.submission_time * 1000000) AS submitted,
+		COALESCE(NULLIF((al->>'allocationTime')::BIGINT, 0), a.submission_time * 1000000) AS started,
+		COALESCE(removed.timestamp_nano, a.finished_time * 1000000, a.deleted_at_nano) AS ended,
+		TRUE AS completed
+	FROM applications a
+	CROSS JOIN LATERAL jsonb_array_elements(COALESCE(a.allocations, '[]'::JSONB)) AS al
+	CROSS JOIN LATERAL (
+		SELECT MIN(e.timestamp_nano) AS timestamp_nano
+		FROM events e
+		WHERE e.type = 'APP' AND e.object_id = a.app_id AND e.reference_id = al->>'allocationKey'
+			AND e.change_type = 'REMOVE' AND e.timestamp_nano >= COALESCE((al->>'allocationTime')::BIGINT, 0)
+	) removed
+	WHERE a.submission_time * 1000000 < @end AND ` + timelineApplicationsFilter + `
+	UNION ALL
+	SELECT
+		COALESCE(NULLIF((ask->>'requestTime')::BIGINT, 0), a.submission_time * 1000000) AS submitted,
+		NULL AS started,
+		COALESCE(removed.timestamp_nano, a.finished_time * 1000000, a.deleted_at_nano) AS ended,
+		FALSE AS completed
+	FROM applications a
+	CROSS JOIN LATERAL jsonb_array_elements(COALESCE(a.requests, '[]'::JSONB)) AS ask
+	CROSS JOIN LATERAL (
+		SELECT MIN(e.timestamp_nano) AS timestamp_nano
+		FROM events e
+		WHERE e.type = 'APP' AND e.object_id = a.app_id AND e.reference_id = ask->>'allocationKey'
+			AND e.change_type = 'REMOVE' AND e.change_detail IN ('REQUEST_ALLOC', 'REQUEST_CANCEL', 'REQUEST_TIMEOUT')
+	) removed
+	WHERE a.submission_time * 1000000 < @end AND ` + timelineApplicationsFilter + `
+		AND NOT COALESCE(a.allocations, '[]'::JSONB) @> jsonb_build_array(jsonb_build_object('allocationKey', ask->>'allocationKey'))
+)`
+
+// applicationStatesTimelineQuery reads the points of a timeline of applications from the application states rollups
+// of the width @width_seconds, which divides the step. The numbers of pending and running applications at a point
+// are those at the start of its bucket, and the applications which completed until the next point are summed.
+var applicationStatesTimelineQuery = `
+WITH points AS (
+	SELECT generate_series(@start::BIGINT, @end::BIGINT - 1, @step::BIGINT) AS t
+)
+SELECT
+	p.t,
+	COALESCE(SUM(a.pending) FILTER (WHERE a.bucket = p.t), 0)::BIGINT,
+	COALESCE(SUM(a.running) FILTER (WHERE a.bucket = p.t), 0)::BIGINT,
+	COALESCE(SUM(a.completed), 0)::BIGINT
+FROM points p
+LEFT JOIN application_state_rollups a ON a.width_seconds = @width_seconds
+	AND a.bucket >= p.t AND a.bucket < p.t + @step AND ` + timelineApplicationsFilter + `
+GROUP BY p.t
+ORDER BY p.t`
+
+// GetApplicationsTimeline returns the numbers of pending, running and completed applications which match the filters
+// at every point of the timeline. Deleted applications are included. The points which are covered by the application
+// states rollups are read from them.
+func (s *PostgresRepository) GetApplicationsTimeline(ctx context.Context, filters TimelineFilters) ([]*model.TimelinePoint, error) {
+	points, err := s.getApplicationsTimeline(ctx, filters)
+	if err != nil {
+		return nil, fmt.Errorf("could not get applications timeline from DB: %v", err)
+	}
+	return points, nil
+}
+
+// getApplicationsTimeline reads the points of the timeline from the application states rollups from the first point
+// which they cover until the last one, and derives the points before and after them from the applications.
+func (s *PostgresRepository) getApplicationsTimeline(ctx context.Context, filters TimelineFilters) ([]*model.TimelinePoint, error) {
+	query := fmt.Sprintf(timelineQuery, applicationLifecycles)
+	if filters.Step <= 0 {
+		return s.getTimeline(ctx, query, 0, filters)
+	}
+	// the end of the last point of the timeline
+	end := filters.Start.Add((filters.End.Sub(filters.Start) + filters.Step - 1) / filters.Step * filters.Step)
+	width, from, to, err := s.rolledUpPeriod(ctx, RollupApplicationStates, filters.Start, filters.Step, filters.Start, end)
+	if err != nil {
+		return nil, err
+	}
+	if width == 0 {
+		return s.getTimeline(ctx, query, 0, filters)
+	}
+
+	var points []*model.TimelinePoint
+	for _, part := range []struct {
+		start time.Time
+		end   time.Time
+		query string
+		width time.Duration
+	}{
+		{start: filters.Start, end: from, query: query},
+		{start: from, end: to, query: applicationStatesTimelineQuery, width: width},
+		{start: to, end: filters.End, query: query},
+	} {
+		if !part.start.Before(part.end) {
+			continue
+		}
+		partFilters := filters
+		partFilters.Start, partFilters.End = part.start, part.end
+		partPoints, err := s.getTimeline(ctx, part.query, part.width, partFilters)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, partPoints...)
+	}
+	return points, nil
+}
+
+// GetContainersTimeline returns the numbers of pending, running and completed containers of the applications which
+// match the filters at every point of the timeline. Deleted applications are included.
+func (s *PostgresRepository) GetContainersTimeline(ctx context.Context, filters TimelineFilters) ([]*model.TimelinePoint, error) {
+	points, err := s.getTimeline(ctx, fmt.Sprintf(timelineQuery, containerLifecycles), 0, filters)
+	if err != nil {
+		return nil, fmt.Errorf("could not get containers timeline from DB: %v", err)
+	}
+	return points, nil
+}
+
+// getTimeline returns the points of the timeline which are computed by the query, which reads the rollups of the width
+// if it is not 0.
+func (s *PostgresRepository) getTimeline(
+	ctx context.Context,
+	query string,
+	width time.Duration,
+	filters TimelineFilters,
+) ([]*model.TimelinePoint, error) {
+	args, err := filters.namedArgs()
+	if err != nil {
+		return nil, err
+	}
+	args["width_seconds"] = int64(width / time.Second)
+	rows, err := s.dbpool.Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []*model.TimelinePoint
+	for rows.Next() {
+		var p model.TimelinePoint
+		if err := rows.Scan(&p.Timestamp, &p.Pending, &p.Running, &p.Completed); err != nil {
+			return nil, err
+		}
+		points = append(points, &p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return points, nil
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/G-Research/yunikorn-core/pkg/webservice/dao"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/G-Research/unicorn-history-server/internal/model"
+	"github.com/G-Research/unicorn-history-server/internal/util"
+)
+
+type TimelineIntTest struct {
+	suite.Suite
+	pool  *pgxpool.Pool
+	repo  *PostgresRepository
+	start time.Time
+}
+
+func (ts *TimelineIntTest) SetupSuite() {
+	require.NotNil(ts.T(), ts.pool)
+	repo, err := NewPostgresRepository(ts.pool)
+	require.NoError(ts.T(), err)
+	ts.repo = repo
+
+	ctx := context.Background()
+	ts.start = time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	start := ts.start
+
+	apps := []*model.Application{
+		// submitted before the timeline, running during the first hour and completed during the third hour
+		{
+			Metadata: model.Metadata{CreatedAtNano: start.UnixNano()},
+			ApplicationDAOInfo: dao.ApplicationDAOInfo{
+				ID:             ulid.Make().String(),
+				ApplicationID:  "app-1",
+				PartitionID:    "1",
+				Partition:      "default",
+				QueueName:      "root.a",
+				User:           "alice",
+				State:          "Completed",
+				SubmissionTime: start.Add(-30 * time.Minute).UnixMilli(),
+				FinishedTime:   util.ToPtr(start.Add(150 * time.Minute).UnixMilli()),
+				StateLog: []*dao.StateDAOInfo{
+					{Time: start.Add(-30 * time.Minute).UnixMilli(), ApplicationState: "New"},
+					{Time: start.Add(30 * time.Minute).UnixMilli(), ApplicationState: "Running"},
+					{Time: start.Add(150 * time.Minute).UnixMilli(), ApplicationState: "Completed"},
+				},
+				Allocations: []*dao.AllocationDAOInfo{
+					{
+						AllocationKey:  "alloc-1",
+						RequestTime:    start.Add(-30 * time.Minute).UnixNano(),
+						AllocationTime: start.Add(30 * time.Minute).UnixNano(),
+					},
+				},
+				// the ask of the allocation is kept after it was allocated
+				Requests: []*dao.AllocationAskDAOInfo{
+					{AllocationKey: "alloc-1", RequestTime: start.Add(-30 * time.Minute).UnixNano()},
+				},
+			},
+		},
+		// submitted during the second hour and still pending, with an ask cancelled during the second hour
+		{
+			Metadata: model.Metadata{CreatedAtNano: start.UnixNano()},
+			ApplicationDAOInfo: dao.ApplicationDAOInfo{
+				ID:             ulid.Make().String(),
+				ApplicationID:  "app-2",
+				PartitionID:    "1",
+				Partition:      "default",
+				QueueName:      "root.b",
+				User:           "bob",
+				State:          "Accepted",
+				SubmissionTime: start.Add(90 * time.Minute).UnixMilli(),
+				Requests: []*dao.AllocationAskDAOInfo{
+					{AllocationKey: "ask-1", RequestTime: start.Add(90 * time.Minute).UnixNano()},
+					{AllocationKey: "ask-2", RequestTime: start.Add(90 * time.Minute).UnixNano()},
+				},
+			},
+		},
+	}
+	for _, app := range apps {
+		require.NoError(ts.T(), repo.InsertApplication(ctx, app))
+	}
+	require.NoError(ts.T(), repo.InsertEvent(ctx, &model.Event{
+		TimestampNano: start.Add(100 * time.Minute).UnixNano(),
+		Type:          "APP",
+		ObjectID:      "app-2",
+		ReferenceID:   "ask-2",
+		ChangeType:    "REMOVE",
+		ChangeDetail:  "REQUEST_CANCEL",
+	}))
+}
+
+func (ts *TimelineIntTest) TearDownSuite() {
+	ts.pool.Close()
+}
+
+func (ts *TimelineIntTest) TestGetApplicationsTimeline() {
+	ctx := context.Background()
+	start := ts.start
+	filters := TimelineFilters{Start: start, End: start.Add(3 * time.Hour), Step: time.Hour}
+	points, err := ts.repo.GetApplicationsTimeline(ctx, filters)
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), []*model.TimelinePoint{
+		{Timestamp: start.UnixNano(), Pending: 1},
+		{Timestamp: start.Add(time.Hour).UnixNano(), Running: 1},
+		{Timestamp: start.Add(2 * time.Hour).UnixNano(), Pending: 1, Running: 1, Completed: 1},
+	}, points)
+
+	filters.User = util.ToPtr("bob")
+	points, err = ts.repo.GetApplicationsTimeline(ctx, filters)
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), []*model.TimelinePoint{
+		{Timestamp: start.UnixNano()},
+		{Timestamp: start.Add(time.Hour).UnixNano()},
+		{Timestamp: start.Add(2 * time.Hour).UnixNano(), Pending: 1},
+	}, points)
+
+	_, err = ts.repo.GetApplicationsTimeline(ctx, TimelineFilters{Start: start, End: start.Add(time.Hour)})
+	require.Error(ts.T(), err)
+}
+
+func (ts *TimelineIntTest) TestGetContainersTimeline() {
+	ctx := context.Background()
+	start := ts.start
+	filters := TimelineFilters{Start: start, End: start.Add(3 * time.Hour), Step: time.Hour, Queue: util.ToPtr("root")}
+	points, err := ts.repo.GetContainersTimeline(ctx, filters)
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), []*model.TimelinePoint{
+		{Timestamp: start.UnixNano(), Pending: 1},
+		{Timestamp: start.Add(time.Hour).UnixNano(), Running: 1},
+		{Timestamp: start.Add(2 * time.Hour).UnixNano(), Pending: 1, Running: 1, Completed: 1},
+	}, points)
+
+	filters.Queue = util.ToPtr("root.a")
+	filters.Start = start.Add(time.Hour)
+	points, err = ts.repo.GetContainersTimeline(ctx, filters)
+	require.NoError(ts.T(), err)
+	assert.Equal(ts.T(), []*model.TimelinePoint{
+		{Timestamp: start.Add(time.Hour).UnixNano(), Running: 1},
+		{Timestamp: start.Add(2 * time.Hour).UnixNano(), Running: 1, Completed: 1},
+	}, points)
+}
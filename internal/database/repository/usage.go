@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/G-Research/unicorn-history-server/internal/model"
+)
+
+// UsageGroupBy is the field by which the usage of resources is grouped.
+type UsageGroupBy string
+
+const (
+	UsageGroupByApplication UsageGroupBy = "application"
+	UsageGroupByUser        UsageGroupBy = "user"
+	// UsageGroupByGroup groups the usage by the groups of the users.
+	// The usage of an application is added to each group of its user.
+	UsageGroupByGroup     UsageGroupBy = "group"
+	UsageGroupByQueue     UsageGroupBy = "queue"
+	UsageGroupByPartition UsageGroupBy = "partition"
+)
+
+// UsageGroupByValues are the fields by which the usage can be grouped.
+var UsageGroupByValues = []UsageGroupBy{
+	UsageGroupByApplication,
+	UsageGroupByUser,
+	UsageGroupByGroup,
+	UsageGroupByQueue,
+	UsageGroupByPartition,
+}
+
+// usageGroupByKeys maps the fields by which the usage can be grouped to the key expression and the joins of the query.
+var usageGroupByKeys = map[UsageGroupBy]struct {
+	expression string
+	join       string
+}{
+	UsageGroupByApplication: {expression: "u.app_id"},
+	UsageGroupByUser:        {expression: `COALESCE(u."user", '')`},
+	UsageGroupByGroup: {
+		expression: "g.name",
+		join:       "CROSS JOIN LATERAL unnest(COALESCE(NULLIF(u.groups, '{}'), ARRAY[''])) AS g(name)",
+	},
+	UsageGroupByQueue:     {expression: "COALESCE(u.queue_name, '')"},
+	UsageGroupByPartition: {expression: "u.partition"},
+}
+
+// UsageFilters select the applications and the period of a usage report.
+type UsageFilters struct {
+	Start   time.Time
+	End     time.Time
+	GroupBy UsageGroupBy
+	// GPUResource is the name of the resource which counts the GPUs of an allocation.
+	GPUResource string
+	Partition   *string
+	// Queue selects the applications in the subtree of a queue.
+	Queue *string
+}
+
+// usageAllocations is the common table expression of the allocations of the applications during the period
+// [@start, @end). An allocation is used from its allocation time until it was removed (by the first REMOVE event
+// of the allocation), or until its application finished or, if it never finished, was deleted, or until the end of
+// the period, and only the time within the period is counted. Deleted applications are included, since the usage is part of the history.
+// The applications which ran entirely during the rolled up period [@rollup_start, @rollup_end) are skipped,
+// since their usage is read from the usage rollups.
+var usageAllocations = `
+allocations AS (
+	SELECT
+		a.id,
+		a.app_id,
+		a."user",
+		a.groups,
+		a.queue_name,
+		a.partition_id,
+		a.partition,
+		GREATEST(al.start_nano, @start) AS start_nano,
+		LEAST(COALESCE(removed.timestamp_nano, a.finished_time * 1000000, a.deleted_at_nano, @end), @end) AS end_nano,
+		al.node_id,
+		al.resource
+	FROM applications a
+	CROSS JOIN LATERAL (
+		SELECT
+			alloc->>'allocationKey' AS key,
+			alloc->>'nodeId' AS node_id,
+			COALESCE(NULLIF((alloc->>'allocationTime')::BIGINT, 0), a.submission_time * 1000000) AS start_nano,
+			alloc->'resource' AS resource
+		FROM jsonb_array_elements(COALESCE(a.allocations, '[]'::JSONB)) AS alloc
+	) al
+	CROSS JOIN LATERAL (
+		SELECT MIN(e.timestamp_nano) AS timestamp_nano
+		FROM events e
+		WHERE e.type = 'APP' AND e.object_id = a.app_id AND e.reference_id = al.key
+			AND e.change_type = 'REMOVE' AND e.timestamp_nano >= al.start_nano
+	) removed
+	WHERE a.submission_time * 1000000 < @end
+		AND (COALESCE(a.finished_time * 1000000, a.deleted_at_nano) IS NULL
+			OR COALESCE(a.finished_time * 1000000, a.deleted_at_nano) > @start)
+		AND (@partition::TEXT IS NULL OR a.partition = @partition)
+		AND ` + queueSubtreeFilter("a.queue_name") + `
+		AND (a.submission_time * 1000000 < @rollup_start
+			OR COALESCE(a.finished_time * 1000000, a.deleted_at_nano) IS NULL
+			OR COALESCE(a.finished_time * 1000000, a.deleted_at_nano) > @rollup_end)
+)`
+
+// usageQuery computes the usage of the allocations of the applications during the period [@start, @end).
+// The usage during the rolled up period [@rollup_start, @rollup_end) is read from the usage rollups of the width
+// @width_seconds, and the usage during the rest of the period from the allocations. Since the rollups keep no
+// applications, the applications which ran during the rolled up period with an allocation made before its end
+// are counted from their rows, without usage.
+var usageQuery = `
+WITH ` + usageAllocations + `,
+usage AS (
+	SELECT
+		u.id,
+		u.app_id,
+		u."user",
+		u.groups,
+		u.queue_name,
+		u.partition,
+		u.seconds * COALESCE((u.resource->>'vcore')::DOUBLE PRECISION, 0) AS vcore_seconds,
+		u.seconds * COALESCE((u.resource->>'memory')::DOUBLE PRECISION, 0) AS memory_seconds,
+		u.seconds * COALESCE((u.resource->>@gpu_resource)::DOUBLE PRECISION, 0) AS gpu_seconds
+	FROM (
+		SELECT
+			*,
+			(end_nano - start_nano - GREATEST(LEAST(end_nano, @rollup_end) - GREATEST(start_nano, @rollup_start), 0))
+				/ 1e9::DOUBLE PRECISION AS seconds
+		FROM allocations
+	) u
+	WHERE u.seconds > 0
+	UNION ALL
+	SELECT
+		NULL,
+		NULL,
+		r."user",
+		r.groups,
+		r.queue_name,
+		r.partition,
+		COALESCE((r.resource_seconds->>'vcore')::DOUBLE PRECISION, 0),
+		COALESCE((r.resource_seconds->>'memory')::DOUBLE PRECISION, 0),
+		COALESCE((r.resource_seconds->>@gpu_resource)::DOUBLE PRECISION, 0)
+	FROM usage_rollups r
+	WHERE r.width_seconds = @width_seconds AND r.bucket >= @rollup_start AND r.bucket < @rollup_end
+		AND (@partition::TEXT IS NULL OR r.partition = @partition)
+		AND ` + queueSubtreeFilter("r.queue_name") + `
+	UNION ALL
+	SELECT a.id, a.app_id, a."user", a.groups, a.queue_name, a.partition, 0, 0, 0
+	FROM applications a
+	WHERE @rollup_start::BIGINT < @rollup_end::BIGINT AND a.submission_time * 1000000 < @rollup_end
+		AND (COALESCE(a.finished_time * 1000000, a.deleted_at_nano) IS NULL
+			OR COALESCE(a.finished_time * 1000000, a.deleted_at_nano) > @rollup_start)
+		AND (@partition::TEXT IS NULL OR a.partition = @partition)
+		AND ` + queueSubtreeFilter("a.queue_name") + `
+		AND EXISTS (
+			SELECT 1
+			FROM jsonb_array_elements(COALESCE(a.allocations, '[]'::JSONB)) AS alloc
+			WHERE COALESCE(NULLIF((alloc->>'allocationTime')::BIGINT, 0), a.submission_time * 1000000) < @rollup_end
+		)
+)
+SELECT
+	%s AS key,
+	COUNT(DISTINCT u.id),
+	COALESCE(SUM(u.vcore_seconds) / 1000, 0),
+	COALESCE(SUM(u.memory_seconds) / 1073741824 / 3600, 0),
+	COALESCE(SUM(u.gpu_seconds) / 3600, 0)
+FROM usage u
+%s
+GROUP BY 1
+ORDER BY 1`
+
+// GetUsage returns the usage of resources during the period of the filters, grouped by the field of the filters.
+// vcore is measured in millicores, so the vcore seconds are divided by 1000, and memory is measured in bytes.
+// The usage during the part of the period which is covered by the usage rollups is read from them, unless it is
+// grouped by application, since the rollups are aggregated per partition, queue, user and groups.
+func (s *PostgresRepository) GetUsage(ctx context.Context, filters UsageFilters) ([]*model.Usage, error) {
+	groupBy, ok := usageGroupByKeys[filters.GroupBy]
+	if !ok {
+		return nil, fmt.Errorf("cannot group usage by %q", filters.GroupBy)
+	}
+	width, rollupStart, rollupEnd, err := s.rolledUpPeriod(ctx, RollupUsage, time.Unix(0, 0), 0, filters.Start, filters.End)
+	if err != nil {
+		return nil, fmt.Errorf("could not get usage from DB: %v", err)
+	}
+	if width == 0 || filters.GroupBy == UsageGroupByApplication {
+		width, rollupStart, rollupEnd = 0, filters.End, filters.End
+	}
+
+	args := pgx.NamedArgs{
+		"start":         filters.Start.UnixNano(),
+		"end":           filters.End.UnixNano(),
+		"rollup_start":  rollupStart.UnixNano(),
+		"rollup_end":    rollupEnd.UnixNano(),
+		"width_seconds": int64(width / time.Second),
+		"partition":     filters.Partition,
+		"gpu_resource":  filters.GPUResource,
+	}
+	maps.Copy(args, queueFilterArgs(filters.Queue))
+
+	rows, err := s.dbpool.Query(ctx, fmt.Sprintf(usageQuery, groupBy.expression, groupBy.join), args)
+	if err != nil {
+		return nil, fmt.Errorf("could not get usage from DB: %v", err)
+	}
+	defer rows.Close()
+
+	var usage []*model.Usage
+	for rows.Next() {
+		var u model.Usage
+		if err := rows.Scan(&u.Key, &u.Applications, &u.VcoreSeconds, &u.MemoryGiBHours, &u.GPUHours); err != nil {
+			return nil, fmt.Errorf("could not scan usage from DB: %v", err)
+		}
+		usage = append(usage, &u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get usage from DB: %v", err)
+	}
+	return usage, nil
+}
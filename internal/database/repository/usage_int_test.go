@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/G-Research/yunikorn-core/pkg/webservice/dao"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/G-Research/unicorn-history-server/internal/model"
+	"github.com/G-Research/unicorn-history-server/internal/util"
+)
+
+type UsageIntTest struct {
+	suite.Suite
+	pool  *pgxpool.Pool
+	repo  *PostgresRepository
+	start time.Time
+}
+
+func (us *UsageIntTest) SetupSuite() {
+	require.NotNil(us.T(), us.pool)
+	repo, err := NewPostgresRepository(us.pool)
+	require.NoError(us.T(), err)
+	us.repo = repo
+
+	ctx := context.Background()
+	us.start = time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	start := us.start
+	const gib = 1 << 30
+
+	apps := []*model.Application{
+		{
+			// running: one allocation removed during the period, one until the end of the period
+			Metadata: model.Metadata{CreatedAtNano: start.UnixNano()},
+			ApplicationDAOInfo: dao.ApplicationDAOInfo{
+				ID:             ulid.Make().String(),
+				ApplicationID:  "app-alice",
+				PartitionID:    "1",
+				Partition:      "default",
+				QueueID:        util.ToPtr("1"),
+				QueueName:      "root.ml.training",
+				SubmissionTime: start.Add(-time.Hour).UnixMilli(),
+				User:           "alice",
+				Groups:         []string{"dev", "ml"},
+				Allocations: []*dao.AllocationDAOInfo{
+					{
+						AllocationKey:    "alloc-1",
+						AllocationTime:   start.Add(-30 * time.Minute).UnixNano(),
+						ResourcePerAlloc: map[string]int64{"vcore": 2000, "memory": 4 * gib},
+					},
+					{
+						AllocationKey:    "alloc-2",
+						AllocationTime:   start.Add(time.Hour).UnixNano(),
+						ResourcePerAlloc: map[string]int64{"vcore": 1000, "nvidia.com/gpu": 1},
+					},
+				},
+			},
+		},
+		{
+			// finished during the period
+			Metadata: model.Metadata{CreatedAtNano: start.UnixNano(), DeletedAtNano: util.ToPtr(start.Add(time.Hour).UnixNano())},
+			ApplicationDAOInfo: dao.ApplicationDAOInfo{
+				ID:             ulid.Make().String(),
+				ApplicationID:  "app-bob",
+				PartitionID:    "1",
+				Partition:      "default",
+				QueueID:        util.ToPtr("2"),
+				QueueName:      "root.batch",
+				SubmissionTime: start.UnixMilli(),
+				FinishedTime:   util.ToPtr(start.Add(time.Hour).UnixMilli()),
+				User:           "bob",
+				Allocations: []*dao.AllocationDAOInfo{
+					{
+						AllocationKey:    "alloc-3",
+						AllocationTime:   start.UnixNano(),
+						ResourcePerAlloc: map[string]int64{"vcore": 4000},
+					},
+				},
+			},
+		},
+		{
+			// deleted during the period without ever finishing
+			Metadata: model.Metadata{CreatedAtNano: start.UnixNano(), DeletedAtNano: util.ToPtr(start.Add(30 * time.Minute).UnixNano())},
+			ApplicationDAOInfo: dao.ApplicationDAOInfo{
+				ID:             ulid.Make().String(),
+				ApplicationID:  "app-carol",
+				PartitionID:    "1",
+				Partition:      "default",
+				QueueID:        util.ToPtr("2"),
+				QueueName:      "root.batch",
+				SubmissionTime: start.UnixMilli(),
+				User:           "carol",
+				Allocations: []*dao.AllocationDAOInfo{
+					{
+						AllocationKey:    "alloc-5",
+						AllocationTime:   start.UnixNano(),
+						ResourcePerAlloc: map[string]int64{"vcore": 1000},
+					},
+				},
+			},
+		},
+		{
+			// finished before the period
+			Metadata: model.Metadata{CreatedAtNano: start.Add(-3 * time.Hour).UnixNano()},
+			ApplicationDAOInfo: dao.ApplicationDAOInfo{
+				ID:             ulid.Make().String(),
+				ApplicationID:  "app-old",
+				PartitionID:    "1",
+				Partition:      "default",
+				QueueID:        util.ToPtr("2"),
+				QueueName:      "root.batch",
+				SubmissionTime: start.Add(-3 * time.Hour).UnixMilli(),
+				FinishedTime:   util.ToPtr(start.Add(-2 * time.Hour).UnixMilli()),
+				User:           "bob",
+				Allocations: []*dao.AllocationDAOInfo{
+					{
+						AllocationKey:    "alloc-4",
+						AllocationTime:   start.Add(-3 * time.Hour).UnixNano(),
+						ResourcePerAlloc: map[string]int64{"vcore": 8000},
+					},
+				},
+			},
+		},
+	}
+	for _, app := range apps {
+		require.NoError(us.T(), repo.InsertApplication(ctx, app))
+	}
+	require.NoError(us.T(), repo.InsertEvent(ctx, &model.Event{
+		TimestampNano: start.Add(30 * time.Minute).UnixNano(),
+		Type:          "APP",
+		ObjectID:      "app-alice",
+		ReferenceID:   "alloc-1",
+		ChangeType:    "REMOVE",
+		ChangeDetail:  "ALLOC_CANCEL",
+	}))
+}
+
+func (us *UsageIntTest) TearDownSuite() {
+	us.pool.Close()
+}
+
+func (us *UsageIntTest) TestGetUsage() {
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		filters  UsageFilters
+		expected []*model.Usage
+	}{
+		{
+			name: "Group by user",
+			filters: UsageFilters{
+				GroupBy: UsageGroupByUser,
+			},
+			expected: []*model.Usage{
+				{Key: "alice", Applications: 1, VcoreSeconds: 2*1800 + 3600, MemoryGiBHours: 2, GPUHours: 1},
+				{Key: "bob", Applications: 1, VcoreSeconds: 4 * 3600},
+				{Key: "carol", Applications: 1, VcoreSeconds: 1800},
+			},
+		},
+		{
+			name: "Group by group",
+			filters: UsageFilters{
+				GroupBy: UsageGroupByGroup,
+			},
+			expected: []*model.Usage{
+				{Key: "", Applications: 2, VcoreSeconds: 4*3600 + 1800},
+				{Key: "dev", Applications: 1, VcoreSeconds: 2*1800 + 3600, MemoryGiBHours: 2, GPUHours: 1},
+				{Key: "ml", Applications: 1, VcoreSeconds: 2*1800 + 3600, MemoryGiBHours: 2, GPUHours: 1},
+			},
+		},
+		{
+			name: "Group by partition and filter by queue",
+			filters: UsageFilters{
+				GroupBy: UsageGroupByPartition,
+				Queue:   util.ToPtr("root.ml"),
+			},
+			expected: []*model.Usage{
+				{Key: "default", Applications: 1, VcoreSeconds: 2*1800 + 3600, MemoryGiBHours: 2, GPUHours: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		us.Run(tt.name, func() {
+			tt.filters.Start = us.start
+			tt.filters.End = us.start.Add(2 * time.Hour)
+			tt.filters.GPUResource = "nvidia.com/gpu"
+
+			usage, err := us.repo.GetUsage(ctx, tt.filters)
+			require.NoError(us.T(), err)
+			require.Len(us.T(), usage, len(tt.expected))
+			for i, expected := range tt.expected {
+				assert.Equal(us.T(), expected.Key, usage[i].Key)
+				assert.Equal(us.T(), expected.Applications, usage[i].Applications)
+				assert.InDelta(us.T(), expected.VcoreSeconds, usage[i].VcoreSeconds, 1e-6)
+				assert.InDelta(us.T(), expected.MemoryGiBHours, usage[i].MemoryGiBHours, 1e-6)
+				assert.InDelta(us.T(), expected.GPUHours, usage[i].GPUHours, 1e-6)
+			}
+		})
+	}
+}
+
+func (us *UsageIntTest) TestGetUsageInvalidGroupBy() {
+	_, err := us.repo.GetUsage(context.Background(), UsageFilters{GroupBy: "team"})
+	require.Error(us.T(), err)
+}
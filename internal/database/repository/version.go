@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/G-Research/unicorn-history-server/internal/model"
+)
+
+// GetApplicationVersions returns all recorded versions of the application, sorted from the oldest to the newest.
+func (s *PostgresRepository) GetApplicationVersions(ctx context.Context, id string) ([]*model.ApplicationVersion, error) {
+	const q = `
+SELECT
+	v.valid_from_nano,
+	v.valid_to_nano,
+	r.id,
+	r.created_at_nano,
+	r.deleted_at_nano,
+	r.app_id,
+	r.used_resource,
+	r.max_used_resource,
+	r.pending_resource,
+	r.partition_id,
+	r.partition,
+	r.queue_id,
+	r.queue_name,
+	r.submission_time,
+	r.finished_time,
+	r.requests,
+	r.allocations,
+	r.state,
+	r."user",
+	r.groups,
+	r.rejected_message,
+	r.state_log,
+	r.place_holder_data,
+	r.has_reserved,
+	r.reservations,
+	r.max_request_priority,
+	r.preempted_resource
+FROM application_versions v
+CROSS JOIN LATERAL jsonb_populate_record(NULL::applications, v.data) r
+WHERE v.object_id = @id
+ORDER BY v.valid_from_nano, v.id
+`
+	rows, err := s.dbpool.Query(ctx, q, pgx.NamedArgs{"id": id})
+	if err != nil {
+		return nil, fmt.Errorf("could not get application versions from DB: %v", err)
+	}
+	defer rows.Close()
+
+	var versions []*model.ApplicationVersion
+	for rows.Next() {
+		var v model.ApplicationVersion
+		app := &v.Object
+		if err := rows.Scan(
+			&v.ValidFromNano,
+			&v.ValidToNano,
+			&app.ID,
+			&app.CreatedAtNano,
+			&app.DeletedAtNano,
+			&app.ApplicationID,
+			&app.UsedResource,
+			&app.MaxUsedResource,
+			&app.PendingResource,
+			&app.PartitionID,
+			&app.Partition,
+			&app.QueueID,
+			&app.QueueName,
+			&app.SubmissionTime,
+			&app.FinishedTime,
+			&app.Requests,
+			&app.Allocations,
+			&app.State,
+			&app.User,
+			&app.Groups,
+			&app.RejectedMessage,
+			&app.StateLog,
+			&app.PlaceholderData,
+			&app.HasReserved,
+			&app.Reservations,
+			&app.MaxRequestPriority,
+			&app.PreemptedResource,
+		); err != nil {
+			return nil, fmt.Errorf("could not scan application version from DB: %v", err)
+		}
+		versions = append(versions, &v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get application versions from DB: %v", err)
+	}
+	return versions, nil
+}
+
+// GetQueueVersions returns all recorded versions of the queue, sorted from the oldest to the newest.
+func (s *PostgresRepository) GetQueueVersions(ctx context.Context, id string) ([]*model.QueueVersion, error) {
+	const q = `
+SELECT
+	v.valid_from_nano,
+	v.valid_to_nano,
+	r.id,
+	r.created_at_nano,
+	r.deleted_at_nano,
+	r.queue_name,
+	r.parent_id,
+	r.parent,
+	r.status,
+	r.partition_id,
+	r.pending_resource,
+	r.max_resource,
+	r.guaranteed_resource,
+	r.allocated_resource,
+	r.preempting_resource,
+	r.head_room,
+	r.is_leaf,
+	r.is_managed,
+	r.properties,
+	r.template_info,
+	r.abs_used_capacity,
+	r.max_running_apps,
+	r.running_apps,
+	r.current_priority,
+	r.allocating_accepted_apps
+FROM queue_versions v
+CROSS JOIN LATERAL jsonb_populate_record(NULL::queues, v.data) r
+WHERE v.object_id = @id
+ORDER BY v.valid_from_nano, v.id
+`
+	rows, err := s.dbpool.Query(ctx, q, pgx.NamedArgs{"id": id})
+	if err != nil {
+		return nil, fmt.Errorf("could not get queue versions from DB: %v", err)
+	}
+	defer rows.Close()
+
+	var versions []*model.QueueVersion
+	for rows.Next() {
+		var v model.QueueVersion
+		queue := &v.Object
+		if err := rows.Scan(
+			&v.ValidFromNano,
+			&v.ValidToNano,
+			&queue.ID,
+			&queue.CreatedAtNano,
+			&queue.DeletedAtNano,
+			&queue.QueueName,
+			&queue.ParentID,
+			&queue.Parent,
+			&queue.Status,
+			&queue.PartitionID,
+			&queue.PendingResource,
+			&queue.MaxResource,
+			&queue.GuaranteedResource,
+			&queue.AllocatedResource,
+			&queue.PreemptingResource,
+			&queue.HeadRoom,
+			&queue.IsLeaf,
+			&queue.IsManaged,
+			&queue.Properties,
+			&queue.TemplateInfo,
+			&queue.AbsUsedCapacity,
+			&queue.MaxRunningApps,
+			&queue.RunningApps,
+			&queue.CurrentPriority,
+			&queue.AllocatingAcceptedApps,
+		); err != nil {
+			return nil, fmt.Errorf("could not scan queue version from DB: %v", err)
+		}
+		versions = append(versions, &v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get queue versions from DB: %v", err)
+	}
+	return versions, nil
+}
+
+// GetNodeVersions returns all recorded versions of the node, sorted from the oldest to the newest.
+func (s *PostgresRepository) GetNodeVersions(ctx context.Context, id string) ([]*model.NodeVersion, error) {
+	const q = `
+SELECT
+	v.valid_from_nano,
+	v.valid_to_nano,
+	r.id,
+	r.created_at_nano,
+	r.deleted_at_nano,
+	r.node_id,
+	r.partition_id,
+	r.host_name,
+	r.rack_name,
+	r.attributes,
+	r.capacity,
+	r.allocated,
+	r.occupied,
+	r.available,
+	r.utilized,
+	r.allocations,
+	r.schedulable,
+	r.is_reserved,
+	r.reservations
+FROM node_versions v
+CROSS JOIN LATERAL jsonb_populate_record(NULL::nodes, v.data) r
+WHERE v.object_id = @id
+ORDER BY v.valid_from_nano, v.id
+`
+	rows, err := s.dbpool.Query(ctx, q, pgx.NamedArgs{"id": id})
+	if err != nil {
+		return nil, fmt.Errorf("could not get node versions from DB: %v", err)
+	}
+	defer rows.Close()
+
+	var versions []*model.NodeVersion
+	for rows.Next() {
+		var v model.NodeVersion
+		node := &v.Object
+		if err := rows.Scan(
+			&v.ValidFromNano,
+			&v.ValidToNano,
+			&node.ID,
+			&node.CreatedAtNano,
+			&node.DeletedAtNano,
+			&node.NodeID,
+			&node.PartitionID,
+			&node.HostName,
+			&node.RackName,
+			&node.Attributes,
+			&node.Capacity,
+			&node.Allocated,
+			&node.Occupied,
+			&node.Available,
+			&node.Utilized,
+			&node.Allocations,
+			&node.Schedulable,
+			&node.IsReserved,
+			&node.Reservations,
+		); err != nil {
+			return nil, fmt.Errorf("could not scan node version from DB: %v", err)
+		}
+		versions = append(versions, &v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get node versions from DB: %v", err)
+	}
+	return versions, nil
+}
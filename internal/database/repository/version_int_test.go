@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/G-Research/yunikorn-core/pkg/webservice/dao"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/G-Research/unicorn-history-server/internal/model"
+	"github.com/G-Research/unicorn-history-server/internal/util"
+)
+
+type VersionIntTest struct {
+	suite.Suite
+	pool *pgxpool.Pool
+	repo *PostgresRepository
+}
+
+func (vs *VersionIntTest) SetupSuite() {
+	require.NotNil(vs.T(), vs.pool)
+	repo, err := NewPostgresRepository(vs.pool)
+	require.NoError(vs.T(), err)
+	vs.repo = repo
+}
+
+func (vs *VersionIntTest) TearDownSuite() {
+	vs.pool.Close()
+}
+
+func (vs *VersionIntTest) TestGetApplicationVersions() {
+	ctx := context.Background()
+	now := time.Now()
+
+	app := &model.Application{
+		Metadata: model.Metadata{
+			CreatedAtNano: now.UnixNano(),
+		},
+		ApplicationDAOInfo: dao.ApplicationDAOInfo{
+			ID:            ulid.Make().String(),
+			ApplicationID: "app-versions",
+			PartitionID:   ulid.Make().String(),
+			QueueID:       util.ToPtr(ulid.Make().String()),
+			State:         "New",
+		},
+	}
+	require.NoError(vs.T(), vs.repo.InsertApplication(ctx, app))
+
+	app.State = "Running"
+	require.NoError(vs.T(), vs.repo.UpdateApplication(ctx, app))
+	// an update which does not change the row must not create a new version
+	require.NoError(vs.T(), vs.repo.UpdateApplication(ctx, app))
+
+	versions, err := vs.repo.GetApplicationVersions(ctx, app.ID)
+	require.NoError(vs.T(), err)
+	require.Len(vs.T(), versions, 2)
+
+	require.Equal(vs.T(), now.UnixNano(), versions[0].ValidFromNano)
+	require.NotNil(vs.T(), versions[0].ValidToNano)
+	require.Equal(vs.T(), versions[1].ValidFromNano, *versions[0].ValidToNano)
+	require.Nil(vs.T(), versions[1].ValidToNano)
+	require.Equal(vs.T(), "New", versions[0].Object.State)
+	require.Equal(vs.T(), "Running", versions[1].Object.State)
+	require.Equal(vs.T(), app.ApplicationID, versions[1].Object.ApplicationID)
+}
+
+func (vs *VersionIntTest) TestGetQueueVersions() {
+	ctx := context.Background()
+	now := time.Now()
+
+	queue := &model.Queue{
+		Metadata: model.Metadata{
+			CreatedAtNano: now.UnixNano(),
+		},
+		PartitionQueueDAOInfo: dao.PartitionQueueDAOInfo{
+			ID:          ulid.Make().String(),
+			PartitionID: ulid.Make().String(),
+			QueueName:   "root",
+			MaxResource: map[string]int64{"memory": 100},
+		},
+	}
+	require.NoError(vs.T(), vs.repo.InsertQueue(ctx, queue))
+
+	queue.MaxResource = map[string]int64{"memory": 200}
+	require.NoError(vs.T(), vs.repo.UpdateQueue(ctx, queue))
+
+	queue.DeletedAtNano = util.ToPtr(time.Now().UnixNano())
+	require.NoError(vs.T(), vs.repo.UpdateQueue(ctx, queue))
+
+	versions, err := vs.repo.GetQueueVersions(ctx, queue.ID)
+	require.NoError(vs.T(), err)
+	require.Len(vs.T(), versions, 3)
+
+	require.Equal(vs.T(), int64(100), versions[0].Object.MaxResource["memory"])
+	require.Equal(vs.T(), int64(200), versions[1].Object.MaxResource["memory"])
+	require.Nil(vs.T(), versions[1].Object.DeletedAtNano)
+	require.NotNil(vs.T(), versions[2].Object.DeletedAtNano)
+	require.Nil(vs.T(), versions[2].ValidToNano)
+}
+
+func (vs *VersionIntTest) TestGetNodeVersions() {
+	ctx := context.Background()
+	now := time.Now()
+
+	node := &model.Node{
+		Metadata: model.Metadata{
+			CreatedAtNano: now.UnixNano(),
+		},
+		NodeDAOInfo: dao.NodeDAOInfo{
+			ID:          ulid.Make().String(),
+			NodeID:      "node-versions",
+			PartitionID: ulid.Make().String(),
+			HostName:    "host",
+			Schedulable: true,
+		},
+	}
+	require.NoError(vs.T(), vs.repo.InsertNode(ctx, node))
+
+	node.Schedulable = false
+	require.NoError(vs.T(), vs.repo.UpdateNode(ctx, node))
+
+	versions, err := vs.repo.GetNodeVersions(ctx, node.ID)
+	require.NoError(vs.T(), err)
+	require.Len(vs.T(), versions, 2)
+	require.True(vs.T(), versions[0].Object.Schedulable)
+	require.False(vs.T(), versions[1].Object.Schedulable)
+
+	versions, err = vs.repo.GetNodeVersions(ctx, ulid.Make().String())
+	require.NoError(vs.T(), err)
+	require.Nil(vs.T(), versions)
+}
+
+func (vs *VersionIntTest) TestAsOfReadsVersions() {
+	ctx := context.Background()
+	now := time.Now()
+	createdAt := now.Add(-time.Hour)
+	asOf := now.Add(-30 * time.Minute)
+	partitionID := ulid.Make().String()
+
+	queue := &model.Queue{
+		Metadata: model.Metadata{CreatedAtNano: createdAt.UnixNano()},
+		PartitionQueueDAOInfo: dao.PartitionQueueDAOInfo{
+			ID:          ulid.Make().String(),
+			PartitionID: partitionID,
+			QueueName:   "root",
+			Status:      "Active",
+		},
+	}
+	require.NoError(vs.T(), vs.repo.InsertQueue(ctx, queue))
+	queue.Status = "Draining"
+	require.NoError(vs.T(), vs.repo.UpdateQueue(ctx, queue))
+
+	app := &model.Application{
+		Metadata: model.Metadata{CreatedAtNano: createdAt.UnixNano()},
+		ApplicationDAOInfo: dao.ApplicationDAOInfo{
+			ID:            ulid.Make().String(),
+			ApplicationID: "app-as-of",
+			PartitionID:   partitionID,
+			State:         "Running",
+		},
+	}
+	require.NoError(vs.T(), vs.repo.InsertApplication(ctx, app))
+	app.State = "Completed"
+	require.NoError(vs.T(), vs.repo.UpdateApplication(ctx, app))
+
+	node := &model.Node{
+		Metadata: model.Metadata{CreatedAtNano: createdAt.UnixNano()},
+		NodeDAOInfo: dao.NodeDAOInfo{
+			ID:          ulid.Make().String(),
+			NodeID:      "node-as-of",
+			PartitionID: partitionID,
+			Schedulable: true,
+		},
+	}
+	require.NoError(vs.T(), vs.repo.InsertNode(ctx, node))
+	node.Schedulable = false
+	require.NoError(vs.T(), vs.repo.UpdateNode(ctx, node))
+
+	// the rows are read as they were at asOf
+	queues, err := vs.repo.GetQueuesInPartition(ctx, partitionID, QueueFilters{AsOf: &asOf})
+	require.NoError(vs.T(), err)
+	require.Len(vs.T(), queues, 1)
+	require.Equal(vs.T(), "Active", queues[0].Status)
+	apps, err := vs.repo.GetAllApplications(ctx, ApplicationFilters{AsOf: &asOf, ApplicationIDPrefix: util.ToPtr("app-as-of")})
+	require.NoError(vs.T(), err)
+	require.Len(vs.T(), apps, 1)
+	require.Equal(vs.T(), "Running", apps[0].State)
+	nodes, err := vs.repo.GetNodesPerPartition(ctx, partitionID, NodeFilters{AsOf: &asOf})
+	require.NoError(vs.T(), err)
+	require.Len(vs.T(), nodes, 1)
+	require.True(vs.T(), nodes[0].Schedulable)
+
+	// without asOf, the current rows are read
+	queues, err = vs.repo.GetQueuesInPartition(ctx, partitionID, QueueFilters{})
+	require.NoError(vs.T(), err)
+	require.Len(vs.T(), queues, 1)
+	require.Equal(vs.T(), "Draining", queues[0].Status)
+	nodes, err = vs.repo.GetNodesPerPartition(ctx, partitionID, NodeFilters{})
+	require.NoError(vs.T(), err)
+	require.Len(vs.T(), nodes, 1)
+	require.False(vs.T(), nodes[0].Schedulable)
+
+	// the rows did not exist before they were created
+	before := createdAt.Add(-time.Minute)
+	queues, err = vs.repo.GetQueuesInPartition(ctx, partitionID, QueueFilters{AsOf: &before})
+	require.NoError(vs.T(), err)
+	require.Empty(vs.T(), queues)
+}
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/G-Research/unicorn-history-server/internal/database/sql"
+	"github.com/G-Research/unicorn-history-server/internal/model"
+)
+
+// WaitTimeGroupBy is a field by which the wait times are grouped.
+type WaitTimeGroupBy string
+
+const (
+	WaitTimeGroupByQueue WaitTimeGroupBy = "queue"
+	WaitTimeGroupByUser  WaitTimeGroupBy = "user"
+)
+
+// WaitTimeGroupByValues are the fields by which the wait times can be grouped.
+var WaitTimeGroupByValues = []WaitTimeGroupBy{
+	WaitTimeGroupByQueue,
+	WaitTimeGroupByUser,
+}
+
+// waitTimeGroupByKeys maps the fields by which the wait times can be grouped to their key expressions.
+var waitTimeGroupByKeys = map[WaitTimeGroupBy]string{
+	WaitTimeGroupByQueue: "queue_name",
+	WaitTimeGroupByUser:  `COALESCE("user", '')`,
+}
+
+// WaitTimeFilters select the applications of a wait time report.
+type WaitTimeFilters struct {
+	// Start and End select the applications which were submitted during the period [Start, End).
+	Start   time.Time
+	End     time.Time
+	GroupBy []WaitTimeGroupBy
+	// Bucket is the width of the time buckets by which the submission times are grouped.
+	// The applications are not grouped by time if it is 0.
+	Bucket    time.Duration
+	Partition *string
+	// Queue selects the applications in the subtree of a queue.
+	Queue *string
+	// Resources filter the applications by the size of their request, see WaitTimeResourceFilterFields.
+	Resources []ResourceFilter
+}
+
+// WaitTimeResourceFilterFields are the fields by which the applications of a wait time report can be filtered
+// with resource filters. requestedResource is the sum of the resources of the asks of an application,
+// or of its allocations if no asks were recorded.
+var WaitTimeResourceFilterFields = &ResourceFilterFields{
+	resources: map[string]string{
+		"requestedResource": "requested_resource",
+	},
+}
+
+// sumResourcesExpression returns the expression which sums the resource maps of the elements of a JSONB array column,
+// e.g. of the asks or the allocations of an application. It is null if the array is null or empty.
+func sumResourcesExpression(column string) string {
+	return `(
+		SELECT jsonb_object_agg(r.key, r.total)
+		FROM (
+			SELECT res.key, SUM(res.value::BIGINT) AS total
+			FROM jsonb_array_elements(` + column + `) AS elem
+			CROSS JOIN LATERAL jsonb_each_text(elem->'resource') AS res
+			GROUP BY res.key
+		) r
+	)`
+}
+
+// waitTimeApplications are the applications with the time when they started running, which is the time of the
+// first Running state of their state log, and the resources which they requested.
+var waitTimeApplications = `(
+	SELECT
+		a.partition,
+		a.queue_name,
+		a."user",
+		a.submission_time,
+		a.finished_time,
+		(
+			SELECT MIN((s->>'time')::BIGINT)
+			FROM jsonb_array_elements(COALESCE(a.state_log, '[]'::JSONB)) AS s
+			WHERE s->>'applicationState' = 'Running'
+		) AS start_time,
+		COALESCE(` + sumResourcesExpression("a.requests") + `, ` + sumResourcesExpression("a.allocations") + `) AS requested_resource
+	FROM applications a
+)`
+
+// waitTimeStatsQuery computes the percentiles of the wait times and runtimes of the applications of the inner query.
+// The first argument are the key expressions followed by a comma, the third one the GROUP BY clause.
+const waitTimeStatsQuery = `
+SELECT
+	%[1]s
+	COUNT(*),
+	COUNT(start_time),
+	COUNT(*) FILTER (WHERE start_time IS NOT NULL AND finished_time IS NOT NULL),
+	percentile_cont(ARRAY[0.5, 0.9, 0.99]) WITHIN GROUP (ORDER BY start_time - submission_time)
+		FILTER (WHERE start_time IS NOT NULL),
+	MAX(start_time - submission_time),
+	percentile_cont(ARRAY[0.5, 0.9, 0.99]) WITHIN GROUP (ORDER BY finished_time - start_time)
+		FILTER (WHERE start_time IS NOT NULL AND finished_time IS NOT NULL),
+	MAX(finished_time - start_time)
+FROM (%[2]s) w
+%[3]s`
+
+// GetWaitTimes returns the distributions of the wait times and runtimes of the applications which match the filters,
+// grouped by the fields and the time buckets of the filters. Deleted applications are included.
+func (s *PostgresRepository) GetWaitTimes(ctx context.Context, filters WaitTimeFilters) ([]*model.WaitTimeStats, error) {
+	var keys []string
+	for _, groupBy := range filters.GroupBy {
+		key, ok := waitTimeGroupByKeys[groupBy]
+		if !ok {
+			return nil, fmt.Errorf("cannot group wait times by %q", groupBy)
+		}
+		keys = append(keys, key)
+	}
+	if filters.Bucket > 0 {
+		// the bucket width is an integer, so it can be used as a literal
+		bucket := strconv.FormatInt(filters.Bucket.Milliseconds(), 10)
+		keys = append(keys, "(submission_time / "+bucket+") * "+bucket)
+	} else if filters.Bucket < 0 {
+		return nil, fmt.Errorf("invalid bucket width %s", filters.Bucket)
+	}
+
+	builder := sql.NewBuilder().
+		Select("queue_name", `"user"`, "submission_time", "finished_time", "start_time").
+		From(waitTimeApplications, "a").
+		Where(
+			sql.Cmp("submission_time", sql.OpGe, filters.Start.UnixMilli()),
+			sql.Cmp("submission_time", sql.OpLt, filters.End.UnixMilli()),
+		)
+	if filters.Partition != nil {
+		builder.Where(sql.Eq("partition", *filters.Partition))
+	}
+	if filters.Queue != nil {
+		builder.Where(queueSubtree("queue_name", *filters.Queue))
+	}
+	if err := WaitTimeResourceFilterFields.apply(builder, filters.Resources); err != nil {
+		return nil, err
+	}
+	inner, args := builder.Build()
+
+	var selectKeys, groupBy string
+	if len(keys) > 0 {
+		selectKeys = strings.Join(keys, ", ") + ","
+		positions := make([]string, len(keys))
+		for i := range keys {
+			positions[i] = strconv.Itoa(i + 1)
+		}
+		groupBy = "GROUP BY " + strings.Join(positions, ", ") + " ORDER BY " + strings.Join(positions, ", ")
+	}
+
+	rows, err := s.dbpool.Query(ctx, fmt.Sprintf(waitTimeStatsQuery, selectKeys, inner, groupBy), args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get wait times from DB: %v", err)
+	}
+	defer rows.Close()
+
+	var stats []*model.WaitTimeStats
+	for rows.Next() {
+		var st model.WaitTimeStats
+		var waitPercentiles, runPercentiles []float64
+		var waitMax, runMax *int64
+		var dest []any
+		for _, groupBy := range filters.GroupBy {
+			switch groupBy {
+			case WaitTimeGroupByQueue:
+				dest = append(dest, &st.Queue)
+			case WaitTimeGroupByUser:
+				dest = append(dest, &st.User)
+			}
+		}
+		if filters.Bucket > 0 {
+			dest = append(dest, &st.Bucket)
+		}
+		dest = append(dest, &st.Applications, &st.Started, &st.Finished, &waitPercentiles, &waitMax, &runPercentiles, &runMax)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("could not scan wait times from DB: %v", err)
+		}
+		st.WaitTime = newPercentiles(waitPercentiles, waitMax)
+		st.RunTime = newPercentiles(runPercentiles, runMax)
+		stats = append(stats, &st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get wait times from DB: %v", err)
+	}
+	return stats, nil
+}
+
+// newPercentiles creates the percentiles from the p50, p90 and p99 values and the maximum.
+// It returns nil if there were no values.
+func newPercentiles(values []float64, maximum *int64) *model.Percentiles {
+	if len(values) != 3 || maximum == nil {
+		return nil
+	}
+	return &model.Percentiles{P50: values[0], P90: values[1], P99: values[2], Max: float64(*maximum)}
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/G-Research/yunikorn-core/pkg/webservice/dao"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/G-Research/unicorn-history-server/internal/database/sql"
+	"github.com/G-Research/unicorn-history-server/internal/model"
+	"github.com/G-Research/unicorn-history-server/internal/util"
+)
+
+type WaitTimesIntTest struct {
+	suite.Suite
+	pool  *pgxpool.Pool
+	repo  *PostgresRepository
+	start time.Time
+}
+
+func (ws *WaitTimesIntTest) SetupSuite() {
+	require.NotNil(ws.T(), ws.pool)
+	repo, err := NewPostgresRepository(ws.pool)
+	require.NoError(ws.T(), err)
+	ws.repo = repo
+
+	ctx := context.Background()
+	ws.start = time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	start := ws.start
+
+	newApp := func(queue, user string, submitted time.Time, running, finished *time.Time, vcore int64) *model.Application {
+		app := &model.Application{
+			Metadata: model.Metadata{CreatedAtNano: submitted.UnixNano()},
+			ApplicationDAOInfo: dao.ApplicationDAOInfo{
+				ID:             ulid.Make().String(),
+				ApplicationID:  ulid.Make().String(),
+				PartitionID:    "1",
+				Partition:      "default",
+				QueueName:      queue,
+				User:           user,
+				SubmissionTime: submitted.UnixMilli(),
+				Requests: []*dao.AllocationAskDAOInfo{
+					{AllocationKey: "ask-1", ResourcePerAlloc: map[string]int64{"vcore": vcore}},
+				},
+				StateLog: []*dao.StateDAOInfo{
+					{Time: submitted.UnixMilli(), ApplicationState: "Accepted"},
+				},
+			},
+		}
+		if running != nil {
+			app.StateLog = append(app.StateLog, &dao.StateDAOInfo{Time: running.UnixMilli(), ApplicationState: "Running"})
+		}
+		if finished != nil {
+			app.FinishedTime = util.ToPtr(finished.UnixMilli())
+		}
+		return app
+	}
+
+	apps := []*model.Application{
+		newApp("root.ml", "alice", start, util.ToPtr(start.Add(10*time.Second)), util.ToPtr(start.Add(70*time.Second)), 4000),
+		newApp("root.ml", "alice", start.Add(time.Hour), util.ToPtr(start.Add(time.Hour+30*time.Second)), nil, 1000),
+		newApp("root.batch", "bob", start.Add(5*time.Minute), nil, nil, 1000),
+		// submitted before the period
+		newApp("root.ml", "alice", start.Add(-24*time.Hour), util.ToPtr(start.Add(-23*time.Hour)), nil, 1000),
+	}
+	for _, app := range apps {
+		require.NoError(ws.T(), repo.InsertApplication(ctx, app))
+	}
+}
+
+func (ws *WaitTimesIntTest) TearDownSuite() {
+	ws.pool.Close()
+}
+
+func (ws *WaitTimesIntTest) TestGetWaitTimes() {
+	ctx := context.Background()
+	start := ws.start
+	tests := []struct {
+		name     string
+		filters  WaitTimeFilters
+		expected []*model.WaitTimeStats
+	}{
+		{
+			name:    "Not grouped",
+			filters: WaitTimeFilters{},
+			expected: []*model.WaitTimeStats{
+				{
+					Applications: 3,
+					Started:      2,
+					Finished:     1,
+					WaitTime:     &model.Percentiles{P50: 20000, P90: 28000, P99: 29800, Max: 30000},
+					RunTime:      &model.Percentiles{P50: 60000, P90: 60000, P99: 60000, Max: 60000},
+				},
+			},
+		},
+		{
+			name: "Grouped by queue, user and hour",
+			filters: WaitTimeFilters{
+				GroupBy: []WaitTimeGroupBy{WaitTimeGroupByQueue, WaitTimeGroupByUser},
+				Bucket:  time.Hour,
+			},
+			expected: []*model.WaitTimeStats{
+				{
+					Queue:        util.ToPtr("root.batch"),
+					User:         util.ToPtr("bob"),
+					Bucket:       util.ToPtr(start.UnixMilli()),
+					Applications: 1,
+				},
+				{
+					Queue:        util.ToPtr("root.ml"),
+					User:         util.ToPtr("alice"),
+					Bucket:       util.ToPtr(start.UnixMilli()),
+					Applications: 1,
+					Started:      1,
+					Finished:     1,
+					WaitTime:     &model.Percentiles{P50: 10000, P90: 10000, P99: 10000, Max: 10000},
+					RunTime:      &model.Percentiles{P50: 60000, P90: 60000, P99: 60000, Max: 60000},
+				},
+				{
+					Queue:        util.ToPtr("root.ml"),
+					User:         util.ToPtr("alice"),
+					Bucket:       util.ToPtr(start.Add(time.Hour).UnixMilli()),
+					Applications: 1,
+					Started:      1,
+					WaitTime:     &model.Percentiles{P50: 30000, P90: 30000, P99: 30000, Max: 30000},
+				},
+			},
+		},
+		{
+			name: "Filtered by request size",
+			filters: WaitTimeFilters{
+				GroupBy: []WaitTimeGroupBy{WaitTimeGroupByUser},
+				Resources: []ResourceFilter{
+					{Field: "requestedResource", Key: "vcore", Operator: sql.OpGe, Value: "2"},
+				},
+			},
+			expected: []*model.WaitTimeStats{
+				{
+					User:         util.ToPtr("alice"),
+					Applications: 1,
+					Started:      1,
+					Finished:     1,
+					WaitTime:     &model.Percentiles{P50: 10000, P90: 10000, P99: 10000, Max: 10000},
+					RunTime:      &model.Percentiles{P50: 60000, P90: 60000, P99: 60000, Max: 60000},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		ws.Run(tt.name, func() {
+			tt.filters.Start = start
+			tt.filters.End = start.Add(2 * time.Hour)
+			stats, err := ws.repo.GetWaitTimes(ctx, tt.filters)
+			require.NoError(ws.T(), err)
+			require.Len(ws.T(), stats, len(tt.expected))
+			for i, expected := range tt.expected {
+				assert.Equal(ws.T(), expected.Queue, stats[i].Queue)
+				assert.Equal(ws.T(), expected.User, stats[i].User)
+				assert.Equal(ws.T(), expected.Bucket, stats[i].Bucket)
+				assert.Equal(ws.T(), expected.Applications, stats[i].Applications)
+				assert.Equal(ws.T(), expected.Started, stats[i].Started)
+				assert.Equal(ws.T(), expected.Finished, stats[i].Finished)
+				assertPercentiles(ws.T(), expected.WaitTime, stats[i].WaitTime)
+				assertPercentiles(ws.T(), expected.RunTime, stats[i].RunTime)
+			}
+		})
+	}
+}
+
+func (ws *WaitTimesIntTest) TestGetWaitTimesInvalidGroupBy() {
+	_, err := ws.repo.GetWaitTimes(context.Background(), WaitTimeFilters{GroupBy: []WaitTimeGroupBy{"node"}})
+	require.Error(ws.T(), err)
+}
+
+func assertPercentiles(t assert.TestingT, expected, actual *model.Percentiles) {
+	if expected == nil {
+		assert.Nil(t, actual)
+		return
+	}
+	if assert.NotNil(t, actual) {
+		assert.InDelta(t, expected.P50, actual.P50, 1e-6)
+		assert.InDelta(t, expected.P90, actual.P90, 1e-6)
+		assert.InDelta(t, expected.P99, actual.P99, 1e-6)
+		assert.InDelta(t, expected.Max, actual.Max, 1e-6)
+	}
+}
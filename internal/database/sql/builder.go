@@ -1,7 +1,7 @@
 package sql
 
 import (
-	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -12,110 +12,205 @@ const (
 	OrderByDescending OrderDirection = "DESC"
 )
 
+type orderBy struct {
+	column    string
+	direction OrderDirection
+}
+
+type join struct {
+	kind  string
+	table string
+	on    Expr
+}
+
+// Builder builds a SELECT query.
+// All values are passed as positional arguments ('$1', '$2'...), so the query and its arguments
+// can be passed to pgx as they are returned by Build.
 type Builder struct {
-	selectStatement  string
-	whereClauses     string
-	hasWhere         bool
-	conditionCounter int
-	args             []any
-	orderByClauses   string
-	limit            string
-	offset           string
+	columns    []string
+	from       string
+	fromExpr   Expr
+	joins      []join
+	conditions []Expr
+	orderBy    []orderBy
+	seekAfter  []any
+	limit      *int
+	offset     *int
 }
 
 func NewBuilder() *Builder {
-	return &Builder{args: make([]any, 0)}
+	return &Builder{}
 }
 
-// SelectAll creates a new query with a SELECT statement which selects all ('*') entities.
+// Select sets the columns (or expressions) which are selected by the query.
+// If no columns are set, all ('*') columns are selected.
+func (b *Builder) Select(columns ...string) *Builder {
+	b.columns = columns
+	return b
+}
+
+// From sets the table which is queried.
 // If an alias is provided, it will be used as the table alias.
-func (b *Builder) SelectAll(table string, alias string) *Builder {
-	b.selectStatement = fmt.Sprintf("SELECT * FROM %s", table)
+func (b *Builder) From(table string, alias string) *Builder {
+	b.from = table
+	b.fromExpr = nil
 	if alias != "" {
-		b.selectStatement += " AS " + alias
+		b.from += " AS " + alias
 	}
 	return b
 }
 
-// Conditionf adds a condition to the query.
-// This function accepts a format string and arguments, which will be used to create the condition.
-// If the condition uses special characters like '%', they need to be escaped like '%%'.
-// Example: Conditionf("name = '%s'", "John")
-func (b *Builder) Conditionf(format string, args ...any) *Builder {
-	expression := fmt.Sprintf(format, args...)
-	return b.condition(expression)
+// FromSubquery sets a subquery which is queried instead of a table. The alias is required,
+// since a subquery in the FROM clause must be named. It replaces the table set by From.
+//
+// Example: FromSubquery(Raw("SELECT * FROM users WHERE age > $1", 30), "users")
+// will be added as "FROM (SELECT * FROM users WHERE age > $1) AS users".
+func (b *Builder) FromSubquery(subquery Expr, alias string) *Builder {
+	b.from = alias
+	b.fromExpr = subquery
+	return b
+}
+
+// SelectAll creates a new query with a SELECT statement which selects all ('*') entities.
+// If an alias is provided, it will be used as the table alias.
+func (b *Builder) SelectAll(table string, alias string) *Builder {
+	return b.Select().From(table, alias)
+}
+
+// Join adds an INNER JOIN clause to the query.
+// table may include an alias, e.g. "queues AS q".
+func (b *Builder) Join(table string, on Expr) *Builder {
+	b.joins = append(b.joins, join{kind: "JOIN", table: table, on: on})
+	return b
 }
 
-// Condition adds a condition to the query.
-// This function accepts a raw expression string.
-// Special characters like '%' do not need to be escaped.
-// Example: Condition("name = '@name'")
-func (b *Builder) Condition(expression string) *Builder {
-	return b.condition(expression)
+// LeftJoin adds a LEFT JOIN clause to the query.
+// table may include an alias, e.g. "queues AS q".
+func (b *Builder) LeftJoin(table string, on Expr) *Builder {
+	b.joins = append(b.joins, join{kind: "LEFT JOIN", table: table, on: on})
+	return b
 }
 
-// Conditionp adds a condition to the query as a positional argument ('$1', '$2'...).
-// lhs is the left-hand side of the condition, op is the operator and val is the value.
+// Where adds conditions to the query. All conditions of the query are combined with AND.
+// Nil conditions are ignored.
 //
-// Example: Conditionp("name", "=", "John") will be added as "name = '$1'".
-func (b *Builder) Conditionp(lhs, op string, val any) *Builder {
-	b.conditionCounter++
-	expression := fmt.Sprintf("%s %s $%d", lhs, op, b.conditionCounter)
-	b.args = append(b.args, val)
-	return b.condition(expression)
-}
-
-func (b *Builder) condition(expression string) *Builder {
-	if !b.hasWhere {
-		b.whereClauses += "WHERE " + expression
-		b.hasWhere = true
-		return b
+// Example: Where(Eq("name", "John"), Or(IsNull("age"), Cmp("age", OpGt, 30)))
+// will be added as "name = $1 AND (age IS NULL OR age > $2)".
+func (b *Builder) Where(conditions ...Expr) *Builder {
+	for _, condition := range conditions {
+		if condition != nil {
+			b.conditions = append(b.conditions, condition)
+		}
 	}
-	b.whereClauses += " AND " + expression
+	return b
+}
+
+// OrderBy adds a sort key to the ORDER BY clause of the query.
+// The query is sorted by the keys in the order in which they were added.
+func (b *Builder) OrderBy(column string, direction OrderDirection) *Builder {
+	b.orderBy = append(b.orderBy, orderBy{column: column, direction: direction})
+	return b
+}
+
+// SeekAfter restricts the query to the rows which come after the row with the given sort key values (keyset pagination).
+// The values must be given in the same order as the sort keys were added with OrderBy, and there may be fewer
+// values than sort keys. The sort keys should not be nullable and should identify a row uniquely,
+// otherwise rows may be skipped.
+func (b *Builder) SeekAfter(values ...any) *Builder {
+	b.seekAfter = values
 	return b
 }
 
 // Limit adds a LIMIT clause to the query.
 func (b *Builder) Limit(limit int) *Builder {
-	b.limit = fmt.Sprintf("LIMIT %d", limit)
+	b.limit = &limit
 	return b
 }
 
 // Offset adds an OFFSET clause to the query.
 func (b *Builder) Offset(offset int) *Builder {
-	b.offset = fmt.Sprintf("OFFSET %d", offset)
+	b.offset = &offset
 	return b
 }
 
-func (b *Builder) OrderBy(column string, direction OrderDirection) *Builder {
-	b.orderByClauses = fmt.Sprintf("ORDER BY %s %s", column, direction)
-	return b
+// Build returns the query and its positional arguments.
+func (b *Builder) Build() (string, []any) {
+	w := &writer{}
+	w.write("SELECT ")
+	if len(b.columns) == 0 {
+		w.write("*")
+	} else {
+		w.write(strings.Join(b.columns, ", "))
+	}
+	b.writeFromAndWhere(w, true)
+	if len(b.orderBy) > 0 {
+		keys := make([]string, 0, len(b.orderBy))
+		for _, o := range b.orderBy {
+			keys = append(keys, o.column+" "+string(o.direction))
+		}
+		w.write(" ORDER BY " + strings.Join(keys, ", "))
+	}
+	if b.limit != nil {
+		w.write(" LIMIT " + strconv.Itoa(*b.limit))
+	}
+	if b.offset != nil {
+		w.write(" OFFSET " + strconv.Itoa(*b.offset))
+	}
+	return w.sql.String(), w.args
+}
+
+// BuildCount returns a query which counts the rows matching the query, and its positional arguments.
+// The columns, sort keys, keyset position, limit and offset of the query are ignored.
+func (b *Builder) BuildCount() (string, []any) {
+	w := &writer{}
+	w.write("SELECT COUNT(*)")
+	b.writeFromAndWhere(w, false)
+	return w.sql.String(), w.args
 }
 
-// Query returns the final query string.
-func (b *Builder) Query() string {
-	query := strings.Builder{}
-	query.WriteString(b.selectStatement)
-	if b.whereClauses != "" {
-		query.WriteString(" ")
-		query.WriteString(b.whereClauses)
+func (b *Builder) writeFromAndWhere(w *writer, withSeek bool) {
+	if b.fromExpr != nil {
+		w.write(" FROM (")
+		b.fromExpr.render(w)
+		w.write(") AS " + b.from)
+	} else {
+		w.write(" FROM " + b.from)
 	}
-	if b.orderByClauses != "" {
-		query.WriteString(" ")
-		query.WriteString(b.orderByClauses)
+	for _, j := range b.joins {
+		w.write(" " + j.kind + " " + j.table + " ON ")
+		j.on.render(w)
 	}
-	if b.limit != "" {
-		query.WriteString(" ")
-		query.WriteString(b.limit)
+	conditions := b.conditions
+	if withSeek && len(b.seekAfter) > 0 {
+		conditions = append(conditions[:len(conditions):len(conditions)], b.seekCondition())
 	}
-	if b.offset != "" {
-		query.WriteString(" ")
-		query.WriteString(b.offset)
+	for i, condition := range conditions {
+		if i == 0 {
+			w.write(" WHERE ")
+		} else {
+			w.write(" AND ")
+		}
+		condition.render(w)
 	}
-	return query.String()
 }
 
-// Args returns the positional arguments used in the query.
-func (b *Builder) Args() []any {
-	return b.args
+// seekCondition creates the condition which selects the rows after the keyset position:
+// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ...
+// where '>' is replaced by '<' for the keys sorted in descending order.
+func (b *Builder) seekCondition() Expr {
+	n := min(len(b.seekAfter), len(b.orderBy))
+	alternatives := make([]Expr, 0, n)
+	for i := 0; i < n; i++ {
+		terms := make([]Expr, 0, i+1)
+		for j := 0; j < i; j++ {
+			terms = append(terms, Eq(b.orderBy[j].column, b.seekAfter[j]))
+		}
+		op := OpGt
+		if b.orderBy[i].direction == OrderByDescending {
+			op = OpLt
+		}
+		terms = append(terms, Cmp(b.orderBy[i].column, op, b.seekAfter[i]))
+		alternatives = append(alternatives, And(terms...))
+	}
+	return Or(alternatives...)
 }
@@ -6,257 +6,141 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func TestSelectAll(t *testing.T) {
+func TestBuild(t *testing.T) {
 	tests := []struct {
-		name     string
-		table    string
-		alias    string
-		expected string
-	}{
-		{"Without alias", "users", "", "SELECT * FROM users"},
-		{"With alias", "users", "u", "SELECT * FROM users AS u"},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			b := NewBuilder().SelectAll(tt.table, tt.alias)
-			assert.Equal(t, tt.expected, b.selectStatement)
-			assert.Empty(t, b.whereClauses)
-			assert.False(t, b.hasWhere)
-			assert.Empty(t, b.orderByClauses)
-			assert.Empty(t, b.limit)
-			assert.Empty(t, b.offset)
-		})
-	}
-}
-
-func TestConditionf(t *testing.T) {
-	tests := []struct {
-		name     string
-		setup    func(*Builder)
-		expected string
+		name         string
+		setup        func() *Builder
+		expected     string
+		expectedArgs []any
 	}{
 		{
-			"Single condition",
-			func(b *Builder) {
-				b.Conditionf("age > %d", 30)
-			},
-			"WHERE age > 30",
+			"Select all without alias",
+			func() *Builder { return NewBuilder().SelectAll("users", "") },
+			"SELECT * FROM users",
+			nil,
 		},
 		{
-			"Multiple conditions",
-			func(b *Builder) {
-				b.Conditionf("name = '%s'", "John")
-				b.Conditionf("age > %d", 30)
-			},
-			"WHERE name = 'John' AND age > 30",
+			"Select all with alias",
+			func() *Builder { return NewBuilder().SelectAll("users", "u") },
+			"SELECT * FROM users AS u",
+			nil,
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			b := NewBuilder()
-			tt.setup(b)
-			assert.Equal(t, tt.expected, b.whereClauses)
-			assert.Empty(t, b.selectStatement)
-			assert.True(t, b.hasWhere)
-			assert.Empty(t, b.orderByClauses)
-			assert.Empty(t, b.limit)
-			assert.Empty(t, b.offset)
-		})
-	}
-}
-
-func TestLimit(t *testing.T) {
-	tests := []struct {
-		name     string
-		limit    int
-		expected string
-	}{
-		{"Limit 10", 10, "LIMIT 10"},
-		{"Limit 50", 50, "LIMIT 50"},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			b := NewBuilder().Limit(tt.limit)
-			assert.Equal(t, tt.expected, b.limit)
-			assert.Empty(t, b.selectStatement)
-			assert.Empty(t, b.whereClauses)
-			assert.False(t, b.hasWhere)
-			assert.Empty(t, b.orderByClauses)
-			assert.Empty(t, b.offset)
-		})
-	}
-}
-
-func TestOffset(t *testing.T) {
-	tests := []struct {
-		name     string
-		offset   int
-		expected string
-	}{
-		{"Offset 10", 10, "OFFSET 10"},
-		{"Offset 50", 50, "OFFSET 50"},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			b := NewBuilder().Offset(tt.offset)
-			assert.Equal(t, tt.expected, b.offset)
-			assert.Empty(t, b.selectStatement)
-			assert.Empty(t, b.whereClauses)
-			assert.False(t, b.hasWhere)
-			assert.Empty(t, b.orderByClauses)
-			assert.Empty(t, b.limit)
-		})
-	}
-}
-
-func TestOrderBy(t *testing.T) {
-	tests := []struct {
-		name      string
-		column    string
-		direction OrderDirection
-		expected  string
-	}{
-		{"Order by ascending", "name", OrderByAscending, "ORDER BY name ASC"},
-		{"Order by descending", "age", OrderByDescending, "ORDER BY age DESC"},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			b := NewBuilder().OrderBy(tt.column, tt.direction)
-			assert.Equal(t, tt.expected, b.orderByClauses)
-			assert.Empty(t, b.selectStatement)
-			assert.Empty(t, b.whereClauses)
-			assert.False(t, b.hasWhere)
-			assert.Empty(t, b.offset)
-			assert.Empty(t, b.limit)
-		})
-	}
-}
-
-func TestCombined(t *testing.T) {
-	tests := []struct {
-		name     string
-		setup    func(*Builder)
-		expected string
-	}{
 		{
-			name: "SelectAll, Conditionf, Limit, Offset, OrderBy",
-			setup: func(b *Builder) {
-				b.SelectAll("users", "u").
-					Conditionf("age > %d", 30).
-					Conditionf("name = '%s'", "John").
-					OrderBy("age", OrderByAscending).
-					Limit(10).
-					Offset(5)
-			},
-			expected: "SELECT * FROM users AS u WHERE age > 30 AND name = 'John' ORDER BY age ASC LIMIT 10 OFFSET 5",
+			"Select columns",
+			func() *Builder { return NewBuilder().Select("id", "name").From("users", "") },
+			"SELECT id, name FROM users",
+			nil,
 		},
 		{
-			name: "SelectAll with alias, Conditionf, Limit",
-			setup: func(b *Builder) {
-				b.SelectAll("products", "p").
-					Conditionf("price < %f", 100.50).
-					Limit(20)
+			"Select from subquery",
+			func() *Builder {
+				return NewBuilder().
+					Select("id").
+					From("users", "").
+					FromSubquery(Raw("SELECT * FROM user_versions WHERE valid_from <= $1", 10), "users").
+					Where(Eq("name", "John"))
 			},
-			expected: "SELECT * FROM products AS p WHERE price < 100.500000 LIMIT 20",
+			"SELECT id FROM (SELECT * FROM user_versions WHERE valid_from <= $1) AS users WHERE name = $2",
+			[]any{10, "John"},
 		},
 		{
-			name: "SelectAll without alias, OrderBy, Offset",
-			setup: func(b *Builder) {
-				b.SelectAll("employees", "").
-					OrderBy("salary", OrderByDescending).
-					Offset(10)
+			"Multiple conditions",
+			func() *Builder {
+				return NewBuilder().
+					SelectAll("users", "").
+					Where(Eq("name", "John")).
+					Where(Cmp("age", OpGt, 30), nil)
 			},
-			expected: "SELECT * FROM employees ORDER BY salary DESC OFFSET 10",
+			"SELECT * FROM users WHERE name = $1 AND age > $2",
+			[]any{"John", 30},
 		},
 		{
-			name: "SelectAll, Conditionf with special characters",
-			setup: func(b *Builder) {
-				b.SelectAll("logs", "l").
-					Conditionf("message LIKE '%%%%error%%%%'").
-					OrderBy("timestamp", OrderByDescending)
+			"Grouped conditions",
+			func() *Builder {
+				return NewBuilder().
+					SelectAll("users", "").
+					Where(
+						Eq("name", "John"),
+						Or(IsNull("age"), And(Cmp("age", OpGe, 18), Cmp("age", OpLt, 65))),
+					)
 			},
-			expected: "SELECT * FROM logs AS l WHERE message LIKE '%%error%%' ORDER BY timestamp DESC",
+			"SELECT * FROM users WHERE name = $1 AND (age IS NULL OR (age >= $2 AND age < $3))",
+			[]any{"John", 18, 65},
 		},
 		{
-			name: "SelectAll, multiple Conditionf, no alias",
-			setup: func(b *Builder) {
-				b.SelectAll("transactions", "").
-					Conditionf("amount > %d", 1000).
-					Conditionf("status = '%s'", "completed")
+			"Join",
+			func() *Builder {
+				return NewBuilder().
+					Select("u.id", "g.name").
+					From("users", "u").
+					Join("groups AS g", Raw("g.id = u.group_id")).
+					LeftJoin("teams AS t", And(Raw("t.id = u.team_id"), Eq("t.active", true))).
+					Where(Eq("u.name", "John"))
 			},
-			expected: "SELECT * FROM transactions WHERE amount > 1000 AND status = 'completed'",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			b := NewBuilder()
-			tt.setup(b)
-			assert.Equal(t, tt.expected, b.Query())
-		})
-	}
-}
-
-func TestConditionp(t *testing.T) {
-	tests := []struct {
-		name               string
-		lhs                string
-		op                 string
-		val                any
-		initialConditions  string
-		initialArgs        []any
-		expectedConditions string
-		expectedArgs       []any
-	}{
-		{
-			name:               "Simple equality condition",
-			lhs:                "name",
-			op:                 "=",
-			val:                "John",
-			initialConditions:  "",
-			initialArgs:        []any{},
-			expectedConditions: "WHERE name = $1",
-			expectedArgs:       []any{"John"},
+			"SELECT u.id, g.name FROM users AS u JOIN groups AS g ON g.id = u.group_id " +
+				"LEFT JOIN teams AS t ON (t.id = u.team_id AND t.active = $1) WHERE u.name = $2",
+			[]any{true, "John"},
 		},
 		{
-			name:               "Greater than condition",
-			lhs:                "age",
-			op:                 ">",
-			val:                30,
-			initialConditions:  "",
-			initialArgs:        []any{},
-			expectedConditions: "WHERE age > $1",
-			expectedArgs:       []any{30},
+			"Multiple sort keys with limit and offset",
+			func() *Builder {
+				return NewBuilder().
+					SelectAll("users", "").
+					OrderBy("name", OrderByAscending).
+					OrderBy("id", OrderByDescending).
+					Limit(10).
+					Offset(20)
+			},
+			"SELECT * FROM users ORDER BY name ASC, id DESC LIMIT 10 OFFSET 20",
+			nil,
 		},
 		{
-			name:               "Multiple conditions",
-			lhs:                "salary",
-			op:                 "<",
-			val:                5000,
-			initialConditions:  "WHERE name = $1",
-			initialArgs:        []any{"John"},
-			expectedConditions: "WHERE name = $1 AND salary < $2",
-			expectedArgs:       []any{"John", 5000},
+			"Keyset pagination",
+			func() *Builder {
+				return NewBuilder().
+					SelectAll("users", "").
+					Where(Eq("active", true)).
+					OrderBy("name", OrderByAscending).
+					OrderBy("id", OrderByDescending).
+					SeekAfter("John", "42").
+					Limit(10)
+			},
+			"SELECT * FROM users WHERE active = $1 AND (name > $2 OR (name = $3 AND id < $4)) " +
+				"ORDER BY name ASC, id DESC LIMIT 10",
+			[]any{true, "John", "John", "42"},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			b := &Builder{
-				whereClauses:     tt.initialConditions,
-				args:             tt.initialArgs,
-				hasWhere:         tt.initialConditions != "",
-				conditionCounter: len(tt.initialArgs),
-			}
-			b.Conditionp(tt.lhs, tt.op, tt.val)
-
-			assert.Equal(t, tt.expectedConditions, b.whereClauses)
-			assert.Equal(t, tt.expectedArgs, b.args)
+			query, args := tt.setup().Build()
+			assert.Equal(t, tt.expected, query)
+			assert.Equal(t, tt.expectedArgs, args)
 		})
 	}
 }
+
+func TestBuildCount(t *testing.T) {
+	b := NewBuilder().
+		Select("id", "name").
+		From("users", "u").
+		Join("groups AS g", Raw("g.id = u.group_id")).
+		Where(Eq("u.name", "John")).
+		OrderBy("u.id", OrderByDescending).
+		SeekAfter("42").
+		Limit(10).
+		Offset(20)
+
+	query, args := b.BuildCount()
+	assert.Equal(t, "SELECT COUNT(*) FROM users AS u JOIN groups AS g ON g.id = u.group_id WHERE u.name = $1", query)
+	assert.Equal(t, []any{"John"}, args)
+
+	// building the count query does not change the query
+	query, args = b.Build()
+	assert.Equal(t,
+		"SELECT id, name FROM users AS u JOIN groups AS g ON g.id = u.group_id WHERE u.name = $1 AND u.id < $2 "+
+			"ORDER BY u.id DESC LIMIT 10 OFFSET 20",
+		query,
+	)
+	assert.Equal(t, []any{"John", "42"}, args)
+}
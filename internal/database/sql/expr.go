@@ -0,0 +1,194 @@
+package sql
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Operator is a binary comparison operator.
+type Operator string
+
+const (
+	OpEq    Operator = "="
+	OpNe    Operator = "<>"
+	OpLt    Operator = "<"
+	OpLe    Operator = "<="
+	OpGt    Operator = ">"
+	OpGe    Operator = ">="
+	OpLike  Operator = "LIKE"
+	OpILike Operator = "ILIKE"
+)
+
+// Expr is a SQL boolean expression.
+// Values are never interpolated into the SQL, they are passed as positional arguments ('$1', '$2'...)
+// which are numbered when the whole query is built.
+type Expr interface {
+	render(w *writer)
+}
+
+// writer collects the SQL and the positional arguments of a query.
+type writer struct {
+	sql  strings.Builder
+	args []any
+}
+
+func (w *writer) write(s string) {
+	w.sql.WriteString(s)
+}
+
+// arg adds a positional argument and writes its placeholder.
+func (w *writer) arg(val any) {
+	w.args = append(w.args, val)
+	w.sql.WriteString("$" + strconv.Itoa(len(w.args)))
+}
+
+type cmpExpr struct {
+	lhs string
+	op  Operator
+	val any
+}
+
+func (e cmpExpr) render(w *writer) {
+	w.write(e.lhs + " " + string(e.op) + " ")
+	w.arg(e.val)
+}
+
+// Eq creates the expression 'lhs = val'.
+func Eq(lhs string, val any) Expr {
+	return cmpExpr{lhs: lhs, op: OpEq, val: val}
+}
+
+// Cmp creates the expression 'lhs op val'.
+func Cmp(lhs string, op Operator, val any) Expr {
+	return cmpExpr{lhs: lhs, op: op, val: val}
+}
+
+type arrayExpr struct {
+	format string
+	lhs    string
+	values any
+}
+
+func (e arrayExpr) render(w *writer) {
+	before, after, _ := strings.Cut(e.format, "?")
+	w.write(fmt.Sprintf(before, e.lhs))
+	w.arg(e.values)
+	w.write(after)
+}
+
+// In creates the expression 'lhs = ANY(values)', which is true if lhs is equal to any element of values.
+// values must be a slice.
+func In(lhs string, values any) Expr {
+	return arrayExpr{format: "%s = ANY(?)", lhs: lhs, values: values}
+}
+
+// NotIn creates the expression 'NOT (lhs = ANY(values))'.
+// values must be a slice.
+func NotIn(lhs string, values any) Expr {
+	return arrayExpr{format: "NOT (%s = ANY(?))", lhs: lhs, values: values}
+}
+
+// Overlaps creates the expression 'lhs && values', which is true if the array lhs has any element in common with values.
+// values must be a slice.
+func Overlaps(lhs string, values any) Expr {
+	return arrayExpr{format: "%s && ?", lhs: lhs, values: values}
+}
+
+// Contains creates the expression 'lhs @> values', which is true if the array lhs contains all elements of values.
+// values must be a slice.
+func Contains(lhs string, values any) Expr {
+	return arrayExpr{format: "%s @> ?", lhs: lhs, values: values}
+}
+
+type rawExpr struct {
+	sql  string
+	args []any
+}
+
+var placeholderRegexp = regexp.MustCompile(`\$(\d+)`)
+
+func (e rawExpr) render(w *writer) {
+	offset := len(w.args)
+	w.args = append(w.args, e.args...)
+	w.write(placeholderRegexp.ReplaceAllStringFunc(e.sql, func(placeholder string) string {
+		n, _ := strconv.Atoi(placeholder[1:])
+		return "$" + strconv.Itoa(n+offset)
+	}))
+}
+
+// Raw creates an expression from a SQL fragment.
+// The fragment refers to its own arguments as '$1', '$2'..., which are renumbered when the query is built.
+//
+// Example: Raw("COALESCE(deleted_at_nano, $1) > $2", math.MaxInt64, asOf)
+func Raw(sql string, args ...any) Expr {
+	return rawExpr{sql: sql, args: args}
+}
+
+// IsNull creates the expression 'lhs IS NULL'.
+func IsNull(lhs string) Expr {
+	return rawExpr{sql: lhs + " IS NULL"}
+}
+
+// IsNotNull creates the expression 'lhs IS NOT NULL'.
+func IsNotNull(lhs string) Expr {
+	return rawExpr{sql: lhs + " IS NOT NULL"}
+}
+
+type junctionExpr struct {
+	separator string
+	empty     string
+	exprs     []Expr
+}
+
+func (e junctionExpr) render(w *writer) {
+	exprs := make([]Expr, 0, len(e.exprs))
+	for _, expr := range e.exprs {
+		if expr != nil {
+			exprs = append(exprs, expr)
+		}
+	}
+	switch len(exprs) {
+	case 0:
+		w.write(e.empty)
+	case 1:
+		exprs[0].render(w)
+	default:
+		w.write("(")
+		for i, expr := range exprs {
+			if i > 0 {
+				w.write(e.separator)
+			}
+			expr.render(w)
+		}
+		w.write(")")
+	}
+}
+
+// And creates the conjunction of the expressions. Nil expressions are ignored.
+// The conjunction of no expressions is TRUE.
+func And(exprs ...Expr) Expr {
+	return junctionExpr{separator: " AND ", empty: "TRUE", exprs: exprs}
+}
+
+// Or creates the disjunction of the expressions. Nil expressions are ignored.
+// The disjunction of no expressions is FALSE.
+func Or(exprs ...Expr) Expr {
+	return junctionExpr{separator: " OR ", empty: "FALSE", exprs: exprs}
+}
+
+type notExpr struct {
+	expr Expr
+}
+
+func (e notExpr) render(w *writer) {
+	w.write("NOT (")
+	e.expr.render(w)
+	w.write(")")
+}
+
+// Not creates the negation of the expression.
+func Not(expr Expr) Expr {
+	return notExpr{expr: expr}
+}
@@ -0,0 +1,50 @@
+package sql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpr(t *testing.T) {
+	tests := []struct {
+		name         string
+		expr         Expr
+		expected     string
+		expectedArgs []any
+	}{
+		{"Eq", Eq("name", "John"), "name = $1", []any{"John"}},
+		{"Cmp", Cmp("age", OpLe, 30), "age <= $1", []any{30}},
+		{"In", In("state", []string{"New", "Running"}), "state = ANY($1)", []any{[]string{"New", "Running"}}},
+		{"NotIn", NotIn("state", []string{"Failed"}), "NOT (state = ANY($1))", []any{[]string{"Failed"}}},
+		{"Overlaps", Overlaps("groups", []string{"a", "b"}), "groups && $1", []any{[]string{"a", "b"}}},
+		{"Contains", Contains("groups", []string{"a"}), "groups @> $1", []any{[]string{"a"}}},
+		{"IsNull", IsNull("deleted_at_nano"), "deleted_at_nano IS NULL", nil},
+		{"IsNotNull", IsNotNull("deleted_at_nano"), "deleted_at_nano IS NOT NULL", nil},
+		{
+			"Raw with arguments",
+			And(Eq("name", "John"), Raw("COALESCE(age, $1) > $2", 0, 18)),
+			"(name = $1 AND COALESCE(age, $2) > $3)",
+			[]any{"John", 0, 18},
+		},
+		{"Not", Not(Eq("name", "John")), "NOT (name = $1)", []any{"John"}},
+		{"Empty And", And(), "TRUE", nil},
+		{"Empty Or", Or(nil), "FALSE", nil},
+		{"Single Or", Or(Eq("name", "John")), "name = $1", []any{"John"}},
+		{
+			"Or of values with injection attempt",
+			Or(Eq("name", "'; DROP TABLE users; --"), Overlaps("groups", []string{"a'", "b"})),
+			"(name = $1 OR groups && $2)",
+			[]any{"'; DROP TABLE users; --", []string{"a'", "b"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &writer{}
+			tt.expr.render(w)
+			assert.Equal(t, tt.expected, w.sql.String())
+			assert.Equal(t, tt.expectedArgs, w.args)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package model
+
+import (
+	"time"
+)
+
+// applicationTerminalStates are the states in which an application has completed and does not change anymore.
+var applicationTerminalStates = map[string]bool{
+	"Completed": true,
+	"Failed":    true,
+	"Rejected":  true,
+	"Expired":   true,
+}
+
+// IsTerminated returns true if the application is in a terminal state.
+func (app *Application) IsTerminated() bool {
+	return applicationTerminalStates[app.State]
+}
+
+// AccountingRecord is the immutable summary of an application, written once when it completes.
+// All times are in milliseconds since the epoch and all durations are in milliseconds.
+type AccountingRecord struct {
+	// ID is the id of the application.
+	ID              string   `json:"id"`
+	ApplicationID   string   `json:"applicationId"`
+	PartitionID     string   `json:"partitionId"`
+	Partition       string   `json:"partition"`
+	QueueID         *string  `json:"queueId,omitempty"`
+	QueueName       string   `json:"queueName"`
+	User            string   `json:"user"`
+	Groups          []string `json:"groups"`
+	State           string   `json:"state"`
+	RejectedMessage string   `json:"rejectedMessage,omitempty"`
+	SubmissionTime  int64    `json:"submissionTime"`
+	// StartTime is the time when the application started running. It is nil if it never ran.
+	StartTime    *int64 `json:"startTime,omitempty"`
+	FinishedTime int64  `json:"finishedTime"`
+	// WaitTime is the time from the submission until the application started running,
+	// or until it finished if it never ran.
+	WaitTime int64 `json:"waitTime"`
+	RunTime  int64 `json:"runTime"`
+	// PeakResource is the maximum of the resources used by the application at the same time.
+	PeakResource map[string]int64 `json:"peakResource,omitempty"`
+	// TotalResource is the usage of each resource multiplied by the seconds it was used, e.g. vcore-seconds.
+	TotalResource map[string]int64 `json:"totalResource,omitempty"`
+	// Allocations is the number of allocations of the application during its lifetime.
+	Allocations int64 `json:"allocations"`
+	// Preemptions is the number of allocations of the application which were preempted.
+	Preemptions    int64 `json:"preemptions"`
+	RecordedAtNano int64 `json:"recordedAtNano"`
+}
+
+// NewAccountingRecord creates the accounting record of an application which completed or was removed at finishedAtNano.
+// The finished time of the application takes precedence over finishedAtNano.
+func NewAccountingRecord(app *Application, finishedAtNano int64) *AccountingRecord {
+	finished := time.Unix(0, finishedAtNano)
+	if app.FinishedTime != nil {
+		finished = time.UnixMilli(*app.FinishedTime)
+	}
+	durations := app.Durations(finished)
+
+	record := &AccountingRecord{
+		ID:              app.ID,
+		ApplicationID:   app.ApplicationID,
+		PartitionID:     app.PartitionID,
+		Partition:       app.Partition,
+		QueueID:         app.QueueID,
+		QueueName:       app.QueueName,
+		User:            app.User,
+		Groups:          app.Groups,
+		State:           app.State,
+		RejectedMessage: app.RejectedMessage,
+		SubmissionTime:  app.SubmissionTime,
+		FinishedTime:    finished.UnixMilli(),
+		WaitTime:        durations.Queued,
+		PeakResource:    app.MaxUsedResource,
+		TotalResource:   app.totalResource(finished),
+		Allocations:     int64(len(app.Allocations)),
+		RecordedAtNano:  finishedAtNano,
+	}
+	if durations.Running != nil {
+		startTime := app.SubmissionTime + durations.Queued
+		record.StartTime = &startTime
+		record.RunTime = *durations.Running
+	}
+	for _, alloc := range app.Allocations {
+		if alloc != nil && alloc.Preempted {
+			record.Preemptions++
+		}
+	}
+	return record
+}
+
+// totalResource returns the resource-seconds used by the application.
+// They are tracked by YuniKorn per instance type, otherwise they are estimated from the allocations,
+// which are assumed to be used from their allocation time until the application finished.
+func (app *Application) totalResource(finished time.Time) map[string]int64 {
+	total := make(map[string]int64)
+	if app.ResourceUsage != nil && len(app.ResourceUsage.TrackedResourceMap) > 0 {
+		for _, usage := range app.ResourceUsage.TrackedResourceMap {
+			if usage == nil {
+				continue
+			}
+			for name, quantity := range usage.Resources {
+				total[name] += int64(quantity)
+			}
+		}
+		return total
+	}
+	for _, alloc := range app.Allocations {
+		if alloc == nil || alloc.AllocationTime == 0 {
+			continue
+		}
+		seconds := int64(finished.Sub(time.Unix(0, alloc.AllocationTime)).Seconds())
+		if seconds <= 0 {
+			continue
+		}
+		for name, quantity := range alloc.ResourcePerAlloc {
+			total[name] += quantity * seconds
+		}
+	}
+	if len(total) == 0 {
+		return nil
+	}
+	return total
+}
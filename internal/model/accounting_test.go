@@ -0,0 +1,151 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/G-Research/yunikorn-core/pkg/common/resources"
+	"github.com/G-Research/yunikorn-core/pkg/webservice/dao"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/G-Research/unicorn-history-server/internal/util"
+)
+
+func TestNewAccountingRecord(t *testing.T) {
+	submitted := time.Date(2024, 12, 1, 10, 0, 0, 0, time.UTC)
+	started := submitted.Add(10 * time.Minute)
+	finished := submitted.Add(time.Hour)
+
+	tests := map[string]struct {
+		app            *Application
+		finishedAtNano int64
+		want           *AccountingRecord
+	}{
+		"completed with tracked resource usage": {
+			app: &Application{
+				ApplicationDAOInfo: dao.ApplicationDAOInfo{
+					ID:              "1",
+					ApplicationID:   "app-1",
+					PartitionID:     "p",
+					Partition:       "default",
+					QueueName:       "root.default",
+					User:            "alice",
+					Groups:          []string{"dev"},
+					State:           "Completed",
+					SubmissionTime:  submitted.UnixMilli(),
+					FinishedTime:    util.ToPtr(finished.UnixMilli()),
+					MaxUsedResource: map[string]int64{"vcore": 2000},
+					StateLog: []*dao.StateDAOInfo{
+						{Time: started.UnixMilli(), ApplicationState: "Running"},
+					},
+					Allocations: []*dao.AllocationDAOInfo{
+						{AllocationKey: "a1", Preempted: true},
+						{AllocationKey: "a2"},
+					},
+					ResourceUsage: &resources.TrackedResource{
+						TrackedResourceMap: map[string]*resources.Resource{
+							"m5.large":  {Resources: map[string]resources.Quantity{"vcore": 1000}},
+							"m5.xlarge": {Resources: map[string]resources.Quantity{"vcore": 500, "memory": 10}},
+						},
+					},
+				},
+			},
+			finishedAtNano: finished.Add(time.Minute).UnixNano(),
+			want: &AccountingRecord{
+				ID:             "1",
+				ApplicationID:  "app-1",
+				PartitionID:    "p",
+				Partition:      "default",
+				QueueName:      "root.default",
+				User:           "alice",
+				Groups:         []string{"dev"},
+				State:          "Completed",
+				SubmissionTime: submitted.UnixMilli(),
+				StartTime:      util.ToPtr(started.UnixMilli()),
+				FinishedTime:   finished.UnixMilli(),
+				WaitTime:       (10 * time.Minute).Milliseconds(),
+				RunTime:        (50 * time.Minute).Milliseconds(),
+				PeakResource:   map[string]int64{"vcore": 2000},
+				TotalResource:  map[string]int64{"vcore": 1500, "memory": 10},
+				Allocations:    2,
+				Preemptions:    1,
+				RecordedAtNano: finished.Add(time.Minute).UnixNano(),
+			},
+		},
+		"removed without finished time estimates the usage from the allocations": {
+			app: &Application{
+				ApplicationDAOInfo: dao.ApplicationDAOInfo{
+					ID:             "2",
+					ApplicationID:  "app-2",
+					State:          "Running",
+					SubmissionTime: submitted.UnixMilli(),
+					StateLog: []*dao.StateDAOInfo{
+						{Time: started.UnixMilli(), ApplicationState: "Running"},
+					},
+					Allocations: []*dao.AllocationDAOInfo{
+						{AllocationKey: "a1", AllocationTime: started.UnixNano(), ResourcePerAlloc: map[string]int64{"vcore": 1000}},
+						{AllocationKey: "a2", AllocationTime: finished.Add(-time.Minute).UnixNano(), ResourcePerAlloc: map[string]int64{"vcore": 10}},
+					},
+				},
+			},
+			finishedAtNano: finished.UnixNano(),
+			want: &AccountingRecord{
+				ID:             "2",
+				ApplicationID:  "app-2",
+				State:          "Running",
+				SubmissionTime: submitted.UnixMilli(),
+				StartTime:      util.ToPtr(started.UnixMilli()),
+				FinishedTime:   finished.UnixMilli(),
+				WaitTime:       (10 * time.Minute).Milliseconds(),
+				RunTime:        (50 * time.Minute).Milliseconds(),
+				TotalResource:  map[string]int64{"vcore": 1000*50*60 + 10*60},
+				Allocations:    2,
+				RecordedAtNano: finished.UnixNano(),
+			},
+		},
+		"rejected application never ran": {
+			app: &Application{
+				ApplicationDAOInfo: dao.ApplicationDAOInfo{
+					ID:              "3",
+					ApplicationID:   "app-3",
+					State:           "Rejected",
+					RejectedMessage: "queue is full",
+					SubmissionTime:  submitted.UnixMilli(),
+					FinishedTime:    util.ToPtr(submitted.Add(time.Second).UnixMilli()),
+				},
+			},
+			finishedAtNano: finished.UnixNano(),
+			want: &AccountingRecord{
+				ID:              "3",
+				ApplicationID:   "app-3",
+				State:           "Rejected",
+				RejectedMessage: "queue is full",
+				SubmissionTime:  submitted.UnixMilli(),
+				FinishedTime:    submitted.Add(time.Second).UnixMilli(),
+				WaitTime:        time.Second.Milliseconds(),
+				RecordedAtNano:  finished.UnixNano(),
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NewAccountingRecord(tt.app, tt.finishedAtNano))
+		})
+	}
+}
+
+func TestApplicationIsTerminated(t *testing.T) {
+	for state, want := range map[string]bool{
+		"Completed": true,
+		"Failed":    true,
+		"Rejected":  true,
+		"Expired":   true,
+		"Running":   false,
+		"Failing":   false,
+		"":          false,
+	} {
+		app := &Application{ApplicationDAOInfo: dao.ApplicationDAOInfo{State: state}}
+		assert.Equal(t, want, app.IsTerminated(), state)
+	}
+}
@@ -0,0 +1,105 @@
+package model
+
+// Percentiles summarize a distribution of durations in milliseconds.
+type Percentiles struct {
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P99 float64 `json:"p99"`
+	Max float64 `json:"max"`
+}
+
+// WaitTimeStats are the distributions of the queueing delay and the runtime of the applications
+// which were submitted to a queue, by a user or during a time bucket.
+type WaitTimeStats struct {
+	// Queue, User and Bucket are the keys by which the applications are grouped. They are nil if not grouped by them.
+	Queue *string `json:"queue,omitempty"`
+	User  *string `json:"user,omitempty"`
+	// Bucket is the start of the time bucket of the submission time in milliseconds since the epoch.
+	Bucket *int64 `json:"bucket,omitempty"`
+	// Applications is the number of submitted applications.
+	Applications int64 `json:"applications"`
+	// Started is the number of applications which started running, i.e. whose queueing delay is known.
+	Started int64 `json:"started"`
+	// Finished is the number of applications which started running and finished, i.e. whose runtime is known.
+	Finished int64 `json:"finished"`
+	// WaitTime is the distribution of the time from the submission until the application started running.
+	// It is nil if no application started.
+	WaitTime *Percentiles `json:"waitTime,omitempty"`
+	// RunTime is the distribution of the time from when the application started running until it finished.
+	// It is nil if no application finished.
+	RunTime *Percentiles `json:"runTime,omitempty"`
+}
+
+// WaitTimeReport is the distribution of the queueing delays and runtimes during a period.
+type WaitTimeReport struct {
+	// Start and End are the period of the submission times in milliseconds since the epoch.
+	Start   int64    `json:"start"`
+	End     int64    `json:"end"`
+	GroupBy []string `json:"groupBy"`
+	// BucketWidth is the width of the time buckets in milliseconds. It is 0 if the report is not bucketed.
+	BucketWidth int64            `json:"bucketWidth,omitempty"`
+	Stats       []*WaitTimeStats `json:"stats"`
+}
+
+// ResourceShare is the average allocation of a queue or a user during a period and its dominant share,
+// which is the largest share of a resource of the capacity of the partition.
+type ResourceShare struct {
+	// AverageResource is the time-weighted average of the allocated resources during the period.
+	AverageResource map[string]float64 `json:"averageResource,omitempty"`
+	// DominantResource is the resource with the largest share. It is empty if the capacity is unknown.
+	DominantResource string  `json:"dominantResource,omitempty"`
+	DominantShare    float64 `json:"dominantShare"`
+}
+
+// NewResourceShare computes the dominant share of the average resources relative to the capacity.
+// Resources without capacity are ignored, and ties are broken by the name of the resource.
+func NewResourceShare(average, capacity map[string]float64) ResourceShare {
+	share := ResourceShare{AverageResource: average}
+	for name, value := range average {
+		if capacity[name] <= 0 {
+			continue
+		}
+		s := value / capacity[name]
+		if share.DominantResource == "" || s > share.DominantShare || (s == share.DominantShare && name < share.DominantResource) {
+			share.DominantResource = name
+			share.DominantShare = s
+		}
+	}
+	return share
+}
+
+// QueueFairness is how long a queue was starved, borrowing or capped during a period.
+// The times are in milliseconds, and the ratios are relative to the time during which the queue was observed.
+type QueueFairness struct {
+	PartitionID string `json:"partitionId"`
+	Queue       string `json:"queue"`
+	// ObservedTime is the time during the period for which the queue has recorded versions.
+	ObservedTime int64 `json:"observedTime"`
+	// StarvedTime is the time during which the allocation of a guaranteed resource was below the guarantee
+	// while the queue had pending demand of that resource.
+	StarvedTime  int64   `json:"starvedTime"`
+	StarvedRatio float64 `json:"starvedRatio"`
+	// BorrowingTime is the time during which the allocation of a guaranteed resource was above the guarantee.
+	BorrowingTime  int64   `json:"borrowingTime"`
+	BorrowingRatio float64 `json:"borrowingRatio"`
+	// CappedTime is the time during which the allocation of a resource reached the maximum of the queue.
+	CappedTime  int64   `json:"cappedTime"`
+	CappedRatio float64 `json:"cappedRatio"`
+	ResourceShare
+}
+
+// UserShare is the dominant resource share of the allocations of the applications of a user in a partition.
+type UserShare struct {
+	PartitionID string `json:"partitionId"`
+	User        string `json:"user"`
+	ResourceShare
+}
+
+// FairnessReport is the fairness of the queues and the resource shares of the users during a period.
+type FairnessReport struct {
+	// Start and End are the period in milliseconds since the epoch.
+	Start  int64            `json:"start"`
+	End    int64            `json:"end"`
+	Queues []*QueueFairness `json:"queues"`
+	Users  []*UserShare     `json:"users"`
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewResourceShare(t *testing.T) {
+	tests := map[string]struct {
+		average  map[string]float64
+		capacity map[string]float64
+		resource string
+		share    float64
+	}{
+		"largest share": {
+			average:  map[string]float64{"vcore": 2000, "memory": 512},
+			capacity: map[string]float64{"vcore": 10000, "memory": 1024},
+			resource: "memory",
+			share:    0.5,
+		},
+		"resources without capacity are ignored": {
+			average:  map[string]float64{"vcore": 1000, "nvidia.com/gpu": 1},
+			capacity: map[string]float64{"vcore": 10000},
+			resource: "vcore",
+			share:    0.1,
+		},
+		"ties are broken by name": {
+			average:  map[string]float64{"vcore": 1000, "memory": 100},
+			capacity: map[string]float64{"vcore": 2000, "memory": 200},
+			resource: "memory",
+			share:    0.5,
+		},
+		"unknown capacity": {
+			average: map[string]float64{"vcore": 1000},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			share := NewResourceShare(tt.average, tt.capacity)
+			assert.Equal(t, tt.average, share.AverageResource)
+			assert.Equal(t, tt.resource, share.DominantResource)
+			assert.InDelta(t, tt.share, share.DominantShare, 1e-9)
+		})
+	}
+}
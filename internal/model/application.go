@@ -1,9 +1,14 @@
 package model
 
 import (
+	"time"
+
 	"github.com/G-Research/yunikorn-core/pkg/webservice/dao"
 )
 
+// applicationStateRunning is the state of an application which has been allocated resources.
+const applicationStateRunning = "Running"
+
 type Application struct {
 	Metadata               `json:",inline"`
 	dao.ApplicationDAOInfo `json:",inline"`
@@ -70,3 +75,67 @@ func (app *Application) MergeFrom(appInfo *dao.ApplicationDAOInfo) {
 		app.PlaceholderResource = appInfo.PlaceholderResource
 	}
 }
+
+// ApplicationDetail is an application together with its lifecycle.
+type ApplicationDetail struct {
+	Application `json:",inline"`
+	Durations   ApplicationDurations `json:"durations"`
+	// Runs are all applications with the same applicationID, most recently submitted first.
+	// There is more than one run if the application was resubmitted.
+	Runs []ApplicationRun `json:"runs"`
+	// TaskGroups are the placeholders of the task groups if the application is gang-scheduled.
+	TaskGroups []*TaskGroupStats `json:"taskGroups,omitempty"`
+}
+
+// ApplicationDurations are the durations of the phases of an application in milliseconds.
+// The phases of an application which has not finished yet end now.
+type ApplicationDurations struct {
+	// Queued is the time from the submission until the application started running,
+	// or until it finished if it never ran.
+	Queued int64 `json:"queuedMillis"`
+	// Running is the time from when the application started running until it finished.
+	// It is nil if the application never ran.
+	Running *int64 `json:"runningMillis,omitempty"`
+	// Total is the time from the submission until the application finished.
+	Total int64 `json:"totalMillis"`
+}
+
+// ApplicationRun is a summary of one submission of an application.
+type ApplicationRun struct {
+	ID             string `json:"id"`
+	SubmissionTime int64  `json:"submissionTime"`
+	FinishedTime   *int64 `json:"finishedTime,omitempty"`
+	State          string `json:"applicationState"`
+}
+
+// Durations computes the durations of the phases of the application from its state log.
+func (app *Application) Durations(now time.Time) ApplicationDurations {
+	end := now.UnixMilli()
+	if app.FinishedTime != nil {
+		end = *app.FinishedTime
+	}
+
+	var durations ApplicationDurations
+	durations.Total = end - app.SubmissionTime
+	durations.Queued = durations.Total
+	for _, state := range app.StateLog {
+		if state != nil && state.ApplicationState == applicationStateRunning {
+			queued := state.Time - app.SubmissionTime
+			running := end - state.Time
+			durations.Queued = queued
+			durations.Running = &running
+			break
+		}
+	}
+	return durations
+}
+
+// Run returns the summary of this submission of the application.
+func (app *Application) Run() ApplicationRun {
+	return ApplicationRun{
+		ID:             app.ID,
+		SubmissionTime: app.SubmissionTime,
+		FinishedTime:   app.FinishedTime,
+		State:          app.State,
+	}
+}
@@ -6,6 +6,8 @@ import (
 
 	"github.com/G-Research/yunikorn-core/pkg/webservice/dao"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/G-Research/unicorn-history-server/internal/util"
 )
 
 func TestApplicationMergeFrom(t *testing.T) {
@@ -187,3 +189,59 @@ func TestApplicationMergeFrom(t *testing.T) {
 		})
 	}
 }
+
+func TestApplicationDurations(t *testing.T) {
+	now := time.UnixMilli(10_000)
+
+	tt := map[string]struct {
+		app  Application
+		want ApplicationDurations
+	}{
+		"finished": {
+			app: Application{
+				ApplicationDAOInfo: dao.ApplicationDAOInfo{
+					SubmissionTime: 1_000,
+					FinishedTime:   util.ToPtr(int64(6_000)),
+					StateLog: []*dao.StateDAOInfo{
+						{Time: 1_000, ApplicationState: "New"},
+						{Time: 1_100, ApplicationState: "Accepted"},
+						{Time: 2_500, ApplicationState: "Running"},
+						{Time: 6_000, ApplicationState: "Completed"},
+					},
+				},
+			},
+			want: ApplicationDurations{Queued: 1_500, Running: util.ToPtr(int64(3_500)), Total: 5_000},
+		},
+		"still running": {
+			app: Application{
+				ApplicationDAOInfo: dao.ApplicationDAOInfo{
+					SubmissionTime: 1_000,
+					StateLog: []*dao.StateDAOInfo{
+						{Time: 3_000, ApplicationState: "Running"},
+						{Time: 4_000, ApplicationState: "Completing"},
+						{Time: 5_000, ApplicationState: "Running"},
+					},
+				},
+			},
+			want: ApplicationDurations{Queued: 2_000, Running: util.ToPtr(int64(7_000)), Total: 9_000},
+		},
+		"rejected": {
+			app: Application{
+				ApplicationDAOInfo: dao.ApplicationDAOInfo{
+					SubmissionTime: 1_000,
+					FinishedTime:   util.ToPtr(int64(1_200)),
+					StateLog: []*dao.StateDAOInfo{
+						{Time: 1_200, ApplicationState: "Rejected"},
+					},
+				},
+			},
+			want: ApplicationDurations{Queued: 200, Total: 200},
+		},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.app.Durations(now))
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package model
+
+// DiagnosticTrendPoint is the number of applications of a diagnostic group in a time bucket.
+type DiagnosticTrendPoint struct {
+	// Bucket is the start of the time bucket in milliseconds since the epoch.
+	Bucket int64 `json:"bucket"`
+	Count  int64 `json:"count"`
+}
+
+// DiagnosticGroup are the rejected or failed applications of a queue and a user with the same normalized message.
+type DiagnosticGroup struct {
+	State string `json:"state"`
+	// Message is the rejection or failure message with its identifiers and numbers replaced by <id> and <n>.
+	Message      string `json:"message"`
+	Queue        string `json:"queue"`
+	User         string `json:"user"`
+	Applications int64  `json:"applications"`
+	// FirstSeen and LastSeen are the first and the last time when an application of the group was rejected or failed,
+	// in milliseconds since the epoch.
+	FirstSeen int64 `json:"firstSeen"`
+	LastSeen  int64 `json:"lastSeen"`
+	// ExampleIDs are the applicationIDs of the most recent applications of the group.
+	ExampleIDs []string `json:"exampleIds"`
+	// Trend is the number of applications per time bucket. Buckets without applications are omitted.
+	Trend []DiagnosticTrendPoint `json:"trend"`
+}
+
+// DiagnosticsReport are the rejected and failed applications during a period, grouped by their normalized messages.
+type DiagnosticsReport struct {
+	// Start and End are the period in milliseconds since the epoch.
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+	// BucketWidth is the width of the time buckets of the trends in milliseconds.
+	BucketWidth int64              `json:"bucketWidth"`
+	Groups      []*DiagnosticGroup `json:"groups"`
+}
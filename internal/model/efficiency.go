@@ -0,0 +1,79 @@
+package model
+
+import "math"
+
+// Efficiency compares the quantity of a resource which an application, or the applications of a user or a queue,
+// requested with the quantity which they used. The quantities are in the unit of the resource, e.g. millicores,
+// and the resource-seconds are the quantities multiplied by the seconds during which they were allocated.
+type Efficiency struct {
+	// ApplicationID is only set for an application. Queue and User are set for an application,
+	// and for the roll-up of a queue or a user respectively.
+	ApplicationID *string `json:"applicationId,omitempty"`
+	Queue         *string `json:"queue,omitempty"`
+	User          *string `json:"user,omitempty"`
+	Applications  int64   `json:"applications"`
+	// Requested is the sum of the allocations and pending asks, and MaxUsed the maximum allocated at the same time.
+	Requested float64 `json:"requested"`
+	MaxUsed   float64 `json:"maxUsed"`
+	// MaxUsedRatio is MaxUsed over Requested, or 0 if nothing was requested.
+	MaxUsedRatio float64 `json:"maxUsedRatio"`
+	// ReservedSeconds is MaxUsed during the runtime, and AllocatedSeconds the part of it which was allocated.
+	AllocatedSeconds float64 `json:"allocatedSeconds"`
+	ReservedSeconds  float64 `json:"reservedSeconds"`
+	// IdleSeconds is the part of ReservedSeconds which was not allocated.
+	IdleSeconds float64 `json:"idleSeconds"`
+	// AllocatedRatio is AllocatedSeconds over ReservedSeconds, at most 1, or 0 if nothing was reserved.
+	AllocatedRatio float64 `json:"allocatedRatio"`
+}
+
+// ComputeRatios computes the ratios and the idle resource-seconds from the quantities.
+func (e *Efficiency) ComputeRatios() {
+	e.MaxUsedRatio = 0
+	if e.Requested > 0 {
+		e.MaxUsedRatio = e.MaxUsed / e.Requested
+	}
+	e.IdleSeconds = math.Max(e.ReservedSeconds-e.AllocatedSeconds, 0)
+	e.AllocatedRatio = 0
+	if e.ReservedSeconds > 0 {
+		e.AllocatedRatio = math.Min(e.AllocatedSeconds/e.ReservedSeconds, 1)
+	}
+}
+
+// EfficiencyReport are the applications, users and queues with the largest waste of a resource,
+// for the applications submitted during a period.
+type EfficiencyReport struct {
+	// Start and End are the period of the submission times in milliseconds since the epoch.
+	Start    int64  `json:"start"`
+	End      int64  `json:"end"`
+	Resource string `json:"resource"`
+	// RankBy is the waste by which the applications, users and queues are ranked, from the largest one.
+	RankBy       string        `json:"rankBy"`
+	Applications []*Efficiency `json:"applications"`
+	Users        []*Efficiency `json:"users"`
+	Queues       []*Efficiency `json:"queues"`
+}
+
+// NewEfficiencyReport creates a report from the efficiencies of the applications, and of the roll-ups
+// per user and per queue, in the order of their ranking.
+func NewEfficiencyReport(start, end int64, resource, rankBy string, efficiencies []*Efficiency) *EfficiencyReport {
+	report := &EfficiencyReport{
+		Start:        start,
+		End:          end,
+		Resource:     resource,
+		RankBy:       rankBy,
+		Applications: []*Efficiency{},
+		Users:        []*Efficiency{},
+		Queues:       []*Efficiency{},
+	}
+	for _, e := range efficiencies {
+		switch {
+		case e.ApplicationID != nil:
+			report.Applications = append(report.Applications, e)
+		case e.User != nil:
+			report.Users = append(report.Users, e)
+		case e.Queue != nil:
+			report.Queues = append(report.Queues, e)
+		}
+	}
+	return report
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/G-Research/unicorn-history-server/internal/util"
+)
+
+func TestEfficiencyComputeRatios(t *testing.T) {
+	e := &Efficiency{Requested: 8000, MaxUsed: 2000, AllocatedSeconds: 3600000, ReservedSeconds: 7200000}
+	e.ComputeRatios()
+	assert.InDelta(t, 0.25, e.MaxUsedRatio, 1e-9)
+	assert.InDelta(t, 3600000, e.IdleSeconds, 1e-9)
+	assert.InDelta(t, 0.5, e.AllocatedRatio, 1e-9)
+
+	// more allocated than reserved, e.g. when the maximum used quantity is not known
+	e = &Efficiency{AllocatedSeconds: 100}
+	e.ComputeRatios()
+	assert.Zero(t, e.MaxUsedRatio)
+	assert.Zero(t, e.IdleSeconds)
+	assert.Zero(t, e.AllocatedRatio)
+}
+
+func TestNewEfficiencyReport(t *testing.T) {
+	app := &Efficiency{ApplicationID: util.ToPtr("app-1"), Queue: util.ToPtr("root.a"), User: util.ToPtr("alice")}
+	user := &Efficiency{User: util.ToPtr("alice")}
+	queue := &Efficiency{Queue: util.ToPtr("root.a")}
+
+	report := NewEfficiencyReport(0, 1000, "vcore", "idle", []*Efficiency{app, queue, user})
+	assert.Equal(t, &EfficiencyReport{
+		Start:        0,
+		End:          1000,
+		Resource:     "vcore",
+		RankBy:       "idle",
+		Applications: []*Efficiency{app},
+		Users:        []*Efficiency{user},
+		Queues:       []*Efficiency{queue},
+	}, report)
+
+	report = NewEfficiencyReport(0, 1000, "vcore", "idle", nil)
+	assert.Equal(t, []*Efficiency{}, report.Applications)
+	assert.Equal(t, []*Efficiency{}, report.Users)
+	assert.Equal(t, []*Efficiency{}, report.Queues)
+}
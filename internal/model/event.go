@@ -0,0 +1,40 @@
+package model
+
+import (
+	"github.com/G-Research/yunikorn-scheduler-interface/lib/go/si"
+)
+
+// Event is an event of the YuniKorn event stream.
+type Event struct {
+	ID            int64  `json:"id"`
+	TimestampNano int64  `json:"timestampNano"`
+	Type          string `json:"type"`
+	// ObjectID is the ID of the object of the event, e.g. the applicationID or the nodeID.
+	ObjectID string `json:"objectID"`
+	// ReferenceID is the ID of a secondary object of the event, e.g. an allocation key.
+	ReferenceID  string           `json:"referenceID,omitempty"`
+	ChangeType   string           `json:"changeType"`
+	ChangeDetail string           `json:"changeDetail"`
+	Message      string           `json:"message,omitempty"`
+	Resource     map[string]int64 `json:"resource,omitempty"`
+}
+
+// NewEvent creates an event from an event record of the YuniKorn event stream.
+func NewEvent(ev *si.EventRecord) *Event {
+	event := &Event{
+		TimestampNano: ev.GetTimestampNano(),
+		Type:          ev.GetType().String(),
+		ObjectID:      ev.GetObjectID(),
+		ReferenceID:   ev.GetReferenceID(),
+		ChangeType:    ev.GetEventChangeType().String(),
+		ChangeDetail:  ev.GetEventChangeDetail().String(),
+		Message:       ev.GetMessage(),
+	}
+	if resources := ev.GetResource().GetResources(); len(resources) > 0 {
+		event.Resource = make(map[string]int64, len(resources))
+		for name, quantity := range resources {
+			event.Resource[name] = quantity.GetValue()
+		}
+	}
+	return event
+}
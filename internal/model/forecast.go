@@ -0,0 +1,242 @@
+package model
+
+import (
+	"math"
+	"time"
+)
+
+// DemandPoint is the average demand of a resource of a queue during a bucket of a demand series.
+type DemandPoint struct {
+	// Timestamp is the start of the bucket in milliseconds since the epoch.
+	Timestamp int64 `json:"timestamp"`
+	// Allocated and Pending are the average allocated and pending quantities. They are nil if the queue
+	// was not observed during the bucket.
+	Allocated *float64 `json:"allocated"`
+	Pending   *float64 `json:"pending"`
+	// Capacity is the average capacity of the queue, or nil if it is unknown.
+	Capacity *float64 `json:"capacity"`
+}
+
+// ForecastBand is a projected value and its confidence interval.
+type ForecastBand struct {
+	Value float64 `json:"value"`
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+}
+
+// ForecastPoint is the projected demand of a resource at a time.
+type ForecastPoint struct {
+	// Timestamp is in milliseconds since the epoch.
+	Timestamp int64        `json:"timestamp"`
+	Demand    ForecastBand `json:"demand"`
+	// Utilization is the projected demand relative to the capacity. It is nil if the capacity is unknown.
+	Utilization *ForecastBand `json:"utilization,omitempty"`
+}
+
+// ForecastReport is the demand of a resource of a queue during a period, and its projection over a horizon
+// by an additive Holt-Winters model.
+type ForecastReport struct {
+	Partition string `json:"partition"`
+	Queue     string `json:"queue"`
+	Resource  string `json:"resource"`
+	// Start and End are the period of the history in milliseconds since the epoch.
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+	// Step is the time between the points, and Horizon the period of the projection, in milliseconds.
+	Step    int64 `json:"step"`
+	Horizon int64 `json:"horizon"`
+	// Season is the number of points of a season, or 0 if the model has no seasonality.
+	Season int `json:"season"`
+	// Alpha, Beta and Gamma are the smoothing parameters of the level, trend and seasonality of the model.
+	Alpha float64 `json:"alpha"`
+	Beta  float64 `json:"beta"`
+	Gamma float64 `json:"gamma"`
+	// Capacity is the latest known capacity of the queue.
+	Capacity *float64 `json:"capacity,omitempty"`
+	// ExhaustionTime is the first projected time at which the demand reaches the capacity, and EarliestExhaustionTime
+	// the first one at which the upper bound of the demand reaches it. They are nil if it is not reached within the horizon.
+	ExhaustionTime         *int64           `json:"exhaustionTime,omitempty"`
+	EarliestExhaustionTime *int64           `json:"earliestExhaustionTime,omitempty"`
+	History                []*DemandPoint   `json:"history"`
+	Forecast               []*ForecastPoint `json:"forecast"`
+}
+
+const (
+	// forecastSeason is the period of the seasonality of the demand.
+	forecastSeason = 24 * time.Hour
+	// forecastZ is the quantile of the normal distribution of the 95% confidence bands.
+	forecastZ = 1.96
+)
+
+var (
+	forecastAlphas = []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9}
+	forecastBetas  = []float64{0, 0.01, 0.05, 0.1, 0.2}
+	forecastGammas = []float64{0.05, 0.1, 0.2, 0.4}
+)
+
+// holtWinters is an additive Holt-Winters model fitted to a series.
+type holtWinters struct {
+	alpha, beta, gamma float64
+	level, trend       float64
+	// seasonal holds the seasonal component of every point of a season, indexed by the position in the series modulo
+	// the season. It is empty if the model is not seasonal.
+	seasonal []float64
+	// n is the number of points of the fitted series, and variance the variance of its one-step errors.
+	n        int
+	variance float64
+	sse      float64
+}
+
+// fitHoltWinters fits a model with the smoothing parameters to the series, which has at least 2 points,
+// or at least 2 seasons if the model is seasonal. The first point or season initializes the model.
+func fitHoltWinters(series []float64, season int, alpha, beta, gamma float64) *holtWinters {
+	hw := &holtWinters{alpha: alpha, beta: beta, gamma: gamma, n: len(series)}
+	first := 1
+	if season > 0 {
+		first = season
+		var mean1, mean2 float64
+		for i := 0; i < season; i++ {
+			mean1 += series[i] / float64(season)
+			mean2 += series[season+i] / float64(season)
+		}
+		hw.level = mean1
+		hw.trend = (mean2 - mean1) / float64(season)
+		hw.seasonal = make([]float64, season)
+		for i := 0; i < season; i++ {
+			hw.seasonal[i] = series[i] - mean1
+		}
+	} else {
+		hw.level = series[0]
+		hw.trend = series[1] - series[0]
+	}
+
+	for t := first; t < len(series); t++ {
+		var s float64
+		if season > 0 {
+			s = hw.seasonal[t%season]
+		}
+		e := series[t] - (hw.level + hw.trend + s)
+		hw.sse += e * e
+		level := alpha*(series[t]-s) + (1-alpha)*(hw.level+hw.trend)
+		hw.trend = beta*(level-hw.level) + (1-beta)*hw.trend
+		hw.level = level
+		if season > 0 {
+			hw.seasonal[t%season] = gamma*(series[t]-level) + (1-gamma)*s
+		}
+	}
+	if count := len(series) - first; count > 0 {
+		hw.variance = hw.sse / float64(count)
+	}
+	return hw
+}
+
+// forecast returns the projected value h steps after the end of the series, and its standard deviation.
+func (hw *holtWinters) forecast(h int) (float64, float64) {
+	value := hw.level + float64(h)*hw.trend
+	season := len(hw.seasonal)
+	if season > 0 {
+		value += hw.seasonal[(hw.n+h-1)%season]
+	}
+	variance := 1.0
+	for j := 1; j < h; j++ {
+		c := hw.alpha * (1 + float64(j)*hw.beta)
+		if season > 0 && j%season == 0 {
+			c += hw.gamma
+		}
+		variance += c * c
+	}
+	return value, math.Sqrt(hw.variance * variance)
+}
+
+// demandSeries returns the demand of the points, from the first observed one. The demand of the points
+// which were not observed after it is the demand of the previous point.
+func demandSeries(history []*DemandPoint) []float64 {
+	var series []float64
+	for _, p := range history {
+		switch {
+		case p.Allocated != nil || p.Pending != nil:
+			var demand float64
+			if p.Allocated != nil {
+				demand += *p.Allocated
+			}
+			if p.Pending != nil {
+				demand += *p.Pending
+			}
+			series = append(series, demand)
+		case len(series) > 0:
+			series = append(series, series[len(series)-1])
+		}
+	}
+	return series
+}
+
+// NewForecastReport fits an additive Holt-Winters model to the demand of the history, the sum of its allocated and
+// pending quantities, and projects it over the horizon. The model has a daily seasonality if the history covers
+// at least two days. Its smoothing parameters minimize the squared one-step errors over the history.
+func NewForecastReport(history []*DemandPoint, step, horizon time.Duration) *ForecastReport {
+	report := &ForecastReport{
+		Step:     step.Milliseconds(),
+		Horizon:  horizon.Milliseconds(),
+		History:  history,
+		Forecast: []*ForecastPoint{},
+	}
+	for _, p := range history {
+		if p.Capacity != nil {
+			report.Capacity = p.Capacity
+		}
+	}
+
+	series := demandSeries(history)
+	if len(series) < 2 || step <= 0 {
+		return report
+	}
+	season := 0
+	if forecastSeason%step == 0 && int(forecastSeason/step) > 1 && len(series) >= 2*int(forecastSeason/step) {
+		season = int(forecastSeason / step)
+	}
+	gammas := forecastGammas
+	if season == 0 {
+		gammas = []float64{0}
+	}
+	var best *holtWinters
+	for _, alpha := range forecastAlphas {
+		for _, beta := range forecastBetas {
+			for _, gamma := range gammas {
+				hw := fitHoltWinters(series, season, alpha, beta, gamma)
+				if best == nil || hw.sse < best.sse {
+					best = hw
+				}
+			}
+		}
+	}
+	report.Season = season
+	report.Alpha, report.Beta, report.Gamma = best.alpha, best.beta, best.gamma
+
+	last := history[len(history)-1].Timestamp
+	for h := 1; h <= int(horizon/step); h++ {
+		value, deviation := best.forecast(h)
+		point := &ForecastPoint{
+			Timestamp: last + int64(h)*step.Milliseconds(),
+			Demand: ForecastBand{
+				Value: math.Max(value, 0),
+				Lower: math.Max(value-forecastZ*deviation, 0),
+				Upper: math.Max(value+forecastZ*deviation, 0),
+			},
+		}
+		if capacity := report.Capacity; capacity != nil && *capacity > 0 {
+			point.Utilization = &ForecastBand{
+				Value: point.Demand.Value / *capacity,
+				Lower: point.Demand.Lower / *capacity,
+				Upper: point.Demand.Upper / *capacity,
+			}
+			if report.ExhaustionTime == nil && point.Demand.Value >= *capacity {
+				report.ExhaustionTime = &point.Timestamp
+			}
+			if report.EarliestExhaustionTime == nil && point.Demand.Upper >= *capacity {
+				report.EarliestExhaustionTime = &point.Timestamp
+			}
+		}
+		report.Forecast = append(report.Forecast, point)
+	}
+	return report
+}
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/G-Research/unicorn-history-server/internal/util"
+)
+
+func TestNewForecastReport(t *testing.T) {
+	t.Run("Trend until the capacity is exhausted", func(t *testing.T) {
+		var history []*DemandPoint
+		for i := 0; i < 10; i++ {
+			history = append(history, &DemandPoint{
+				Timestamp: (time.Duration(i) * time.Hour).Milliseconds(),
+				Allocated: util.ToPtr(float64(50 * i)),
+				Pending:   util.ToPtr(float64(50 * i)),
+				Capacity:  util.ToPtr(1500.0),
+			})
+		}
+		report := NewForecastReport(history, time.Hour, 12*time.Hour)
+
+		assert.Equal(t, 0, report.Season, "the history is shorter than two days")
+		assert.Equal(t, util.ToPtr(1500.0), report.Capacity)
+		require.Len(t, report.Forecast, 12)
+		for h, point := range report.Forecast {
+			assert.Equal(t, (time.Duration(9+h+1) * time.Hour).Milliseconds(), point.Timestamp)
+			assert.InDelta(t, float64(900+100*(h+1)), point.Demand.Value, 1e-9)
+			assert.InDelta(t, point.Demand.Value, point.Demand.Upper, 1e-9, "the trend fits the history exactly")
+			require.NotNil(t, point.Utilization)
+			assert.InDelta(t, point.Demand.Value/1500, point.Utilization.Value, 1e-9)
+		}
+		assert.Equal(t, util.ToPtr((15 * time.Hour).Milliseconds()), report.ExhaustionTime)
+		assert.Equal(t, report.ExhaustionTime, report.EarliestExhaustionTime)
+	})
+
+	t.Run("Daily seasonality", func(t *testing.T) {
+		demand := func(i int) float64 {
+			return 1000 + 500*math.Sin(2*math.Pi*float64(i)/24)
+		}
+		var history []*DemandPoint
+		for i := 0; i < 3*24; i++ {
+			history = append(history, &DemandPoint{
+				Timestamp: (time.Duration(i) * time.Hour).Milliseconds(),
+				Allocated: util.ToPtr(demand(i)),
+				Pending:   util.ToPtr(0.0),
+			})
+		}
+		report := NewForecastReport(history, time.Hour, 24*time.Hour)
+
+		assert.Equal(t, 24, report.Season)
+		assert.Nil(t, report.Capacity)
+		assert.Nil(t, report.ExhaustionTime)
+		require.Len(t, report.Forecast, 24)
+		for h, point := range report.Forecast {
+			expected := demand(3*24 + h)
+			assert.InDelta(t, expected, point.Demand.Value, 50, h)
+			assert.LessOrEqual(t, point.Demand.Lower, point.Demand.Value)
+			assert.GreaterOrEqual(t, point.Demand.Upper, point.Demand.Value)
+			assert.Nil(t, point.Utilization)
+		}
+	})
+
+	t.Run("Not enough history", func(t *testing.T) {
+		report := NewForecastReport([]*DemandPoint{
+			{Timestamp: 0},
+			{Timestamp: time.Hour.Milliseconds(), Allocated: util.ToPtr(1.0), Pending: util.ToPtr(0.0)},
+		}, time.Hour, 24*time.Hour)
+		assert.Empty(t, report.Forecast)
+		assert.Nil(t, report.ExhaustionTime)
+	})
+}
+
+func TestDemandSeries(t *testing.T) {
+	series := demandSeries([]*DemandPoint{
+		{Timestamp: 0},
+		{Timestamp: 1, Allocated: util.ToPtr(2.0), Pending: util.ToPtr(1.0)},
+		{Timestamp: 2},
+		{Timestamp: 3, Allocated: util.ToPtr(4.0)},
+	})
+	assert.Equal(t, []float64{3, 3, 4}, series)
+}
@@ -0,0 +1,98 @@
+package model
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+// TaskGroupStats are the placeholders of a task group of gang-scheduled applications, and the time from the
+// allocation of a placeholder until it was replaced by a real allocation.
+type TaskGroupStats struct {
+	// Queue is the queue of the applications. It is only set in the gang scheduling report.
+	Queue         *string `json:"queue,omitempty"`
+	TaskGroupName string  `json:"taskGroupName"`
+	// Applications is the number of applications with the task group. It is only set in the gang scheduling report.
+	Applications int64 `json:"applications,omitempty"`
+	Placeholders int64 `json:"placeholders"`
+	Replaced     int64 `json:"replaced"`
+	TimedOut     int64 `json:"timedOut"`
+	// TimeoutRate is the ratio of the placeholders which timed out.
+	TimeoutRate float64 `json:"timeoutRate"`
+	// ReplacementTime is the distribution of the time in milliseconds from the allocation of a placeholder
+	// until its real allocation. It is nil if no placeholder of the current allocations was replaced.
+	ReplacementTime *Percentiles `json:"replacementTime,omitempty"`
+}
+
+// GangReport are the task groups of the gang-scheduled applications submitted during a period, per queue.
+type GangReport struct {
+	// Start and End are the period of the submission times in milliseconds since the epoch.
+	Start      int64             `json:"start"`
+	End        int64             `json:"end"`
+	TaskGroups []*TaskGroupStats `json:"taskGroups"`
+}
+
+// TaskGroupStats returns the task groups of the application in the order of its placeholder data.
+// The replacement times are known for the current allocations which replaced a placeholder,
+// whose request time is the time of the allocation of the placeholder.
+func (app *Application) TaskGroupStats() []*TaskGroupStats {
+	var stats []*TaskGroupStats
+	byName := make(map[string]*TaskGroupStats)
+	get := func(name string) *TaskGroupStats {
+		if st, ok := byName[name]; ok {
+			return st
+		}
+		st := &TaskGroupStats{TaskGroupName: name}
+		byName[name] = st
+		stats = append(stats, st)
+		return st
+	}
+
+	for _, ph := range app.PlaceholderData {
+		if ph == nil {
+			continue
+		}
+		st := get(ph.TaskGroupName)
+		st.Placeholders += ph.Count
+		st.Replaced += ph.Replaced
+		st.TimedOut += ph.TimedOut
+	}
+	replacementTimes := make(map[string][]int64)
+	for _, alloc := range app.Allocations {
+		if alloc == nil || !alloc.PlaceholderUsed || alloc.AllocationTime == 0 || alloc.RequestTime == 0 {
+			continue
+		}
+		get(alloc.TaskGroupName)
+		replacementTimes[alloc.TaskGroupName] = append(replacementTimes[alloc.TaskGroupName],
+			time.Duration(alloc.AllocationTime-alloc.RequestTime).Milliseconds())
+	}
+	for _, st := range stats {
+		if st.Placeholders > 0 {
+			st.TimeoutRate = float64(st.TimedOut) / float64(st.Placeholders)
+		}
+		st.ReplacementTime = newPercentiles(replacementTimes[st.TaskGroupName])
+	}
+	return stats
+}
+
+// newPercentiles computes the percentiles of the values with linear interpolation between the closest ranks,
+// the same way as percentile_cont of PostgreSQL. It returns nil if there are no values.
+func newPercentiles(values []int64) *Percentiles {
+	if len(values) == 0 {
+		return nil
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	percentile := func(p float64) float64 {
+		rank := p * float64(len(sorted)-1)
+		lower := int(math.Floor(rank))
+		upper := int(math.Ceil(rank))
+		return float64(sorted[lower]) + (rank-float64(lower))*float64(sorted[upper]-sorted[lower])
+	}
+	return &Percentiles{
+		P50: percentile(0.5),
+		P90: percentile(0.9),
+		P99: percentile(0.99),
+		Max: float64(sorted[len(sorted)-1]),
+	}
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/G-Research/yunikorn-core/pkg/webservice/dao"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplicationTaskGroupStats(t *testing.T) {
+	placeholderAllocated := time.Date(2024, 12, 1, 10, 0, 0, 0, time.UTC)
+	replaced := func(taskGroup string, after time.Duration) *dao.AllocationDAOInfo {
+		return &dao.AllocationDAOInfo{
+			TaskGroupName:   taskGroup,
+			PlaceholderUsed: true,
+			RequestTime:     placeholderAllocated.UnixNano(),
+			AllocationTime:  placeholderAllocated.Add(after).UnixNano(),
+		}
+	}
+
+	app := &Application{
+		ApplicationDAOInfo: dao.ApplicationDAOInfo{
+			PlaceholderData: []*dao.PlaceholderDAOInfo{
+				{TaskGroupName: "driver", Count: 1, Replaced: 1},
+				{TaskGroupName: "executor", Count: 4, Replaced: 2, TimedOut: 1},
+			},
+			Allocations: []*dao.AllocationDAOInfo{
+				replaced("driver", time.Second),
+				replaced("executor", 2*time.Second),
+				replaced("executor", 4*time.Second),
+				// not a replacement
+				{TaskGroupName: "executor", AllocationTime: placeholderAllocated.UnixNano()},
+			},
+		},
+	}
+
+	assert.Equal(t, []*TaskGroupStats{
+		{
+			TaskGroupName:   "driver",
+			Placeholders:    1,
+			Replaced:        1,
+			ReplacementTime: &Percentiles{P50: 1000, P90: 1000, P99: 1000, Max: 1000},
+		},
+		{
+			TaskGroupName:   "executor",
+			Placeholders:    4,
+			Replaced:        2,
+			TimedOut:        1,
+			TimeoutRate:     0.25,
+			ReplacementTime: &Percentiles{P50: 3000, P90: 3800, P99: 3980, Max: 4000},
+		},
+	}, app.TaskGroupStats())
+
+	assert.Empty(t, (&Application{}).TaskGroupStats())
+}
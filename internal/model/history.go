@@ -23,3 +23,24 @@ type ContainerHistory struct {
 func (h *ContainerHistory) MergeFromContainerHistory(other *dao.ContainerHistoryDAOInfo) {
 	h.ContainerHistoryDAOInfo = *other
 }
+
+// TimelinePoint is the number of applications or containers at a point of a timeline,
+// which is derived from the stored lifecycles of the applications.
+type TimelinePoint struct {
+	// Timestamp is the time of the point in nanoseconds since the epoch.
+	Timestamp int64 `json:"timestamp"`
+	// Pending and Running are the numbers at the timestamp.
+	Pending int64 `json:"pending"`
+	Running int64 `json:"running"`
+	// Completed is the number which completed from the timestamp until the next point.
+	Completed int64 `json:"completed"`
+}
+
+// HistoryPoint is the aggregate of the applications or containers history entries in a time bucket
+// of a downsampled history.
+type HistoryPoint struct {
+	// Timestamp is the start of the bucket in nanoseconds since the epoch, which is aligned to the step.
+	Timestamp int64 `json:"timestamp"`
+	// Value is the aggregate of the total numbers of the entries in the bucket, or nil if there are none.
+	Value *float64 `json:"value"`
+}
@@ -1,6 +1,9 @@
 package model
 
 import (
+	"sort"
+	"strings"
+
 	"github.com/G-Research/yunikorn-core/pkg/webservice/dao"
 )
 
@@ -48,3 +51,164 @@ func (n *Node) MergeFrom(nodeInfo *dao.NodeDAOInfo) {
 		clear(lookup)
 	}
 }
+
+// NodeDetail is a node together with its lifecycle.
+type NodeDetail struct {
+	Node     `json:",inline"`
+	Timeline []*NodeTimelineEntry `json:"timeline"`
+}
+
+type NodeTimelineEntryType string
+
+const (
+	NodeTimelineAdded              NodeTimelineEntryType = "added"
+	NodeTimelineRemoved            NodeTimelineEntryType = "removed"
+	NodeTimelineSchedulable        NodeTimelineEntryType = "schedulable"
+	NodeTimelineUnschedulable      NodeTimelineEntryType = "unschedulable"
+	NodeTimelineAttributesChanged  NodeTimelineEntryType = "attributesChanged"
+	NodeTimelineCapacityChanged    NodeTimelineEntryType = "capacityChanged"
+	NodeTimelineUtilizationChanged NodeTimelineEntryType = "utilizationChanged"
+	NodeTimelineEvent              NodeTimelineEntryType = "event"
+)
+
+// NodeTimelineEntry is a change of a node, which was either detected between two versions of the node,
+// or reported by an event of the node.
+type NodeTimelineEntry struct {
+	TimestampNano int64                 `json:"timestampNano"`
+	Type          NodeTimelineEntryType `json:"type"`
+	// Changes are the changed fields of the node, if the entry was detected between two versions.
+	Changes []FieldChange `json:"changes,omitempty"`
+	// Event is the event of the node, if the entry was reported by an event.
+	Event *Event `json:"event,omitempty"`
+	// Utilized is the utilization of the node at the time of the entry,
+	// as it was known by the version of the node which was valid at that time.
+	Utilized map[string]int64 `json:"utilized,omitempty"`
+	// RunningApplications are the applications which had allocations placed on the node at the time of the entry.
+	RunningApplications []string `json:"runningApplications,omitempty"`
+}
+
+// AllocationPeriod is the period during which an allocation of an application was placed on a node.
+type AllocationPeriod struct {
+	ApplicationID string `json:"applicationId"`
+	AllocationKey string `json:"allocationKey"`
+	StartNano     int64  `json:"startNano"`
+	// EndNano is nil if the allocation was not removed and its application did not end yet.
+	EndNano *int64 `json:"endNano,omitempty"`
+}
+
+// contains returns true if the allocation was placed on the node at the time.
+func (p *AllocationPeriod) contains(timestampNano int64) bool {
+	return p.StartNano <= timestampNano && (p.EndNano == nil || timestampNano < *p.EndNano)
+}
+
+// nodeTimelineFieldTypes maps the prefixes of the changed fields to the type of the timeline entry.
+var nodeTimelineFieldTypes = []struct {
+	prefix    string
+	entryType NodeTimelineEntryType
+}{
+	{"attributes.", NodeTimelineAttributesChanged},
+	{"capacity.", NodeTimelineCapacityChanged},
+	{"allocated.", NodeTimelineUtilizationChanged},
+	{"occupied.", NodeTimelineUtilizationChanged},
+	{"available.", NodeTimelineUtilizationChanged},
+	{"utilized.", NodeTimelineUtilizationChanged},
+}
+
+// BuildNodeTimeline builds the timeline of a node from its versions, its events and the periods of the allocations
+// which were placed on it. The versions are expected to be sorted from the oldest to the newest, and must have their
+// changes computed (see ComputeChanges). The returned entries are sorted by time.
+func BuildNodeTimeline(versions []*NodeVersion, events []*Event, periods []*AllocationPeriod) []*NodeTimelineEntry {
+	var timeline []*NodeTimelineEntry
+	for i, v := range versions {
+		if i == 0 {
+			timeline = append(timeline, &NodeTimelineEntry{TimestampNano: v.ValidFromNano, Type: NodeTimelineAdded})
+		}
+		byType := make(map[NodeTimelineEntryType]*NodeTimelineEntry)
+		var types []NodeTimelineEntryType
+		for _, change := range v.Changes {
+			entryType, ok := nodeTimelineEntryType(change, &v.Object)
+			if !ok {
+				continue
+			}
+			entry, ok := byType[entryType]
+			if !ok {
+				entry = &NodeTimelineEntry{TimestampNano: v.ValidFromNano, Type: entryType}
+				byType[entryType] = entry
+				types = append(types, entryType)
+			}
+			entry.Changes = append(entry.Changes, change)
+		}
+		for _, entryType := range types {
+			timeline = append(timeline, byType[entryType])
+		}
+	}
+	if len(versions) > 0 {
+		if deletedAtNano := versions[len(versions)-1].Object.DeletedAtNano; deletedAtNano != nil {
+			timeline = append(timeline, &NodeTimelineEntry{TimestampNano: *deletedAtNano, Type: NodeTimelineRemoved})
+		}
+	}
+	for _, event := range events {
+		timeline = append(timeline, &NodeTimelineEntry{TimestampNano: event.TimestampNano, Type: NodeTimelineEvent, Event: event})
+	}
+
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].TimestampNano < timeline[j].TimestampNano
+	})
+	for _, entry := range timeline {
+		if node := nodeAt(versions, entry.TimestampNano); node != nil {
+			entry.Utilized = node.Utilized
+		}
+		entry.RunningApplications = runningApplications(periods, entry.TimestampNano)
+	}
+	return timeline
+}
+
+func nodeTimelineEntryType(change FieldChange, node *Node) (NodeTimelineEntryType, bool) {
+	if change.Field == "schedulable" {
+		if node.Schedulable {
+			return NodeTimelineSchedulable, true
+		}
+		return NodeTimelineUnschedulable, true
+	}
+	for _, fieldType := range nodeTimelineFieldTypes {
+		if strings.HasPrefix(change.Field, fieldType.prefix) {
+			return fieldType.entryType, true
+		}
+	}
+	return "", false
+}
+
+// nodeAt returns the version of the node which was valid at the time, or the first version
+// if the time is before the first version.
+func nodeAt(versions []*NodeVersion, timestampNano int64) *Node {
+	if len(versions) == 0 {
+		return nil
+	}
+	node := &versions[0].Object
+	for _, v := range versions {
+		if v.ValidFromNano > timestampNano {
+			break
+		}
+		node = &v.Object
+	}
+	return node
+}
+
+// runningApplications returns the IDs of the applications which had allocations placed on the node at the time, sorted.
+// The allocations of a node are not derived from its versions, since the versions keep every allocation
+// which was ever placed on the node.
+func runningApplications(periods []*AllocationPeriod, timestampNano int64) []string {
+	var ids []string
+	seen := make(map[string]struct{})
+	for _, p := range periods {
+		if !p.contains(timestampNano) {
+			continue
+		}
+		if _, ok := seen[p.ApplicationID]; !ok {
+			seen[p.ApplicationID] = struct{}{}
+			ids = append(ids, p.ApplicationID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -6,6 +6,9 @@ import (
 
 	"github.com/G-Research/yunikorn-core/pkg/webservice/dao"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/G-Research/unicorn-history-server/internal/util"
 )
 
 func TestNodeMergeFrom(t *testing.T) {
@@ -114,3 +117,67 @@ func TestNodeMergeFrom(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildNodeTimeline(t *testing.T) {
+	node := func(schedulable bool, utilized int64, apps ...string) Node {
+		n := Node{NodeDAOInfo: dao.NodeDAOInfo{
+			ID:          "1",
+			NodeID:      "node-1",
+			Schedulable: schedulable,
+			Attributes:  map[string]string{"instance-type": "m5.large"},
+			Utilized:    map[string]int64{"memory": utilized},
+		}}
+		for _, app := range apps {
+			n.Allocations = append(n.Allocations, &dao.AllocationDAOInfo{AllocationKey: app + "-alloc", ApplicationID: app})
+		}
+		return n
+	}
+	// the versions keep the allocations which were removed from the node
+	removed := node(false, 0, "app-1", "app-2")
+	removed.DeletedAtNano = util.ToPtr(int64(400))
+
+	versions := []*NodeVersion{
+		{VersionInfo: VersionInfo{ValidFromNano: 100}, Object: node(true, 0)},
+		{VersionInfo: VersionInfo{ValidFromNano: 200}, Object: node(true, 50, "app-1", "app-2")},
+		{VersionInfo: VersionInfo{ValidFromNano: 300}, Object: node(false, 50, "app-1", "app-2")},
+		{VersionInfo: VersionInfo{ValidFromNano: 400}, Object: removed},
+	}
+	versions[2].Object.Attributes = map[string]string{"instance-type": "m5.large", "cordoned": "true"}
+	versions[3].Object.Attributes = versions[2].Object.Attributes
+	require.NoError(t, ComputeChanges(versions))
+
+	events := []*Event{
+		{TimestampNano: 250, Type: "NODE", ObjectID: "node-1", ChangeType: "SET", ChangeDetail: "NODE_ALLOC"},
+		{TimestampNano: 50, Type: "NODE", ObjectID: "node-1", ChangeType: "ADD", ChangeDetail: "DETAILS_NONE"},
+	}
+
+	periods := []*AllocationPeriod{
+		{ApplicationID: "app-1", AllocationKey: "app-1-alloc", StartNano: 200, EndNano: util.ToPtr(int64(400))},
+		{ApplicationID: "app-2", AllocationKey: "app-2-alloc", StartNano: 150, EndNano: util.ToPtr(int64(250))},
+	}
+
+	timeline := BuildNodeTimeline(versions, events, periods)
+
+	type entry struct {
+		timestampNano int64
+		entryType     NodeTimelineEntryType
+		apps          []string
+	}
+	var got []entry
+	for _, e := range timeline {
+		got = append(got, entry{e.TimestampNano, e.Type, e.RunningApplications})
+	}
+	want := []entry{
+		{50, NodeTimelineEvent, nil},
+		{100, NodeTimelineAdded, nil},
+		{200, NodeTimelineUtilizationChanged, []string{"app-1", "app-2"}},
+		{250, NodeTimelineEvent, []string{"app-1"}},
+		{300, NodeTimelineAttributesChanged, []string{"app-1"}},
+		{300, NodeTimelineUnschedulable, []string{"app-1"}},
+		{400, NodeTimelineUtilizationChanged, nil},
+		{400, NodeTimelineRemoved, nil},
+	}
+	assert.Equal(t, want, got)
+	assert.Equal(t, []FieldChange{{Field: "attributes.cordoned", New: "true"}}, timeline[4].Changes)
+	assert.Equal(t, events[0], timeline[3].Event)
+}
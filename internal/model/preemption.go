@@ -0,0 +1,61 @@
+package model
+
+const (
+	// PreemptionSourceEvent marks a preemption recorded from the ALLOC_PREEMPT event of the allocation.
+	PreemptionSourceEvent = "event"
+	// PreemptionSourceSync marks a preemption recorded from the preempted flag of the allocation during a sync.
+	// Its timestamp is the time of the sync, since the time of the preemption is unknown.
+	PreemptionSourceSync = "sync"
+)
+
+// Preemption is the preemption of an allocation of a victim application.
+type Preemption struct {
+	ID            int64  `json:"id"`
+	TimestampNano int64  `json:"timestampNano"`
+	Source        string `json:"source"`
+	// ApplicationID and AllocationKey identify the preempted allocation.
+	ApplicationID string           `json:"applicationId"`
+	AllocationKey string           `json:"allocationKey"`
+	Partition     string           `json:"partition"`
+	QueueName     string           `json:"queueName"`
+	User          string           `json:"user"`
+	Resource      map[string]int64 `json:"resource,omitempty"`
+	// PreemptorApplicationID and PreemptorAllocationKey identify the ask which triggered the preemption.
+	// They are inferred from the asks of the partition which triggered preemption, and are nil if none was found.
+	PreemptorApplicationID *string `json:"preemptorApplicationId,omitempty"`
+	PreemptorAllocationKey *string `json:"preemptorAllocationKey,omitempty"`
+}
+
+// NewPreemption creates the preemption of an allocation of the victim application.
+func NewPreemption(victim *Application, allocationKey string, resource map[string]int64, timestampNano int64, source string) *Preemption {
+	return &Preemption{
+		TimestampNano: timestampNano,
+		Source:        source,
+		ApplicationID: victim.ApplicationID,
+		AllocationKey: allocationKey,
+		Partition:     victim.Partition,
+		QueueName:     victim.QueueName,
+		User:          victim.User,
+		Resource:      resource,
+	}
+}
+
+// PreemptionStats are the preemptions of the allocations of a queue or a user.
+type PreemptionStats struct {
+	// Key is the queue or the user.
+	Key         string `json:"key"`
+	Preemptions int64  `json:"preemptions"`
+	// Applications is the number of victim applications.
+	Applications int64 `json:"applications"`
+	// Resource is the sum of the resources of the preempted allocations.
+	Resource map[string]int64 `json:"resource,omitempty"`
+}
+
+// PreemptionReport are the preemptions during a period, aggregated by the queues and the users of the victims.
+type PreemptionReport struct {
+	// Start and End are the period in milliseconds since the epoch.
+	Start  int64              `json:"start"`
+	End    int64              `json:"end"`
+	Queues []*PreemptionStats `json:"queues"`
+	Users  []*PreemptionStats `json:"users"`
+}
@@ -12,3 +12,35 @@ type Queue struct {
 func (q *Queue) MergeFrom(qInfo *dao.PartitionQueueDAOInfo) {
 	q.PartitionQueueDAOInfo = *qInfo
 }
+
+// QueueDetail is a queue together with the number of its applications.
+type QueueDetail struct {
+	Queue        `json:",inline"`
+	Applications QueueApplicationCounts `json:"applications"`
+}
+
+// QueueApplicationCounts are the numbers of the current applications of a queue.
+type QueueApplicationCounts struct {
+	// Running is the number of applications which are running or completing.
+	Running int64 `json:"running"`
+	// Pending is the number of applications which are new or accepted, but not running yet.
+	Pending int64            `json:"pending"`
+	ByState map[string]int64 `json:"byState"`
+}
+
+// NewQueueApplicationCounts creates the application counts of a queue from the number of applications per state.
+func NewQueueApplicationCounts(byState map[string]int64) QueueApplicationCounts {
+	counts := QueueApplicationCounts{ByState: byState}
+	if counts.ByState == nil {
+		counts.ByState = map[string]int64{}
+	}
+	for state, count := range counts.ByState {
+		switch state {
+		case "Running", "Completing":
+			counts.Running += count
+		case "New", "Accepted":
+			counts.Pending += count
+		}
+	}
+	return counts
+}
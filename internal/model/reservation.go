@@ -0,0 +1,131 @@
+package model
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// ReservationOutcomeAllocated marks a reservation which ended when its ask was allocated.
+	ReservationOutcomeAllocated = "allocated"
+	// ReservationOutcomeUnreserved marks a reservation which ended while its ask was still pending.
+	ReservationOutcomeUnreserved = "unreserved"
+	// ReservationOutcomeCancelled marks a reservation which ended because its ask was cancelled or timed out,
+	// or because its application was removed.
+	ReservationOutcomeCancelled = "cancelled"
+)
+
+// ReservationKey identifies the reservation of a node for an ask of an application.
+type ReservationKey struct {
+	ApplicationID string
+	AllocationKey string
+	NodeID        string
+}
+
+// ApplicationReservationKeys parses the reservations of an application, which are keyed by node and allocation key.
+// Malformed keys are skipped.
+func ApplicationReservationKeys(applicationID string, reservations []string) []ReservationKey {
+	var keys []ReservationKey
+	for _, res := range reservations {
+		nodeID, allocationKey, ok := strings.Cut(res, "|")
+		if !ok || nodeID == "" || allocationKey == "" {
+			continue
+		}
+		keys = append(keys, ReservationKey{ApplicationID: applicationID, AllocationKey: allocationKey, NodeID: nodeID})
+	}
+	return keys
+}
+
+// NodeReservationKeys parses the reservations of a node, which are keyed by application and allocation key.
+// Malformed keys are skipped.
+func NodeReservationKeys(nodeID string, reservations []string) []ReservationKey {
+	var keys []ReservationKey
+	for _, res := range reservations {
+		applicationID, allocationKey, ok := strings.Cut(res, "|")
+		if !ok || applicationID == "" || allocationKey == "" {
+			continue
+		}
+		keys = append(keys, ReservationKey{ApplicationID: applicationID, AllocationKey: allocationKey, NodeID: nodeID})
+	}
+	return keys
+}
+
+// Reservation is an episode during which a node was reserved for an ask of an application.
+type Reservation struct {
+	ID            int64  `json:"id"`
+	PartitionID   string `json:"partitionId"`
+	ApplicationID string `json:"applicationId"`
+	AllocationKey string `json:"allocationKey"`
+	NodeID        string `json:"nodeId"`
+	// Queue and User are those of the application, and are nil if the application is unknown.
+	Queue         *string `json:"queue,omitempty"`
+	User          *string `json:"user,omitempty"`
+	StartTimeNano int64   `json:"startTimeNano"`
+	// EndTimeNano and Outcome are nil while the node is still reserved.
+	EndTimeNano *int64  `json:"endTimeNano,omitempty"`
+	Outcome     *string `json:"outcome,omitempty"`
+	// Duration is the time in milliseconds during which the node was reserved, until the end of the report period
+	// if it is still reserved.
+	Duration int64 `json:"duration"`
+}
+
+// BlockedNode is a node which was reserved for longer than the minimum duration of a reservation report.
+type BlockedNode struct {
+	PartitionID  string `json:"partitionId"`
+	NodeID       string `json:"nodeId"`
+	Reservations int64  `json:"reservations"`
+	Applications int64  `json:"applications"`
+	// ReservedSinceNano is the start of the oldest reservation of the node which had not ended at the end of the
+	// report period, and is nil if the node was not reserved anymore.
+	ReservedSinceNano *int64 `json:"reservedSinceNano,omitempty"`
+	// LongestReservation is the duration in milliseconds of the longest reservation of the node.
+	LongestReservation int64 `json:"longestReservation"`
+}
+
+// ReservationReport are the long-lived reservations during a period and the nodes which were blocked by them.
+type ReservationReport struct {
+	// Start and End are the period in milliseconds since the epoch.
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+	// MinDuration is the duration in milliseconds from which a reservation is long-lived.
+	MinDuration  int64          `json:"minDuration"`
+	Reservations []*Reservation `json:"reservations"`
+	BlockedNodes []*BlockedNode `json:"blockedNodes"`
+}
+
+// NewBlockedNodes aggregates the reservations per node, with the nodes which are reserved for the longest time first.
+func NewBlockedNodes(reservations []*Reservation, end time.Time) []*BlockedNode {
+	var nodes []*BlockedNode
+	byNode := make(map[string]*BlockedNode)
+	applications := make(map[string]map[string]struct{})
+	for _, res := range reservations {
+		key := res.PartitionID + "|" + res.NodeID
+		node, ok := byNode[key]
+		if !ok {
+			node = &BlockedNode{PartitionID: res.PartitionID, NodeID: res.NodeID}
+			byNode[key] = node
+			applications[key] = make(map[string]struct{})
+			nodes = append(nodes, node)
+		}
+		node.Reservations++
+		applications[key][res.ApplicationID] = struct{}{}
+		node.LongestReservation = max(node.LongestReservation, res.Duration)
+		open := res.EndTimeNano == nil || *res.EndTimeNano > end.UnixNano()
+		if open && (node.ReservedSinceNano == nil || res.StartTimeNano < *node.ReservedSinceNano) {
+			since := res.StartTimeNano
+			node.ReservedSinceNano = &since
+		}
+	}
+	for key, node := range byNode {
+		node.Applications = int64(len(applications[key]))
+	}
+	slices.SortStableFunc(nodes, func(a, b *BlockedNode) int {
+		if c := cmp.Compare(b.LongestReservation, a.LongestReservation); c != 0 {
+			return c
+		}
+		return cmp.Or(cmp.Compare(a.PartitionID, b.PartitionID), cmp.Compare(a.NodeID, b.NodeID))
+	})
+	return nodes
+}
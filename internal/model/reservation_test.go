@@ -0,0 +1,63 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/G-Research/unicorn-history-server/internal/util"
+)
+
+func TestReservationKeys(t *testing.T) {
+	assert.Equal(t, []ReservationKey{
+		{ApplicationID: "app-1", AllocationKey: "alloc-1", NodeID: "node-1"},
+	}, ApplicationReservationKeys("app-1", []string{"node-1|alloc-1", "malformed", "|alloc-2"}))
+	assert.Equal(t, []ReservationKey{
+		{ApplicationID: "app-1", AllocationKey: "alloc-1", NodeID: "node-1"},
+		{ApplicationID: "app-2", AllocationKey: "alloc-2", NodeID: "node-1"},
+	}, NodeReservationKeys("node-1", []string{"app-1|alloc-1", "app-2|alloc-2", "app-3|"}))
+	assert.Nil(t, NodeReservationKeys("node-1", nil))
+}
+
+func TestNewBlockedNodes(t *testing.T) {
+	end := time.Date(2024, 12, 1, 12, 0, 0, 0, time.UTC)
+	reservation := func(appID, nodeID string, start time.Time, ended *time.Time) *Reservation {
+		res := &Reservation{PartitionID: "1", ApplicationID: appID, NodeID: nodeID, StartTimeNano: start.UnixNano()}
+		until := end
+		if ended != nil {
+			res.EndTimeNano = util.ToPtr(ended.UnixNano())
+			until = *ended
+		}
+		res.Duration = until.Sub(start).Milliseconds()
+		return res
+	}
+	endedAt := end.Add(-time.Hour)
+	endsLater := end.Add(time.Hour)
+
+	nodes := NewBlockedNodes([]*Reservation{
+		reservation("app-1", "node-1", end.Add(-2*time.Hour), &endedAt),
+		reservation("app-2", "node-2", end.Add(-3*time.Hour), nil),
+		reservation("app-1", "node-2", end.Add(-30*time.Minute), &endsLater),
+		reservation("app-2", "node-2", end.Add(-20*time.Minute), nil),
+	}, end)
+
+	assert.Equal(t, []*BlockedNode{
+		{
+			PartitionID:        "1",
+			NodeID:             "node-2",
+			Reservations:       3,
+			Applications:       2,
+			ReservedSinceNano:  util.ToPtr(end.Add(-3 * time.Hour).UnixNano()),
+			LongestReservation: (3 * time.Hour).Milliseconds(),
+		},
+		{
+			PartitionID:        "1",
+			NodeID:             "node-1",
+			Reservations:       1,
+			Applications:       1,
+			LongestReservation: time.Hour.Milliseconds(),
+		},
+	}, nodes)
+	assert.Nil(t, NewBlockedNodes(nil, end))
+}
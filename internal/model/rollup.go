@@ -0,0 +1,29 @@
+package model
+
+// RollupWatermark is the period [StartNano, WatermarkNano) which is covered by the buckets of a rollup of a width.
+type RollupWatermark struct {
+	Rollup        string `json:"rollup"`
+	WidthSeconds  int64  `json:"widthSeconds"`
+	StartNano     int64  `json:"startNano"`
+	WatermarkNano int64  `json:"watermarkNano"`
+	UpdatedAtNano int64  `json:"updatedAtNano"`
+}
+
+// NodeUtilization is the utilization of a node during the part of a period which is covered by the node utilization rollups.
+type NodeUtilization struct {
+	NodeID    string `json:"nodeId"`
+	Partition string `json:"partition"`
+	// Hours is the time during which the node existed in the covered part of the period.
+	Hours float64 `json:"hours"`
+	// Utilization maps a resource of the capacity of the node to the ratio of its allocated quantity over its capacity,
+	// averaged over Hours.
+	Utilization map[string]float64 `json:"utilization"`
+}
+
+// NodeUtilizationReport is the utilization of the nodes during a period.
+type NodeUtilizationReport struct {
+	// Start and End are the period in milliseconds since the epoch.
+	Start int64              `json:"start"`
+	End   int64              `json:"end"`
+	Nodes []*NodeUtilization `json:"nodes"`
+}
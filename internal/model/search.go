@@ -0,0 +1,33 @@
+package model
+
+// SearchResultType is the type of the object which a search result refers to.
+type SearchResultType string
+
+const (
+	SearchResultTypeApplication SearchResultType = "application"
+	SearchResultTypeQueue       SearchResultType = "queue"
+	SearchResultTypeNode        SearchResultType = "node"
+)
+
+// SearchResult is an object which matches a search query.
+type SearchResult struct {
+	Type SearchResultType `json:"type"`
+	ID   string           `json:"id"`
+	// Name is the name of the object: the applicationID, the queue path or the nodeID.
+	Name        string `json:"name"`
+	PartitionID string `json:"partitionId"`
+	// MatchedField is the field which matches the query best, e.g. 'rejectedMessage' or 'attributes.instance-type'.
+	MatchedField string `json:"matchedField"`
+	MatchedValue string `json:"matchedValue"`
+	// Score is the rank of the result between 0 and 1, 1 is an exact match.
+	Score   float64 `json:"score"`
+	Deleted bool    `json:"deleted"`
+}
+
+// SearchResults are the results of a search query grouped by type, best matches first.
+type SearchResults struct {
+	Query        string          `json:"query"`
+	Applications []*SearchResult `json:"applications"`
+	Queues       []*SearchResult `json:"queues"`
+	Nodes        []*SearchResult `json:"nodes"`
+}
@@ -0,0 +1,63 @@
+package model
+
+// UsagePrices are the unit prices which turn the usage of resources into cost.
+type UsagePrices struct {
+	Currency      string  `json:"currency,omitempty"`
+	VcoreHour     float64 `json:"vcoreHour"`
+	MemoryGiBHour float64 `json:"memoryGiBHour"`
+	GPUHour       float64 `json:"gpuHour"`
+}
+
+// Usage is the usage of resources by the allocations of a group of applications during a period.
+type Usage struct {
+	// Key is the value of the field by which the usage is grouped, e.g. the user or the queue.
+	Key string `json:"key"`
+	// Applications is the number of applications which used resources during the period.
+	Applications   int64   `json:"applications"`
+	VcoreSeconds   float64 `json:"vcoreSeconds"`
+	MemoryGiBHours float64 `json:"memoryGiBHours"`
+	GPUHours       float64 `json:"gpuHours"`
+	Cost           float64 `json:"cost"`
+}
+
+// ApplyPrices computes the cost of the usage.
+func (u *Usage) ApplyPrices(prices UsagePrices) {
+	u.Cost = u.VcoreSeconds/3600*prices.VcoreHour + u.MemoryGiBHours*prices.MemoryGiBHour + u.GPUHours*prices.GPUHour
+}
+
+// UsageReport is the usage of resources during a period, grouped by a field.
+type UsageReport struct {
+	// Start and End are the period of the report in milliseconds since the epoch.
+	Start   int64       `json:"start"`
+	End     int64       `json:"end"`
+	GroupBy string      `json:"groupBy"`
+	Prices  UsagePrices `json:"prices"`
+	Usage   []*Usage    `json:"usage"`
+	// Total is the usage of all applications. Its Key is empty.
+	Total Usage `json:"total"`
+}
+
+// NewUsageReport creates a report of the usage and computes the costs and the total.
+// The total number of applications is the sum of the groups, so applications which are in multiple groups
+// (e.g. when grouped by the groups of the users) are counted multiple times.
+func NewUsageReport(start, end int64, groupBy string, prices UsagePrices, usage []*Usage) *UsageReport {
+	report := &UsageReport{
+		Start:   start,
+		End:     end,
+		GroupBy: groupBy,
+		Prices:  prices,
+		Usage:   usage,
+	}
+	if report.Usage == nil {
+		report.Usage = []*Usage{}
+	}
+	for _, u := range report.Usage {
+		u.ApplyPrices(prices)
+		report.Total.Applications += u.Applications
+		report.Total.VcoreSeconds += u.VcoreSeconds
+		report.Total.MemoryGiBHours += u.MemoryGiBHours
+		report.Total.GPUHours += u.GPUHours
+		report.Total.Cost += u.Cost
+	}
+	return report
+}
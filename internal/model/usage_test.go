@@ -0,0 +1,36 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewUsageReport(t *testing.T) {
+	prices := UsagePrices{Currency: "USD", VcoreHour: 0.04, MemoryGiBHour: 0.005, GPUHour: 2.5}
+	usage := []*Usage{
+		{Key: "alice", Applications: 2, VcoreSeconds: 7200, MemoryGiBHours: 100, GPUHours: 0},
+		{Key: "bob", Applications: 1, VcoreSeconds: 3600, MemoryGiBHours: 0, GPUHours: 4},
+	}
+
+	report := NewUsageReport(1000, 2000, "user", prices, usage)
+
+	assert.Equal(t, int64(1000), report.Start)
+	assert.Equal(t, int64(2000), report.End)
+	assert.Equal(t, "user", report.GroupBy)
+	assert.InDelta(t, 2*0.04+100*0.005, report.Usage[0].Cost, 1e-9)
+	assert.InDelta(t, 0.04+4*2.5, report.Usage[1].Cost, 1e-9)
+	assert.Equal(t, "", report.Total.Key)
+	assert.Equal(t, int64(3), report.Total.Applications)
+	assert.InDelta(t, 10800.0, report.Total.VcoreSeconds, 1e-9)
+	assert.InDelta(t, 100.0, report.Total.MemoryGiBHours, 1e-9)
+	assert.InDelta(t, 4.0, report.Total.GPUHours, 1e-9)
+	assert.InDelta(t, report.Usage[0].Cost+report.Usage[1].Cost, report.Total.Cost, 1e-9)
+}
+
+func TestNewUsageReportEmpty(t *testing.T) {
+	report := NewUsageReport(1000, 2000, "queue", UsagePrices{}, nil)
+	assert.NotNil(t, report.Usage)
+	assert.Empty(t, report.Usage)
+	assert.Equal(t, Usage{}, report.Total)
+}
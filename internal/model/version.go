@@ -0,0 +1,115 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// VersionInfo describes the validity of a snapshot of an object, which was valid from ValidFromNano (inclusive)
+// until ValidToNano (exclusive). The current version of an object does not have a ValidToNano.
+type VersionInfo struct {
+	ValidFromNano int64  `json:"validFromNano"`
+	ValidToNano   *int64 `json:"validToNano,omitempty"`
+	// Changes contains the fields which changed compared to the previous version.
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+type ApplicationVersion struct {
+	VersionInfo
+	Object Application `json:"object"`
+}
+
+type QueueVersion struct {
+	VersionInfo
+	Object Queue `json:"object"`
+}
+
+type NodeVersion struct {
+	VersionInfo
+	Object Node `json:"object"`
+}
+
+func (v *ApplicationVersion) versioned() (*VersionInfo, any) { return &v.VersionInfo, v.Object }
+
+func (v *QueueVersion) versioned() (*VersionInfo, any) { return &v.VersionInfo, v.Object }
+
+func (v *NodeVersion) versioned() (*VersionInfo, any) { return &v.VersionInfo, v.Object }
+
+type version interface {
+	versioned() (*VersionInfo, any)
+}
+
+// FieldChange describes the change of a single field between two consecutive versions.
+// Field is the JSON path of the field, where nested fields are separated by a dot.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old,omitempty"`
+	New   any    `json:"new,omitempty"`
+}
+
+// ComputeChanges sets the Changes of every version to the field-level difference from the previous version.
+// The versions are expected to be sorted from the oldest to the newest.
+func ComputeChanges[V version](versions []V) error {
+	var previous map[string]any
+	for _, v := range versions {
+		info, object := v.versioned()
+		current, err := toFieldMap(object)
+		if err != nil {
+			return err
+		}
+		if previous != nil {
+			info.Changes = diffFields("", previous, current)
+		}
+		previous = current
+	}
+	return nil
+}
+
+func toFieldMap(object any) (map[string]any, error) {
+	data, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// diffFields compares old and new recursively and returns the changed fields sorted by their path.
+// Nested objects are compared field by field, every other value (including arrays) is compared as a whole.
+func diffFields(prefix string, old, new map[string]any) []FieldChange {
+	keys := make(map[string]struct{})
+	for k := range old {
+		keys[k] = struct{}{}
+	}
+	for k := range new {
+		keys[k] = struct{}{}
+	}
+	sortedKeys := make([]string, 0, len(keys))
+	for k := range keys {
+		sortedKeys = append(sortedKeys, k)
+	}
+	sort.Strings(sortedKeys)
+
+	var changes []FieldChange
+	for _, k := range sortedKeys {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		oldValue, newValue := old[k], new[k]
+		oldObject, oldIsObject := oldValue.(map[string]any)
+		newObject, newIsObject := newValue.(map[string]any)
+		if oldIsObject && newIsObject {
+			changes = append(changes, diffFields(path, oldObject, newObject)...)
+			continue
+		}
+		if !reflect.DeepEqual(oldValue, newValue) {
+			changes = append(changes, FieldChange{Field: path, Old: oldValue, New: newValue})
+		}
+	}
+	return changes
+}
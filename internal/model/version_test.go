@@ -0,0 +1,64 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/G-Research/yunikorn-core/pkg/webservice/dao"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/G-Research/unicorn-history-server/internal/util"
+)
+
+func TestComputeChanges(t *testing.T) {
+	versions := []*NodeVersion{
+		{
+			VersionInfo: VersionInfo{ValidFromNano: 1, ValidToNano: util.ToPtr(int64(2))},
+			Object: Node{
+				Metadata: Metadata{CreatedAtNano: 1},
+				NodeDAOInfo: dao.NodeDAOInfo{
+					ID:          "1",
+					NodeID:      "node-1",
+					Schedulable: true,
+					Capacity:    map[string]int64{"memory": 100, "vcore": 10},
+				},
+			},
+		},
+		{
+			VersionInfo: VersionInfo{ValidFromNano: 2, ValidToNano: util.ToPtr(int64(3))},
+			Object: Node{
+				Metadata: Metadata{CreatedAtNano: 1},
+				NodeDAOInfo: dao.NodeDAOInfo{
+					ID:          "1",
+					NodeID:      "node-1",
+					Schedulable: false,
+					Capacity:    map[string]int64{"memory": 200, "vcore": 10},
+				},
+			},
+		},
+		{
+			VersionInfo: VersionInfo{ValidFromNano: 3},
+			Object: Node{
+				Metadata: Metadata{CreatedAtNano: 1, DeletedAtNano: util.ToPtr(int64(3))},
+				NodeDAOInfo: dao.NodeDAOInfo{
+					ID:          "1",
+					NodeID:      "node-1",
+					Schedulable: false,
+					Capacity:    map[string]int64{"memory": 200, "vcore": 10},
+				},
+			},
+		},
+	}
+
+	err := ComputeChanges(versions)
+	require.NoError(t, err)
+
+	assert.Nil(t, versions[0].Changes)
+	assert.Equal(t, []FieldChange{
+		{Field: "capacity.memory", Old: float64(100), New: float64(200)},
+		{Field: "schedulable", Old: true, New: false},
+	}, versions[1].Changes)
+	assert.Equal(t, []FieldChange{
+		{Field: "deletedAtNano", Old: nil, New: float64(3)},
+	}, versions[2].Changes)
+}
@@ -0,0 +1,77 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/G-Research/unicorn-history-server/internal/config"
+	"github.com/G-Research/unicorn-history-server/internal/database/repository"
+	"github.com/G-Research/unicorn-history-server/internal/log"
+)
+
+// Service deletes the stored events which are older than the retention of the configuration.
+type Service struct {
+	repo   repository.Repository
+	config config.EventsConfig
+	now    func() time.Time
+}
+
+type Option func(*Service)
+
+// WithClock sets the function which returns the current time of the service.
+func WithClock(now func() time.Time) Option {
+	return func(s *Service) {
+		s.now = now
+	}
+}
+
+func NewService(repo repository.Repository, cfg config.EventsConfig, opts ...Option) *Service {
+	s := &Service{
+		repo:   repo,
+		config: cfg,
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Run deletes the expired events every cleanup interval until the context is done.
+func (s *Service) Run(ctx context.Context) error {
+	logger := log.FromContext(ctx).With("component", "retention")
+	ctx = log.ToContext(ctx, logger)
+
+	logger.Infow("starting events cleanup", "retention", s.config.Retention, "interval", s.config.CleanupInterval)
+	ticker := time.NewTicker(s.config.CleanupInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.Cleanup(ctx); err != nil {
+			if errors.Is(err, context.Canceled) {
+				logger.Warn("shutting down events cleanup")
+				return nil
+			}
+			logger.Errorf("error deleting expired events: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			logger.Warn("shutting down events cleanup")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Cleanup deletes the events which are older than the retention. A retention of 0 keeps the events forever.
+func (s *Service) Cleanup(ctx context.Context) error {
+	if s.config.Retention == 0 {
+		return nil
+	}
+	deleted, err := s.repo.DeleteEventsBefore(ctx, s.now().Add(-s.config.Retention).UnixNano())
+	if err != nil {
+		return err
+	}
+	log.FromContext(ctx).Debugw("deleted expired events", "count", deleted)
+	return nil
+}
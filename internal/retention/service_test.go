@@ -0,0 +1,53 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/G-Research/unicorn-history-server/internal/config"
+	"github.com/G-Research/unicorn-history-server/internal/database/repository"
+)
+
+func TestCleanup(t *testing.T) {
+	now := time.Date(2024, 12, 10, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Delete the events older than the retention", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := repository.NewMockRepository(ctrl)
+
+		before := time.Date(2024, 12, 3, 10, 0, 0, 0, time.UTC).UnixNano()
+		mockRepo.EXPECT().DeleteEventsBefore(gomock.Any(), before).Return(int64(3), nil)
+
+		cfg := config.EventsConfig{Retention: 7 * 24 * time.Hour, CleanupInterval: time.Hour}
+		service := NewService(mockRepo, cfg, WithClock(func() time.Time { return now }))
+		require.NoError(t, service.Cleanup(context.Background()))
+	})
+
+	t.Run("Keep the events without a retention", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := repository.NewMockRepository(ctrl)
+
+		cfg := config.EventsConfig{CleanupInterval: time.Hour}
+		service := NewService(mockRepo, cfg, WithClock(func() time.Time { return now }))
+		require.NoError(t, service.Cleanup(context.Background()))
+	})
+
+	t.Run("Return the errors of the repository", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := repository.NewMockRepository(ctrl)
+
+		mockRepo.EXPECT().DeleteEventsBefore(gomock.Any(), gomock.Any()).Return(int64(0), fmt.Errorf("boom"))
+
+		cfg := config.EventsConfig{Retention: time.Hour, CleanupInterval: time.Hour}
+		service := NewService(mockRepo, cfg, WithClock(func() time.Time { return now }))
+		require.Error(t, service.Cleanup(context.Background()))
+	})
+}
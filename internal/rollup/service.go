@@ -0,0 +1,131 @@
+package rollup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/G-Research/unicorn-history-server/internal/config"
+	"github.com/G-Research/unicorn-history-server/internal/database/repository"
+	"github.com/G-Research/unicorn-history-server/internal/log"
+)
+
+// chunkBuckets is the number of buckets which are computed by a single statement,
+// so that a backfill is committed progressively.
+const chunkBuckets = 24
+
+// Service computes the hourly and daily rollups of the applications and the nodes incrementally.
+// Every rollup has a watermark, before which its buckets are computed: the buckets which ended since
+// the watermark are computed, once they ended at least the lag of the configuration ago.
+type Service struct {
+	repo   repository.Repository
+	config config.RollupConfig
+	now    func() time.Time
+}
+
+type Option func(*Service)
+
+// WithClock sets the function which returns the current time of the service.
+func WithClock(now func() time.Time) Option {
+	return func(s *Service) {
+		s.now = now
+	}
+}
+
+func NewService(repo repository.Repository, cfg config.RollupConfig, opts ...Option) *Service {
+	s := &Service{
+		repo:   repo,
+		config: cfg,
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Run updates the rollups every interval until the context is done.
+func (s *Service) Run(ctx context.Context) error {
+	logger := log.FromContext(ctx).With("component", "rollup")
+	ctx = log.ToContext(ctx, logger)
+
+	logger.Infow("starting rollups", "interval", s.config.Interval, "lag", s.config.Lag)
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Update(ctx); err != nil {
+			if errors.Is(err, context.Canceled) {
+				logger.Warn("shutting down rollups")
+				return nil
+			}
+			logger.Errorf("error updating rollups: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			logger.Warn("shutting down rollups")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Update computes the buckets of every rollup from its watermark. A rollup which was never computed starts
+// the backfill period of the configuration before now.
+func (s *Service) Update(ctx context.Context) error {
+	return s.compute(ctx, time.Time{})
+}
+
+// Backfill computes the buckets of every rollup from the start, including the buckets which were already computed.
+// A rollup whose watermark is before the start is computed from its watermark, so that it has no gaps.
+func (s *Service) Backfill(ctx context.Context, start time.Time) error {
+	if start.IsZero() {
+		return fmt.Errorf("the start of a backfill must be set")
+	}
+	return s.compute(ctx, start)
+}
+
+func (s *Service) compute(ctx context.Context, start time.Time) error {
+	now := s.now()
+	var errs []error
+	for _, rollup := range repository.Rollups {
+		for _, width := range repository.RollupWidths {
+			if err := s.computeRollup(ctx, rollup, width, start, now); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// computeRollup computes the buckets of the rollup of the width from the start, or from its watermark if the start
+// is zero, until the last bucket which ended the lag before now.
+func (s *Service) computeRollup(ctx context.Context, rollup repository.Rollup, width time.Duration, start, now time.Time) error {
+	logger := log.FromContext(ctx)
+
+	end := now.Add(-s.config.Lag).Truncate(width)
+	watermark, err := s.repo.GetRollupWatermark(ctx, rollup, width)
+	if err != nil {
+		return err
+	}
+	from := start.Truncate(width)
+	switch {
+	case watermark == nil && start.IsZero():
+		from = now.Add(-s.config.Backfill).Truncate(width)
+	case watermark != nil && (start.IsZero() || start.UnixNano() > watermark.WatermarkNano):
+		from = time.Unix(0, watermark.WatermarkNano).In(now.Location())
+	}
+
+	for from.Before(end) {
+		to := from.Add(chunkBuckets * width)
+		if to.After(end) {
+			to = end
+		}
+		if err := s.repo.ComputeRollup(ctx, rollup, width, from, to, s.now().UnixNano()); err != nil {
+			return err
+		}
+		logger.Debugw("computed rollup", "rollup", rollup, "width", width, "from", from, "to", to)
+		from = to
+	}
+	return nil
+}
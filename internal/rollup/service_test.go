@@ -0,0 +1,92 @@
+package rollup
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/G-Research/unicorn-history-server/internal/config"
+	"github.com/G-Research/unicorn-history-server/internal/database/repository"
+	"github.com/G-Research/unicorn-history-server/internal/model"
+)
+
+func TestUpdate(t *testing.T) {
+	now := time.Date(2024, 12, 10, 10, 20, 0, 0, time.UTC)
+	day := func(d, h int) time.Time { return time.Date(2024, 12, d, h, 0, 0, 0, time.UTC) }
+	cfg := config.RollupConfig{Enabled: true, Interval: 15 * time.Minute, Lag: 15 * time.Minute, Backfill: 48 * time.Hour}
+
+	t.Run("Backfill period when the rollups were never computed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := repository.NewMockRepository(ctrl)
+
+		for _, rollup := range repository.Rollups {
+			mockRepo.EXPECT().GetRollupWatermark(gomock.Any(), rollup, gomock.Any()).Return(nil, nil).Times(2)
+			mockRepo.EXPECT().ComputeRollup(gomock.Any(), rollup, 24*time.Hour, day(8, 0), day(10, 0), now.UnixNano())
+			mockRepo.EXPECT().ComputeRollup(gomock.Any(), rollup, time.Hour, day(8, 10), day(9, 10), now.UnixNano())
+			mockRepo.EXPECT().ComputeRollup(gomock.Any(), rollup, time.Hour, day(9, 10), day(10, 10), now.UnixNano())
+		}
+
+		service := NewService(mockRepo, cfg, WithClock(func() time.Time { return now }))
+		require.NoError(t, service.Update(context.Background()))
+	})
+
+	t.Run("Buckets which ended since the watermark", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := repository.NewMockRepository(ctrl)
+
+		for _, rollup := range repository.Rollups {
+			mockRepo.EXPECT().GetRollupWatermark(gomock.Any(), rollup, 24*time.Hour).
+				Return(&model.RollupWatermark{StartNano: day(1, 0).UnixNano(), WatermarkNano: day(10, 0).UnixNano()}, nil)
+			mockRepo.EXPECT().GetRollupWatermark(gomock.Any(), rollup, time.Hour).
+				Return(&model.RollupWatermark{StartNano: day(1, 0).UnixNano(), WatermarkNano: day(10, 8).UnixNano()}, nil)
+			mockRepo.EXPECT().ComputeRollup(gomock.Any(), rollup, time.Hour, day(10, 8), day(10, 10), now.UnixNano())
+		}
+
+		service := NewService(mockRepo, cfg, WithClock(func() time.Time { return now }))
+		require.NoError(t, service.Update(context.Background()))
+	})
+
+	t.Run("Errors do not stop the other rollups", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := repository.NewMockRepository(ctrl)
+
+		watermark := &model.RollupWatermark{StartNano: day(1, 0).UnixNano(), WatermarkNano: day(10, 0).UnixNano()}
+		mockRepo.EXPECT().GetRollupWatermark(gomock.Any(), gomock.Any(), gomock.Any()).Return(watermark, nil).AnyTimes()
+		mockRepo.EXPECT().ComputeRollup(gomock.Any(), gomock.Any(), time.Hour, day(10, 0), day(10, 10), now.UnixNano()).
+			Return(fmt.Errorf("connection refused")).Times(len(repository.Rollups))
+
+		service := NewService(mockRepo, cfg, WithClock(func() time.Time { return now }))
+		require.Error(t, service.Update(context.Background()))
+	})
+}
+
+func TestBackfill(t *testing.T) {
+	now := time.Date(2024, 12, 10, 10, 20, 0, 0, time.UTC)
+	day := func(d, h int) time.Time { return time.Date(2024, 12, d, h, 0, 0, 0, time.UTC) }
+	cfg := config.RollupConfig{Enabled: true, Interval: 15 * time.Minute, Lag: 15 * time.Minute, Backfill: 48 * time.Hour}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := repository.NewMockRepository(ctrl)
+
+	for _, rollup := range repository.Rollups {
+		// the daily buckets are computed again from the start, the hourly buckets from the watermark which is before the start
+		mockRepo.EXPECT().GetRollupWatermark(gomock.Any(), rollup, 24*time.Hour).
+			Return(&model.RollupWatermark{StartNano: day(1, 0).UnixNano(), WatermarkNano: day(10, 0).UnixNano()}, nil)
+		mockRepo.EXPECT().GetRollupWatermark(gomock.Any(), rollup, time.Hour).
+			Return(&model.RollupWatermark{StartNano: day(9, 0).UnixNano(), WatermarkNano: day(9, 12).UnixNano()}, nil)
+		mockRepo.EXPECT().ComputeRollup(gomock.Any(), rollup, 24*time.Hour, day(9, 0), day(10, 0), now.UnixNano())
+		mockRepo.EXPECT().ComputeRollup(gomock.Any(), rollup, time.Hour, day(9, 12), day(10, 10), now.UnixNano())
+	}
+
+	service := NewService(mockRepo, cfg, WithClock(func() time.Time { return now }))
+	require.NoError(t, service.Backfill(context.Background(), day(9, 18)))
+	require.Error(t, service.Backfill(context.Background(), time.Time{}))
+}
@@ -3,11 +3,13 @@ package webservice
 import (
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/G-Research/unicorn-history-server/internal/database/repository"
+	"github.com/G-Research/unicorn-history-server/internal/database/sql"
 )
 
 const (
@@ -29,8 +31,54 @@ const (
 	queryParamName                         = "name"
 	queryParamLastStateTransitionTimeStart = "lastStateTransitionTimeStart"
 	queryParamLastStateTransitionTimeEnd   = "lastStateTransitionTimeEnd"
+	queryParamAsOf                         = "asOf"
+	queryParamIncludeDeleted               = "includeDeleted"
+	queryParamAfter                        = "after"
+	queryParamTotal                        = "total"
+	queryParamSort                         = "sort"
+	queryParamFinishedStartTime            = "finishedStartTime"
+	queryParamFinishedEndTime              = "finishedEndTime"
+	queryParamRunningStartTime             = "runningStartTime"
+	queryParamRunningEndTime               = "runningEndTime"
+	queryParamQueue                        = "queue"
+	queryParamIncludeSubqueues             = "includeSubqueues"
+	queryParamPartition                    = "partition"
+	queryParamApplicationIDPrefix          = "applicationIdPrefix"
+	queryParamDepth                        = "depth"
+	queryParamResourceFilter               = "resourceFilter"
+	queryParamFilter                       = "filter"
+	queryParamSearch                       = "q"
+	queryParamStart                        = "start"
+	queryParamEnd                          = "end"
+	queryParamGroupBy                      = "groupBy"
+	queryParamFormat                       = "format"
+	queryParamBucket                       = "bucket"
+	queryParamMinDuration                  = "minDuration"
+	queryParamStep                         = "step"
+	queryParamAgg                          = "agg"
+	queryParamResource                     = "resource"
+	queryParamHorizon                      = "horizon"
+	queryParamRankBy                       = "rankBy"
 )
 
+// defaultQueueDepth is the number of levels of children which are returned with a queue by default.
+const defaultQueueDepth = 1
+
+// maxNodeTimelineEvents is the number of the latest events of a node which are included in its timeline.
+const maxNodeTimelineEvents = 1000
+
+const (
+	// defaultSearchLimit is the number of search results per type which are returned by default.
+	defaultSearchLimit = 10
+	maxSearchLimit     = 100
+)
+
+// sortFields is the allow-list of the fields by which a list can be sorted.
+type sortFields interface {
+	Names() []string
+	Validate(sortKeys []repository.SortKey, after repository.Cursor) error
+}
+
 func parsePartitionFilters(r *http.Request) (*repository.PartitionFilters, error) {
 	var filters repository.PartitionFilters
 
@@ -50,6 +98,40 @@ func parsePartitionFilters(r *http.Request) (*repository.PartitionFilters, error
 	}
 	filters.LastStateTransitionTimeEnd = lastStateTransitionTimeEnd
 
+	asOf, err := getAsOfQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.AsOf = asOf
+
+	includeDeleted, err := getIncludeDeletedQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.IncludeDeleted = includeDeleted
+
+	sortKeys, err := getSortQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.Sort = sortKeys
+
+	after, err := getAfterQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.After = after
+
+	if err := repository.PartitionSortFields.Validate(filters.Sort, filters.After); err != nil {
+		return nil, fmt.Errorf("invalid '%s' query parameter: %v", queryParamSort, err)
+	}
+
+	expression, err := getFilterQueryParam(r, repository.PartitionFilterSchema)
+	if err != nil {
+		return nil, err
+	}
+	filters.Expression = expression
+
 	offset, err := getOffsetQueryParam(r)
 	if err != nil {
 		return nil, err
@@ -89,6 +171,71 @@ func parseApplicationFilters(r *http.Request) (*repository.ApplicationFilters, e
 	if submissionEndTime != nil {
 		filters.SubmissionEndTime = submissionEndTime
 	}
+	finishedStartTime, err := getFinishedStartTimeQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.FinishedStartTime = finishedStartTime
+	finishedEndTime, err := getFinishedEndTimeQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.FinishedEndTime = finishedEndTime
+	runningStartTime, err := getRunningStartTimeQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.RunningStartTime = runningStartTime
+	runningEndTime, err := getRunningEndTimeQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.RunningEndTime = runningEndTime
+	filters.States = getStatesQueryParam(r)
+	filters.Queue = getQueueQueryParam(r)
+	includeSubqueues, err := getIncludeSubqueuesQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.IncludeSubqueues = includeSubqueues
+	filters.Partition = getPartitionQueryParam(r)
+	filters.ApplicationIDPrefix = getApplicationIDPrefixQueryParam(r)
+	asOf, err := getAsOfQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.AsOf = asOf
+	includeDeleted, err := getIncludeDeletedQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.IncludeDeleted = includeDeleted
+	sortKeys, err := getSortQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.Sort = sortKeys
+	after, err := getAfterQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.After = after
+	if err := repository.ApplicationSortFields.Validate(filters.Sort, filters.After); err != nil {
+		return nil, fmt.Errorf("invalid '%s' query parameter: %v", queryParamSort, err)
+	}
+	resourceFilters, err := getResourceFiltersQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := repository.ApplicationResourceFilterFields.Validate(resourceFilters); err != nil {
+		return nil, fmt.Errorf("invalid '%s' query parameter: %v", queryParamResourceFilter, err)
+	}
+	filters.Resources = resourceFilters
+	expression, err := getFilterQueryParam(r, repository.ApplicationFilterSchema)
+	if err != nil {
+		return nil, err
+	}
+	filters.Expression = expression
 	offset, err := getOffsetQueryParam(r)
 	if err != nil {
 		return nil, err
@@ -110,7 +257,7 @@ func parseApplicationFilters(r *http.Request) (*repository.ApplicationFilters, e
 	return &filters, nil
 }
 
-func parseHistoryFilters(r *http.Request) (*repository.HistoryFilters, error) {
+func parseHistoryFilters(r *http.Request, fields sortFields) (*repository.HistoryFilters, error) {
 	var filters repository.HistoryFilters
 	timestampStart, err := getTimestampStartQueryParam(r)
 	if err != nil {
@@ -126,6 +273,19 @@ func parseHistoryFilters(r *http.Request) (*repository.HistoryFilters, error) {
 	if timestampEnd != nil {
 		filters.TimestampEnd = timestampEnd
 	}
+	sortKeys, err := getSortQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.Sort = sortKeys
+	after, err := getAfterQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.After = after
+	if err := fields.Validate(filters.Sort, filters.After); err != nil {
+		return nil, fmt.Errorf("invalid '%s' query parameter: %v", queryParamSort, err)
+	}
 	offset, err := getOffsetQueryParam(r)
 	if err != nil {
 		return nil, err
@@ -165,6 +325,42 @@ func parseNodeFilters(r *http.Request) (*repository.NodeFilters, error) {
 	if isReserved != nil {
 		filters.IsReserved = isReserved
 	}
+	asOf, err := getAsOfQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.AsOf = asOf
+	includeDeleted, err := getIncludeDeletedQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.IncludeDeleted = includeDeleted
+	sortKeys, err := getSortQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.Sort = sortKeys
+	after, err := getAfterQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.After = after
+	if err := repository.NodeSortFields.Validate(filters.Sort, filters.After); err != nil {
+		return nil, fmt.Errorf("invalid '%s' query parameter: %v", queryParamSort, err)
+	}
+	resourceFilters, err := getResourceFiltersQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := repository.NodeResourceFilterFields.Validate(resourceFilters); err != nil {
+		return nil, fmt.Errorf("invalid '%s' query parameter: %v", queryParamResourceFilter, err)
+	}
+	filters.Resources = resourceFilters
+	expression, err := getFilterQueryParam(r, repository.NodeFilterSchema)
+	if err != nil {
+		return nil, err
+	}
+	filters.Expression = expression
 	offset, err := getOffsetQueryParam(r)
 	if err != nil {
 		return nil, err
@@ -182,6 +378,33 @@ func parseNodeFilters(r *http.Request) (*repository.NodeFilters, error) {
 	return &filters, nil
 }
 
+func parseQueueFilters(r *http.Request) (*repository.QueueFilters, error) {
+	var filters repository.QueueFilters
+	asOf, err := getAsOfQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.AsOf = asOf
+	includeDeleted, err := getIncludeDeletedQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.IncludeDeleted = includeDeleted
+	return &filters, nil
+}
+
+func getDepthQueryParam(r *http.Request) (int, error) {
+	depthStr := r.URL.Query().Get(queryParamDepth)
+	if depthStr == "" {
+		return defaultQueueDepth, nil
+	}
+	depth, err := strconv.Atoi(depthStr)
+	if err != nil || depth < 0 {
+		return 0, fmt.Errorf("invalid 'depth' query parameter: must be a non-negative integer")
+	}
+	return depth, nil
+}
+
 func getNodeIdQueryParam(r *http.Request) string {
 	return r.URL.Query().Get(queryParamNodeId)
 }
@@ -212,6 +435,18 @@ func getIsReservedQueryParam(r *http.Request) *bool {
 	return &isReserved
 }
 
+func getIncludeDeletedQueryParam(r *http.Request) (bool, error) {
+	includeDeletedStr := r.URL.Query().Get(queryParamIncludeDeleted)
+	if includeDeletedStr == "" {
+		return false, nil
+	}
+	includeDeleted, err := strconv.ParseBool(includeDeletedStr)
+	if err != nil {
+		return false, fmt.Errorf("invalid 'includeDeleted' query parameter: %v", err)
+	}
+	return includeDeleted, nil
+}
+
 func getClusterIDQueryParam(r *http.Request) *string {
 	clusterId := r.URL.Query().Get(queryParamClusterID)
 	if clusterId != "" {
@@ -228,6 +463,52 @@ func getStateQueryParam(r *http.Request) *string {
 	return nil
 }
 
+// getStatesQueryParam returns the comma-separated list of states.
+func getStatesQueryParam(r *http.Request) []string {
+	var states []string
+	statesStr := r.URL.Query().Get(queryParamState)
+	if statesStr != "" {
+		states = strings.Split(statesStr, ",")
+	}
+	return states
+}
+
+func getQueueQueryParam(r *http.Request) *string {
+	queue := r.URL.Query().Get(queryParamQueue)
+	if queue != "" {
+		return &queue
+	}
+	return nil
+}
+
+func getIncludeSubqueuesQueryParam(r *http.Request) (bool, error) {
+	includeSubqueuesStr := r.URL.Query().Get(queryParamIncludeSubqueues)
+	if includeSubqueuesStr == "" {
+		return false, nil
+	}
+	includeSubqueues, err := strconv.ParseBool(includeSubqueuesStr)
+	if err != nil {
+		return false, fmt.Errorf("invalid 'includeSubqueues' query parameter: %v", err)
+	}
+	return includeSubqueues, nil
+}
+
+func getPartitionQueryParam(r *http.Request) *string {
+	partition := r.URL.Query().Get(queryParamPartition)
+	if partition != "" {
+		return &partition
+	}
+	return nil
+}
+
+func getApplicationIDPrefixQueryParam(r *http.Request) *string {
+	prefix := r.URL.Query().Get(queryParamApplicationIDPrefix)
+	if prefix != "" {
+		return &prefix
+	}
+	return nil
+}
+
 func getNameQueryParam(r *http.Request) *string {
 	name := r.URL.Query().Get(queryParamName)
 	if name != "" {
@@ -249,6 +530,761 @@ func getGroupsQueryParam(r *http.Request) []string {
 	return groupsSlice
 }
 
+func getSearchQueryParam(r *http.Request) (string, error) {
+	query := strings.TrimSpace(r.URL.Query().Get(queryParamSearch))
+	if query == "" {
+		return "", fmt.Errorf("missing '%s' query parameter", queryParamSearch)
+	}
+	return query, nil
+}
+
+func getSearchLimitQueryParam(r *http.Request) (int, error) {
+	limit, err := getLimitQueryParam(r)
+	if err != nil {
+		return 0, err
+	}
+	if limit == nil {
+		return defaultSearchLimit, nil
+	}
+	if *limit > maxSearchLimit {
+		return 0, fmt.Errorf("invalid '%s' query parameter: must be between 1 and %d", queryParamLimit, maxSearchLimit)
+	}
+	return *limit, nil
+}
+
+// defaultReportPeriod is the period of a report which ends now, if no start is requested.
+const defaultReportPeriod = 30 * 24 * time.Hour
+
+// reportFormat is the format in which a report is returned.
+type reportFormat string
+
+const (
+	reportFormatJSON reportFormat = "json"
+	reportFormatCSV  reportFormat = "csv"
+	// reportFormatNDJSON is newline-delimited JSON, one object per line.
+	reportFormatNDJSON reportFormat = "ndjson"
+)
+
+const (
+	mimeCSV    = "text/csv"
+	mimeNDJSON = "application/x-ndjson"
+)
+
+// reportFormatMIMETypes are the MIME types of the report formats, which select the format by the Accept header.
+var reportFormatMIMETypes = map[reportFormat]string{
+	reportFormatJSON:   "application/json",
+	reportFormatCSV:    mimeCSV,
+	reportFormatNDJSON: mimeNDJSON,
+}
+
+func parseUsageFilters(r *http.Request) (*repository.UsageFilters, error) {
+	var filters repository.UsageFilters
+	start, end, err := getReportPeriodQueryParams(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.Start = start
+	filters.End = end
+	groupBy, err := getUsageGroupByQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.GroupBy = groupBy
+	filters.Partition = getPartitionQueryParam(r)
+	filters.Queue = getQueueQueryParam(r)
+	return &filters, nil
+}
+
+const (
+	// minAnalyticsBucket is the narrowest time bucket of an analytics report.
+	minAnalyticsBucket = time.Minute
+	// maxAnalyticsBuckets is the maximum number of time buckets of the period of an analytics report.
+	maxAnalyticsBuckets = 1000
+	// defaultDiagnosticsBucket is the width of the time buckets of the trends of the diagnostics report by default.
+	defaultDiagnosticsBucket = 24 * time.Hour
+	// defaultTimelinePeriod is the period of a timeline until now by default.
+	defaultTimelinePeriod = 24 * time.Hour
+	// defaultTimelineStep is the time between the points of a timeline by default.
+	defaultTimelineStep = time.Hour
+	// defaultReservationMinDuration is the duration from which a reservation is long-lived by default.
+	defaultReservationMinDuration = 10 * time.Minute
+	// defaultForecastHorizon is the period of a forecast by default.
+	defaultForecastHorizon = 30 * 24 * time.Hour
+	// maxForecastHorizon is the longest period of a forecast.
+	maxForecastHorizon = 366 * 24 * time.Hour
+	// defaultForecastQueue is the queue whose demand is forecast by default, which is the demand of the partition.
+	defaultForecastQueue = "root"
+	// defaultEfficiencyResource is the resource of an efficiency report by default.
+	defaultEfficiencyResource = "vcore"
+	// defaultEfficiencyLimit is the number of applications, users and queues of an efficiency report by default.
+	defaultEfficiencyLimit = 10
+	// maxEfficiencyLimit is the largest number of applications, users and queues of an efficiency report.
+	maxEfficiencyLimit = 1000
+)
+
+func parseWaitTimeFilters(r *http.Request) (*repository.WaitTimeFilters, error) {
+	var filters repository.WaitTimeFilters
+	start, end, err := getReportPeriodQueryParams(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.Start = start
+	filters.End = end
+	groupBy, err := getWaitTimeGroupByQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.GroupBy = groupBy
+	bucket, err := getBucketQueryParam(r, end.Sub(start))
+	if err != nil {
+		return nil, err
+	}
+	filters.Bucket = bucket
+	filters.Partition = getPartitionQueryParam(r)
+	filters.Queue = getQueueQueryParam(r)
+	resourceFilters, err := getResourceFiltersQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := repository.WaitTimeResourceFilterFields.Validate(resourceFilters); err != nil {
+		return nil, fmt.Errorf("invalid '%s' query parameter: %v", queryParamResourceFilter, err)
+	}
+	filters.Resources = resourceFilters
+	return &filters, nil
+}
+
+func parseFairnessFilters(r *http.Request) (*repository.FairnessFilters, error) {
+	start, end, err := getReportPeriodQueryParams(r)
+	if err != nil {
+		return nil, err
+	}
+	return &repository.FairnessFilters{
+		Start:     start,
+		End:       end,
+		Partition: getPartitionQueryParam(r),
+		Queue:     getQueueQueryParam(r),
+	}, nil
+}
+
+func parsePreemptionFilters(r *http.Request) (*repository.PreemptionFilters, error) {
+	start, end, err := getReportPeriodQueryParams(r)
+	if err != nil {
+		return nil, err
+	}
+	filters := repository.PreemptionFilters{
+		Start:     start,
+		End:       end,
+		Partition: getPartitionQueryParam(r),
+		Queue:     getQueueQueryParam(r),
+	}
+	if user := getUserQueryParam(r); user != "" {
+		filters.User = &user
+	}
+	return &filters, nil
+}
+
+func parseGangFilters(r *http.Request) (*repository.GangFilters, error) {
+	start, end, err := getReportPeriodQueryParams(r)
+	if err != nil {
+		return nil, err
+	}
+	filters := repository.GangFilters{
+		Start:     start,
+		End:       end,
+		Partition: getPartitionQueryParam(r),
+		Queue:     getQueueQueryParam(r),
+	}
+	if user := getUserQueryParam(r); user != "" {
+		filters.User = &user
+	}
+	return &filters, nil
+}
+
+func parseDiagnosticsFilters(r *http.Request) (*repository.DiagnosticsFilters, error) {
+	start, end, err := getReportPeriodQueryParams(r)
+	if err != nil {
+		return nil, err
+	}
+	bucket, err := getBucketQueryParam(r, end.Sub(start))
+	if err != nil {
+		return nil, err
+	}
+	if bucket == 0 {
+		bucket = defaultBucketWidth(end.Sub(start), defaultDiagnosticsBucket)
+	}
+	filters := repository.DiagnosticsFilters{
+		Start:     start,
+		End:       end,
+		Bucket:    bucket,
+		Partition: getPartitionQueryParam(r),
+		Queue:     getQueueQueryParam(r),
+	}
+	if user := getUserQueryParam(r); user != "" {
+		filters.User = &user
+	}
+	return &filters, nil
+}
+
+func parseReservationFilters(r *http.Request) (*repository.ReservationFilters, error) {
+	start, end, err := getReportPeriodQueryParams(r)
+	if err != nil {
+		return nil, err
+	}
+	minDuration := defaultReservationMinDuration
+	if minDurationStr := r.URL.Query().Get(queryParamMinDuration); minDurationStr != "" {
+		minDuration, err = time.ParseDuration(minDurationStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid '%s' query parameter: %v", queryParamMinDuration, err)
+		}
+		if minDuration < 0 {
+			return nil, fmt.Errorf("invalid '%s' query parameter: must not be negative", queryParamMinDuration)
+		}
+	}
+	filters := repository.ReservationFilters{
+		Start:       start,
+		End:         end,
+		MinDuration: minDuration,
+		Partition:   getPartitionQueryParam(r),
+		Queue:       getQueueQueryParam(r),
+	}
+	if user := getUserQueryParam(r); user != "" {
+		filters.User = &user
+	}
+	return &filters, nil
+}
+
+func parseNodeUtilizationFilters(r *http.Request) (*repository.NodeUtilizationFilters, error) {
+	start, end, err := getReportPeriodQueryParams(r)
+	if err != nil {
+		return nil, err
+	}
+	return &repository.NodeUtilizationFilters{
+		Start:     start,
+		End:       end,
+		Partition: getPartitionQueryParam(r),
+	}, nil
+}
+
+// parseForecastFilters returns the demand series of a forecast, and its horizon. The period of the series is aligned
+// to its step, which is an hour unless the period would have more than maxAnalyticsBuckets buckets.
+func parseForecastFilters(r *http.Request) (*repository.DemandFilters, time.Duration, error) {
+	start, end, err := getReportPeriodQueryParams(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	step := defaultBucketWidth(end.Sub(start), time.Hour)
+	start, end = start.Truncate(step), end.Truncate(step)
+	if !start.Before(end) {
+		return nil, 0, fmt.Errorf("invalid report period: it must cover at least %s", step)
+	}
+	partition := r.URL.Query().Get(queryParamPartition)
+	if partition == "" {
+		return nil, 0, fmt.Errorf("missing '%s' query parameter", queryParamPartition)
+	}
+	resource := r.URL.Query().Get(queryParamResource)
+	if resource == "" {
+		return nil, 0, fmt.Errorf("missing '%s' query parameter", queryParamResource)
+	}
+	queue := defaultForecastQueue
+	if q := getQueueQueryParam(r); q != nil {
+		queue = *q
+	}
+	horizon, err := getHorizonQueryParam(r, step)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &repository.DemandFilters{
+		Start:     start,
+		End:       end,
+		Step:      step,
+		Partition: partition,
+		Queue:     queue,
+		Resource:  resource,
+	}, horizon, nil
+}
+
+// getHorizonQueryParam returns the period of a forecast, as a number of days, e.g. '30d', or as a duration, e.g. '72h'.
+// It is rounded down to the step of the forecast.
+func getHorizonQueryParam(r *http.Request, step time.Duration) (time.Duration, error) {
+	horizonStr := r.URL.Query().Get(queryParamHorizon)
+	if horizonStr == "" {
+		return defaultForecastHorizon.Truncate(step), nil
+	}
+	var horizon time.Duration
+	if days, ok := strings.CutSuffix(horizonStr, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid '%s' query parameter: %v", queryParamHorizon, err)
+		}
+		// the days are checked before they are converted, which could overflow otherwise
+		if n < 0 || n > int(maxForecastHorizon/(24*time.Hour)) {
+			return 0, fmt.Errorf("invalid '%s' query parameter: must be between %s and %s", queryParamHorizon, step, maxForecastHorizon)
+		}
+		horizon = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		horizon, err = time.ParseDuration(horizonStr)
+		if err != nil {
+			return 0, fmt.Errorf("invalid '%s' query parameter: %v", queryParamHorizon, err)
+		}
+	}
+	if horizon < step || horizon > maxForecastHorizon {
+		return 0, fmt.Errorf("invalid '%s' query parameter: must be between %s and %s", queryParamHorizon, step, maxForecastHorizon)
+	}
+	return horizon.Truncate(step), nil
+}
+
+func parseEfficiencyFilters(r *http.Request) (*repository.EfficiencyFilters, error) {
+	start, end, err := getReportPeriodQueryParams(r)
+	if err != nil {
+		return nil, err
+	}
+	rankBy, err := getEfficiencyRankByQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	limit := defaultEfficiencyLimit
+	if l, err := getLimitQueryParam(r); err != nil {
+		return nil, err
+	} else if l != nil {
+		if *l > maxEfficiencyLimit {
+			return nil, fmt.Errorf("invalid '%s' query parameter: must be between 1 and %d", queryParamLimit, maxEfficiencyLimit)
+		}
+		limit = *l
+	}
+	filters := repository.EfficiencyFilters{
+		Start:     start,
+		End:       end,
+		Partition: getPartitionQueryParam(r),
+		Queue:     getQueueQueryParam(r),
+		Resource:  defaultEfficiencyResource,
+		RankBy:    rankBy,
+		Limit:     limit,
+	}
+	if user := getUserQueryParam(r); user != "" {
+		filters.User = &user
+	}
+	if resource := r.URL.Query().Get(queryParamResource); resource != "" {
+		filters.Resource = resource
+	}
+	return &filters, nil
+}
+
+// getWaitTimeGroupByQueryParam returns the comma-separated list of fields by which the wait times are grouped.
+func getWaitTimeGroupByQueryParam(r *http.Request) ([]repository.WaitTimeGroupBy, error) {
+	groupByStr := r.URL.Query().Get(queryParamGroupBy)
+	if groupByStr == "" {
+		return nil, nil
+	}
+	var groupBy []repository.WaitTimeGroupBy
+	for _, field := range strings.Split(groupByStr, ",") {
+		field := repository.WaitTimeGroupBy(strings.TrimSpace(field))
+		if !slices.Contains(repository.WaitTimeGroupByValues, field) {
+			return nil, fmt.Errorf("invalid '%s' query parameter: cannot group by %q", queryParamGroupBy, field)
+		}
+		if slices.Contains(groupBy, field) {
+			return nil, fmt.Errorf("invalid '%s' query parameter: duplicate field %q", queryParamGroupBy, field)
+		}
+		groupBy = append(groupBy, field)
+	}
+	return groupBy, nil
+}
+
+// getBucketQueryParam returns the width of the time buckets of a report over the period, e.g. '1h' or '24h'.
+// It is 0 if the report is not bucketed.
+func getBucketQueryParam(r *http.Request, period time.Duration) (time.Duration, error) {
+	return getBucketWidthQueryParam(r, queryParamBucket, period)
+}
+
+// getBucketWidthQueryParam returns the width of the time buckets over the period of the query parameter, or 0 if it is not set.
+func getBucketWidthQueryParam(r *http.Request, param string, period time.Duration) (time.Duration, error) {
+	bucketStr := r.URL.Query().Get(param)
+	if bucketStr == "" {
+		return 0, nil
+	}
+	bucket, err := time.ParseDuration(bucketStr)
+	if err != nil {
+		return 0, fmt.Errorf("invalid '%s' query parameter: %v", param, err)
+	}
+	if bucket < minAnalyticsBucket {
+		return 0, fmt.Errorf("invalid '%s' query parameter: must be at least %s", param, minAnalyticsBucket)
+	}
+	if period/bucket > maxAnalyticsBuckets {
+		return 0, fmt.Errorf("invalid '%s' query parameter: the period would have more than %d buckets", param, maxAnalyticsBuckets)
+	}
+	return bucket, nil
+}
+
+// defaultBucketWidth returns the default width of the time buckets over the period,
+// which is widened to whole hours if the period would have more than maxAnalyticsBuckets buckets.
+func defaultBucketWidth(period time.Duration, width time.Duration) time.Duration {
+	if period/width > maxAnalyticsBuckets {
+		return (period / maxAnalyticsBuckets).Truncate(time.Hour) + time.Hour
+	}
+	return width
+}
+
+// isTimelineRequest returns true if the history of the applications or the containers is requested as a timeline
+// derived from the stored applications, rather than the history recorded by YuniKorn.
+func isTimelineRequest(r *http.Request) bool {
+	query := r.URL.Query()
+	for _, param := range []string{queryParamQueue, queryParamUser, queryParamPartition} {
+		if query.Has(param) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHistorySeriesRequest returns true if the history recorded by YuniKorn is requested downsampled to a step.
+func isHistorySeriesRequest(r *http.Request) bool {
+	query := r.URL.Query()
+	return query.Has(queryParamStep) || query.Has(queryParamAgg)
+}
+
+// getSeriesQueryParams returns the period and the step of a time series. The period ends now and starts
+// defaultTimelinePeriod before its end by default, and the step is defaultTimelineStep by default,
+// which is widened for long periods. The step is the width of the buckets of the series, which is a whole number
+// of seconds, and the start is aligned to it, so that the points of every series are at the same timestamps.
+func getSeriesQueryParams(r *http.Request) (time.Time, time.Time, time.Duration, error) {
+	start, err := getTimestampStartQueryParam(r)
+	if err != nil {
+		return time.Time{}, time.Time{}, 0, err
+	}
+	end, err := getTimestampEndQueryParam(r)
+	if err != nil {
+		return time.Time{}, time.Time{}, 0, err
+	}
+	seriesEnd := time.Now()
+	if end != nil {
+		seriesEnd = *end
+	}
+	seriesStart := seriesEnd.Add(-defaultTimelinePeriod)
+	if start != nil {
+		seriesStart = *start
+	}
+	if !seriesStart.Before(seriesEnd) {
+		return time.Time{}, time.Time{}, 0,
+			fmt.Errorf("invalid period: '%s' must be before '%s'", queryParamTimestampStart, queryParamTimestampEnd)
+	}
+	period := seriesEnd.Sub(seriesStart)
+	step, err := getBucketWidthQueryParam(r, queryParamStep, period)
+	if err != nil {
+		return time.Time{}, time.Time{}, 0, err
+	}
+	if step == 0 {
+		step = defaultBucketWidth(period, defaultTimelineStep)
+	}
+	if step%time.Second != 0 {
+		return time.Time{}, time.Time{}, 0,
+			fmt.Errorf("invalid '%s' query parameter: must be a whole number of seconds", queryParamStep)
+	}
+	seriesStart = time.Unix(0, seriesStart.UnixNano()-seriesStart.UnixNano()%step.Nanoseconds())
+	return seriesStart, seriesEnd, step, nil
+}
+
+// parseHistorySeriesFilters parses the filters of a downsampled history, whose entries are aggregated
+// by their average by default.
+func parseHistorySeriesFilters(r *http.Request) (*repository.HistoryFilters, error) {
+	start, end, step, err := getSeriesQueryParams(r)
+	if err != nil {
+		return nil, err
+	}
+	agg := repository.HistoryAggAvg
+	if aggStr := r.URL.Query().Get(queryParamAgg); aggStr != "" {
+		agg = repository.HistoryAgg(aggStr)
+		if !slices.Contains(repository.HistoryAggValues, agg) {
+			return nil, fmt.Errorf("invalid '%s' query parameter: cannot aggregate by %q", queryParamAgg, aggStr)
+		}
+	}
+	return &repository.HistoryFilters{
+		TimestampStart: &start,
+		TimestampEnd:   &end,
+		Step:           step,
+		Agg:            agg,
+	}, nil
+}
+
+// parseTimelineFilters parses the filters of a timeline, which has a point at the start of every bucket of the step.
+// The points of a timeline are counts at their timestamps rather than aggregates of their buckets, so the timeline
+// cannot be aggregated.
+func parseTimelineFilters(r *http.Request) (*repository.TimelineFilters, error) {
+	if r.URL.Query().Has(queryParamAgg) {
+		return nil, fmt.Errorf("invalid '%s' query parameter: cannot be combined with '%s', '%s' or '%s'",
+			queryParamAgg, queryParamPartition, queryParamQueue, queryParamUser)
+	}
+	start, end, step, err := getSeriesQueryParams(r)
+	if err != nil {
+		return nil, err
+	}
+	filters := repository.TimelineFilters{
+		Start:     start,
+		End:       end,
+		Step:      step,
+		Partition: getPartitionQueryParam(r),
+		Queue:     getQueueQueryParam(r),
+	}
+	if user := getUserQueryParam(r); user != "" {
+		filters.User = &user
+	}
+	return &filters, nil
+}
+
+func parseAccountingFilters(r *http.Request) (*repository.AccountingFilters, error) {
+	var filters repository.AccountingFilters
+	finishedStartTime, err := getFinishedStartTimeQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.FinishedStartTime = finishedStartTime
+	finishedEndTime, err := getFinishedEndTimeQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.FinishedEndTime = finishedEndTime
+	if user := getUserQueryParam(r); user != "" {
+		filters.User = &user
+	}
+	filters.Groups = getGroupsQueryParam(r)
+	filters.States = getStatesQueryParam(r)
+	filters.Queue = getQueueQueryParam(r)
+	includeSubqueues, err := getIncludeSubqueuesQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.IncludeSubqueues = includeSubqueues
+	filters.Partition = getPartitionQueryParam(r)
+	sortKeys, err := getSortQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.Sort = sortKeys
+	after, err := getAfterQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.After = after
+	if err := repository.AccountingSortFields.Validate(filters.Sort, filters.After); err != nil {
+		return nil, fmt.Errorf("invalid '%s' query parameter: %v", queryParamSort, err)
+	}
+	offset, err := getOffsetQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	filters.Offset = offset
+	return &filters, nil
+}
+
+// getReportPeriodQueryParams returns the period of a report. It ends now and starts defaultReportPeriod before its end by default.
+func getReportPeriodQueryParams(r *http.Request) (time.Time, time.Time, error) {
+	end := time.Now()
+	if endStr := r.URL.Query().Get(queryParamEnd); endStr != "" {
+		t, err := toTime(endStr)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid '%s' query parameter: %v", queryParamEnd, err)
+		}
+		end = *t
+	}
+	start := end.Add(-defaultReportPeriod)
+	if startStr := r.URL.Query().Get(queryParamStart); startStr != "" {
+		t, err := toTime(startStr)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid '%s' query parameter: %v", queryParamStart, err)
+		}
+		start = *t
+	}
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid report period: '%s' must be before '%s'", queryParamStart, queryParamEnd)
+	}
+	return start, end, nil
+}
+
+// getEfficiencyRankByQueryParam returns the waste by which an efficiency report is ranked, the idle resource-seconds by default.
+func getEfficiencyRankByQueryParam(r *http.Request) (repository.EfficiencyRankBy, error) {
+	rankByStr := r.URL.Query().Get(queryParamRankBy)
+	if rankByStr == "" {
+		return repository.EfficiencyRankByIdle, nil
+	}
+	var allowed []string
+	for _, rankBy := range repository.EfficiencyRankByValues {
+		if string(rankBy) == rankByStr {
+			return rankBy, nil
+		}
+		allowed = append(allowed, string(rankBy))
+	}
+	return "", fmt.Errorf("invalid '%s' query parameter: must be one of %s", queryParamRankBy, strings.Join(allowed, ", "))
+}
+
+func getUsageGroupByQueryParam(r *http.Request) (repository.UsageGroupBy, error) {
+	groupByStr := r.URL.Query().Get(queryParamGroupBy)
+	if groupByStr == "" {
+		return repository.UsageGroupByUser, nil
+	}
+	var allowed []string
+	for _, groupBy := range repository.UsageGroupByValues {
+		if string(groupBy) == groupByStr {
+			return groupBy, nil
+		}
+		allowed = append(allowed, string(groupBy))
+	}
+	return "", fmt.Errorf("invalid '%s' query parameter: must be one of %s", queryParamGroupBy, strings.Join(allowed, ", "))
+}
+
+// getFormatQueryParam returns the format of a report, which must be one of the formats: the 'format' query parameter if set,
+// otherwise the first other format whose MIME type is accepted (if JSON is not), otherwise JSON.
+func getFormatQueryParam(r *http.Request, formats ...reportFormat) (reportFormat, error) {
+	if format := reportFormat(r.URL.Query().Get(queryParamFormat)); format != "" {
+		if format == reportFormatJSON || slices.Contains(formats, format) {
+			return format, nil
+		}
+		allowed := []string{string(reportFormatJSON)}
+		for _, f := range formats {
+			allowed = append(allowed, string(f))
+		}
+		return "", fmt.Errorf("invalid '%s' query parameter: must be one of %s", queryParamFormat, strings.Join(allowed, ", "))
+	}
+	accept := r.Header.Get("Accept")
+	if strings.Contains(accept, reportFormatMIMETypes[reportFormatJSON]) {
+		return reportFormatJSON, nil
+	}
+	for _, format := range formats {
+		if strings.Contains(accept, reportFormatMIMETypes[format]) {
+			return format, nil
+		}
+	}
+	return reportFormatJSON, nil
+}
+
+// getSortQueryParam parses the comma-separated list of sort keys, e.g. '-submissionTime,user'.
+// A key prefixed with '-' is sorted in descending order, otherwise in ascending order.
+func getSortQueryParam(r *http.Request) ([]repository.SortKey, error) {
+	sortStr := r.URL.Query().Get(queryParamSort)
+	if sortStr == "" {
+		return nil, nil
+	}
+	var sortKeys []repository.SortKey
+	for _, field := range strings.Split(sortStr, ",") {
+		key := repository.SortKey{Field: strings.TrimSpace(field)}
+		if strings.HasPrefix(key.Field, "-") {
+			key.Field = key.Field[1:]
+			key.Descending = true
+		} else {
+			key.Field = strings.TrimPrefix(key.Field, "+")
+		}
+		if key.Field == "" {
+			return nil, fmt.Errorf("invalid '%s' query parameter: empty sort key", queryParamSort)
+		}
+		sortKeys = append(sortKeys, key)
+	}
+	return sortKeys, nil
+}
+
+// sortDescription returns the documentation of the 'sort' query parameter for the allowed fields.
+func sortDescription(fields sortFields) string {
+	return "Comma-separated list of fields to sort by, prefixed with '-' for descending order. Allowed fields: " +
+		strings.Join(fields.Names(), ", ")
+}
+
+// resourceFilterOperators are the operators of resource filters.
+// Operators which start with another operator must come first, so the longest operator is matched.
+var resourceFilterOperators = []struct {
+	token    string
+	operator sql.Operator
+}{
+	{">=", sql.OpGe},
+	{"<=", sql.OpLe},
+	{"!=", sql.OpNe},
+	{">", sql.OpGt},
+	{"<", sql.OpLt},
+	{"=", sql.OpEq},
+}
+
+// getResourceFiltersQueryParam parses the resource filters, e.g. 'available.memory>=64Gi'.
+// The query parameter can be repeated, all filters must match.
+func getResourceFiltersQueryParam(r *http.Request) ([]repository.ResourceFilter, error) {
+	var filters []repository.ResourceFilter
+	for _, value := range r.URL.Query()[queryParamResourceFilter] {
+		filter, err := parseResourceFilter(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid '%s' query parameter: %v", queryParamResourceFilter, err)
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
+
+func parseResourceFilter(value string) (repository.ResourceFilter, error) {
+	i := strings.IndexAny(value, "<>=!")
+	if i < 0 {
+		return repository.ResourceFilter{}, fmt.Errorf("%q has no operator, expected '<field>.<key><operator><value>'", value)
+	}
+	field, key, ok := strings.Cut(strings.TrimSpace(value[:i]), ".")
+	if !ok || field == "" || key == "" {
+		return repository.ResourceFilter{}, fmt.Errorf("%q has no '<field>.<key>', expected '<field>.<key><operator><value>'", value)
+	}
+	for _, op := range resourceFilterOperators {
+		if strings.HasPrefix(value[i:], op.token) {
+			return repository.ResourceFilter{
+				Field:    field,
+				Key:      key,
+				Operator: op.operator,
+				Value:    strings.TrimSpace(value[i+len(op.token):]),
+			}, nil
+		}
+	}
+	return repository.ResourceFilter{}, fmt.Errorf("%q has an invalid operator, allowed are >=, <=, !=, >, <, =", value)
+}
+
+// resourceFilterDescription returns the documentation of the 'resourceFilter' query parameter for the allowed fields.
+func resourceFilterDescription(fields *repository.ResourceFilterFields) string {
+	return "Filter by a resource or attribute, e.g. 'available.memory>=64Gi' or 'attributes.instance-type=m5.large'. " +
+		"Can be repeated, all filters must match. Quantities may have a unit (k, M, G, Ki, Mi, Gi, ..., m for vcore). " +
+		"Allowed fields: " + strings.Join(fields.Names(), ", ")
+}
+
+// getFilterQueryParam compiles the filter expression of the 'filter' query parameter against the schema.
+func getFilterQueryParam(r *http.Request, schema *repository.FilterSchema) (sql.Expr, error) {
+	filterStr := r.URL.Query().Get(queryParamFilter)
+	if filterStr == "" {
+		return nil, nil
+	}
+	expression, err := schema.Compile(filterStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid '%s' query parameter: %v", queryParamFilter, err)
+	}
+	return expression, nil
+}
+
+// filterDescription returns the documentation of the 'filter' query parameter for the fields of the schema.
+func filterDescription(schema *repository.FilterSchema) string {
+	return "Filter expression, e.g. 'state in (Failed,Rejected) and (user = \"bob\" or queue ~ \"root.ml.*\")'. " +
+		"Conditions are combined with 'and', 'or', 'not' and parentheses. " +
+		"Operators: =, !=, <, <=, >, >=, ~ (glob), !~, in (...), not in (...), is null, is not null. Fields: " +
+		strings.Join(schema.Names(), ", ")
+}
+
+func getAfterQueryParam(r *http.Request) (repository.Cursor, error) {
+	afterStr := r.URL.Query().Get(queryParamAfter)
+	if afterStr == "" {
+		return nil, nil
+	}
+	after, err := repository.DecodeCursor(afterStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid 'after' query parameter: %v", err)
+	}
+	return after, nil
+}
+
+func getTotalQueryParam(r *http.Request) (totalMode, error) {
+	total := totalMode(r.URL.Query().Get(queryParamTotal))
+	switch total {
+	case totalNone, totalExact, totalEstimated:
+		return total, nil
+	default:
+		return totalNone, fmt.Errorf("invalid 'total' query parameter: must be %q or %q", totalExact, totalEstimated)
+	}
+}
+
 func getOffsetQueryParam(r *http.Request) (*int, error) {
 	offsetStr := r.URL.Query().Get(queryParamOffset)
 	if offsetStr == "" {
@@ -258,13 +1294,18 @@ func getOffsetQueryParam(r *http.Request) (*int, error) {
 	return toInt(offsetStr)
 }
 
+// getLimitQueryParam returns the 'limit' query parameter, which must be a positive integer, or nil if it is not set.
 func getLimitQueryParam(r *http.Request) (*int, error) {
 	limitStr := r.URL.Query().Get(queryParamLimit)
 	if limitStr == "" {
 		return nil, nil
 	}
 
-	return toInt(limitStr)
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 1 {
+		return nil, fmt.Errorf("invalid '%s' query parameter: must be a positive integer", queryParamLimit)
+	}
+	return &limit, nil
 }
 
 func toInt(numberString string) (*int, error) {
@@ -312,6 +1353,42 @@ func getSubmissionEndTimeQueryParam(r *http.Request) (*time.Time, error) {
 	return toTime(endStr)
 }
 
+func getFinishedStartTimeQueryParam(r *http.Request) (*time.Time, error) {
+	startStr := r.URL.Query().Get(queryParamFinishedStartTime)
+	if startStr == "" {
+		return nil, nil
+	}
+
+	return toTime(startStr)
+}
+
+func getFinishedEndTimeQueryParam(r *http.Request) (*time.Time, error) {
+	endStr := r.URL.Query().Get(queryParamFinishedEndTime)
+	if endStr == "" {
+		return nil, nil
+	}
+
+	return toTime(endStr)
+}
+
+func getRunningStartTimeQueryParam(r *http.Request) (*time.Time, error) {
+	startStr := r.URL.Query().Get(queryParamRunningStartTime)
+	if startStr == "" {
+		return nil, nil
+	}
+
+	return toTime(startStr)
+}
+
+func getRunningEndTimeQueryParam(r *http.Request) (*time.Time, error) {
+	endStr := r.URL.Query().Get(queryParamRunningEndTime)
+	if endStr == "" {
+		return nil, nil
+	}
+
+	return toTime(endStr)
+}
+
 func getTimestampStartQueryParam(r *http.Request) (*time.Time, error) {
 	startStr := r.URL.Query().Get(queryParamTimestampStart)
 	if startStr == "" {
@@ -330,6 +1407,15 @@ func getTimestampEndQueryParam(r *http.Request) (*time.Time, error) {
 	return toTime(endStr)
 }
 
+func getAsOfQueryParam(r *http.Request) (*time.Time, error) {
+	asOfStr := r.URL.Query().Get(queryParamAsOf)
+	if asOfStr == "" {
+		return nil, nil
+	}
+
+	return toTime(asOfStr)
+}
+
 func toTime(millisString string) (*time.Time, error) {
 	startMillis, err := strconv.ParseInt(millisString, 10, 64)
 	if err != nil {
@@ -2,11 +2,14 @@ package webservice
 
 import (
 	"net/http"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/G-Research/unicorn-history-server/internal/database/repository"
+	"github.com/G-Research/unicorn-history-server/internal/database/sql"
 	"github.com/G-Research/unicorn-history-server/internal/util"
 )
 
@@ -89,6 +92,8 @@ func TestGetLimitQueryParam(t *testing.T) {
 		{"No limit param", "", nil, false},
 		{"Valid limit", "limit=10", util.ToPtr(10), false},
 		{"Invalid limit", "limit=xyz", nil, true},
+		{"Zero limit", "limit=0", nil, true},
+		{"Negative limit", "limit=-1", nil, true},
 	}
 
 	for _, tt := range tests {
@@ -216,3 +221,484 @@ func TestGetTimestampEndQueryParam(t *testing.T) {
 		}
 	}
 }
+
+func TestGetAsOfQueryParam(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		result *time.Time
+		hasErr bool
+	}{
+		{"No asOf param", "", nil, false},
+		{"Valid asOf", "asOf=1625097600000", util.ToPtr(time.UnixMilli(1625097600000)), false},
+		{"Invalid asOf", "asOf=invalid", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/?"+tt.query, nil)
+			require.NoError(t, err)
+			result, err := getAsOfQueryParam(req)
+			require.Equal(t, tt.hasErr, err != nil)
+			require.Equal(t, tt.result, result)
+		})
+	}
+}
+
+func TestGetIncludeDeletedQueryParam(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		result bool
+		hasErr bool
+	}{
+		{"No includeDeleted param", "", false, false},
+		{"includeDeleted true", "includeDeleted=true", true, false},
+		{"includeDeleted false", "includeDeleted=false", false, false},
+		{"Invalid includeDeleted", "includeDeleted=maybe", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/?"+tt.query, nil)
+			require.NoError(t, err)
+			result, err := getIncludeDeletedQueryParam(req)
+			require.Equal(t, tt.hasErr, err != nil)
+			require.Equal(t, tt.result, result)
+		})
+	}
+}
+
+func TestGetAfterQueryParam(t *testing.T) {
+	cursor := repository.Cursor{int64(1625097600000), "app-1"}
+	tests := []struct {
+		name   string
+		query  string
+		result repository.Cursor
+		hasErr bool
+	}{
+		{"No after param", "", nil, false},
+		{"Valid after", "after=" + cursor.Encode(), cursor, false},
+		{"Invalid after", "after=invalid", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/?"+tt.query, nil)
+			require.NoError(t, err)
+			result, err := getAfterQueryParam(req)
+			require.Equal(t, tt.hasErr, err != nil)
+			require.Equal(t, tt.result, result)
+		})
+	}
+}
+
+func TestGetTotalQueryParam(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		result totalMode
+		hasErr bool
+	}{
+		{"No total param", "", totalNone, false},
+		{"Exact total", "total=exact", totalExact, false},
+		{"Estimated total", "total=estimated", totalEstimated, false},
+		{"Invalid total", "total=all", totalNone, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/?"+tt.query, nil)
+			require.NoError(t, err)
+			result, err := getTotalQueryParam(req)
+			require.Equal(t, tt.hasErr, err != nil)
+			require.Equal(t, tt.result, result)
+		})
+	}
+}
+
+func TestGetSortQueryParam(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		result []repository.SortKey
+		hasErr bool
+	}{
+		{"No sort param", "", nil, false},
+		{"Ascending", "sort=user", []repository.SortKey{{Field: "user"}}, false},
+		{"Explicit ascending", "sort=%2Buser", []repository.SortKey{{Field: "user"}}, false},
+		{
+			"Multiple keys",
+			"sort=-submissionTime,user",
+			[]repository.SortKey{{Field: "submissionTime", Descending: true}, {Field: "user"}},
+			false,
+		},
+		{
+			"Resource key",
+			"sort=-usedResource.memory",
+			[]repository.SortKey{{Field: "usedResource.memory", Descending: true}},
+			false,
+		},
+		{"Empty key", "sort=user,,id", nil, true},
+		{"Only direction", "sort=-", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/?"+tt.query, nil)
+			require.NoError(t, err)
+			result, err := getSortQueryParam(req)
+			require.Equal(t, tt.hasErr, err != nil)
+			require.Equal(t, tt.result, result)
+		})
+	}
+}
+
+func TestGetResourceFiltersQueryParam(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		result []repository.ResourceFilter
+		hasErr bool
+	}{
+		{"No resource filter param", "", nil, false},
+		{
+			"Greater or equal",
+			"resourceFilter=available.memory>=64Gi",
+			[]repository.ResourceFilter{{Field: "available", Key: "memory", Operator: sql.OpGe, Value: "64Gi"}},
+			false,
+		},
+		{
+			"Resource with dots and slash",
+			"resourceFilter=usedResource.nvidia.com/gpu>0",
+			[]repository.ResourceFilter{{Field: "usedResource", Key: "nvidia.com/gpu", Operator: sql.OpGt, Value: "0"}},
+			false,
+		},
+		{
+			"Multiple filters",
+			"resourceFilter=attributes.instance-type=m5.large&resourceFilter=capacity.vcore!=500m",
+			[]repository.ResourceFilter{
+				{Field: "attributes", Key: "instance-type", Operator: sql.OpEq, Value: "m5.large"},
+				{Field: "capacity", Key: "vcore", Operator: sql.OpNe, Value: "500m"},
+			},
+			false,
+		},
+		{"No operator", "resourceFilter=available.memory", nil, true},
+		{"No key", "resourceFilter=available>1", nil, true},
+		{"Invalid operator", "resourceFilter=available.memory!1", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/?"+tt.query, nil)
+			require.NoError(t, err)
+			result, err := getResourceFiltersQueryParam(req)
+			require.Equal(t, tt.hasErr, err != nil)
+			require.Equal(t, tt.result, result)
+		})
+	}
+}
+
+func TestGetDepthQueryParam(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		result int
+		hasErr bool
+	}{
+		{"No depth param", "", defaultQueueDepth, false},
+		{"Zero depth", "depth=0", 0, false},
+		{"Valid depth", "depth=3", 3, false},
+		{"Negative depth", "depth=-1", 0, true},
+		{"Invalid depth", "depth=all", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/?"+tt.query, nil)
+			require.NoError(t, err)
+			result, err := getDepthQueryParam(req)
+			require.Equal(t, tt.hasErr, err != nil)
+			require.Equal(t, tt.result, result)
+		})
+	}
+}
+
+func TestGetFormatQueryParam(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		accept string
+		result reportFormat
+		hasErr bool
+	}{
+		{"Default", "", "", reportFormatJSON, false},
+		{"Query param", "format=ndjson", "application/json", reportFormatNDJSON, false},
+		{"Accept NDJSON", "", "application/x-ndjson", reportFormatNDJSON, false},
+		{"Accept CSV", "", "text/csv, */*", reportFormatCSV, false},
+		{"Accept JSON and CSV", "", "text/csv, application/json", reportFormatJSON, false},
+		{"Accept other", "", "text/html", reportFormatJSON, false},
+		{"Unknown format", "format=xml", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/?"+tt.query, nil)
+			require.NoError(t, err)
+			req.Header.Set("Accept", tt.accept)
+			result, err := getFormatQueryParam(req, reportFormatNDJSON, reportFormatCSV)
+			require.Equal(t, tt.hasErr, err != nil)
+			require.Equal(t, tt.result, result)
+		})
+	}
+}
+
+func TestGetBucketQueryParam(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		result time.Duration
+		hasErr bool
+	}{
+		{"No bucket", "", 0, false},
+		{"Hour", "bucket=1h", time.Hour, false},
+		{"Day", "bucket=24h", 24 * time.Hour, false},
+		{"Too narrow", "bucket=30s", 0, true},
+		{"Too many buckets", "bucket=1m", 0, true},
+		{"Invalid bucket", "bucket=daily", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/?"+tt.query, nil)
+			require.NoError(t, err)
+			result, err := getBucketQueryParam(req, 7*24*time.Hour)
+			require.Equal(t, tt.hasErr, err != nil)
+			require.Equal(t, tt.result, result)
+		})
+	}
+}
+
+func TestGetWaitTimeGroupByQueryParam(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		result []repository.WaitTimeGroupBy
+		hasErr bool
+	}{
+		{"Not grouped", "", nil, false},
+		{"Queue", "groupBy=queue", []repository.WaitTimeGroupBy{repository.WaitTimeGroupByQueue}, false},
+		{
+			"User and queue", "groupBy=user,queue",
+			[]repository.WaitTimeGroupBy{repository.WaitTimeGroupByUser, repository.WaitTimeGroupByQueue}, false,
+		},
+		{"Duplicate", "groupBy=user,user", nil, true},
+		{"Unknown", "groupBy=node", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/?"+tt.query, nil)
+			require.NoError(t, err)
+			result, err := getWaitTimeGroupByQueryParam(req)
+			require.Equal(t, tt.hasErr, err != nil)
+			require.Equal(t, tt.result, result)
+		})
+	}
+}
+
+func TestParseTimelineFilters(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		start  time.Time
+		end    time.Time
+		step   time.Duration
+		hasErr bool
+	}{
+		{"Default step", "timestampStart=0&timestampEnd=3600000", time.UnixMilli(0), time.UnixMilli(3600000), time.Hour, false},
+		{"Step", "timestampStart=0&timestampEnd=3600000&step=5m", time.UnixMilli(0), time.UnixMilli(3600000), 5 * time.Minute, false},
+		{
+			"Default step of a long period", "timestampStart=0&timestampEnd=" + strconv.FormatInt((90*24*time.Hour).Milliseconds(), 10),
+			time.UnixMilli(0), time.UnixMilli(0).Add(90 * 24 * time.Hour), 3 * time.Hour, false,
+		},
+		{"Default period", "timestampEnd=86400000", time.UnixMilli(0), time.UnixMilli(86400000), time.Hour, false},
+		{"Empty period", "timestampStart=3600000&timestampEnd=3600000", time.Time{}, time.Time{}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/?"+tt.query, nil)
+			require.NoError(t, err)
+			filters, err := parseTimelineFilters(req)
+			require.Equal(t, tt.hasErr, err != nil)
+			if tt.hasErr {
+				return
+			}
+			require.True(t, tt.start.Equal(filters.Start))
+			require.True(t, tt.end.Equal(filters.End))
+			require.Equal(t, tt.step, filters.Step)
+		})
+	}
+}
+
+func TestParseForecastFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		end     time.Time
+		queue   string
+		horizon time.Duration
+		hasErr  bool
+	}{
+		{"Defaults", "start=0&end=5400000", time.UnixMilli(3600000), "root", 30 * 24 * time.Hour, false},
+		{"Horizon in days", "start=0&end=7200000&queue=root.a&horizon=7d", time.UnixMilli(7200000), "root.a", 7 * 24 * time.Hour, false},
+		{"Horizon rounded down to the step", "start=0&end=7200000&horizon=90m", time.UnixMilli(7200000), "root", time.Hour, false},
+		{"Horizon shorter than the step", "start=0&end=7200000&horizon=30m", time.Time{}, "", 0, true},
+		{"Horizon too long", "start=0&end=7200000&horizon=400d", time.Time{}, "", 0, true},
+		{"Horizon overflowing in days", "start=0&end=7200000&horizon=200000d", time.Time{}, "", 0, true},
+		{"Negative horizon in days", "start=0&end=7200000&horizon=-1d", time.Time{}, "", 0, true},
+		{"Invalid horizon", "start=0&end=7200000&horizon=xd", time.Time{}, "", 0, true},
+		{"Period shorter than the step", "start=0&end=1800000", time.Time{}, "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/?partition=default&resource=vcore&"+tt.query, nil)
+			require.NoError(t, err)
+			filters, horizon, err := parseForecastFilters(req)
+			require.Equal(t, tt.hasErr, err != nil)
+			if tt.hasErr {
+				return
+			}
+			require.True(t, time.UnixMilli(0).Equal(filters.Start))
+			require.True(t, tt.end.Equal(filters.End))
+			require.Equal(t, time.Hour, filters.Step)
+			require.Equal(t, "default", filters.Partition)
+			require.Equal(t, "vcore", filters.Resource)
+			require.Equal(t, tt.queue, filters.Queue)
+			require.Equal(t, tt.horizon, horizon)
+		})
+	}
+
+	for _, query := range []string{"resource=vcore", "partition=default"} {
+		req, err := http.NewRequest("GET", "/?"+query, nil)
+		require.NoError(t, err)
+		_, _, err = parseForecastFilters(req)
+		require.Error(t, err, query)
+	}
+}
+
+func TestParseEfficiencyFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected *repository.EfficiencyFilters
+	}{
+		{
+			name:  "Defaults",
+			query: "start=0&end=3600000",
+			expected: &repository.EfficiencyFilters{
+				Start:    time.UnixMilli(0),
+				End:      time.UnixMilli(3600000),
+				Resource: "vcore",
+				RankBy:   repository.EfficiencyRankByIdle,
+				Limit:    10,
+			},
+		},
+		{
+			name:  "All parameters",
+			query: "start=0&end=3600000&partition=default&queue=root.a&user=alice&resource=memory&rankBy=unused&limit=5",
+			expected: &repository.EfficiencyFilters{
+				Start:     time.UnixMilli(0),
+				End:       time.UnixMilli(3600000),
+				Partition: util.ToPtr("default"),
+				Queue:     util.ToPtr("root.a"),
+				User:      util.ToPtr("alice"),
+				Resource:  "memory",
+				RankBy:    repository.EfficiencyRankByUnused,
+				Limit:     5,
+			},
+		},
+		{name: "Invalid ranking", query: "rankBy=cost"},
+		{name: "Invalid limit", query: "limit=ten"},
+		{name: "Limit too large", query: "limit=1001"},
+		{name: "Limit not positive", query: "limit=0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/?"+tt.query, nil)
+			require.NoError(t, err)
+			filters, err := parseEfficiencyFilters(req)
+			if tt.expected == nil {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, filters)
+		})
+	}
+}
+
+func TestIsTimelineRequest(t *testing.T) {
+	for query, want := range map[string]bool{
+		"":                   false,
+		"timestampStart=0":   false,
+		"limit=10&sort=id":   false,
+		"step=1h":            false,
+		"queue=root.default": true,
+		"user=alice":         true,
+		"partition=default":  true,
+	} {
+		req, err := http.NewRequest("GET", "/?"+query, nil)
+		require.NoError(t, err)
+		require.Equal(t, want, isTimelineRequest(req), query)
+	}
+}
+
+func TestParseHistorySeriesFilters(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		step   time.Duration
+		agg    repository.HistoryAgg
+		hasErr bool
+	}{
+		{"Default step and aggregation", "timestampStart=0&timestampEnd=86400000", time.Hour, repository.HistoryAggAvg, false},
+		{"Step", "timestampStart=0&timestampEnd=86400000&step=15m", 15 * time.Minute, repository.HistoryAggAvg, false},
+		{"Aggregation", "timestampStart=0&timestampEnd=86400000&agg=last", time.Hour, repository.HistoryAggLast, false},
+		{"Invalid aggregation", "timestampStart=0&timestampEnd=86400000&agg=sum", 0, "", true},
+		{"Fractional step", "timestampStart=0&timestampEnd=86400000&step=90500ms", 0, "", true},
+		{"Empty period", "timestampStart=3600000&timestampEnd=3600000", 0, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/?"+tt.query, nil)
+			require.NoError(t, err)
+			filters, err := parseHistorySeriesFilters(req)
+			require.Equal(t, tt.hasErr, err != nil)
+			if tt.hasErr {
+				return
+			}
+			require.True(t, time.UnixMilli(0).Equal(*filters.TimestampStart))
+			require.True(t, time.UnixMilli(86400000).Equal(*filters.TimestampEnd))
+			require.Equal(t, tt.step, filters.Step)
+			require.Equal(t, tt.agg, filters.Agg)
+		})
+	}
+}
+
+func TestIsHistorySeriesRequest(t *testing.T) {
+	for query, want := range map[string]bool{
+		"":                 false,
+		"timestampStart=0": false,
+		"step=1h":          true,
+		"agg=max":          true,
+	} {
+		req, err := http.NewRequest("GET", "/?"+query, nil)
+		require.NoError(t, err)
+		require.Equal(t, want, isHistorySeriesRequest(req), query)
+	}
+}
@@ -0,0 +1,100 @@
+package webservice
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	restful "github.com/emicklei/go-restful/v3"
+
+	"github.com/G-Research/unicorn-history-server/internal/database/repository"
+)
+
+const (
+	headerTotalCount = "X-Total-Count"
+	headerLink       = "Link"
+)
+
+// totalMode defines if and how the total number of items of a list is returned.
+type totalMode string
+
+const (
+	totalNone      totalMode = ""
+	totalExact     totalMode = "exact"
+	totalEstimated totalMode = "estimated"
+)
+
+// pagination contains the pagination query parameters of a list request.
+type pagination struct {
+	total totalMode
+	limit *int
+}
+
+func parsePagination(r *http.Request) (*pagination, error) {
+	total, err := getTotalQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	limit, err := getLimitQueryParam(r)
+	if err != nil {
+		return nil, err
+	}
+	return &pagination{total: total, limit: limit}, nil
+}
+
+// queryLimit returns the limit which should be used to query the items.
+// One more item than requested is queried to find out whether there is a next page.
+func (p *pagination) queryLimit() *int {
+	if p.limit == nil {
+		return nil
+	}
+	limit := *p.limit + 1
+	return &limit
+}
+
+// paginate returns the items of the requested page and sets the pagination headers of the response:
+// X-Total-Count contains the total number of items, if it was requested with the 'total' query parameter,
+// Link contains the URL of the next page, if there is one.
+// The items must have been queried with the limit returned by pagination.queryLimit.
+func paginate[T any](
+	req *restful.Request,
+	resp *restful.Response,
+	p *pagination,
+	items []*T,
+	cursor func(*T) repository.Cursor,
+	count func(estimated bool) (int64, error),
+) ([]*T, error) {
+	if p.total != totalNone {
+		total, err := count(p.total == totalEstimated)
+		if err != nil {
+			return nil, err
+		}
+		resp.AddHeader(headerTotalCount, strconv.FormatInt(total, 10))
+	}
+
+	if p.limit == nil || len(items) <= *p.limit {
+		return items, nil
+	}
+	items = items[:*p.limit]
+
+	next := *req.Request.URL
+	query := next.Query()
+	query.Set(queryParamAfter, cursor(items[len(items)-1]).Encode())
+	query.Del(queryParamOffset)
+	next.RawQuery = query.Encode()
+	resp.AddHeader(headerLink, fmt.Sprintf("<%s>; rel=\"next\"", next.RequestURI()))
+
+	return items, nil
+}
+
+// paginationHeaders documents the pagination headers of list responses.
+var paginationHeaders = map[string]restful.Header{
+	headerTotalCount: {
+		Items:       &restful.Items{Type: "integer"},
+		Description: "Total number of items, if requested with the 'total' query parameter",
+	},
+	headerLink: {
+		Items:       &restful.Items{Type: "string"},
+		Description: "Link to the next page (rel=\"next\"), if there is one",
+	},
+}
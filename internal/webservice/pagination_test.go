@@ -0,0 +1,99 @@
+package webservice
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/G-Research/yunikorn-core/pkg/webservice/dao"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/G-Research/unicorn-history-server/internal/database/repository"
+	"github.com/G-Research/unicorn-history-server/internal/model"
+	"github.com/G-Research/unicorn-history-server/internal/util"
+)
+
+func TestPaginate(t *testing.T) {
+	histories := []*model.AppHistory{
+		{ID: "3", ApplicationHistoryDAOInfo: dao.ApplicationHistoryDAOInfo{Timestamp: 300}},
+		{ID: "2", ApplicationHistoryDAOInfo: dao.ApplicationHistoryDAOInfo{Timestamp: 200}},
+		{ID: "1", ApplicationHistoryDAOInfo: dao.ApplicationHistoryDAOInfo{Timestamp: 100}},
+	}
+
+	tests := []struct {
+		name          string
+		query         string
+		expectedItems int
+		expectedTotal string
+		expectedLink  string
+	}{
+		{
+			name:          "No limit",
+			query:         "",
+			expectedItems: 3,
+		},
+		{
+			name:          "Last page",
+			query:         "limit=3",
+			expectedItems: 3,
+		},
+		{
+			name:          "Next page",
+			query:         "limit=2&offset=4&timestampStart=1",
+			expectedItems: 2,
+			expectedLink: fmt.Sprintf(
+				"</api/v1/history/apps?after=%s&limit=2&timestampStart=1>; rel=\"next\"",
+				repository.Cursor{int64(200), "2"}.Encode(),
+			),
+		},
+		{
+			name:          "Exact total",
+			query:         "total=exact",
+			expectedItems: 3,
+			expectedTotal: "42",
+		},
+		{
+			name:          "Estimated total",
+			query:         "total=estimated",
+			expectedItems: 3,
+			expectedTotal: "40",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/api/v1/history/apps?"+tt.query, nil)
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+			resp := restful.NewResponse(rr)
+
+			page, err := parsePagination(req)
+			require.NoError(t, err)
+			items := histories
+			if limit := page.queryLimit(); limit != nil && *limit < len(items) {
+				items = items[:*limit]
+			}
+
+			items, err = paginate(restful.NewRequest(req), resp, page, items, repository.HistoryFilters{}.AppHistoryCursor,
+				func(estimated bool) (int64, error) {
+					if estimated {
+						return 40, nil
+					}
+					return 42, nil
+				},
+			)
+			require.NoError(t, err)
+			assert.Len(t, items, tt.expectedItems)
+			assert.Equal(t, tt.expectedTotal, rr.Header().Get(headerTotalCount))
+			assert.Equal(t, tt.expectedLink, rr.Header().Get(headerLink))
+		})
+	}
+}
+
+func TestPaginationQueryLimit(t *testing.T) {
+	assert.Nil(t, (&pagination{}).queryLimit())
+	assert.Equal(t, util.ToPtr(11), (&pagination{limit: util.ToPtr(10)}).queryLimit())
+}
@@ -1,6 +1,9 @@
 package webservice
 
 import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
 	"net/http"
 
 	restful "github.com/emicklei/go-restful/v3"
@@ -15,6 +18,30 @@ func jsonResponse(response *restful.Response, data any) {
 	}
 }
 
+// csvResponse writes the records as a CSV attachment with the file name.
+func csvResponse(response *restful.Response, filename string, records [][]string) {
+	response.AddHeader("Content-Type", mimeCSV)
+	response.AddHeader("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	response.WriteHeader(http.StatusOK)
+	w := csv.NewWriter(response)
+	if err := w.WriteAll(records); err != nil {
+		log.Logger.Errorf("could not write CSV response: %v", err)
+	}
+}
+
+// ndjsonResponse writes the items as newline-delimited JSON, one item per line.
+func ndjsonResponse[T any](response *restful.Response, items []*T) {
+	response.AddHeader("Content-Type", mimeNDJSON)
+	response.WriteHeader(http.StatusOK)
+	encoder := json.NewEncoder(response)
+	for _, item := range items {
+		if err := encoder.Encode(item); err != nil {
+			log.Logger.Errorf("could not write NDJSON response: %v", err)
+			return
+		}
+	}
+}
+
 // errorResponse writes an RFC7807 Problem error response to the response writer.
 func errorResponse(req *restful.Request, resp *restful.Response, err error) {
 	problemDetails := ProblemDetails{
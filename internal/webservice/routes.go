@@ -3,23 +3,29 @@ package webservice
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/G-Research/yunikorn-core/pkg/webservice/dao"
+	"github.com/G-Research/yunikorn-scheduler-interface/lib/go/si"
 	restfulspec "github.com/emicklei/go-restful-openapi/v2"
 	restful "github.com/emicklei/go-restful/v3"
 	"github.com/go-openapi/spec"
 	"github.com/google/uuid"
 
+	"github.com/G-Research/unicorn-history-server/internal/database/repository"
 	"github.com/G-Research/unicorn-history-server/internal/health"
 	"github.com/G-Research/unicorn-history-server/internal/log"
 	"github.com/G-Research/unicorn-history-server/internal/model"
+	"github.com/G-Research/unicorn-history-server/internal/util"
 	ykmodel "github.com/G-Research/unicorn-history-server/internal/yunikorn/model"
 )
 
@@ -28,10 +34,31 @@ const (
 	routeClusters                 = "/api/v1/clusters"
 	routePartitions               = "/api/v1/partitions"
 	routeQueuesPerPartition       = "/api/v1/partition/{partition_id}/queues"
+	routeQueueByPath              = "/api/v1/partition/{partition_id}/queues/{queue_path}"
+	routeQueue                    = "/api/v1/queues/{queue_id}"
 	routeAppsPerPartitionPerQueue = "/api/v1/partition/{partition_id}/queue/{queue_id}/applications"
+	routeApplications             = "/api/v1/applications"
 	routeAppsHistory              = "/api/v1/history/apps"
 	routeContainersHistory        = "/api/v1/history/containers"
 	routeNodesPerPartition        = "/api/v1/partition/{partition_id}/nodes"
+	routeApplication              = "/api/v1/applications/{application_id}"
+	routeApplicationVersions      = "/api/v1/applications/{application_id}/versions"
+	routeApplicationPreemptions   = "/api/v1/applications/{application_id}/preemptions"
+	routeQueueVersions            = "/api/v1/queues/{queue_id}/versions"
+	routeNode                     = "/api/v1/nodes/{node_id}"
+	routeNodeVersions             = "/api/v1/nodes/{node_id}/versions"
+	routeSearch                   = "/api/v1/search"
+	routeUsageReport              = "/api/v1/reports/usage"
+	routeAccountingRecords        = "/api/v1/accounting"
+	routeWaitTimes                = "/api/v1/analytics/wait-times"
+	routeFairness                 = "/api/v1/analytics/fairness"
+	routePreemptions              = "/api/v1/analytics/preemptions"
+	routeGangs                    = "/api/v1/analytics/gangs"
+	routeDiagnostics              = "/api/v1/analytics/diagnostics"
+	routeReservations             = "/api/v1/analytics/reservations"
+	routeNodeUtilization          = "/api/v1/analytics/node-utilization"
+	routeForecast                 = "/api/v1/analytics/forecast"
+	routeEfficiency               = "/api/v1/analytics/efficiency"
 	routeSchedulerHealthcheck     = "/api/v1/scheduler/healthcheck"
 	routeEventStatistics          = "/api/v1/event-statistics"
 	routeHealthLiveness           = "/api/v1/health/liveness"
@@ -60,9 +87,18 @@ func (ws *WebService) init(ctx context.Context) {
 				"lastStateTransitionTimeEnd",
 				"Filter until the lastStateTransitionTime (unix nanoseconds)",
 			).DataType("string")).
+			Param(service.QueryParameter("asOf", "Return the partitions which existed at this point in time (unix milliseconds)").
+				DataType("string")).
+			Param(service.QueryParameter("includeDeleted", "Include deleted partitions").DataType("boolean")).
 			Param(service.QueryParameter("limit", "Limit the number of returned partitions").DataType("int")).
 			Param(service.QueryParameter("offset", "Offset the returned partitions").DataType("int")).
-			Returns(200, "OK", []dao.PartitionInfo{}).
+			Param(service.QueryParameter("sort", sortDescription(repository.PartitionSortFields)).DataType("string")).
+			Param(service.QueryParameter("filter", filterDescription(repository.PartitionFilterSchema)).DataType("string")).
+			Param(service.QueryParameter("after", "Return the partitions after this cursor (see the Link header)").DataType("string")).
+			Param(service.QueryParameter("total", "Return the total number of partitions in the X-Total-Count header (exact or estimated)").
+				DataType("string")).
+			ReturnsWithHeaders(200, "OK", []dao.PartitionInfo{}, paginationHeaders).
+			Returns(400, "Bad Request", ProblemDetails{}).
 			Returns(500, "Internal Server Error", ProblemDetails{}),
 	)
 	service.Route(
@@ -77,10 +113,43 @@ func (ws *WebService) init(ctx context.Context) {
 			).
 			Produces(restful.MIME_JSON).
 			Writes([]*model.Queue{}).
+			Param(service.QueryParameter("asOf", "Return the queue tree as it was at this point in time (unix milliseconds)").
+				DataType("string")).
+			Param(service.QueryParameter("includeDeleted", "Include deleted queues").DataType("boolean")).
 			Returns(200, "OK", []*model.Queue{}).
+			Returns(400, "Bad Request", ProblemDetails{}).
 			Returns(500, "Internal Server Error", ProblemDetails{}).
 			Doc("Get all queues for a partition"),
 	)
+	service.Route(
+		service.GET(routeQueueByPath).
+			To(ws.getQueueByPath).
+			Param(service.PathParameter("partition_id", "partition id").DataType("string")).
+			Param(service.PathParameter("queue_path", "full path of the queue, e.g. root.eng.ml").DataType("string")).
+			Produces(restful.MIME_JSON).
+			Writes(model.Queue{}).
+			Param(service.QueryParameter("depth", "Number of levels of children to return (default 1, 0 returns only the queue)").
+				DataType("int")).
+			Param(service.QueryParameter("asOf", "Return the queue as it was at this point in time (unix milliseconds)").
+				DataType("string")).
+			Param(service.QueryParameter("includeDeleted", "Include deleted queues").DataType("boolean")).
+			Returns(200, "OK", model.Queue{}).
+			Returns(400, "Bad Request", ProblemDetails{}).
+			Returns(404, "Not Found", ProblemDetails{}).
+			Returns(500, "Internal Server Error", ProblemDetails{}).
+			Doc("Get a queue by its path, with its children up to the given depth"),
+	)
+	service.Route(
+		service.GET(routeQueue).
+			To(ws.getQueue).
+			Param(service.PathParameter("queue_id", "queue id").DataType("string")).
+			Produces(restful.MIME_JSON).
+			Writes(model.QueueDetail{}).
+			Returns(200, "OK", model.QueueDetail{}).
+			Returns(404, "Not Found", ProblemDetails{}).
+			Returns(500, "Internal Server Error", ProblemDetails{}).
+			Doc("Get a queue with its limits, properties and the number of its running and pending applications"),
+	)
 	service.Route(
 		service.GET(routeAppsPerPartitionPerQueue).
 			To(ws.getAppsPerPartitionPerQueue).
@@ -107,13 +176,84 @@ func (ws *WebService) init(ctx context.Context) {
 				DataType("string")).
 			Param(service.QueryParameter("submissionEndTime", "Filter until the submission time (unix nanoseconds)").
 				DataType("string")).
+			Param(service.QueryParameter("finishedStartTime", "Filter from the finished time (unix milliseconds)").
+				DataType("string")).
+			Param(service.QueryParameter("finishedEndTime", "Filter until the finished time (unix milliseconds)").
+				DataType("string")).
+			Param(service.QueryParameter(
+				"runningStartTime",
+				"Filter the applications which were running at any time after this point in time (unix milliseconds)",
+			).DataType("string")).
+			Param(service.QueryParameter(
+				"runningEndTime",
+				"Filter the applications which were running at any time before this point in time (unix milliseconds)",
+			).DataType("string")).
+			Param(service.QueryParameter("state", "Filter by state (comma-separated list)").DataType("string")).
+			Param(service.QueryParameter("applicationIdPrefix", "Filter by the prefix of the application ID").DataType("string")).
+			Param(service.QueryParameter("asOf", "Return the applications as they were at this point in time (unix milliseconds)").
+				DataType("string")).
+			Param(service.QueryParameter("includeDeleted", "Include deleted applications").DataType("boolean")).
 			Param(service.QueryParameter("limit", "Limit the number of returned applications").DataType("int")).
 			Param(service.QueryParameter("offset", "Offset the returned applications").DataType("int")).
-			Returns(200, "OK", []dao.ApplicationDAOInfo{}).
+			Param(service.QueryParameter("sort", sortDescription(repository.ApplicationSortFields)).DataType("string")).
+			Param(service.QueryParameter("filter", filterDescription(repository.ApplicationFilterSchema)).DataType("string")).
+			Param(service.QueryParameter("resourceFilter", resourceFilterDescription(repository.ApplicationResourceFilterFields)).
+				DataType("string").AllowMultiple(true)).
+			Param(service.QueryParameter("after", "Return the applications after this cursor (see the Link header)").DataType("string")).
+			Param(service.QueryParameter("total", "Return the total number of applications in the X-Total-Count header (exact or estimated)").
+				DataType("string")).
+			ReturnsWithHeaders(200, "OK", []dao.ApplicationDAOInfo{}, paginationHeaders).
 			Returns(400, "Bad Request", ProblemDetails{}).
 			Returns(500, "Internal Server Error", ProblemDetails{}).
 			Doc("Get all applications for a partition and queue"),
 	)
+	service.Route(
+		service.GET(routeApplications).
+			To(ws.getApplications).
+			Produces(restful.MIME_JSON).
+			Writes([]dao.ApplicationDAOInfo{}).
+			Param(service.QueryParameter("user", "Filter by user").DataType("string")).
+			Param(service.QueryParameter("groups", "Filter by groups (comma-separated list)").
+				DataType("string")).
+			Param(service.QueryParameter("queue", "Filter by the full path of the queue, e.g. root.default").DataType("string")).
+			Param(service.QueryParameter("includeSubqueues", "Include the applications of the queues below the queue").
+				DataType("boolean")).
+			Param(service.QueryParameter("partition", "Filter by partition name").DataType("string")).
+			Param(service.QueryParameter("submissionStartTime", "Filter from the submission time (unix milliseconds)").
+				DataType("string")).
+			Param(service.QueryParameter("submissionEndTime", "Filter until the submission time (unix milliseconds)").
+				DataType("string")).
+			Param(service.QueryParameter("finishedStartTime", "Filter from the finished time (unix milliseconds)").
+				DataType("string")).
+			Param(service.QueryParameter("finishedEndTime", "Filter until the finished time (unix milliseconds)").
+				DataType("string")).
+			Param(service.QueryParameter(
+				"runningStartTime",
+				"Filter the applications which were running at any time after this point in time (unix milliseconds)",
+			).DataType("string")).
+			Param(service.QueryParameter(
+				"runningEndTime",
+				"Filter the applications which were running at any time before this point in time (unix milliseconds)",
+			).DataType("string")).
+			Param(service.QueryParameter("state", "Filter by state (comma-separated list)").DataType("string")).
+			Param(service.QueryParameter("applicationIdPrefix", "Filter by the prefix of the application ID").DataType("string")).
+			Param(service.QueryParameter("asOf", "Return the applications as they were at this point in time (unix milliseconds)").
+				DataType("string")).
+			Param(service.QueryParameter("includeDeleted", "Include deleted applications").DataType("boolean")).
+			Param(service.QueryParameter("limit", "Limit the number of returned applications").DataType("int")).
+			Param(service.QueryParameter("offset", "Offset the returned applications").DataType("int")).
+			Param(service.QueryParameter("sort", sortDescription(repository.ApplicationSortFields)).DataType("string")).
+			Param(service.QueryParameter("filter", filterDescription(repository.ApplicationFilterSchema)).DataType("string")).
+			Param(service.QueryParameter("resourceFilter", resourceFilterDescription(repository.ApplicationResourceFilterFields)).
+				DataType("string").AllowMultiple(true)).
+			Param(service.QueryParameter("after", "Return the applications after this cursor (see the Link header)").DataType("string")).
+			Param(service.QueryParameter("total", "Return the total number of applications in the X-Total-Count header (exact or estimated)").
+				DataType("string")).
+			ReturnsWithHeaders(200, "OK", []dao.ApplicationDAOInfo{}, paginationHeaders).
+			Returns(400, "Bad Request", ProblemDetails{}).
+			Returns(500, "Internal Server Error", ProblemDetails{}).
+			Doc("Search applications across all partitions and queues"),
+	)
 	service.Route(
 		service.GET(routeNodesPerPartition).
 			To(ws.getNodesPerPartition).
@@ -131,9 +271,20 @@ func (ws *WebService) init(ctx context.Context) {
 			Param(service.QueryParameter("rackName", "Filter by rackName").DataType("string")).
 			Param(service.QueryParameter("schedulable", "Filter by schedulable status").DataType("boolean")).
 			Param(service.QueryParameter("isReserved", "Filter by reservation status").DataType("boolean")).
+			Param(service.QueryParameter("asOf", "Return the nodes as they were at this point in time (unix milliseconds)").
+				DataType("string")).
+			Param(service.QueryParameter("includeDeleted", "Include deleted nodes").DataType("boolean")).
 			Param(service.QueryParameter("limit", "Limit the number of returned nodes").DataType("int")).
 			Param(service.QueryParameter("offset", "Offset the returned nodes").DataType("int")).
-			Returns(200, "OK", []model.Node{}).
+			Param(service.QueryParameter("sort", sortDescription(repository.NodeSortFields)).DataType("string")).
+			Param(service.QueryParameter("filter", filterDescription(repository.NodeFilterSchema)).DataType("string")).
+			Param(service.QueryParameter("resourceFilter", resourceFilterDescription(repository.NodeResourceFilterFields)).
+				DataType("string").AllowMultiple(true)).
+			Param(service.QueryParameter("after", "Return the nodes after this cursor (see the Link header)").DataType("string")).
+			Param(service.QueryParameter("total", "Return the total number of nodes in the X-Total-Count header (exact or estimated)").
+				DataType("string")).
+			ReturnsWithHeaders(200, "OK", []model.Node{}, paginationHeaders).
+			Returns(400, "Bad Request", ProblemDetails{}).
 			Returns(500, "Internal Server Error", ProblemDetails{}).
 			Doc("Get all nodes for a partition"),
 	)
@@ -146,9 +297,22 @@ func (ws *WebService) init(ctx context.Context) {
 			Param(service.QueryParameter("timestampEnd", "Filter until the timestamp").DataType("string")).
 			Param(service.QueryParameter("limit", "Limit the number of returned objects").DataType("int")).
 			Param(service.QueryParameter("offset", "Offset the returned objects").DataType("int")).
-			Returns(200, "OK", []model.AppHistory{}).
+			Param(service.QueryParameter("sort", sortDescription(repository.AppHistorySortFields)).DataType("string")).
+			Param(service.QueryParameter("step", "Width of the buckets of the downsampled history or the timeline, "+
+				"which are aligned to it, in whole seconds, e.g. '1h', one hour by default").DataType("string")).
+			Param(service.QueryParameter("agg", "Aggregation of the history in a bucket of the downsampled history: "+
+				"avg (default), min, max or last. Cannot be combined with partition, queue or user").DataType("string")).
+			Param(service.QueryParameter("partition", "Filter the timeline by partition name").DataType("string")).
+			Param(service.QueryParameter("queue", "Filter the timeline by the full path of the queue, including its subqueues").
+				DataType("string")).
+			Param(service.QueryParameter("user", "Filter the timeline by user").DataType("string")).
+			Param(service.QueryParameter("after", "Return the objects after this cursor (see the Link header)").DataType("string")).
+			Param(service.QueryParameter("total", "Return the total number of objects in the X-Total-Count header (exact or estimated)").
+				DataType("string")).
+			ReturnsWithHeaders(200, "OK", []model.AppHistory{}, paginationHeaders).
+			Returns(400, "Bad Request", ProblemDetails{}).
 			Returns(500, "Internal Server Error", ProblemDetails{}).
-			Doc("Get applications history"),
+			Doc("Get applications history, downsampled if step or agg is set, or the timeline if partition, queue or user is set"),
 	)
 	service.Route(
 		service.GET(routeContainersHistory).
@@ -159,9 +323,317 @@ func (ws *WebService) init(ctx context.Context) {
 			Param(service.QueryParameter("timestampEnd", "Filter until the timestamp").DataType("string")).
 			Param(service.QueryParameter("limit", "Limit the number of returned objects").DataType("int")).
 			Param(service.QueryParameter("offset", "Offset the returned objects").DataType("int")).
-			Returns(200, "OK", []model.ContainerHistory{}).
+			Param(service.QueryParameter("sort", sortDescription(repository.ContainerHistorySortFields)).DataType("string")).
+			Param(service.QueryParameter("step", "Width of the buckets of the downsampled history or the timeline, "+
+				"which are aligned to it, in whole seconds, e.g. '1h', one hour by default").DataType("string")).
+			Param(service.QueryParameter("agg", "Aggregation of the history in a bucket of the downsampled history: "+
+				"avg (default), min, max or last. Cannot be combined with partition, queue or user").DataType("string")).
+			Param(service.QueryParameter("partition", "Filter the timeline by partition name").DataType("string")).
+			Param(service.QueryParameter("queue", "Filter the timeline by the full path of the queue, including its subqueues").
+				DataType("string")).
+			Param(service.QueryParameter("user", "Filter the timeline by user").DataType("string")).
+			Param(service.QueryParameter("after", "Return the objects after this cursor (see the Link header)").DataType("string")).
+			Param(service.QueryParameter("total", "Return the total number of objects in the X-Total-Count header (exact or estimated)").
+				DataType("string")).
+			ReturnsWithHeaders(200, "OK", []model.ContainerHistory{}, paginationHeaders).
+			Returns(400, "Bad Request", ProblemDetails{}).
+			Returns(500, "Internal Server Error", ProblemDetails{}).
+			Doc("Get containers history, downsampled if step or agg is set, or the timeline if partition, queue or user is set"),
+	)
+	service.Route(
+		service.GET(routeApplication).
+			To(ws.getApplication).
+			Param(service.PathParameter(
+				"application_id",
+				"id of the application, or its applicationID (the most recent run is returned if it was resubmitted)",
+			).DataType("string")).
+			Produces(restful.MIME_JSON).
+			Writes(model.ApplicationDetail{}).
+			Returns(200, "OK", model.ApplicationDetail{}).
+			Returns(404, "Not Found", ProblemDetails{}).
+			Returns(500, "Internal Server Error", ProblemDetails{}).
+			Doc("Get an application with its lifecycle, durations and all of its runs"),
+	)
+	service.Route(
+		service.GET(routeApplicationVersions).
+			To(ws.getApplicationVersions).
+			Param(service.PathParameter("application_id", "application id").DataType("string")).
+			Produces(restful.MIME_JSON).
+			Writes([]model.ApplicationVersion{}).
+			Returns(200, "OK", []model.ApplicationVersion{}).
+			Returns(404, "Not Found", ProblemDetails{}).
+			Returns(500, "Internal Server Error", ProblemDetails{}).
+			Doc("Get all versions of an application with the fields changed between consecutive versions"),
+	)
+	service.Route(
+		service.GET(routeApplicationPreemptions).
+			To(ws.getApplicationPreemptions).
+			Param(service.PathParameter("application_id", "application id").DataType("string")).
+			Produces(restful.MIME_JSON).
+			Writes([]model.Preemption{}).
+			Returns(200, "OK", []model.Preemption{}).
+			Returns(500, "Internal Server Error", ProblemDetails{}).
+			Doc("Get the preemptions of the allocations of an application and the preemptions which it triggered"),
+	)
+	service.Route(
+		service.GET(routeQueueVersions).
+			To(ws.getQueueVersions).
+			Param(service.PathParameter("queue_id", "queue id").DataType("string")).
+			Produces(restful.MIME_JSON).
+			Writes([]model.QueueVersion{}).
+			Returns(200, "OK", []model.QueueVersion{}).
+			Returns(404, "Not Found", ProblemDetails{}).
+			Returns(500, "Internal Server Error", ProblemDetails{}).
+			Doc("Get all versions of a queue with the fields changed between consecutive versions"),
+	)
+	service.Route(
+		service.GET(routeNode).
+			To(ws.getNode).
+			Param(service.PathParameter("node_id", "node id").DataType("string")).
+			Produces(restful.MIME_JSON).
+			Writes(model.NodeDetail{}).
+			Returns(200, "OK", model.NodeDetail{}).
+			Returns(404, "Not Found", ProblemDetails{}).
+			Returns(500, "Internal Server Error", ProblemDetails{}).
+			Doc("Get a node with the timeline of its changes and its latest events"),
+	)
+	service.Route(
+		service.GET(routeNodeVersions).
+			To(ws.getNodeVersions).
+			Param(service.PathParameter("node_id", "node id").DataType("string")).
+			Produces(restful.MIME_JSON).
+			Writes([]model.NodeVersion{}).
+			Returns(200, "OK", []model.NodeVersion{}).
+			Returns(404, "Not Found", ProblemDetails{}).
+			Returns(500, "Internal Server Error", ProblemDetails{}).
+			Doc("Get all versions of a node with the fields changed between consecutive versions"),
+	)
+	service.Route(
+		service.GET(routeSearch).
+			To(ws.search).
+			Param(service.QueryParameter("q", "Fragment of an application ID, user, queue, rejection message, "+
+				"node ID, host name, rack name or node attribute").DataType("string").Required(true)).
+			Param(service.QueryParameter("limit", "Maximum number of results per type").DataType("integer").
+				DefaultValue(strconv.Itoa(defaultSearchLimit))).
+			Produces(restful.MIME_JSON).
+			Writes(model.SearchResults{}).
+			Returns(200, "OK", model.SearchResults{}).
+			Returns(400, "Bad Request", ProblemDetails{}).
+			Returns(500, "Internal Server Error", ProblemDetails{}).
+			Doc("Search applications, queues and nodes, grouped by type and ranked by how well they match"),
+	)
+	service.Route(
+		service.GET(routeUsageReport).
+			To(ws.getUsageReport).
+			Param(service.QueryParameter("start", "Start of the period (unix milliseconds), 30 days before the end by default").
+				DataType("string")).
+			Param(service.QueryParameter("end", "End of the period (unix milliseconds), now by default").DataType("string")).
+			Param(service.QueryParameter("groupBy", "Field by which the usage is grouped").DataType("string").
+				AllowableValues(usageGroupByAllowableValues()).DefaultValue(string(repository.UsageGroupByUser))).
+			Param(service.QueryParameter("partition", "Filter by partition name").DataType("string")).
+			Param(service.QueryParameter("queue", "Filter by the full path of the queue, including its subqueues").
+				DataType("string")).
+			Param(service.QueryParameter("format", "Format of the report, by default CSV if only text/csv is accepted").
+				DataType("string").AllowableValues(map[string]string{"json": "JSON", "csv": "CSV"})).
+			Produces(restful.MIME_JSON, mimeCSV).
+			Writes(model.UsageReport{}).
+			Returns(200, "OK", model.UsageReport{}).
+			Returns(400, "Bad Request", ProblemDetails{}).
+			Returns(500, "Internal Server Error", ProblemDetails{}).
+			Doc("Get the usage of resources (vcore seconds, memory GiB hours, GPU hours) and its cost during a period"),
+	)
+	service.Route(
+		service.GET(routeAccountingRecords).
+			To(ws.getAccountingRecords).
+			Param(service.QueryParameter("finishedStartTime", "Filter from the finished time (unix milliseconds)").
+				DataType("string")).
+			Param(service.QueryParameter("finishedEndTime", "Filter until the finished time (unix milliseconds)").
+				DataType("string")).
+			Param(service.QueryParameter("user", "Filter by user").DataType("string")).
+			Param(service.QueryParameter("groups", "Filter by groups (comma-separated list)").DataType("string")).
+			Param(service.QueryParameter("state", "Filter by final state (comma-separated list)").DataType("string")).
+			Param(service.QueryParameter("queue", "Filter by the full path of the queue").DataType("string")).
+			Param(service.QueryParameter("includeSubqueues", "Include the records of the queues below the queue").
+				DataType("boolean")).
+			Param(service.QueryParameter("partition", "Filter by partition name").DataType("string")).
+			Param(service.QueryParameter("limit", "Limit the number of returned records").DataType("int")).
+			Param(service.QueryParameter("offset", "Offset the returned records").DataType("int")).
+			Param(service.QueryParameter("sort", sortDescription(repository.AccountingSortFields)).DataType("string")).
+			Param(service.QueryParameter("after", "Return the records after this cursor (see the Link header)").DataType("string")).
+			Param(service.QueryParameter("total", "Return the total number of records in the X-Total-Count header (exact or estimated)").
+				DataType("string")).
+			Param(service.QueryParameter("format", "Format of the records, by default NDJSON or CSV if only these are accepted").
+				DataType("string").AllowableValues(map[string]string{"json": "JSON", "ndjson": "NDJSON", "csv": "CSV"})).
+			Produces(restful.MIME_JSON, mimeNDJSON, mimeCSV).
+			Writes([]model.AccountingRecord{}).
+			ReturnsWithHeaders(200, "OK", []model.AccountingRecord{}, paginationHeaders).
+			Returns(400, "Bad Request", ProblemDetails{}).
+			Returns(500, "Internal Server Error", ProblemDetails{}).
+			Doc("Get the accounting records of the completed applications"),
+	)
+	service.Route(
+		service.GET(routeWaitTimes).
+			To(ws.getWaitTimes).
+			Param(service.QueryParameter("start", "Start of the period of the submission times (unix milliseconds), "+
+				"30 days before the end by default").DataType("string")).
+			Param(service.QueryParameter("end", "End of the period of the submission times (unix milliseconds), now by default").
+				DataType("string")).
+			Param(service.QueryParameter("groupBy", "Comma-separated list of the fields by which the applications are grouped: "+
+				strings.Join(waitTimeGroupByNames(), ", ")).DataType("string")).
+			Param(service.QueryParameter("bucket", "Width of the time buckets of the submission times, e.g. '1h' or '24h'").
+				DataType("string")).
+			Param(service.QueryParameter("partition", "Filter by partition name").DataType("string")).
+			Param(service.QueryParameter("queue", "Filter by the full path of the queue, including its subqueues").
+				DataType("string")).
+			Param(service.QueryParameter("resourceFilter", resourceFilterDescription(repository.WaitTimeResourceFilterFields)).
+				DataType("string").AllowMultiple(true)).
+			Produces(restful.MIME_JSON).
+			Writes(model.WaitTimeReport{}).
+			Returns(200, "OK", model.WaitTimeReport{}).
+			Returns(400, "Bad Request", ProblemDetails{}).
+			Returns(500, "Internal Server Error", ProblemDetails{}).
+			Doc("Get the percentiles of the queueing delays and runtimes of the applications"),
+	)
+	service.Route(
+		service.GET(routeFairness).
+			To(ws.getFairness).
+			Param(service.QueryParameter("start", "Start of the period (unix milliseconds), 30 days before the end by default").
+				DataType("string")).
+			Param(service.QueryParameter("end", "End of the period (unix milliseconds), now by default").DataType("string")).
+			Param(service.QueryParameter("partition", "Filter by partition name").DataType("string")).
+			Param(service.QueryParameter("queue", "Filter by the full path of the queue, including its subqueues").
+				DataType("string")).
+			Produces(restful.MIME_JSON).
+			Writes(model.FairnessReport{}).
+			Returns(200, "OK", model.FairnessReport{}).
+			Returns(400, "Bad Request", ProblemDetails{}).
+			Returns(500, "Internal Server Error", ProblemDetails{}).
+			Doc("Get how long the queues were starved, borrowing or capped, and the dominant resource shares of the queues and users"),
+	)
+	service.Route(
+		service.GET(routePreemptions).
+			To(ws.getPreemptions).
+			Param(service.QueryParameter("start", "Start of the period (unix milliseconds), 30 days before the end by default").
+				DataType("string")).
+			Param(service.QueryParameter("end", "End of the period (unix milliseconds), now by default").DataType("string")).
+			Param(service.QueryParameter("partition", "Filter by partition name").DataType("string")).
+			Param(service.QueryParameter("queue", "Filter by the full path of the queue of the victims, including its subqueues").
+				DataType("string")).
+			Param(service.QueryParameter("user", "Filter by the user of the victims").DataType("string")).
+			Produces(restful.MIME_JSON).
+			Writes(model.PreemptionReport{}).
+			Returns(200, "OK", model.PreemptionReport{}).
+			Returns(400, "Bad Request", ProblemDetails{}).
+			Returns(500, "Internal Server Error", ProblemDetails{}).
+			Doc("Get the number and the resources of the preempted allocations per queue and per user"),
+	)
+	service.Route(
+		service.GET(routeGangs).
+			To(ws.getGangs).
+			Param(service.QueryParameter("start", "Start of the period of the submission times (unix milliseconds), "+
+				"30 days before the end by default").DataType("string")).
+			Param(service.QueryParameter("end", "End of the period of the submission times (unix milliseconds), now by default").
+				DataType("string")).
+			Param(service.QueryParameter("partition", "Filter by partition name").DataType("string")).
+			Param(service.QueryParameter("queue", "Filter by the full path of the queue, including its subqueues").
+				DataType("string")).
+			Param(service.QueryParameter("user", "Filter by user").DataType("string")).
+			Produces(restful.MIME_JSON).
+			Writes(model.GangReport{}).
+			Returns(200, "OK", model.GangReport{}).
+			Returns(400, "Bad Request", ProblemDetails{}).
+			Returns(500, "Internal Server Error", ProblemDetails{}).
+			Doc("Get the placeholders, replacements, timeouts and replacement times of the task groups per queue"),
+	)
+	service.Route(
+		service.GET(routeDiagnostics).
+			To(ws.getDiagnostics).
+			Param(service.QueryParameter("start", "Start of the period in which the applications were rejected or failed "+
+				"(unix milliseconds), 30 days before the end by default").DataType("string")).
+			Param(service.QueryParameter("end", "End of the period (unix milliseconds), now by default").DataType("string")).
+			Param(service.QueryParameter("bucket", "Width of the time buckets of the trends, e.g. '1h', one day by default").
+				DataType("string")).
+			Param(service.QueryParameter("partition", "Filter by partition name").DataType("string")).
+			Param(service.QueryParameter("queue", "Filter by the full path of the queue, including its subqueues").
+				DataType("string")).
+			Param(service.QueryParameter("user", "Filter by user").DataType("string")).
+			Produces(restful.MIME_JSON).
+			Writes(model.DiagnosticsReport{}).
+			Returns(200, "OK", model.DiagnosticsReport{}).
+			Returns(400, "Bad Request", ProblemDetails{}).
+			Returns(500, "Internal Server Error", ProblemDetails{}).
+			Doc("Get the rejected and failed applications grouped by normalized message, queue and user, with their trends"),
+	)
+	service.Route(
+		service.GET(routeReservations).
+			To(ws.getReservations).
+			Param(service.QueryParameter("start", "Start of the period of the reservations (unix milliseconds), "+
+				"30 days before the end by default").DataType("string")).
+			Param(service.QueryParameter("end", "End of the period (unix milliseconds), now by default").DataType("string")).
+			Param(service.QueryParameter("minDuration", "Minimum duration of a long-lived reservation, e.g. '1h', "+
+				"10 minutes by default").DataType("string")).
+			Param(service.QueryParameter("partition", "Filter by partition name").DataType("string")).
+			Param(service.QueryParameter("queue", "Filter by the full path of the queue of the application, including its subqueues").
+				DataType("string")).
+			Param(service.QueryParameter("user", "Filter by user").DataType("string")).
+			Produces(restful.MIME_JSON).
+			Writes(model.ReservationReport{}).
+			Returns(200, "OK", model.ReservationReport{}).
+			Returns(400, "Bad Request", ProblemDetails{}).
+			Returns(500, "Internal Server Error", ProblemDetails{}).
+			Doc("Get the long-lived reservations of nodes for pending asks, and the nodes which were blocked by them"),
+	)
+	service.Route(
+		service.GET(routeNodeUtilization).
+			To(ws.getNodeUtilization).
+			Param(service.QueryParameter("start", "Start of the period of the utilization (unix milliseconds), "+
+				"30 days before the end by default").DataType("string")).
+			Param(service.QueryParameter("end", "End of the period (unix milliseconds), now by default").DataType("string")).
+			Param(service.QueryParameter("partition", "Filter by partition name").DataType("string")).
+			Produces(restful.MIME_JSON).
+			Writes(model.NodeUtilizationReport{}).
+			Returns(200, "OK", model.NodeUtilizationReport{}).
+			Returns(400, "Bad Request", ProblemDetails{}).
+			Returns(500, "Internal Server Error", ProblemDetails{}).
+			Doc("Get the utilization of the nodes from the hourly and daily rollups, during the part of the period which they cover"),
+	)
+	service.Route(
+		service.GET(routeForecast).
+			To(ws.getForecast).
+			Param(service.QueryParameter("partition", "Name of the partition").DataType("string").Required(true)).
+			Param(service.QueryParameter("resource", "Name of the resource, e.g. vcore").DataType("string").Required(true)).
+			Param(service.QueryParameter("queue", "Full path of the queue, the root queue by default").DataType("string")).
+			Param(service.QueryParameter("horizon", "Period of the forecast in days, e.g. 30d, or as a duration, e.g. 72h, "+
+				"30 days by default").DataType("string")).
+			Param(service.QueryParameter("start", "Start of the history (unix milliseconds), "+
+				"30 days before the end by default").DataType("string")).
+			Param(service.QueryParameter("end", "End of the history (unix milliseconds), now by default").DataType("string")).
+			Produces(restful.MIME_JSON).
+			Writes(model.ForecastReport{}).
+			Returns(200, "OK", model.ForecastReport{}).
+			Returns(400, "Bad Request", ProblemDetails{}).
 			Returns(500, "Internal Server Error", ProblemDetails{}).
-			Doc("Get containers history"),
+			Doc("Forecast the demand of a resource of a queue, its allocated and pending quantities, and when it exhausts the capacity"),
+	)
+	service.Route(
+		service.GET(routeEfficiency).
+			To(ws.getEfficiency).
+			Param(service.QueryParameter("start", "Start of the period of the submission times (unix milliseconds), "+
+				"30 days before the end by default").DataType("string")).
+			Param(service.QueryParameter("end", "End of the period (unix milliseconds), now by default").DataType("string")).
+			Param(service.QueryParameter("partition", "Filter by partition name").DataType("string")).
+			Param(service.QueryParameter("queue", "Filter by queue, including the queues below it").DataType("string")).
+			Param(service.QueryParameter("user", "Filter by user").DataType("string")).
+			Param(service.QueryParameter("resource", "Name of the resource, vcore by default").DataType("string")).
+			Param(service.QueryParameter("rankBy", "Rank by the idle resource-seconds of the peak (idle, the default) "+
+				"or by the requested quantity which was never used (unused)").DataType("string")).
+			Param(service.QueryParameter("limit", "Number of applications, users and queues, 10 by default").DataType("integer")).
+			Produces(restful.MIME_JSON).
+			Writes(model.EfficiencyReport{}).
+			Returns(200, "OK", model.EfficiencyReport{}).
+			Returns(400, "Bad Request", ProblemDetails{}).
+			Returns(500, "Internal Server Error", ProblemDetails{}).
+			Doc("Get the applications, users and queues which waste the most of a resource they requested"),
 	)
 	service.Route(
 		service.GET(routeEventStatistics).
@@ -250,6 +722,7 @@ func (s *WebService) applyCORS(next http.Handler) http.Handler {
 		w.Header().Set("Access-Control-Allow-Origin", strings.Join(s.config.CORSConfig.AllowedOrigins, ","))
 		w.Header().Set("Access-Control-Allow-Methods", strings.Join(s.config.CORSConfig.AllowedMethods, ","))
 		w.Header().Set("Access-Control-Allow-Headers", strings.Join(s.config.CORSConfig.AllowedHeaders, ","))
+		w.Header().Set("Access-Control-Expose-Headers", strings.Join([]string{headerLink, headerTotalCount}, ","))
 
 		if r.Method == http.MethodOptions {
 			return
@@ -266,6 +739,12 @@ func (ws *WebService) getPartitions(req *restful.Request, resp *restful.Response
 		badRequestResponse(req, resp, err)
 		return
 	}
+	page, err := parsePagination(req.Request)
+	if err != nil {
+		badRequestResponse(req, resp, err)
+		return
+	}
+	filters.Limit = page.queryLimit()
 	partitions, err := ws.repository.GetAllPartitions(ctx, *filters)
 	if err != nil {
 		errorResponse(req, resp, err)
@@ -275,13 +754,25 @@ func (ws *WebService) getPartitions(req *restful.Request, resp *restful.Response
 		notFoundResponse(req, resp, fmt.Errorf("no partitions found"))
 		return
 	}
+	partitions, err = paginate(req, resp, page, partitions, filters.Cursor, func(estimated bool) (int64, error) {
+		return ws.repository.CountAllPartitions(ctx, *filters, estimated)
+	})
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
 	jsonResponse(resp, partitions)
 }
 
 func (ws *WebService) getQueuesPerPartition(req *restful.Request, resp *restful.Response) {
 	ctx := req.Request.Context()
 	partitionID := req.PathParameter("partition_id")
-	queues, err := ws.repository.GetQueuesInPartition(ctx, partitionID)
+	filters, err := parseQueueFilters(req.Request)
+	if err != nil {
+		badRequestResponse(req, resp, err)
+		return
+	}
+	queues, err := ws.repository.GetQueuesInPartition(ctx, partitionID, *filters)
 	if err != nil {
 		errorResponse(req, resp, err)
 		return
@@ -293,10 +784,85 @@ func (ws *WebService) getQueuesPerPartition(req *restful.Request, resp *restful.
 	root, err := buildPartitionQueueTrees(ctx, queues)
 	if err != nil {
 		errorResponse(req, resp, err)
+		return
 	}
 	jsonResponse(resp, root)
 }
 
+func (ws *WebService) getQueueByPath(req *restful.Request, resp *restful.Response) {
+	ctx := req.Request.Context()
+	partitionID := req.PathParameter("partition_id")
+	queuePath := req.PathParameter("queue_path")
+	filters, err := parseQueueFilters(req.Request)
+	if err != nil {
+		badRequestResponse(req, resp, err)
+		return
+	}
+	depth, err := getDepthQueryParam(req.Request)
+	if err != nil {
+		badRequestResponse(req, resp, err)
+		return
+	}
+	queues, err := ws.repository.GetQueueSubtree(ctx, partitionID, queuePath, depth, *filters)
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	queue := buildQueueSubtree(queues, queuePath)
+	if queue == nil {
+		notFoundResponse(req, resp, fmt.Errorf("queue %q not found", queuePath))
+		return
+	}
+	jsonResponse(resp, queue)
+}
+
+// buildQueueSubtree links the queues of a subtree to their parents and returns the root of the subtree,
+// which is the queue with the path. Queues whose parent is not part of the subtree are ignored.
+func buildQueueSubtree(queues []*model.Queue, queuePath string) *model.Queue {
+	queueMap := make(map[string]*model.Queue, len(queues))
+	for _, queue := range queues {
+		queueMap[queue.ID] = queue
+	}
+
+	var root *model.Queue
+	for _, queue := range queues {
+		if queue.QueueName == queuePath {
+			root = queue
+			continue
+		}
+		if queue.ParentID == nil {
+			continue
+		}
+		if parent, ok := queueMap[*queue.ParentID]; ok {
+			parent.Children = append(parent.Children, queue)
+		}
+	}
+	return root
+}
+
+func (ws *WebService) getQueue(req *restful.Request, resp *restful.Response) {
+	ctx := req.Request.Context()
+	queueID := req.PathParameter("queue_id")
+	queue, err := ws.repository.GetQueue(ctx, queueID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			notFoundResponse(req, resp, err)
+			return
+		}
+		errorResponse(req, resp, err)
+		return
+	}
+	counts, err := ws.repository.CountApplicationsInQueueByState(ctx, queueID)
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	jsonResponse(resp, model.QueueDetail{
+		Queue:        *queue,
+		Applications: model.NewQueueApplicationCounts(counts),
+	})
+}
+
 func buildPartitionQueueTrees(ctx context.Context, queues []*model.Queue) ([]*model.Queue, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, err
@@ -343,58 +909,165 @@ func buildPartitionQueueTrees(ctx context.Context, queues []*model.Queue) ([]*mo
 	return roots, nil
 }
 
-func (ws *WebService) getAppsPerPartitionPerQueue(req *restful.Request, resp *restful.Response) {
+func (ws *WebService) getApplications(req *restful.Request, resp *restful.Response) {
 	ctx := req.Request.Context()
-	partitionID := req.PathParameter("partition_id")
-	queueID := req.PathParameter("queue_id")
-
 	filters, err := parseApplicationFilters(req.Request)
 	if err != nil {
 		badRequestResponse(req, resp, err)
 		return
 	}
+	page, err := parsePagination(req.Request)
+	if err != nil {
+		badRequestResponse(req, resp, err)
+		return
+	}
+	filters.Limit = page.queryLimit()
 
-	apps, err := ws.repository.GetAppsPerPartitionPerQueue(ctx, partitionID, queueID, *filters)
+	apps, err := ws.repository.GetAllApplications(ctx, *filters)
 	if err != nil {
 		errorResponse(req, resp, err)
 		return
 	}
+	// an empty search result is not an error
 	if apps == nil {
-		notFoundResponse(req, resp, fmt.Errorf("no applications found"))
+		apps = []*model.Application{}
+	}
+	apps, err = paginate(req, resp, page, apps, filters.Cursor, func(estimated bool) (int64, error) {
+		return ws.repository.CountAllApplications(ctx, *filters, estimated)
+	})
+	if err != nil {
+		errorResponse(req, resp, err)
 		return
 	}
 
 	jsonResponse(resp, apps)
 }
 
-func (ws *WebService) getNodesPerPartition(req *restful.Request, resp *restful.Response) {
+func (ws *WebService) getApplication(req *restful.Request, resp *restful.Response) {
 	ctx := req.Request.Context()
-	partitionID := req.PathParameter("partition_id")
-	filters, err := parseNodeFilters(req.Request)
-	if err != nil {
-		badRequestResponse(req, resp, err)
-		return
-	}
-	nodes, err := ws.repository.GetNodesPerPartition(ctx, partitionID, *filters)
+	applicationID := req.PathParameter("application_id")
+	runs, err := ws.repository.GetApplicationRuns(ctx, applicationID)
 	if err != nil {
 		errorResponse(req, resp, err)
 		return
 	}
-	if nodes == nil {
-		notFoundResponse(req, resp, fmt.Errorf("no nodes found"))
+	if len(runs) == 0 {
+		notFoundResponse(req, resp, fmt.Errorf("application %q not found", applicationID))
 		return
 	}
-	jsonResponse(resp, nodes)
+
+	// the application is addressed either by the id of one of its runs, or by its applicationID
+	app := runs[0]
+	for _, run := range runs {
+		if run.ID == applicationID {
+			app = run
+			break
+		}
+	}
+	detail := model.ApplicationDetail{
+		Application: *app,
+		Durations:   app.Durations(time.Now()),
+		Runs:        make([]model.ApplicationRun, 0, len(runs)),
+		TaskGroups:  app.TaskGroupStats(),
+	}
+	for _, run := range runs {
+		detail.Runs = append(detail.Runs, run.Run())
+	}
+	jsonResponse(resp, detail)
 }
 
-func (ws *WebService) getAppsHistory(req *restful.Request, resp *restful.Response) {
+func (ws *WebService) getAppsPerPartitionPerQueue(req *restful.Request, resp *restful.Response) {
 	ctx := req.Request.Context()
-	filters, err := parseHistoryFilters(req.Request)
+	partitionID := req.PathParameter("partition_id")
+	queueID := req.PathParameter("queue_id")
+
+	filters, err := parseApplicationFilters(req.Request)
 	if err != nil {
 		badRequestResponse(req, resp, err)
 		return
 	}
-
+	page, err := parsePagination(req.Request)
+	if err != nil {
+		badRequestResponse(req, resp, err)
+		return
+	}
+	filters.Limit = page.queryLimit()
+
+	apps, err := ws.repository.GetAppsPerPartitionPerQueue(ctx, partitionID, queueID, *filters)
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	if apps == nil {
+		notFoundResponse(req, resp, fmt.Errorf("no applications found"))
+		return
+	}
+	apps, err = paginate(req, resp, page, apps, filters.Cursor, func(estimated bool) (int64, error) {
+		return ws.repository.CountAppsPerPartitionPerQueue(ctx, partitionID, queueID, *filters, estimated)
+	})
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+
+	jsonResponse(resp, apps)
+}
+
+func (ws *WebService) getNodesPerPartition(req *restful.Request, resp *restful.Response) {
+	ctx := req.Request.Context()
+	partitionID := req.PathParameter("partition_id")
+	filters, err := parseNodeFilters(req.Request)
+	if err != nil {
+		badRequestResponse(req, resp, err)
+		return
+	}
+	page, err := parsePagination(req.Request)
+	if err != nil {
+		badRequestResponse(req, resp, err)
+		return
+	}
+	filters.Limit = page.queryLimit()
+	nodes, err := ws.repository.GetNodesPerPartition(ctx, partitionID, *filters)
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	if nodes == nil {
+		notFoundResponse(req, resp, fmt.Errorf("no nodes found"))
+		return
+	}
+	nodes, err = paginate(req, resp, page, nodes, filters.Cursor, func(estimated bool) (int64, error) {
+		return ws.repository.CountNodesPerPartition(ctx, partitionID, *filters, estimated)
+	})
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	jsonResponse(resp, nodes)
+}
+
+func (ws *WebService) getAppsHistory(req *restful.Request, resp *restful.Response) {
+	ctx := req.Request.Context()
+	if isTimelineRequest(req.Request) {
+		ws.getTimeline(req, resp, ws.repository.GetApplicationsTimeline)
+		return
+	}
+	if isHistorySeriesRequest(req.Request) {
+		ws.getHistorySeries(req, resp, ws.repository.GetApplicationsHistorySeries)
+		return
+	}
+	filters, err := parseHistoryFilters(req.Request, repository.AppHistorySortFields)
+	if err != nil {
+		badRequestResponse(req, resp, err)
+		return
+	}
+	page, err := parsePagination(req.Request)
+	if err != nil {
+		badRequestResponse(req, resp, err)
+		return
+	}
+	filters.Limit = page.queryLimit()
+
 	appsHistory, err := ws.repository.GetApplicationsHistory(ctx, *filters)
 	if err != nil {
 		errorResponse(req, resp, err)
@@ -404,16 +1077,37 @@ func (ws *WebService) getAppsHistory(req *restful.Request, resp *restful.Respons
 		notFoundResponse(req, resp, fmt.Errorf("no applications history found"))
 		return
 	}
+	appsHistory, err = paginate(req, resp, page, appsHistory, filters.AppHistoryCursor, func(estimated bool) (int64, error) {
+		return ws.repository.CountApplicationsHistory(ctx, *filters, estimated)
+	})
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
 	jsonResponse(resp, appsHistory)
 }
 
 func (ws *WebService) getContainersHistory(req *restful.Request, resp *restful.Response) {
 	ctx := req.Request.Context()
-	filters, err := parseHistoryFilters(req.Request)
+	if isTimelineRequest(req.Request) {
+		ws.getTimeline(req, resp, ws.repository.GetContainersTimeline)
+		return
+	}
+	if isHistorySeriesRequest(req.Request) {
+		ws.getHistorySeries(req, resp, ws.repository.GetContainersHistorySeries)
+		return
+	}
+	filters, err := parseHistoryFilters(req.Request, repository.ContainerHistorySortFields)
+	if err != nil {
+		badRequestResponse(req, resp, err)
+		return
+	}
+	page, err := parsePagination(req.Request)
 	if err != nil {
 		badRequestResponse(req, resp, err)
 		return
 	}
+	filters.Limit = page.queryLimit()
 	containersHistory, err := ws.repository.GetContainersHistory(ctx, *filters)
 	if err != nil {
 		errorResponse(req, resp, err)
@@ -423,9 +1117,622 @@ func (ws *WebService) getContainersHistory(req *restful.Request, resp *restful.R
 		notFoundResponse(req, resp, fmt.Errorf("no containers history found"))
 		return
 	}
+	containersHistory, err = paginate(
+		req, resp, page, containersHistory, filters.ContainerHistoryCursor,
+		func(estimated bool) (int64, error) {
+			return ws.repository.CountContainersHistory(ctx, *filters, estimated)
+		},
+	)
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
 	jsonResponse(resp, containersHistory)
 }
 
+// getTimeline writes the timeline of the applications or the containers, which is returned by getPoints.
+func (ws *WebService) getTimeline(
+	req *restful.Request,
+	resp *restful.Response,
+	getPoints func(context.Context, repository.TimelineFilters) ([]*model.TimelinePoint, error),
+) {
+	filters, err := parseTimelineFilters(req.Request)
+	if err != nil {
+		badRequestResponse(req, resp, err)
+		return
+	}
+	points, err := getPoints(req.Request.Context(), *filters)
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	if points == nil {
+		points = []*model.TimelinePoint{}
+	}
+	jsonResponse(resp, points)
+}
+
+// getHistorySeries writes the downsampled history of the applications or the containers, which is returned by getPoints.
+func (ws *WebService) getHistorySeries(
+	req *restful.Request,
+	resp *restful.Response,
+	getPoints func(context.Context, repository.HistoryFilters) ([]*model.HistoryPoint, error),
+) {
+	filters, err := parseHistorySeriesFilters(req.Request)
+	if err != nil {
+		badRequestResponse(req, resp, err)
+		return
+	}
+	points, err := getPoints(req.Request.Context(), *filters)
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	if points == nil {
+		points = []*model.HistoryPoint{}
+	}
+	jsonResponse(resp, points)
+}
+
+func (ws *WebService) getApplicationVersions(req *restful.Request, resp *restful.Response) {
+	ctx := req.Request.Context()
+	applicationID := req.PathParameter("application_id")
+	versions, err := ws.repository.GetApplicationVersions(ctx, applicationID)
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	if versions == nil {
+		notFoundResponse(req, resp, fmt.Errorf("no versions found for application %q", applicationID))
+		return
+	}
+	if err := model.ComputeChanges(versions); err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	jsonResponse(resp, versions)
+}
+
+func (ws *WebService) getApplicationPreemptions(req *restful.Request, resp *restful.Response) {
+	ctx := req.Request.Context()
+	preemptions, err := ws.repository.GetApplicationPreemptions(ctx, req.PathParameter("application_id"))
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	if preemptions == nil {
+		preemptions = []*model.Preemption{}
+	}
+	jsonResponse(resp, preemptions)
+}
+
+func (ws *WebService) getQueueVersions(req *restful.Request, resp *restful.Response) {
+	ctx := req.Request.Context()
+	queueID := req.PathParameter("queue_id")
+	versions, err := ws.repository.GetQueueVersions(ctx, queueID)
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	if versions == nil {
+		notFoundResponse(req, resp, fmt.Errorf("no versions found for queue %q", queueID))
+		return
+	}
+	if err := model.ComputeChanges(versions); err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	jsonResponse(resp, versions)
+}
+
+func (ws *WebService) getNode(req *restful.Request, resp *restful.Response) {
+	ctx := req.Request.Context()
+	nodeID := req.PathParameter("node_id")
+	versions, err := ws.repository.GetNodeVersions(ctx, nodeID)
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	if versions == nil {
+		// nodes which were stored before their versions were recorded have no versions,
+		// so their current state is their only version
+		node, err := ws.repository.GetNodeByID(ctx, nodeID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				notFoundResponse(req, resp, err)
+				return
+			}
+			errorResponse(req, resp, err)
+			return
+		}
+		versions = []*model.NodeVersion{{VersionInfo: model.VersionInfo{ValidFromNano: node.CreatedAtNano}, Object: *node}}
+	}
+	if err := model.ComputeChanges(versions); err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+
+	// the latest version is the current state of the node
+	node := versions[len(versions)-1].Object
+	events, err := ws.repository.GetEvents(ctx, repository.EventFilters{
+		Type:        util.ToPtr(si.EventRecord_NODE.String()),
+		ObjectID:    &node.NodeID,
+		NewestFirst: true,
+		Limit:       util.ToPtr(maxNodeTimelineEvents),
+	})
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	slices.Reverse(events)
+	periods, err := ws.repository.GetNodeAllocationPeriods(ctx, node.PartitionID, node.NodeID)
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	jsonResponse(resp, model.NodeDetail{
+		Node:     node,
+		Timeline: model.BuildNodeTimeline(versions, events, periods),
+	})
+}
+
+func (ws *WebService) getNodeVersions(req *restful.Request, resp *restful.Response) {
+	ctx := req.Request.Context()
+	nodeID := req.PathParameter("node_id")
+	versions, err := ws.repository.GetNodeVersions(ctx, nodeID)
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	if versions == nil {
+		notFoundResponse(req, resp, fmt.Errorf("no versions found for node %q", nodeID))
+		return
+	}
+	if err := model.ComputeChanges(versions); err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	jsonResponse(resp, versions)
+}
+
+func (ws *WebService) search(req *restful.Request, resp *restful.Response) {
+	ctx := req.Request.Context()
+	query, err := getSearchQueryParam(req.Request)
+	if err != nil {
+		badRequestResponse(req, resp, err)
+		return
+	}
+	limit, err := getSearchLimitQueryParam(req.Request)
+	if err != nil {
+		badRequestResponse(req, resp, err)
+		return
+	}
+	results, err := ws.repository.Search(ctx, query, limit)
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	jsonResponse(resp, results)
+}
+
+func (ws *WebService) getUsageReport(req *restful.Request, resp *restful.Response) {
+	ctx := req.Request.Context()
+	filters, err := parseUsageFilters(req.Request)
+	if err != nil {
+		badRequestResponse(req, resp, err)
+		return
+	}
+	format, err := getFormatQueryParam(req.Request, reportFormatCSV)
+	if err != nil {
+		badRequestResponse(req, resp, err)
+		return
+	}
+	filters.GPUResource = ws.reportsConfig.GPUResource
+
+	usage, err := ws.repository.GetUsage(ctx, *filters)
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	prices := model.UsagePrices{
+		Currency:      ws.reportsConfig.Currency,
+		VcoreHour:     ws.reportsConfig.VcoreHourPrice,
+		MemoryGiBHour: ws.reportsConfig.MemoryGiBHourPrice,
+		GPUHour:       ws.reportsConfig.GPUHourPrice,
+	}
+	report := model.NewUsageReport(filters.Start.UnixMilli(), filters.End.UnixMilli(), string(filters.GroupBy), prices, usage)
+	if format == reportFormatCSV {
+		csvResponse(resp, fmt.Sprintf("usage-by-%s.csv", report.GroupBy), usageReportRecords(report))
+		return
+	}
+	jsonResponse(resp, report)
+}
+
+// usageReportRecords returns the CSV records of a usage report: a header, a record per group and the total.
+func usageReportRecords(report *model.UsageReport) [][]string {
+	formatFloat := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	record := func(key string, u *model.Usage) []string {
+		return []string{
+			key,
+			strconv.FormatInt(u.Applications, 10),
+			formatFloat(u.VcoreSeconds),
+			formatFloat(u.MemoryGiBHours),
+			formatFloat(u.GPUHours),
+			formatFloat(u.Cost),
+			report.Prices.Currency,
+		}
+	}
+	records := make([][]string, 0, len(report.Usage)+2)
+	records = append(records, []string{
+		report.GroupBy, "applications", "vcore_seconds", "memory_gib_hours", "gpu_hours", "cost", "currency",
+	})
+	for _, u := range report.Usage {
+		records = append(records, record(u.Key, u))
+	}
+	return append(records, record("total", &report.Total))
+}
+
+func usageGroupByAllowableValues() map[string]string {
+	values := make(map[string]string, len(repository.UsageGroupByValues))
+	for _, groupBy := range repository.UsageGroupByValues {
+		values[string(groupBy)] = string(groupBy)
+	}
+	return values
+}
+
+func (ws *WebService) getAccountingRecords(req *restful.Request, resp *restful.Response) {
+	ctx := req.Request.Context()
+	filters, err := parseAccountingFilters(req.Request)
+	if err != nil {
+		badRequestResponse(req, resp, err)
+		return
+	}
+	format, err := getFormatQueryParam(req.Request, reportFormatNDJSON, reportFormatCSV)
+	if err != nil {
+		badRequestResponse(req, resp, err)
+		return
+	}
+	page, err := parsePagination(req.Request)
+	if err != nil {
+		badRequestResponse(req, resp, err)
+		return
+	}
+	filters.Limit = page.queryLimit()
+
+	records, err := ws.repository.GetAccountingRecords(ctx, *filters)
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	records, err = paginate(req, resp, page, records, filters.AccountingCursor, func(estimated bool) (int64, error) {
+		return ws.repository.CountAccountingRecords(ctx, *filters, estimated)
+	})
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	switch format {
+	case reportFormatNDJSON:
+		ndjsonResponse(resp, records)
+	case reportFormatCSV:
+		csvResponse(resp, "accounting.csv", accountingRecordsCSV(records))
+	default:
+		if records == nil {
+			records = []*model.AccountingRecord{}
+		}
+		jsonResponse(resp, records)
+	}
+}
+
+// accountingRecordsCSV returns the CSV records of accounting records: a header and a record per accounting record.
+// The peak and total usage of every resource of any of the records is a column, e.g. 'peak_memory' and 'total_vcore'.
+func accountingRecordsCSV(records []*model.AccountingRecord) [][]string {
+	resourceSet := make(map[string]struct{})
+	for _, r := range records {
+		for name := range r.PeakResource {
+			resourceSet[name] = struct{}{}
+		}
+		for name := range r.TotalResource {
+			resourceSet[name] = struct{}{}
+		}
+	}
+	resources := make([]string, 0, len(resourceSet))
+	for name := range resourceSet {
+		resources = append(resources, name)
+	}
+	sort.Strings(resources)
+
+	header := []string{
+		"id", "application_id", "partition", "queue", "user", "groups", "state",
+		"submission_time", "start_time", "finished_time", "wait_time", "run_time", "allocations", "preemptions",
+	}
+	for _, name := range resources {
+		header = append(header, "peak_"+name)
+	}
+	for _, name := range resources {
+		header = append(header, "total_"+name)
+	}
+
+	csvRecords := [][]string{header}
+	for _, r := range records {
+		startTime := ""
+		if r.StartTime != nil {
+			startTime = strconv.FormatInt(*r.StartTime, 10)
+		}
+		record := []string{
+			r.ID,
+			r.ApplicationID,
+			r.Partition,
+			r.QueueName,
+			r.User,
+			strings.Join(r.Groups, ";"),
+			r.State,
+			strconv.FormatInt(r.SubmissionTime, 10),
+			startTime,
+			strconv.FormatInt(r.FinishedTime, 10),
+			strconv.FormatInt(r.WaitTime, 10),
+			strconv.FormatInt(r.RunTime, 10),
+			strconv.FormatInt(r.Allocations, 10),
+			strconv.FormatInt(r.Preemptions, 10),
+		}
+		for _, name := range resources {
+			record = append(record, strconv.FormatInt(r.PeakResource[name], 10))
+		}
+		for _, name := range resources {
+			record = append(record, strconv.FormatInt(r.TotalResource[name], 10))
+		}
+		csvRecords = append(csvRecords, record)
+	}
+	return csvRecords
+}
+
+func (ws *WebService) getWaitTimes(req *restful.Request, resp *restful.Response) {
+	ctx := req.Request.Context()
+	filters, err := parseWaitTimeFilters(req.Request)
+	if err != nil {
+		badRequestResponse(req, resp, err)
+		return
+	}
+	stats, err := ws.repository.GetWaitTimes(ctx, *filters)
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	report := model.WaitTimeReport{
+		Start:       filters.Start.UnixMilli(),
+		End:         filters.End.UnixMilli(),
+		GroupBy:     make([]string, 0, len(filters.GroupBy)),
+		BucketWidth: filters.Bucket.Milliseconds(),
+		Stats:       stats,
+	}
+	for _, groupBy := range filters.GroupBy {
+		report.GroupBy = append(report.GroupBy, string(groupBy))
+	}
+	if report.Stats == nil {
+		report.Stats = []*model.WaitTimeStats{}
+	}
+	jsonResponse(resp, report)
+}
+
+func waitTimeGroupByNames() []string {
+	names := make([]string, 0, len(repository.WaitTimeGroupByValues))
+	for _, groupBy := range repository.WaitTimeGroupByValues {
+		names = append(names, string(groupBy))
+	}
+	return names
+}
+
+func (ws *WebService) getFairness(req *restful.Request, resp *restful.Response) {
+	ctx := req.Request.Context()
+	filters, err := parseFairnessFilters(req.Request)
+	if err != nil {
+		badRequestResponse(req, resp, err)
+		return
+	}
+	queues, err := ws.repository.GetQueueFairness(ctx, *filters)
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	users, err := ws.repository.GetUserShares(ctx, *filters)
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	report := model.FairnessReport{
+		Start:  filters.Start.UnixMilli(),
+		End:    filters.End.UnixMilli(),
+		Queues: queues,
+		Users:  users,
+	}
+	if report.Queues == nil {
+		report.Queues = []*model.QueueFairness{}
+	}
+	if report.Users == nil {
+		report.Users = []*model.UserShare{}
+	}
+	jsonResponse(resp, report)
+}
+
+func (ws *WebService) getPreemptions(req *restful.Request, resp *restful.Response) {
+	ctx := req.Request.Context()
+	filters, err := parsePreemptionFilters(req.Request)
+	if err != nil {
+		badRequestResponse(req, resp, err)
+		return
+	}
+	filters.GroupBy = repository.PreemptionGroupByQueue
+	queues, err := ws.repository.GetPreemptionStats(ctx, *filters)
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	filters.GroupBy = repository.PreemptionGroupByUser
+	users, err := ws.repository.GetPreemptionStats(ctx, *filters)
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	report := model.PreemptionReport{
+		Start:  filters.Start.UnixMilli(),
+		End:    filters.End.UnixMilli(),
+		Queues: queues,
+		Users:  users,
+	}
+	if report.Queues == nil {
+		report.Queues = []*model.PreemptionStats{}
+	}
+	if report.Users == nil {
+		report.Users = []*model.PreemptionStats{}
+	}
+	jsonResponse(resp, report)
+}
+
+func (ws *WebService) getGangs(req *restful.Request, resp *restful.Response) {
+	ctx := req.Request.Context()
+	filters, err := parseGangFilters(req.Request)
+	if err != nil {
+		badRequestResponse(req, resp, err)
+		return
+	}
+	taskGroups, err := ws.repository.GetGangStats(ctx, *filters)
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	report := model.GangReport{
+		Start:      filters.Start.UnixMilli(),
+		End:        filters.End.UnixMilli(),
+		TaskGroups: taskGroups,
+	}
+	if report.TaskGroups == nil {
+		report.TaskGroups = []*model.TaskGroupStats{}
+	}
+	jsonResponse(resp, report)
+}
+
+func (ws *WebService) getDiagnostics(req *restful.Request, resp *restful.Response) {
+	ctx := req.Request.Context()
+	filters, err := parseDiagnosticsFilters(req.Request)
+	if err != nil {
+		badRequestResponse(req, resp, err)
+		return
+	}
+	groups, err := ws.repository.GetDiagnostics(ctx, *filters)
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	report := model.DiagnosticsReport{
+		Start:       filters.Start.UnixMilli(),
+		End:         filters.End.UnixMilli(),
+		BucketWidth: filters.Bucket.Milliseconds(),
+		Groups:      groups,
+	}
+	if report.Groups == nil {
+		report.Groups = []*model.DiagnosticGroup{}
+	}
+	jsonResponse(resp, report)
+}
+
+func (ws *WebService) getReservations(req *restful.Request, resp *restful.Response) {
+	ctx := req.Request.Context()
+	filters, err := parseReservationFilters(req.Request)
+	if err != nil {
+		badRequestResponse(req, resp, err)
+		return
+	}
+	reservations, err := ws.repository.GetLongLivedReservations(ctx, *filters)
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	report := model.ReservationReport{
+		Start:        filters.Start.UnixMilli(),
+		End:          filters.End.UnixMilli(),
+		MinDuration:  filters.MinDuration.Milliseconds(),
+		Reservations: reservations,
+		BlockedNodes: model.NewBlockedNodes(reservations, filters.End),
+	}
+	if report.Reservations == nil {
+		report.Reservations = []*model.Reservation{}
+	}
+	if report.BlockedNodes == nil {
+		report.BlockedNodes = []*model.BlockedNode{}
+	}
+	jsonResponse(resp, report)
+}
+
+func (ws *WebService) getNodeUtilization(req *restful.Request, resp *restful.Response) {
+	ctx := req.Request.Context()
+	filters, err := parseNodeUtilizationFilters(req.Request)
+	if err != nil {
+		badRequestResponse(req, resp, err)
+		return
+	}
+	nodes, err := ws.repository.GetNodeUtilization(ctx, *filters)
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	report := model.NodeUtilizationReport{
+		Start: filters.Start.UnixMilli(),
+		End:   filters.End.UnixMilli(),
+		Nodes: nodes,
+	}
+	if report.Nodes == nil {
+		report.Nodes = []*model.NodeUtilization{}
+	}
+	jsonResponse(resp, report)
+}
+
+func (ws *WebService) getForecast(req *restful.Request, resp *restful.Response) {
+	ctx := req.Request.Context()
+	filters, horizon, err := parseForecastFilters(req.Request)
+	if err != nil {
+		badRequestResponse(req, resp, err)
+		return
+	}
+	history, err := ws.repository.GetDemandSeries(ctx, *filters)
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	if history == nil {
+		history = []*model.DemandPoint{}
+	}
+	report := model.NewForecastReport(history, filters.Step, horizon)
+	report.Partition = filters.Partition
+	report.Queue = filters.Queue
+	report.Resource = filters.Resource
+	report.Start = filters.Start.UnixMilli()
+	report.End = filters.End.UnixMilli()
+	jsonResponse(resp, report)
+}
+
+func (ws *WebService) getEfficiency(req *restful.Request, resp *restful.Response) {
+	ctx := req.Request.Context()
+	filters, err := parseEfficiencyFilters(req.Request)
+	if err != nil {
+		badRequestResponse(req, resp, err)
+		return
+	}
+	efficiencies, err := ws.repository.GetEfficiency(ctx, *filters)
+	if err != nil {
+		errorResponse(req, resp, err)
+		return
+	}
+	jsonResponse(resp, model.NewEfficiencyReport(
+		filters.Start.UnixMilli(),
+		filters.End.UnixMilli(),
+		filters.Resource,
+		string(filters.RankBy),
+		efficiencies,
+	))
+}
+
 func (ws *WebService) getEventStatistics(req *restful.Request, resp *restful.Response) {
 	ctx := req.Request.Context()
 	counts, err := ws.eventRepository.Counts(ctx)
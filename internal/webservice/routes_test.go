@@ -2,10 +2,13 @@ package webservice
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -17,6 +20,7 @@ import (
 	"go.uber.org/mock/gomock"
 
 	"github.com/G-Research/unicorn-history-server/internal/config"
+	"github.com/G-Research/unicorn-history-server/internal/database/sql"
 	"github.com/G-Research/unicorn-history-server/internal/model"
 	"github.com/G-Research/unicorn-history-server/internal/util"
 )
@@ -279,6 +283,228 @@ func TestGetAppsPerPartitionPerQueue(t *testing.T) {
 	}
 }
 
+func TestGetApplications(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := repository.NewMockRepository(ctrl)
+
+	tests := []struct {
+		name            string
+		query           string
+		apps            []*model.Application
+		expectedFilters repository.ApplicationFilters
+		expectedStatus  int
+		expectedBody    string
+	}{
+		{
+			name:  "Apps found",
+			query: "user=alice&state=Failed,Rejected&queue=root.team&includeSubqueues=true&partition=default&applicationIdPrefix=spark-",
+			apps: []*model.Application{
+				{ApplicationDAOInfo: dao.ApplicationDAOInfo{ID: "1", ApplicationID: "spark-1", User: "alice"}},
+			},
+			expectedFilters: repository.ApplicationFilters{
+				User:                util.ToPtr("alice"),
+				States:              []string{"Failed", "Rejected"},
+				Queue:               util.ToPtr("root.team"),
+				IncludeSubqueues:    true,
+				Partition:           util.ToPtr("default"),
+				ApplicationIDPrefix: util.ToPtr("spark-"),
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "Running during an interval",
+			query: "runningStartTime=1000&runningEndTime=2000&finishedEndTime=3000",
+			expectedFilters: repository.ApplicationFilters{
+				RunningStartTime: util.ToPtr(time.UnixMilli(1000)),
+				RunningEndTime:   util.ToPtr(time.UnixMilli(2000)),
+				FinishedEndTime:  util.ToPtr(time.UnixMilli(3000)),
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "[]",
+		},
+		{
+			name:  "Filter expression",
+			query: "filter=" + url.QueryEscape(`applicationState in (Failed,Rejected) and (user = "bob" or queueName ~ "root.ml.*")`),
+			expectedFilters: repository.ApplicationFilters{
+				Expression: mustCompileFilter(t, repository.ApplicationFilterSchema,
+					`applicationState in (Failed,Rejected) and (user = "bob" or queueName ~ "root.ml.*")`),
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "[]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo.EXPECT().
+				GetAllApplications(gomock.Any(), tt.expectedFilters).
+				Return(tt.apps, nil)
+
+			ws := &WebService{repository: mockRepo}
+
+			req, err := http.NewRequest(http.MethodGet, "/api/v1/applications?"+tt.query, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+
+			ws.getApplications(restful.NewRequest(req), restful.NewResponse(rr))
+			require.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetApplicationsInvalidFilter(t *testing.T) {
+	ws := &WebService{}
+
+	req, err := http.NewRequest(http.MethodGet, "/api/v1/applications?filter="+url.QueryEscape("applicationState in (Failed"), nil)
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+
+	ws.getApplications(restful.NewRequest(req), restful.NewResponse(rr))
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "application/problem+json", rr.Header().Get("Content-Type"))
+
+	var problem ProblemDetails
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+	assert.Equal(t, http.StatusBadRequest, problem.Status)
+	assert.Equal(t, "invalid 'filter' query parameter: unexpected end of expression, expected ',' or ')' at position 28", problem.Detail)
+}
+
+func TestListInvalidLimit(t *testing.T) {
+	ws := &WebService{}
+
+	tests := []struct {
+		name    string
+		url     string
+		handler func(*restful.Request, *restful.Response)
+	}{
+		{name: "Applications with zero limit", url: "/api/v1/applications?limit=0", handler: ws.getApplications},
+		{name: "Applications with negative limit", url: "/api/v1/applications?limit=-1", handler: ws.getApplications},
+		{name: "Partitions with zero limit", url: "/api/v1/partitions?limit=0", handler: ws.getPartitions},
+		{name: "Partitions with negative limit", url: "/api/v1/partitions?limit=-1", handler: ws.getPartitions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tt.url, nil)
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+
+			// the repository is not queried, so a panic of the pagination would fail the test
+			tt.handler(restful.NewRequest(req), restful.NewResponse(rr))
+			require.Equal(t, http.StatusBadRequest, rr.Code)
+
+			var problem ProblemDetails
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+			assert.Equal(t, "invalid 'limit' query parameter: must be a positive integer", problem.Detail)
+		})
+	}
+}
+
+func mustCompileFilter(t *testing.T, schema *repository.FilterSchema, expression string) sql.Expr {
+	t.Helper()
+	expr, err := schema.Compile(expression)
+	require.NoError(t, err)
+	return expr
+}
+
+func TestGetApplication(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := repository.NewMockRepository(ctrl)
+
+	runs := []*model.Application{
+		{
+			ApplicationDAOInfo: dao.ApplicationDAOInfo{
+				ID:             "2",
+				ApplicationID:  "app1",
+				SubmissionTime: 2000,
+				State:          "Running",
+				PlaceholderData: []*dao.PlaceholderDAOInfo{
+					{TaskGroupName: "executor", Count: 2, Replaced: 1, TimedOut: 1},
+				},
+			},
+		},
+		{
+			ApplicationDAOInfo: dao.ApplicationDAOInfo{
+				ID:             "1",
+				ApplicationID:  "app1",
+				SubmissionTime: 1000,
+				FinishedTime:   util.ToPtr(int64(1500)),
+				State:          "Failed",
+			},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		id             string
+		runs           []*model.Application
+		expectedStatus int
+		expectedID     string
+	}{
+		{
+			name:           "Get by applicationID returns the most recent run",
+			id:             "app1",
+			runs:           runs,
+			expectedStatus: http.StatusOK,
+			expectedID:     "2",
+		},
+		{
+			name:           "Get by ID returns the run",
+			id:             "1",
+			runs:           runs,
+			expectedStatus: http.StatusOK,
+			expectedID:     "1",
+		},
+		{
+			name:           "Application not found",
+			id:             "app2",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo.EXPECT().
+				GetApplicationRuns(gomock.Any(), tt.id).
+				Return(tt.runs, nil)
+
+			ws := &WebService{repository: mockRepo}
+
+			req, err := http.NewRequest(http.MethodGet, "/api/v1/applications/"+tt.id, nil)
+			require.NoError(t, err)
+			restfulReq := restful.NewRequest(req)
+			restfulReq.PathParameters()["application_id"] = tt.id
+
+			rr := httptest.NewRecorder()
+
+			ws.getApplication(restfulReq, restful.NewResponse(rr))
+			require.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var detail model.ApplicationDetail
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &detail))
+			assert.Equal(t, tt.expectedID, detail.ID)
+			assert.Len(t, detail.Runs, len(tt.runs))
+			assert.Equal(t, "2", detail.Runs[0].ID)
+			if tt.expectedID == "2" {
+				assert.Equal(t, []*model.TaskGroupStats{
+					{TaskGroupName: "executor", Placeholders: 2, Replaced: 1, TimedOut: 1, TimeoutRate: 0.5},
+				}, detail.TaskGroups)
+			} else {
+				assert.Empty(t, detail.TaskGroups)
+			}
+		})
+	}
+}
+
 func TestGetPartitions(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -333,6 +559,71 @@ func TestGetPartitions(t *testing.T) {
 	}
 }
 
+func TestBuildQueueSubtree(t *testing.T) {
+	queues := []*model.Queue{
+		{PartitionQueueDAOInfo: dao.PartitionQueueDAOInfo{ID: "2", QueueName: "root.org", ParentID: util.ToPtr("1")}},
+		{PartitionQueueDAOInfo: dao.PartitionQueueDAOInfo{ID: "3", QueueName: "root.org.eng", ParentID: util.ToPtr("2")}},
+		{PartitionQueueDAOInfo: dao.PartitionQueueDAOInfo{ID: "4", QueueName: "root.org.eng.ml", ParentID: util.ToPtr("3")}},
+		{PartitionQueueDAOInfo: dao.PartitionQueueDAOInfo{ID: "5", QueueName: "root.org.sales", ParentID: util.ToPtr("2")}},
+	}
+
+	root := buildQueueSubtree(queues, "root.org")
+	require.NotNil(t, root)
+	assert.Equal(t, "2", root.ID)
+	require.Len(t, root.Children, 2)
+	assert.Equal(t, "root.org.eng", root.Children[0].QueueName)
+	assert.Equal(t, "root.org.sales", root.Children[1].QueueName)
+	require.Len(t, root.Children[0].Children, 1)
+	assert.Equal(t, "root.org.eng.ml", root.Children[0].Children[0].QueueName)
+
+	assert.Nil(t, buildQueueSubtree(nil, "root.org"))
+}
+
+func TestGetQueue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := repository.NewMockRepository(ctrl)
+
+	queue := &model.Queue{
+		PartitionQueueDAOInfo: dao.PartitionQueueDAOInfo{
+			ID:             "1",
+			QueueName:      "root.default",
+			MaxRunningApps: 10,
+			Properties:     map[string]string{"application.sort.policy": "fifo"},
+		},
+	}
+	mockRepo.EXPECT().GetQueue(gomock.Any(), "1").Return(queue, nil)
+	mockRepo.EXPECT().
+		CountApplicationsInQueueByState(gomock.Any(), "1").
+		Return(map[string]int64{"Running": 2, "Completing": 1, "Accepted": 3, "Completed": 4}, nil)
+	mockRepo.EXPECT().GetQueue(gomock.Any(), "2").Return(nil, fmt.Errorf("queue %q %w", "2", repository.ErrNotFound))
+
+	ws := &WebService{repository: mockRepo}
+
+	req, err := http.NewRequest(http.MethodGet, "/api/v1/queues/1", nil)
+	require.NoError(t, err)
+	restfulReq := restful.NewRequest(req)
+	restfulReq.PathParameters()["queue_id"] = "1"
+	rr := httptest.NewRecorder()
+	ws.getQueue(restfulReq, restful.NewResponse(rr))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var detail model.QueueDetail
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &detail))
+	assert.Equal(t, "root.default", detail.QueueName)
+	assert.Equal(t, int64(3), detail.Applications.Running)
+	assert.Equal(t, int64(3), detail.Applications.Pending)
+	assert.Equal(t, int64(4), detail.Applications.ByState["Completed"])
+
+	req, err = http.NewRequest(http.MethodGet, "/api/v1/queues/2", nil)
+	require.NoError(t, err)
+	restfulReq = restful.NewRequest(req)
+	restfulReq.PathParameters()["queue_id"] = "2"
+	rr = httptest.NewRecorder()
+	ws.getQueue(restfulReq, restful.NewResponse(rr))
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
+
 func TestGetQueuesPerPartition(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -372,7 +663,7 @@ func TestGetQueuesPerPartition(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo.EXPECT().
-				GetQueuesInPartition(gomock.Any(), gomock.Any()).
+				GetQueuesInPartition(gomock.Any(), gomock.Any(), gomock.Any()).
 				Return(tt.expectedQueues, nil)
 
 			ws := &WebService{repository: mockRepo}
@@ -444,6 +735,169 @@ func TestGetNodesPerPartition(t *testing.T) {
 	}
 }
 
+func TestGetNode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := repository.NewMockRepository(ctrl)
+
+	versions := []*model.NodeVersion{
+		{
+			VersionInfo: model.VersionInfo{ValidFromNano: 1, ValidToNano: util.ToPtr(int64(3))},
+			Object:      model.Node{NodeDAOInfo: dao.NodeDAOInfo{ID: "1", NodeID: "node1", PartitionID: "default", Schedulable: true}},
+		},
+		{
+			VersionInfo: model.VersionInfo{ValidFromNano: 3},
+			Object:      model.Node{NodeDAOInfo: dao.NodeDAOInfo{ID: "1", NodeID: "node1", PartitionID: "default", Schedulable: false}},
+		},
+	}
+	events := []*model.Event{
+		{TimestampNano: 2, Type: "NODE", ObjectID: "node1", ChangeType: "SET", ChangeDetail: "NODE_SCHEDULABLE"},
+	}
+
+	eventFilters := repository.EventFilters{
+		Type:        util.ToPtr("NODE"),
+		ObjectID:    util.ToPtr("node1"),
+		NewestFirst: true,
+		Limit:       util.ToPtr(maxNodeTimelineEvents),
+	}
+	periods := []*model.AllocationPeriod{
+		{ApplicationID: "app-1", AllocationKey: "alloc-1", StartNano: 2},
+	}
+
+	mockRepo.EXPECT().GetNodeVersions(gomock.Any(), "1").Return(versions, nil)
+	mockRepo.EXPECT().GetEvents(gomock.Any(), eventFilters).Return(events, nil)
+	mockRepo.EXPECT().GetNodeAllocationPeriods(gomock.Any(), "default", "node1").Return(periods, nil)
+	mockRepo.EXPECT().GetNodeVersions(gomock.Any(), "2").Return(nil, nil)
+	mockRepo.EXPECT().GetNodeByID(gomock.Any(), "2").Return(nil, fmt.Errorf("node \"2\" %w", repository.ErrNotFound))
+
+	ws := &WebService{repository: mockRepo}
+
+	req, err := http.NewRequest(http.MethodGet, "/api/v1/nodes/1", nil)
+	require.NoError(t, err)
+	restfulReq := restful.NewRequest(req)
+	restfulReq.PathParameters()["node_id"] = "1"
+	rr := httptest.NewRecorder()
+	ws.getNode(restfulReq, restful.NewResponse(rr))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var detail model.NodeDetail
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &detail))
+	assert.False(t, detail.Schedulable)
+	var types []model.NodeTimelineEntryType
+	for _, entry := range detail.Timeline {
+		types = append(types, entry.Type)
+	}
+	assert.Equal(t, []model.NodeTimelineEntryType{
+		model.NodeTimelineAdded,
+		model.NodeTimelineEvent,
+		model.NodeTimelineUnschedulable,
+	}, types)
+	assert.Nil(t, detail.Timeline[0].RunningApplications)
+	assert.Equal(t, []string{"app-1"}, detail.Timeline[2].RunningApplications)
+
+	req, err = http.NewRequest(http.MethodGet, "/api/v1/nodes/2", nil)
+	require.NoError(t, err)
+	restfulReq = restful.NewRequest(req)
+	restfulReq.PathParameters()["node_id"] = "2"
+	rr = httptest.NewRecorder()
+	ws.getNode(restfulReq, restful.NewResponse(rr))
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestGetNodeWithoutVersions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := repository.NewMockRepository(ctrl)
+
+	node := &model.Node{
+		Metadata:    model.Metadata{CreatedAtNano: 1},
+		NodeDAOInfo: dao.NodeDAOInfo{ID: "1", NodeID: "node1", PartitionID: "default", Schedulable: true},
+	}
+	mockRepo.EXPECT().GetNodeVersions(gomock.Any(), "1").Return(nil, nil)
+	mockRepo.EXPECT().GetNodeByID(gomock.Any(), "1").Return(node, nil)
+	mockRepo.EXPECT().GetEvents(gomock.Any(), gomock.Any()).Return(nil, nil)
+	mockRepo.EXPECT().GetNodeAllocationPeriods(gomock.Any(), "default", "node1").Return(nil, nil)
+
+	ws := &WebService{repository: mockRepo}
+
+	req, err := http.NewRequest(http.MethodGet, "/api/v1/nodes/1", nil)
+	require.NoError(t, err)
+	restfulReq := restful.NewRequest(req)
+	restfulReq.PathParameters()["node_id"] = "1"
+	rr := httptest.NewRecorder()
+	ws.getNode(restfulReq, restful.NewResponse(rr))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var detail model.NodeDetail
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &detail))
+	assert.Equal(t, "node1", detail.NodeID)
+	require.Len(t, detail.Timeline, 1)
+	assert.Equal(t, model.NodeTimelineAdded, detail.Timeline[0].Type)
+	assert.Equal(t, int64(1), detail.Timeline[0].TimestampNano)
+}
+
+func TestGetNodeVersions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := repository.NewMockRepository(ctrl)
+
+	tests := []struct {
+		name            string
+		versions        []*model.NodeVersion
+		expectedStatus  int
+		expectedChanges [][]model.FieldChange
+	}{
+		{
+			name: "Versions found",
+			versions: []*model.NodeVersion{
+				{
+					VersionInfo: model.VersionInfo{ValidFromNano: 1, ValidToNano: util.ToPtr(int64(2))},
+					Object: model.Node{
+						NodeDAOInfo: dao.NodeDAOInfo{ID: "1", NodeID: "node1", Schedulable: true},
+					},
+				},
+				{
+					VersionInfo: model.VersionInfo{ValidFromNano: 2},
+					Object: model.Node{
+						NodeDAOInfo: dao.NodeDAOInfo{ID: "1", NodeID: "node1", Schedulable: false},
+					},
+				},
+			},
+			expectedStatus: http.StatusOK,
+			expectedChanges: [][]model.FieldChange{
+				nil,
+				{{Field: "schedulable", Old: true, New: false}},
+			},
+		},
+		{
+			name:           "No versions found",
+			versions:       nil,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo.EXPECT().
+				GetNodeVersions(gomock.Any(), gomock.Any()).
+				Return(tt.versions, nil)
+
+			ws := &WebService{repository: mockRepo}
+
+			req, err := http.NewRequest(http.MethodGet, "/api/v1/nodes/1/versions", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+
+			ws.getNodeVersions(restful.NewRequest(req), restful.NewResponse(rr))
+			require.Equal(t, tt.expectedStatus, rr.Code)
+			for i, changes := range tt.expectedChanges {
+				assert.Equal(t, changes, tt.versions[i].Changes)
+			}
+		})
+	}
+}
+
 func TestGetAppsHistory(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -545,3 +999,1058 @@ func TestGetContainersHistory(t *testing.T) {
 		})
 	}
 }
+
+func TestSearch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := repository.NewMockRepository(ctrl)
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedQuery  string
+		expectedLimit  int
+		expectedStatus int
+	}{
+		{
+			name:           "Default limit",
+			query:          "q=spark-pi",
+			expectedQuery:  "spark-pi",
+			expectedLimit:  defaultSearchLimit,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Query is trimmed",
+			query:          "q=%20worker%20&limit=5",
+			expectedQuery:  "worker",
+			expectedLimit:  5,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Missing query",
+			query:          "q=%20",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Limit too large",
+			query:          "q=spark&limit=1000",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := &model.SearchResults{
+				Query: tt.expectedQuery,
+				Applications: []*model.SearchResult{
+					{Type: model.SearchResultTypeApplication, ID: "1", Name: "spark-pi-2024", MatchedField: "applicationId"},
+				},
+				Queues: []*model.SearchResult{},
+				Nodes:  []*model.SearchResult{},
+			}
+			if tt.expectedStatus == http.StatusOK {
+				mockRepo.EXPECT().Search(gomock.Any(), tt.expectedQuery, tt.expectedLimit).Return(results, nil)
+			}
+
+			ws := &WebService{repository: mockRepo}
+
+			req, err := http.NewRequest(http.MethodGet, "/api/v1/search?"+tt.query, nil)
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+
+			ws.search(restful.NewRequest(req), restful.NewResponse(rr))
+			require.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var got model.SearchResults
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+			assert.Equal(t, *results, got)
+		})
+	}
+}
+
+func TestGetUsageReport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := repository.NewMockRepository(ctrl)
+
+	ws := &WebService{
+		repository: mockRepo,
+		reportsConfig: config.ReportsConfig{
+			Currency:       "USD",
+			VcoreHourPrice: 0.5,
+			GPUHourPrice:   2,
+			GPUResource:    "nvidia.com/gpu",
+		},
+	}
+
+	expectedFilters := repository.UsageFilters{
+		Start:       time.UnixMilli(1000),
+		End:         time.UnixMilli(3601000),
+		GroupBy:     repository.UsageGroupByQueue,
+		GPUResource: "nvidia.com/gpu",
+		Queue:       util.ToPtr("root.ml"),
+	}
+	usage := func() []*model.Usage {
+		return []*model.Usage{
+			{Key: "root.ml.inference", Applications: 1, VcoreSeconds: 3600, GPUHours: 1},
+			{Key: "root.ml.training", Applications: 2, VcoreSeconds: 7200},
+		}
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		mockRepo.EXPECT().GetUsage(gomock.Any(), expectedFilters).Return(usage(), nil)
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/reports/usage?start=1000&end=3601000&groupBy=queue&queue=root.ml", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getUsageReport(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var report model.UsageReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		assert.Equal(t, "queue", report.GroupBy)
+		assert.Equal(t, "USD", report.Prices.Currency)
+		require.Len(t, report.Usage, 2)
+		assert.InDelta(t, 2.5, report.Usage[0].Cost, 1e-9)
+		assert.InDelta(t, 1.0, report.Usage[1].Cost, 1e-9)
+		assert.InDelta(t, 3.5, report.Total.Cost, 1e-9)
+		assert.Equal(t, int64(3), report.Total.Applications)
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		mockRepo.EXPECT().GetUsage(gomock.Any(), expectedFilters).Return(usage(), nil)
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/reports/usage?start=1000&end=3601000&groupBy=queue&queue=root.ml", nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept", "text/csv")
+		rr := httptest.NewRecorder()
+
+		ws.getUsageReport(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "text/csv", rr.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="usage-by-queue.csv"`, rr.Header().Get("Content-Disposition"))
+		assert.Equal(t, "queue,applications,vcore_seconds,memory_gib_hours,gpu_hours,cost,currency\n"+
+			"root.ml.inference,1,3600,0,1,2.5,USD\n"+
+			"root.ml.training,2,7200,0,0,1,USD\n"+
+			"total,3,10800,0,1,3.5,USD\n", rr.Body.String())
+	})
+
+	for name, query := range map[string]string{
+		"Invalid groupBy": "groupBy=team",
+		"Invalid format":  "format=xml",
+		"Invalid period":  "start=2000&end=1000",
+	} {
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/api/v1/reports/usage?"+query, nil)
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+
+			ws.getUsageReport(restful.NewRequest(req), restful.NewResponse(rr))
+			require.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+}
+
+func TestGetAccountingRecords(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := repository.NewMockRepository(ctrl)
+
+	ws := &WebService{repository: mockRepo}
+
+	expectedFilters := repository.AccountingFilters{
+		User:   util.ToPtr("alice"),
+		States: []string{"Completed", "Failed"},
+		Limit:  util.ToPtr(3),
+	}
+	records := func() []*model.AccountingRecord {
+		return []*model.AccountingRecord{
+			{
+				ID:             "1",
+				ApplicationID:  "app-1",
+				Partition:      "default",
+				QueueName:      "root.ml",
+				User:           "alice",
+				Groups:         []string{"dev", "ml"},
+				State:          "Completed",
+				SubmissionTime: 1000,
+				StartTime:      util.ToPtr(int64(2000)),
+				FinishedTime:   5000,
+				WaitTime:       1000,
+				RunTime:        3000,
+				PeakResource:   map[string]int64{"vcore": 2000},
+				TotalResource:  map[string]int64{"vcore": 6000, "memory": 10},
+				Allocations:    2,
+				Preemptions:    1,
+			},
+			{
+				ID:             "2",
+				ApplicationID:  "app-2",
+				Partition:      "default",
+				QueueName:      "root.ml",
+				User:           "alice",
+				State:          "Failed",
+				SubmissionTime: 1000,
+				FinishedTime:   1500,
+				WaitTime:       500,
+			},
+		}
+	}
+	const query = "/api/v1/accounting?user=alice&state=Completed,Failed&limit=2"
+
+	t.Run("JSON", func(t *testing.T) {
+		mockRepo.EXPECT().GetAccountingRecords(gomock.Any(), expectedFilters).Return(records(), nil)
+		req, err := http.NewRequest(http.MethodGet, query, nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getAccountingRecords(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var got []*model.AccountingRecord
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+		assert.Equal(t, records(), got)
+	})
+
+	t.Run("NDJSON", func(t *testing.T) {
+		mockRepo.EXPECT().GetAccountingRecords(gomock.Any(), expectedFilters).Return(records(), nil)
+		req, err := http.NewRequest(http.MethodGet, query+"&format=ndjson", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getAccountingRecords(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+
+		lines := strings.Split(strings.TrimSuffix(rr.Body.String(), "\n"), "\n")
+		require.Len(t, lines, 2)
+		for i, line := range lines {
+			var got model.AccountingRecord
+			require.NoError(t, json.Unmarshal([]byte(line), &got))
+			assert.Equal(t, records()[i], &got)
+		}
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		mockRepo.EXPECT().GetAccountingRecords(gomock.Any(), expectedFilters).Return(records(), nil)
+		req, err := http.NewRequest(http.MethodGet, query, nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept", "text/csv")
+		rr := httptest.NewRecorder()
+
+		ws.getAccountingRecords(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "text/csv", rr.Header().Get("Content-Type"))
+		assert.Equal(t, "id,application_id,partition,queue,user,groups,state,submission_time,start_time,finished_time,"+
+			"wait_time,run_time,allocations,preemptions,peak_memory,peak_vcore,total_memory,total_vcore\n"+
+			"1,app-1,default,root.ml,alice,dev;ml,Completed,1000,2000,5000,1000,3000,2,1,0,2000,10,6000\n"+
+			"2,app-2,default,root.ml,alice,,Failed,1000,,1500,500,0,0,0,0,0,0,0\n", rr.Body.String())
+	})
+
+	for name, query := range map[string]string{
+		"Invalid format": "format=xml",
+		"Invalid sort":   "sort=cost",
+		"Invalid time":   "finishedStartTime=yesterday",
+	} {
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/api/v1/accounting?"+query, nil)
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+
+			ws.getAccountingRecords(restful.NewRequest(req), restful.NewResponse(rr))
+			require.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+}
+
+func TestGetWaitTimes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := repository.NewMockRepository(ctrl)
+
+	ws := &WebService{repository: mockRepo}
+
+	t.Run("Grouped and bucketed", func(t *testing.T) {
+		expectedFilters := repository.WaitTimeFilters{
+			Start:   time.UnixMilli(0),
+			End:     time.UnixMilli(7200000),
+			GroupBy: []repository.WaitTimeGroupBy{repository.WaitTimeGroupByQueue, repository.WaitTimeGroupByUser},
+			Bucket:  time.Hour,
+			Queue:   util.ToPtr("root.ml"),
+			Resources: []repository.ResourceFilter{
+				{Field: "requestedResource", Key: "memory", Operator: sql.OpGe, Value: "1Gi"},
+			},
+		}
+		stats := []*model.WaitTimeStats{
+			{
+				Queue:        util.ToPtr("root.ml"),
+				User:         util.ToPtr("alice"),
+				Bucket:       util.ToPtr(int64(0)),
+				Applications: 2,
+				Started:      1,
+				WaitTime:     &model.Percentiles{P50: 1000, P90: 1000, P99: 1000, Max: 1000},
+			},
+		}
+		mockRepo.EXPECT().GetWaitTimes(gomock.Any(), expectedFilters).Return(stats, nil)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/analytics/wait-times?start=0&end=7200000&groupBy=queue,user&bucket=1h"+
+			"&queue=root.ml&resourceFilter="+url.QueryEscape("requestedResource.memory>=1Gi"), nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getWaitTimes(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var report model.WaitTimeReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		assert.Equal(t, model.WaitTimeReport{
+			Start:       0,
+			End:         7200000,
+			GroupBy:     []string{"queue", "user"},
+			BucketWidth: time.Hour.Milliseconds(),
+			Stats:       stats,
+		}, report)
+	})
+
+	t.Run("No applications", func(t *testing.T) {
+		mockRepo.EXPECT().GetWaitTimes(gomock.Any(), gomock.Any()).Return(nil, nil)
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/analytics/wait-times", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getWaitTimes(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusOK, rr.Code)
+		var report model.WaitTimeReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		assert.Equal(t, []string{}, report.GroupBy)
+		assert.Equal(t, []*model.WaitTimeStats{}, report.Stats)
+	})
+
+	for name, query := range map[string]string{
+		"Invalid groupBy":        "groupBy=node",
+		"Invalid bucket":         "bucket=hourly",
+		"Too many buckets":       "start=0&end=86400000&bucket=1m",
+		"Invalid resource field": "resourceFilter=usedResource.memory>1",
+	} {
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/api/v1/analytics/wait-times?"+query, nil)
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+
+			ws.getWaitTimes(restful.NewRequest(req), restful.NewResponse(rr))
+			require.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+}
+
+func TestGetFairness(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := repository.NewMockRepository(ctrl)
+
+	ws := &WebService{repository: mockRepo}
+
+	t.Run("Queues and users", func(t *testing.T) {
+		expectedFilters := repository.FairnessFilters{
+			Start:     time.UnixMilli(0),
+			End:       time.UnixMilli(3600000),
+			Partition: util.ToPtr("default"),
+			Queue:     util.ToPtr("root.ml"),
+		}
+		queues := []*model.QueueFairness{
+			{
+				PartitionID:   "1",
+				Queue:         "root.ml",
+				ObservedTime:  3600000,
+				StarvedTime:   900000,
+				StarvedRatio:  0.25,
+				ResourceShare: model.ResourceShare{AverageResource: map[string]float64{"vcore": 5500}, DominantResource: "vcore", DominantShare: 0.55},
+			},
+		}
+		users := []*model.UserShare{
+			{
+				PartitionID:   "1",
+				User:          "alice",
+				ResourceShare: model.ResourceShare{AverageResource: map[string]float64{"vcore": 1000}, DominantResource: "vcore", DominantShare: 0.1},
+			},
+		}
+		mockRepo.EXPECT().GetQueueFairness(gomock.Any(), expectedFilters).Return(queues, nil)
+		mockRepo.EXPECT().GetUserShares(gomock.Any(), expectedFilters).Return(users, nil)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/analytics/fairness?start=0&end=3600000&partition=default&queue=root.ml", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getFairness(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var report model.FairnessReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		assert.Equal(t, model.FairnessReport{Start: 0, End: 3600000, Queues: queues, Users: users}, report)
+	})
+
+	t.Run("No queues", func(t *testing.T) {
+		mockRepo.EXPECT().GetQueueFairness(gomock.Any(), gomock.Any()).Return(nil, nil)
+		mockRepo.EXPECT().GetUserShares(gomock.Any(), gomock.Any()).Return(nil, nil)
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/analytics/fairness", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getFairness(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusOK, rr.Code)
+		var report model.FairnessReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		assert.Equal(t, []*model.QueueFairness{}, report.Queues)
+		assert.Equal(t, []*model.UserShare{}, report.Users)
+	})
+
+	t.Run("Invalid period", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/analytics/fairness?start=3600000&end=0", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getFairness(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestGetPreemptions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := repository.NewMockRepository(ctrl)
+
+	ws := &WebService{repository: mockRepo}
+
+	t.Run("Queues and users", func(t *testing.T) {
+		filters := repository.PreemptionFilters{
+			Start: time.UnixMilli(0),
+			End:   time.UnixMilli(3600000),
+			Queue: util.ToPtr("root.batch"),
+			User:  util.ToPtr("bob"),
+		}
+		queues := []*model.PreemptionStats{{Key: "root.batch", Preemptions: 2, Applications: 1, Resource: map[string]int64{"vcore": 1500}}}
+		users := []*model.PreemptionStats{{Key: "bob", Preemptions: 2, Applications: 1, Resource: map[string]int64{"vcore": 1500}}}
+		filters.GroupBy = repository.PreemptionGroupByQueue
+		mockRepo.EXPECT().GetPreemptionStats(gomock.Any(), filters).Return(queues, nil)
+		filters.GroupBy = repository.PreemptionGroupByUser
+		mockRepo.EXPECT().GetPreemptionStats(gomock.Any(), filters).Return(users, nil)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/analytics/preemptions?start=0&end=3600000&queue=root.batch&user=bob", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getPreemptions(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var report model.PreemptionReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		assert.Equal(t, model.PreemptionReport{Start: 0, End: 3600000, Queues: queues, Users: users}, report)
+	})
+
+	t.Run("No preemptions", func(t *testing.T) {
+		mockRepo.EXPECT().GetPreemptionStats(gomock.Any(), gomock.Any()).Return(nil, nil).Times(2)
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/analytics/preemptions", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getPreemptions(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusOK, rr.Code)
+		var report model.PreemptionReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		assert.Equal(t, []*model.PreemptionStats{}, report.Queues)
+		assert.Equal(t, []*model.PreemptionStats{}, report.Users)
+	})
+
+	t.Run("Invalid period", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/analytics/preemptions?start=foo", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getPreemptions(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestGetApplicationPreemptions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := repository.NewMockRepository(ctrl)
+
+	ws := &WebService{repository: mockRepo}
+
+	preemptions := []*model.Preemption{
+		{
+			ID:                     1,
+			TimestampNano:          100,
+			Source:                 model.PreemptionSourceEvent,
+			ApplicationID:          "app-1",
+			AllocationKey:          "alloc-1",
+			QueueName:              "root.batch",
+			PreemptorApplicationID: util.ToPtr("app-2"),
+		},
+	}
+	mockRepo.EXPECT().GetApplicationPreemptions(gomock.Any(), "app-1").Return(preemptions, nil)
+	mockRepo.EXPECT().GetApplicationPreemptions(gomock.Any(), "app-2").Return(nil, nil)
+
+	for id, expected := range map[string][]*model.Preemption{"app-1": preemptions, "app-2": {}} {
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/applications/"+id+"/preemptions", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		restReq := restful.NewRequest(req)
+		restReq.PathParameters()["application_id"] = id
+
+		ws.getApplicationPreemptions(restReq, restful.NewResponse(rr))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var result []*model.Preemption
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+		assert.Equal(t, expected, result)
+	}
+}
+
+func TestGetGangs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := repository.NewMockRepository(ctrl)
+
+	ws := &WebService{repository: mockRepo}
+
+	t.Run("Task groups", func(t *testing.T) {
+		expectedFilters := repository.GangFilters{
+			Start:     time.UnixMilli(0),
+			End:       time.UnixMilli(3600000),
+			Partition: util.ToPtr("default"),
+			User:      util.ToPtr("alice"),
+		}
+		taskGroups := []*model.TaskGroupStats{
+			{
+				Queue:           util.ToPtr("root.spark"),
+				TaskGroupName:   "executor",
+				Applications:    2,
+				Placeholders:    8,
+				Replaced:        6,
+				TimedOut:        2,
+				TimeoutRate:     0.25,
+				ReplacementTime: &model.Percentiles{P50: 4000, P90: 5600, P99: 5960, Max: 6000},
+			},
+		}
+		mockRepo.EXPECT().GetGangStats(gomock.Any(), expectedFilters).Return(taskGroups, nil)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/analytics/gangs?start=0&end=3600000&partition=default&user=alice", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getGangs(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var report model.GangReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		assert.Equal(t, model.GangReport{Start: 0, End: 3600000, TaskGroups: taskGroups}, report)
+	})
+
+	t.Run("No gang-scheduled applications", func(t *testing.T) {
+		mockRepo.EXPECT().GetGangStats(gomock.Any(), gomock.Any()).Return(nil, nil)
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/analytics/gangs", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getGangs(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusOK, rr.Code)
+		var report model.GangReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		assert.Equal(t, []*model.TaskGroupStats{}, report.TaskGroups)
+	})
+}
+
+func TestGetDiagnostics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := repository.NewMockRepository(ctrl)
+
+	ws := &WebService{repository: mockRepo}
+
+	t.Run("Groups", func(t *testing.T) {
+		expectedFilters := repository.DiagnosticsFilters{
+			Start:  time.UnixMilli(0),
+			End:    time.UnixMilli(7 * 24 * 3600000),
+			Bucket: 24 * time.Hour,
+			Queue:  util.ToPtr("root.a"),
+		}
+		groups := []*model.DiagnosticGroup{
+			{
+				State:        "Rejected",
+				Message:      "application app-<n> rejected: queue root.a has reached max applications <n>",
+				Queue:        "root.a",
+				User:         "alice",
+				Applications: 2,
+				FirstSeen:    600000,
+				LastSeen:     5400000,
+				ExampleIDs:   []string{"app-2", "app-1"},
+				Trend:        []model.DiagnosticTrendPoint{{Bucket: 0, Count: 2}},
+			},
+		}
+		mockRepo.EXPECT().GetDiagnostics(gomock.Any(), expectedFilters).Return(groups, nil)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/analytics/diagnostics?start=0&end=604800000&queue=root.a", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getDiagnostics(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var report model.DiagnosticsReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		assert.Equal(t, model.DiagnosticsReport{Start: 0, End: 604800000, BucketWidth: 86400000, Groups: groups}, report)
+	})
+
+	t.Run("No rejected or failed applications", func(t *testing.T) {
+		mockRepo.EXPECT().GetDiagnostics(gomock.Any(), gomock.Any()).Return(nil, nil)
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/analytics/diagnostics?bucket=1h", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getDiagnostics(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusOK, rr.Code)
+		var report model.DiagnosticsReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		assert.Equal(t, int64(3600000), report.BucketWidth)
+		assert.Equal(t, []*model.DiagnosticGroup{}, report.Groups)
+	})
+
+	t.Run("Invalid bucket", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/analytics/diagnostics?bucket=daily", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getDiagnostics(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestGetReservations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := repository.NewMockRepository(ctrl)
+
+	ws := &WebService{repository: mockRepo}
+
+	t.Run("Long-lived reservations", func(t *testing.T) {
+		expectedFilters := repository.ReservationFilters{
+			Start:       time.UnixMilli(0),
+			End:         time.UnixMilli(7200000),
+			MinDuration: time.Hour,
+			Partition:   util.ToPtr("default"),
+		}
+		reservations := []*model.Reservation{
+			{
+				ID:            1,
+				PartitionID:   "1",
+				ApplicationID: "app-1",
+				AllocationKey: "alloc-1",
+				NodeID:        "node-1",
+				Queue:         util.ToPtr("root.a"),
+				User:          util.ToPtr("alice"),
+				StartTimeNano: 0,
+				Duration:      7200000,
+			},
+		}
+		mockRepo.EXPECT().GetLongLivedReservations(gomock.Any(), expectedFilters).Return(reservations, nil)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/analytics/reservations?start=0&end=7200000&minDuration=1h&partition=default", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getReservations(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var report model.ReservationReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		assert.Equal(t, model.ReservationReport{
+			Start:        0,
+			End:          7200000,
+			MinDuration:  3600000,
+			Reservations: reservations,
+			BlockedNodes: []*model.BlockedNode{
+				{
+					PartitionID:        "1",
+					NodeID:             "node-1",
+					Reservations:       1,
+					Applications:       1,
+					ReservedSinceNano:  util.ToPtr(int64(0)),
+					LongestReservation: 7200000,
+				},
+			},
+		}, report)
+	})
+
+	t.Run("No long-lived reservations", func(t *testing.T) {
+		mockRepo.EXPECT().GetLongLivedReservations(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, filters repository.ReservationFilters) ([]*model.Reservation, error) {
+				assert.Equal(t, 10*time.Minute, filters.MinDuration)
+				return nil, nil
+			})
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/analytics/reservations", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getReservations(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusOK, rr.Code)
+		var report model.ReservationReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		assert.Equal(t, []*model.Reservation{}, report.Reservations)
+		assert.Equal(t, []*model.BlockedNode{}, report.BlockedNodes)
+	})
+
+	t.Run("Invalid minimum duration", func(t *testing.T) {
+		for _, minDuration := range []string{"long", "-1h"} {
+			req, err := http.NewRequest(http.MethodGet, "/api/v1/analytics/reservations?minDuration="+minDuration, nil)
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+
+			ws.getReservations(restful.NewRequest(req), restful.NewResponse(rr))
+			require.Equal(t, http.StatusBadRequest, rr.Code, minDuration)
+		}
+	})
+}
+
+func TestGetHistoryTimeline(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := repository.NewMockRepository(ctrl)
+
+	ws := &WebService{repository: mockRepo}
+	points := []*model.TimelinePoint{
+		{Timestamp: 0, Pending: 1},
+		{Timestamp: time.Hour.Nanoseconds(), Running: 1, Completed: 1},
+	}
+
+	t.Run("Applications", func(t *testing.T) {
+		expectedFilters := repository.TimelineFilters{
+			Start: time.UnixMilli(0),
+			End:   time.UnixMilli(7200000),
+			Step:  time.Hour,
+			Queue: util.ToPtr("root.a"),
+			User:  util.ToPtr("alice"),
+		}
+		mockRepo.EXPECT().GetApplicationsTimeline(gomock.Any(), expectedFilters).Return(points, nil)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/history/apps?timestampStart=0&timestampEnd=7200000&queue=root.a&user=alice", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getAppsHistory(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var result []*model.TimelinePoint
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+		assert.Equal(t, points, result)
+	})
+
+	t.Run("Containers", func(t *testing.T) {
+		expectedFilters := repository.TimelineFilters{
+			Start:     time.UnixMilli(0),
+			End:       time.UnixMilli(7200000),
+			Step:      30 * time.Minute,
+			Partition: util.ToPtr("default"),
+		}
+		mockRepo.EXPECT().GetContainersTimeline(gomock.Any(), expectedFilters).Return(nil, nil)
+
+		req, err := http.NewRequest(http.MethodGet,
+			"/api/v1/history/containers?timestampStart=0&timestampEnd=7200000&step=30m&partition=default", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getContainersHistory(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var result []*model.TimelinePoint
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+		assert.Equal(t, []*model.TimelinePoint{}, result)
+	})
+
+	t.Run("Start aligned to the step", func(t *testing.T) {
+		expectedFilters := repository.TimelineFilters{
+			Start:     time.UnixMilli(0),
+			End:       time.UnixMilli(7200000),
+			Step:      time.Hour,
+			Partition: util.ToPtr("default"),
+		}
+		mockRepo.EXPECT().GetApplicationsTimeline(gomock.Any(), expectedFilters).Return(nil, nil)
+
+		req, err := http.NewRequest(http.MethodGet,
+			"/api/v1/history/apps?timestampStart=1800000&timestampEnd=7200000&step=1h&partition=default", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getAppsHistory(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("Invalid step", func(t *testing.T) {
+		for _, step := range []string{"hourly", "1s", "1m", "90.5s"} {
+			req, err := http.NewRequest(http.MethodGet,
+				"/api/v1/history/apps?timestampStart=0&timestampEnd=604800000&partition=default&step="+step, nil)
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+
+			ws.getAppsHistory(restful.NewRequest(req), restful.NewResponse(rr))
+			require.Equal(t, http.StatusBadRequest, rr.Code, step)
+		}
+	})
+
+	t.Run("Aggregation of the timeline", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/history/containers?queue=root.a&agg=max", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getContainersHistory(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestGetHistorySeries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := repository.NewMockRepository(ctrl)
+
+	ws := &WebService{repository: mockRepo}
+	points := []*model.HistoryPoint{
+		{Timestamp: 0, Value: util.ToPtr(1.5)},
+		{Timestamp: time.Hour.Nanoseconds()},
+	}
+
+	t.Run("Applications", func(t *testing.T) {
+		expectedFilters := repository.HistoryFilters{
+			TimestampStart: util.ToPtr(time.UnixMilli(0)),
+			TimestampEnd:   util.ToPtr(time.UnixMilli(7200000)),
+			Step:           time.Hour,
+			Agg:            repository.HistoryAggMax,
+		}
+		mockRepo.EXPECT().GetApplicationsHistorySeries(gomock.Any(), expectedFilters).Return(points, nil)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/history/apps?timestampStart=0&timestampEnd=7200000&agg=max", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getAppsHistory(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var result []*model.HistoryPoint
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+		assert.Equal(t, points, result)
+	})
+
+	t.Run("Containers", func(t *testing.T) {
+		expectedFilters := repository.HistoryFilters{
+			TimestampStart: util.ToPtr(time.UnixMilli(0)),
+			TimestampEnd:   util.ToPtr(time.UnixMilli(7200000)),
+			Step:           30 * time.Minute,
+			Agg:            repository.HistoryAggAvg,
+		}
+		mockRepo.EXPECT().GetContainersHistorySeries(gomock.Any(), expectedFilters).Return(nil, nil)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/history/containers?timestampStart=0&timestampEnd=7200000&step=30m", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getContainersHistory(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var result []*model.HistoryPoint
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+		assert.Equal(t, []*model.HistoryPoint{}, result)
+	})
+
+	t.Run("Invalid aggregation", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/history/apps?agg=median", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getAppsHistory(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestGetNodeUtilization(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := repository.NewMockRepository(ctrl)
+
+	ws := &WebService{repository: mockRepo}
+
+	t.Run("Utilization of the nodes", func(t *testing.T) {
+		expectedFilters := repository.NodeUtilizationFilters{
+			Start:     time.UnixMilli(0),
+			End:       time.UnixMilli(7200000),
+			Partition: util.ToPtr("default"),
+		}
+		nodes := []*model.NodeUtilization{
+			{NodeID: "node-1", Partition: "default", Hours: 2, Utilization: map[string]float64{"vcore": 0.125}},
+		}
+		mockRepo.EXPECT().GetNodeUtilization(gomock.Any(), expectedFilters).Return(nodes, nil)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/analytics/node-utilization?start=0&end=7200000&partition=default", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getNodeUtilization(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var report model.NodeUtilizationReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		assert.Equal(t, model.NodeUtilizationReport{Start: 0, End: 7200000, Nodes: nodes}, report)
+	})
+
+	t.Run("No rollups", func(t *testing.T) {
+		mockRepo.EXPECT().GetNodeUtilization(gomock.Any(), gomock.Any()).Return(nil, nil)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/analytics/node-utilization", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getNodeUtilization(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusOK, rr.Code)
+		var report model.NodeUtilizationReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		assert.Equal(t, []*model.NodeUtilization{}, report.Nodes)
+	})
+
+	t.Run("Invalid period", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/analytics/node-utilization?start=7200000&end=0", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getNodeUtilization(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestGetForecast(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := repository.NewMockRepository(ctrl)
+
+	ws := &WebService{repository: mockRepo}
+
+	t.Run("Forecast", func(t *testing.T) {
+		expectedFilters := repository.DemandFilters{
+			Start:     time.UnixMilli(0),
+			End:       time.UnixMilli(7200000),
+			Step:      time.Hour,
+			Partition: "default",
+			Queue:     "root",
+			Resource:  "vcore",
+		}
+		history := []*model.DemandPoint{
+			{Timestamp: 0, Allocated: util.ToPtr(1000.0), Pending: util.ToPtr(0.0), Capacity: util.ToPtr(4000.0)},
+			{Timestamp: 3600000, Allocated: util.ToPtr(2000.0), Pending: util.ToPtr(0.0), Capacity: util.ToPtr(4000.0)},
+		}
+		mockRepo.EXPECT().GetDemandSeries(gomock.Any(), expectedFilters).Return(history, nil)
+
+		req, err := http.NewRequest(http.MethodGet,
+			"/api/v1/analytics/forecast?start=0&end=7200000&partition=default&resource=vcore&horizon=3h", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getForecast(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var report model.ForecastReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		assert.Equal(t, "default", report.Partition)
+		assert.Equal(t, "root", report.Queue)
+		assert.Equal(t, "vcore", report.Resource)
+		assert.Equal(t, int64(0), report.Start)
+		assert.Equal(t, int64(7200000), report.End)
+		assert.Equal(t, time.Hour.Milliseconds(), report.Step)
+		assert.Equal(t, (3 * time.Hour).Milliseconds(), report.Horizon)
+		assert.Equal(t, history, report.History)
+		require.Len(t, report.Forecast, 3)
+		assert.InDelta(t, 3000, report.Forecast[0].Demand.Value, 1e-9)
+		assert.Equal(t, util.ToPtr(int64(3*3600000)), report.ExhaustionTime)
+	})
+
+	t.Run("No history", func(t *testing.T) {
+		mockRepo.EXPECT().GetDemandSeries(gomock.Any(), gomock.Any()).Return(nil, nil)
+
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/analytics/forecast?partition=default&resource=vcore", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getForecast(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusOK, rr.Code)
+		var report model.ForecastReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		assert.Equal(t, []*model.DemandPoint{}, report.History)
+		assert.Equal(t, []*model.ForecastPoint{}, report.Forecast)
+	})
+
+	t.Run("Missing resource", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/analytics/forecast?partition=default", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getForecast(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestGetEfficiency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := repository.NewMockRepository(ctrl)
+
+	ws := &WebService{repository: mockRepo}
+
+	t.Run("Efficiency", func(t *testing.T) {
+		expectedFilters := repository.EfficiencyFilters{
+			Start:    time.UnixMilli(0),
+			End:      time.UnixMilli(7200000),
+			Queue:    util.ToPtr("root"),
+			Resource: "memory",
+			RankBy:   repository.EfficiencyRankByUnused,
+			Limit:    3,
+		}
+		app := &model.Efficiency{
+			ApplicationID: util.ToPtr("app-1"),
+			Queue:         util.ToPtr("root.a"),
+			User:          util.ToPtr("alice"),
+			Applications:  1,
+			Requested:     4000,
+			MaxUsed:       1000,
+			MaxUsedRatio:  0.25,
+		}
+		user := &model.Efficiency{User: util.ToPtr("alice"), Applications: 1, Requested: 4000, MaxUsed: 1000, MaxUsedRatio: 0.25}
+		mockRepo.EXPECT().GetEfficiency(gomock.Any(), expectedFilters).Return([]*model.Efficiency{app, user}, nil)
+
+		req, err := http.NewRequest(http.MethodGet,
+			"/api/v1/analytics/efficiency?start=0&end=7200000&queue=root&resource=memory&rankBy=unused&limit=3", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getEfficiency(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var report model.EfficiencyReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		assert.Equal(t, model.EfficiencyReport{
+			Start:        0,
+			End:          7200000,
+			Resource:     "memory",
+			RankBy:       "unused",
+			Applications: []*model.Efficiency{app},
+			Users:        []*model.Efficiency{user},
+			Queues:       []*model.Efficiency{},
+		}, report)
+	})
+
+	t.Run("Invalid ranking", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/analytics/efficiency?rankBy=cost", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		ws.getEfficiency(restful.NewRequest(req), restful.NewResponse(rr))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
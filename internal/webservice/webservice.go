@@ -18,10 +18,12 @@ type WebService struct {
 	eventRepository repository.EventRepository
 	healthService   health.Interface
 	config          config.UHSConfig
+	reportsConfig   config.ReportsConfig
 }
 
 func NewWebService(
 	cfg config.UHSConfig,
+	reportsCfg config.ReportsConfig,
 	repository repository.Repository,
 	eventRepository repository.EventRepository,
 	healthService health.Interface,
@@ -35,6 +37,7 @@ func NewWebService(
 		eventRepository: eventRepository,
 		healthService:   healthService,
 		config:          cfg,
+		reportsConfig:   reportsCfg,
 	}
 }
 
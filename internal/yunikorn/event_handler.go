@@ -16,6 +16,10 @@ type EventHandler func(context.Context, *si.EventRecord) error
 func (s *Service) handleEvent(ctx context.Context, ev *si.EventRecord) error {
 	logger := log.FromContext(ctx)
 
+	if err := s.repo.InsertEvent(ctx, model.NewEvent(ev)); err != nil {
+		logger.Errorf("could not insert event: %v", err)
+	}
+
 	switch ev.GetType() {
 	case si.EventRecord_UNKNOWN_EVENTRECORD_TYPE:
 	case si.EventRecord_REQUEST:
@@ -69,7 +73,8 @@ func (s *Service) handleAppEvent(ctx context.Context, ev *si.EventRecord) {
 	}
 
 	app.MergeFrom(&daoApp)
-	if ev.GetEventChangeType() == si.EventRecord_REMOVE {
+	removed := isApplicationRemoved(ev)
+	if removed {
 		app.DeletedAtNano = &ev.TimestampNano
 	}
 
@@ -77,6 +82,85 @@ func (s *Service) handleAppEvent(ctx context.Context, ev *si.EventRecord) {
 		logger.Errorf("could not update application: %v", err)
 		return
 	}
+
+	s.recordApplicationReservations(ctx, app, ev.TimestampNano)
+
+	if ev.GetEventChangeDetail() == si.EventRecord_ALLOC_PREEMPT {
+		preemption := model.NewPreemption(app, ev.GetReferenceID(), model.NewEvent(ev).Resource, ev.TimestampNano, model.PreemptionSourceEvent)
+		if err := s.repo.InsertPreemption(ctx, preemption); err != nil {
+			logger.Errorf("could not insert preemption: %v", err)
+		}
+	}
+
+	if removed || app.IsTerminated() {
+		s.recordApplicationCompletion(ctx, app, ev.TimestampNano)
+	}
+}
+
+// isApplicationRemoved returns true if the event removes the application,
+// rather than one of its allocations or asks, which are removed with a REMOVE event as well.
+func isApplicationRemoved(ev *si.EventRecord) bool {
+	if ev.GetEventChangeType() != si.EventRecord_REMOVE {
+		return false
+	}
+	switch ev.GetEventChangeDetail() {
+	case si.EventRecord_ALLOC_CANCEL, si.EventRecord_ALLOC_PREEMPT, si.EventRecord_ALLOC_TIMEOUT, si.EventRecord_ALLOC_REPLACED,
+		si.EventRecord_ALLOC_NODEREMOVED, si.EventRecord_REQUEST_CANCEL, si.EventRecord_REQUEST_ALLOC, si.EventRecord_REQUEST_TIMEOUT,
+		si.EventRecord_APP_ALLOC, si.EventRecord_APP_REQUEST:
+		return false
+	}
+	return true
+}
+
+// recordApplicationCompletion writes the accounting record of a completed application.
+// An application is only recorded once, when it reaches a terminal state or is removed, whichever happens first.
+func (s *Service) recordApplicationCompletion(ctx context.Context, app *model.Application, finishedAtNano int64) {
+	logger := log.FromContext(ctx)
+
+	record := model.NewAccountingRecord(app, finishedAtNano)
+	inserted, err := s.repo.InsertAccountingRecord(ctx, record)
+	if err != nil {
+		logger.Errorf("could not insert accounting record: %v", err)
+		return
+	}
+	if inserted {
+		logger.Debugf("recorded completion of application %s in state %s", app.ID, app.State)
+	}
+}
+
+// recordPreemptions records the preempted allocations of the application, which are recorded from the event stream
+// as well, unless the events were missed.
+func (s *Service) recordPreemptions(ctx context.Context, app *model.Application, nowNano int64) {
+	logger := log.FromContext(ctx)
+
+	for _, alloc := range app.Allocations {
+		if alloc == nil || !alloc.Preempted {
+			continue
+		}
+		preemption := model.NewPreemption(app, alloc.AllocationKey, alloc.ResourcePerAlloc, nowNano, model.PreemptionSourceSync)
+		if err := s.repo.InsertPreemption(ctx, preemption); err != nil {
+			logger.Errorf("could not insert preemption: %v", err)
+		}
+	}
+}
+
+// recordApplicationReservations records the current reservations of the application.
+func (s *Service) recordApplicationReservations(ctx context.Context, app *model.Application, nowNano int64) {
+	if err := s.repo.UpdateApplicationReservations(ctx, app, nowNano); err != nil {
+		log.FromContext(ctx).Errorf("could not update reservations: %v", err)
+	}
+}
+
+// recordNodeReservations records the current reservations of the node, which are not merged like those of
+// the stored node. A removed node has no reservations.
+func (s *Service) recordNodeReservations(ctx context.Context, node *dao.NodeDAOInfo, removed bool, nowNano int64) {
+	reservations := node.Reservations
+	if removed {
+		reservations = nil
+	}
+	if err := s.repo.UpdateNodeReservations(ctx, node.PartitionID, node.NodeID, reservations, nowNano); err != nil {
+		log.FromContext(ctx).Errorf("could not update reservations: %v", err)
+	}
 }
 
 func (s *Service) handleQueueEvent(ctx context.Context, ev *si.EventRecord) {
@@ -158,6 +242,7 @@ func (s *Service) handleNodeEvent(ctx context.Context, ev *si.EventRecord) {
 			logger.Errorf("could not insert node: %v", err)
 			return
 		}
+		s.recordNodeReservations(ctx, &daoNode, false, ev.TimestampNano)
 		return
 	}
 
@@ -168,7 +253,8 @@ func (s *Service) handleNodeEvent(ctx context.Context, ev *si.EventRecord) {
 	}
 	node.MergeFrom(&daoNode)
 
-	if ev.GetEventChangeType() == si.EventRecord_REMOVE {
+	removed := isNodeRemoved(ev)
+	if removed {
 		node.DeletedAtNano = &ev.TimestampNano
 	}
 
@@ -176,4 +262,18 @@ func (s *Service) handleNodeEvent(ctx context.Context, ev *si.EventRecord) {
 		logger.Errorf("could not update node: %v", err)
 		return
 	}
+	s.recordNodeReservations(ctx, &daoNode, removed, ev.TimestampNano)
+}
+
+// isNodeRemoved returns true if the event removes the node,
+// rather than one of its allocations or reservations, which are removed with a REMOVE event as well.
+func isNodeRemoved(ev *si.EventRecord) bool {
+	if ev.GetEventChangeType() != si.EventRecord_REMOVE {
+		return false
+	}
+	switch ev.GetEventChangeDetail() {
+	case si.EventRecord_NODE_ALLOC, si.EventRecord_NODE_RESERVATION:
+		return false
+	}
+	return true
 }
@@ -0,0 +1,253 @@
+package yunikorn
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/G-Research/yunikorn-core/pkg/webservice/dao"
+	"github.com/G-Research/yunikorn-scheduler-interface/lib/go/si"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/G-Research/unicorn-history-server/internal/database/repository"
+	"github.com/G-Research/unicorn-history-server/internal/model"
+)
+
+func TestHandleAppEventRecordsPreemption(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := repository.NewMockRepository(ctrl)
+
+	daoApp := dao.ApplicationDAOInfo{
+		ID:            "1",
+		ApplicationID: "app-1",
+		Partition:     "default",
+		QueueName:     "root.batch",
+		User:          "bob",
+		State:         "Running",
+	}
+	state, err := json.Marshal(daoApp)
+	require.NoError(t, err)
+	ev := &si.EventRecord{
+		Type:              si.EventRecord_APP,
+		ObjectID:          "app-1",
+		ReferenceID:       "alloc-1",
+		EventChangeType:   si.EventRecord_REMOVE,
+		EventChangeDetail: si.EventRecord_ALLOC_PREEMPT,
+		TimestampNano:     100,
+		Resource:          &si.Resource{Resources: map[string]*si.Quantity{"vcore": {Value: 1000}}},
+		State:             string(state),
+	}
+
+	mockRepo.EXPECT().GetApplicationByID(gomock.Any(), "1").Return(&model.Application{ApplicationDAOInfo: daoApp}, nil)
+	mockRepo.EXPECT().UpdateApplication(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, app *model.Application) error {
+			// removing an allocation does not remove the application
+			assert.Nil(t, app.DeletedAtNano)
+			return nil
+		})
+	mockRepo.EXPECT().UpdateApplicationReservations(gomock.Any(), gomock.Any(), int64(100)).Return(nil)
+	mockRepo.EXPECT().InsertPreemption(gomock.Any(), &model.Preemption{
+		TimestampNano: 100,
+		Source:        model.PreemptionSourceEvent,
+		ApplicationID: "app-1",
+		AllocationKey: "alloc-1",
+		Partition:     "default",
+		QueueName:     "root.batch",
+		User:          "bob",
+		Resource:      map[string]int64{"vcore": 1000},
+	}).Return(nil)
+
+	s := NewService(mockRepo, nil, nil)
+	s.handleAppEvent(context.Background(), ev)
+}
+
+func TestHandleAppEventRemoval(t *testing.T) {
+	daoApp := dao.ApplicationDAOInfo{
+		ID:            "1",
+		ApplicationID: "app-1",
+		Partition:     "default",
+		QueueName:     "root.batch",
+		State:         "Running",
+	}
+	state, err := json.Marshal(daoApp)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		changeDetail si.EventRecord_ChangeDetail
+		wantRemoved  bool
+	}{
+		"application removed": {changeDetail: si.EventRecord_DETAILS_NONE, wantRemoved: true},
+		"allocation removed":  {changeDetail: si.EventRecord_ALLOC_CANCEL},
+		"ask removed":         {changeDetail: si.EventRecord_REQUEST_CANCEL},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockRepo := repository.NewMockRepository(ctrl)
+
+			ev := &si.EventRecord{
+				Type:              si.EventRecord_APP,
+				ObjectID:          "app-1",
+				EventChangeType:   si.EventRecord_REMOVE,
+				EventChangeDetail: tt.changeDetail,
+				TimestampNano:     100,
+				State:             string(state),
+			}
+
+			mockRepo.EXPECT().GetApplicationByID(gomock.Any(), "1").Return(&model.Application{ApplicationDAOInfo: daoApp}, nil)
+			mockRepo.EXPECT().UpdateApplication(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, app *model.Application) error {
+					if tt.wantRemoved {
+						assert.Equal(t, int64(100), *app.DeletedAtNano)
+					} else {
+						assert.Nil(t, app.DeletedAtNano)
+					}
+					return nil
+				})
+			mockRepo.EXPECT().UpdateApplicationReservations(gomock.Any(), gomock.Any(), int64(100)).Return(nil)
+			// only the removal of the application completes it
+			if tt.wantRemoved {
+				mockRepo.EXPECT().InsertAccountingRecord(gomock.Any(), gomock.Any()).Return(true, nil)
+			}
+
+			s := NewService(mockRepo, nil, nil)
+			s.handleAppEvent(context.Background(), ev)
+		})
+	}
+}
+
+func TestIsApplicationRemoved(t *testing.T) {
+	tests := map[string]struct {
+		changeType   si.EventRecord_ChangeType
+		changeDetail si.EventRecord_ChangeDetail
+		want         bool
+	}{
+		"application removed":  {changeType: si.EventRecord_REMOVE, changeDetail: si.EventRecord_DETAILS_NONE, want: true},
+		"application rejected": {changeType: si.EventRecord_REMOVE, changeDetail: si.EventRecord_APP_REJECT, want: true},
+		"allocation preempted": {changeType: si.EventRecord_REMOVE, changeDetail: si.EventRecord_ALLOC_PREEMPT},
+		"ask cancelled":        {changeType: si.EventRecord_REMOVE, changeDetail: si.EventRecord_REQUEST_CANCEL},
+		"state change":         {changeType: si.EventRecord_SET, changeDetail: si.EventRecord_APP_COMPLETED},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ev := &si.EventRecord{EventChangeType: tt.changeType, EventChangeDetail: tt.changeDetail}
+			assert.Equal(t, tt.want, isApplicationRemoved(ev))
+		})
+	}
+}
+
+func TestHandleNodeEventRecordsReservations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := repository.NewMockRepository(ctrl)
+
+	daoNode := dao.NodeDAOInfo{
+		ID:           "1",
+		NodeID:       "node-1",
+		PartitionID:  "1",
+		IsReserved:   true,
+		Reservations: []string{"app-2|alloc-2"},
+	}
+	state, err := json.Marshal(daoNode)
+	require.NoError(t, err)
+	ev := &si.EventRecord{
+		Type:              si.EventRecord_NODE,
+		ObjectID:          "node-1",
+		ReferenceID:       "alloc-1",
+		EventChangeType:   si.EventRecord_REMOVE,
+		EventChangeDetail: si.EventRecord_NODE_RESERVATION,
+		TimestampNano:     100,
+		State:             string(state),
+	}
+
+	mockRepo.EXPECT().GetNodeByID(gomock.Any(), "1").Return(&model.Node{NodeDAOInfo: daoNode}, nil)
+	mockRepo.EXPECT().UpdateNode(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, node *model.Node) error {
+			// removing a reservation does not remove the node
+			assert.Nil(t, node.DeletedAtNano)
+			return nil
+		})
+	mockRepo.EXPECT().UpdateNodeReservations(gomock.Any(), "1", "node-1", []string{"app-2|alloc-2"}, int64(100)).Return(nil)
+
+	s := NewService(mockRepo, nil, nil)
+	s.handleNodeEvent(context.Background(), ev)
+}
+
+func TestHandleNodeEventRemoval(t *testing.T) {
+	daoNode := dao.NodeDAOInfo{
+		ID:          "1",
+		NodeID:      "node-1",
+		PartitionID: "1",
+	}
+	state, err := json.Marshal(daoNode)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		changeDetail si.EventRecord_ChangeDetail
+		wantRemoved  bool
+	}{
+		"node removed":        {changeDetail: si.EventRecord_DETAILS_NONE, wantRemoved: true},
+		"allocation removed":  {changeDetail: si.EventRecord_NODE_ALLOC},
+		"reservation removed": {changeDetail: si.EventRecord_NODE_RESERVATION},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockRepo := repository.NewMockRepository(ctrl)
+
+			ev := &si.EventRecord{
+				Type:              si.EventRecord_NODE,
+				ObjectID:          "node-1",
+				EventChangeType:   si.EventRecord_REMOVE,
+				EventChangeDetail: tt.changeDetail,
+				TimestampNano:     100,
+				State:             string(state),
+			}
+
+			mockRepo.EXPECT().GetNodeByID(gomock.Any(), "1").Return(&model.Node{NodeDAOInfo: daoNode}, nil)
+			mockRepo.EXPECT().UpdateNode(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, node *model.Node) error {
+					if tt.wantRemoved {
+						assert.Equal(t, int64(100), *node.DeletedAtNano)
+					} else {
+						assert.Nil(t, node.DeletedAtNano)
+					}
+					return nil
+				})
+			mockRepo.EXPECT().UpdateNodeReservations(gomock.Any(), "1", "node-1", gomock.Any(), int64(100)).Return(nil)
+
+			s := NewService(mockRepo, nil, nil)
+			s.handleNodeEvent(context.Background(), ev)
+		})
+	}
+}
+
+func TestIsNodeRemoved(t *testing.T) {
+	tests := map[string]struct {
+		changeType   si.EventRecord_ChangeType
+		changeDetail si.EventRecord_ChangeDetail
+		want         bool
+	}{
+		"node removed":        {changeType: si.EventRecord_REMOVE, changeDetail: si.EventRecord_DETAILS_NONE, want: true},
+		"node decommissioned": {changeType: si.EventRecord_REMOVE, changeDetail: si.EventRecord_NODE_DECOMISSION, want: true},
+		"allocation removed":  {changeType: si.EventRecord_REMOVE, changeDetail: si.EventRecord_NODE_ALLOC},
+		"reservation removed": {changeType: si.EventRecord_REMOVE, changeDetail: si.EventRecord_NODE_RESERVATION},
+		"reservation added":   {changeType: si.EventRecord_ADD, changeDetail: si.EventRecord_NODE_RESERVATION},
+		"schedulable changed": {changeType: si.EventRecord_SET, changeDetail: si.EventRecord_NODE_SCHEDULABLE},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ev := &si.EventRecord{EventChangeType: tt.changeType, EventChangeDetail: tt.changeDetail}
+			assert.Equal(t, tt.want, isNodeRemoved(ev))
+		})
+	}
+}
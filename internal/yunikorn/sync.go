@@ -104,13 +104,13 @@ func flattenQueues(qs []*dao.PartitionQueueDAOInfo) []*dao.PartitionQueueDAOInfo
 
 func (s *Service) syncNodes(ctx context.Context, daoNodes []*dao.NodesDAOInfo) error {
 	var errs []error
+	nowNano := time.Now().UnixNano()
 	for _, nodesInfo := range daoNodes {
 		nodes := nodesInfo.Nodes
 		ids := make([]string, 0, len(nodes))
 		for _, n := range nodes {
 			ids = append(ids, n.ID)
 		}
-		nowNano := time.Now().UnixNano()
 		if err := s.repo.DeleteNodesNotInIDs(ctx, ids, nowNano); err != nil {
 			errs = append(errs, err)
 		}
@@ -126,16 +126,26 @@ func (s *Service) syncNodes(ctx context.Context, daoNodes []*dao.NodesDAOInfo) e
 				}
 				if err := s.repo.InsertNode(ctx, node); err != nil {
 					errs = append(errs, fmt.Errorf("could not insert node %s: %v", n.NodeID, err))
+					continue
 				}
+				s.recordNodeReservations(ctx, n, false, nowNano)
 				continue
 			}
 
 			current.MergeFrom(n)
+			// the node is still known to the scheduler, so it is not deleted
+			current.DeletedAtNano = nil
 			if err := s.repo.UpdateNode(ctx, current); err != nil {
 				errs = append(errs, fmt.Errorf("could not update node %s: %v", n.NodeID, err))
+				continue
 			}
+			s.recordNodeReservations(ctx, n, false, nowNano)
 		}
 	}
+	// the applications are synced before the nodes
+	if err := s.repo.CloseDeletedReservations(ctx, nowNano); err != nil {
+		errs = append(errs, err)
+	}
 
 	return errors.Join(errs...)
 }
@@ -165,13 +175,23 @@ func (s *Service) syncApplications(ctx context.Context, applications []*dao.Appl
 			if err := s.repo.InsertApplication(ctx, application); err != nil {
 				return err
 			}
+			s.recordPreemptions(ctx, application, nowNano)
+			s.recordApplicationReservations(ctx, application, nowNano)
 			continue
 		}
 
 		current.MergeFrom(app)
+		// the application is still known to the scheduler, so it is not deleted
+		current.DeletedAtNano = nil
 		if err := s.repo.UpdateApplication(ctx, current); err != nil {
 			return err
 		}
+		s.recordPreemptions(ctx, current, nowNano)
+		s.recordApplicationReservations(ctx, current, nowNano)
+		// record applications which completed while the event stream was not received
+		if current.IsTerminated() {
+			s.recordApplicationCompletion(ctx, current, nowNano)
+		}
 	}
 
 	return nil
@@ -6,6 +6,7 @@ import (
 
 	"github.com/G-Research/unicorn-history-server/internal/database/repository"
 	"github.com/G-Research/unicorn-history-server/internal/model"
+	"github.com/G-Research/unicorn-history-server/internal/util"
 	"github.com/G-Research/yunikorn-core/pkg/webservice/dao"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/assert"
@@ -120,6 +121,36 @@ func (ss *SyncApplicationsIntTest) TestSyncApplications() {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Should restore deleted application which is still known to the scheduler",
+			stateApplications: []*dao.ApplicationDAOInfo{
+				{ID: "1", ApplicationID: "app-1"},
+			},
+			existingApplications: []*model.Application{
+				{
+					Metadata: model.Metadata{
+						CreatedAtNano: now,
+						DeletedAtNano: &now,
+					},
+					ApplicationDAOInfo: dao.ApplicationDAOInfo{
+						ID:            "1",
+						ApplicationID: "app-1",
+					},
+				},
+			},
+			expectedLive: []*model.Application{
+				{
+					Metadata: model.Metadata{
+						CreatedAtNano: now,
+					},
+					ApplicationDAOInfo: dao.ApplicationDAOInfo{
+						ID:            "1",
+						ApplicationID: "app-1",
+					},
+				},
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -146,7 +177,7 @@ func (ss *SyncApplicationsIntTest) TestSyncApplications() {
 
 			applicationsInDB, err := s.repo.GetAllApplications(
 				ctx,
-				repository.ApplicationFilters{},
+				repository.ApplicationFilters{IncludeDeleted: true},
 			)
 			require.NoError(ss.T(), err)
 
@@ -175,3 +206,86 @@ func (ss *SyncApplicationsIntTest) TestSyncApplications() {
 		})
 	}
 }
+
+func (ss *SyncApplicationsIntTest) TestSyncApplicationsRecordsCompletion() {
+	ctx := context.Background()
+	now := time.Now()
+
+	existing := &model.Application{
+		Metadata: model.Metadata{CreatedAtNano: now.UnixNano()},
+		ApplicationDAOInfo: dao.ApplicationDAOInfo{
+			ID:             "completed-1",
+			ApplicationID:  "app-completed",
+			PartitionID:    "1",
+			Partition:      "default",
+			QueueName:      "root.default",
+			SubmissionTime: now.Add(-time.Hour).UnixMilli(),
+			State:          "Running",
+		},
+	}
+	require.NoError(ss.T(), ss.repo.InsertApplication(ctx, existing))
+	ss.T().Cleanup(func() {
+		_, err := ss.pool.Exec(ctx, "DELETE FROM applications")
+		require.NoError(ss.T(), err)
+	})
+
+	completed := existing.ApplicationDAOInfo
+	completed.State = "Completed"
+	completed.FinishedTime = util.ToPtr(now.UnixMilli())
+	completed.StateLog = []*dao.StateDAOInfo{
+		{Time: now.Add(-30 * time.Minute).UnixMilli(), ApplicationState: "Running"},
+		{Time: now.UnixMilli(), ApplicationState: "Completed"},
+	}
+
+	s := NewService(ss.repo, nil, nil)
+	// syncing twice records the application only once
+	require.NoError(ss.T(), s.syncApplications(ctx, []*dao.ApplicationDAOInfo{&completed}))
+	require.NoError(ss.T(), s.syncApplications(ctx, []*dao.ApplicationDAOInfo{&completed}))
+
+	records, err := ss.repo.GetAccountingRecords(ctx, repository.AccountingFilters{})
+	require.NoError(ss.T(), err)
+	require.Len(ss.T(), records, 1)
+	assert.Equal(ss.T(), "completed-1", records[0].ID)
+	assert.Equal(ss.T(), "Completed", records[0].State)
+	assert.Equal(ss.T(), now.UnixMilli(), records[0].FinishedTime)
+	assert.Equal(ss.T(), (30 * time.Minute).Milliseconds(), records[0].WaitTime)
+	assert.Equal(ss.T(), (30 * time.Minute).Milliseconds(), records[0].RunTime)
+}
+
+func (ss *SyncApplicationsIntTest) TestSyncApplicationsRecordsPreemptions() {
+	ctx := context.Background()
+	now := time.Now()
+
+	app := &dao.ApplicationDAOInfo{
+		ID:             "preempted-1",
+		ApplicationID:  "app-preempted",
+		PartitionID:    "1",
+		Partition:      "default",
+		QueueName:      "root.default",
+		SubmissionTime: now.Add(-time.Hour).UnixMilli(),
+		State:          "Running",
+		Allocations: []*dao.AllocationDAOInfo{
+			{AllocationKey: "alloc-1", ResourcePerAlloc: map[string]int64{"vcore": 1000}, Preempted: true},
+			{AllocationKey: "alloc-2", ResourcePerAlloc: map[string]int64{"vcore": 1000}},
+		},
+	}
+	ss.T().Cleanup(func() {
+		_, err := ss.pool.Exec(ctx, "DELETE FROM applications")
+		require.NoError(ss.T(), err)
+		_, err = ss.pool.Exec(ctx, "DELETE FROM preemptions")
+		require.NoError(ss.T(), err)
+	})
+
+	s := NewService(ss.repo, nil, nil)
+	// syncing twice records the preemption only once
+	require.NoError(ss.T(), s.syncApplications(ctx, []*dao.ApplicationDAOInfo{app}))
+	require.NoError(ss.T(), s.syncApplications(ctx, []*dao.ApplicationDAOInfo{app}))
+
+	preemptions, err := ss.repo.GetApplicationPreemptions(ctx, "preempted-1")
+	require.NoError(ss.T(), err)
+	require.Len(ss.T(), preemptions, 1)
+	assert.Equal(ss.T(), "app-preempted", preemptions[0].ApplicationID)
+	assert.Equal(ss.T(), "alloc-1", preemptions[0].AllocationKey)
+	assert.Equal(ss.T(), model.PreemptionSourceSync, preemptions[0].Source)
+	assert.Equal(ss.T(), map[string]int64{"vcore": 1000}, preemptions[0].Resource)
+}
@@ -84,6 +84,27 @@ func (ss *SyncNodesIntTest) TestSyncNodes() {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Sync nodes restores deleted nodes which are still known to the scheduler",
+			stateNodes: []*dao.NodesDAOInfo{
+				{
+					PartitionName: "default",
+					Nodes: []*dao.NodeDAOInfo{
+						{ID: "1", NodeID: "node-1", PartitionID: partitionID, HostName: "host-1"},
+					},
+				},
+			},
+			existingNodes: []*model.Node{
+				{
+					Metadata:    model.Metadata{DeletedAtNano: &nowNano},
+					NodeDAOInfo: dao.NodeDAOInfo{ID: "1", NodeID: "node-1", HostName: "host-1", PartitionID: partitionID},
+				},
+			},
+			expectedNodes: []*model.Node{
+				{NodeDAOInfo: dao.NodeDAOInfo{ID: "1", NodeID: "node-1", HostName: "host-1", PartitionID: partitionID}}, // restored
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -108,7 +129,7 @@ func (ss *SyncNodesIntTest) TestSyncNodes() {
 			}
 			require.NoError(ss.T(), err)
 
-			nodesInDB, err := s.repo.GetNodesPerPartition(ctx, partitionID, repository.NodeFilters{})
+			nodesInDB, err := s.repo.GetNodesPerPartition(ctx, partitionID, repository.NodeFilters{IncludeDeleted: true})
 			require.NoError(ss.T(), err)
 			for i, target := range tt.expectedNodes {
 				require.Equal(ss.T(), target.ID, nodesInDB[i].ID)
@@ -116,8 +137,73 @@ func (ss *SyncNodesIntTest) TestSyncNodes() {
 				require.Equal(ss.T(), target.HostName, nodesInDB[i].HostName)
 				if target.DeletedAtNano != nil {
 					require.NotNil(ss.T(), nodesInDB[i].DeletedAtNano)
+				} else {
+					require.Nil(ss.T(), nodesInDB[i].DeletedAtNano)
 				}
 			}
 		})
 	}
 }
+
+func (ss *SyncNodesIntTest) TestSyncNodesRecordsReservations() {
+	ctx := context.Background()
+	start := time.Now()
+
+	app := &dao.ApplicationDAOInfo{
+		ID:            "reserving-1",
+		ApplicationID: "app-reserving",
+		PartitionID:   "1",
+		Partition:     "default",
+		QueueName:     "root.default",
+		State:         "Running",
+		Requests:      []*dao.AllocationAskDAOInfo{{AllocationKey: "alloc-1"}},
+		HasReserved:   true,
+		Reservations:  []string{"node-reserved|alloc-1"},
+	}
+	node := &dao.NodeDAOInfo{
+		ID:           "reserved-1",
+		NodeID:       "node-reserved",
+		PartitionID:  "1",
+		HostName:     "host-reserved",
+		IsReserved:   true,
+		Reservations: []string{"app-reserving|alloc-1"},
+	}
+	nodes := []*dao.NodesDAOInfo{{PartitionName: "default", Nodes: []*dao.NodeDAOInfo{node}}}
+	ss.T().Cleanup(func() {
+		for _, table := range []string{"applications", "nodes", "reservations"} {
+			_, err := ss.pool.Exec(ctx, "DELETE FROM "+table)
+			require.NoError(ss.T(), err)
+		}
+	})
+
+	s := NewService(ss.repo, nil, nil)
+	// the reservation is recorded once from both the application and the node
+	require.NoError(ss.T(), s.syncApplications(ctx, []*dao.ApplicationDAOInfo{app}))
+	require.NoError(ss.T(), s.syncNodes(ctx, nodes))
+
+	filters := repository.ReservationFilters{Start: start, End: time.Now().Add(time.Hour)}
+	reservations, err := ss.repo.GetLongLivedReservations(ctx, filters)
+	require.NoError(ss.T(), err)
+	require.Len(ss.T(), reservations, 1)
+	require.Nil(ss.T(), reservations[0].EndTimeNano)
+
+	// the ask is allocated on the reserved node
+	app.Requests = nil
+	app.Allocations = []*dao.AllocationDAOInfo{{AllocationKey: "alloc-1", NodeID: "node-reserved"}}
+	app.HasReserved = false
+	app.Reservations = nil
+	node.IsReserved = false
+	node.Reservations = nil
+	require.NoError(ss.T(), s.syncApplications(ctx, []*dao.ApplicationDAOInfo{app}))
+	require.NoError(ss.T(), s.syncNodes(ctx, nodes))
+
+	reservations, err = ss.repo.GetLongLivedReservations(ctx, filters)
+	require.NoError(ss.T(), err)
+	require.Len(ss.T(), reservations, 1)
+	res := reservations[0]
+	require.Equal(ss.T(), "app-reserving", res.ApplicationID)
+	require.Equal(ss.T(), "alloc-1", res.AllocationKey)
+	require.Equal(ss.T(), "node-reserved", res.NodeID)
+	require.NotNil(ss.T(), res.EndTimeNano)
+	require.Equal(ss.T(), model.ReservationOutcomeAllocated, *res.Outcome)
+}
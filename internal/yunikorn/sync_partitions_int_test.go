@@ -138,7 +138,7 @@ func (ss *SyncPartitionIntTest) TestSyncPartitions() {
 			require.NoError(ss.T(), err)
 
 			var partitionsInDB []*model.Partition
-			partitionsInDB, err = s.repo.GetAllPartitions(ctx, repository.PartitionFilters{})
+			partitionsInDB, err = s.repo.GetAllPartitions(ctx, repository.PartitionFilters{IncludeDeleted: true})
 			require.NoError(ss.T(), err)
 
 			for _, dbPartition := range partitionsInDB {
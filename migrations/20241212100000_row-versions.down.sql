@@ -0,0 +1,10 @@
+DROP TRIGGER IF EXISTS applications_insert_version ON applications;
+DROP TRIGGER IF EXISTS applications_update_version ON applications;
+DROP TRIGGER IF EXISTS queues_insert_version ON queues;
+DROP TRIGGER IF EXISTS queues_update_version ON queues;
+DROP TRIGGER IF EXISTS nodes_insert_version ON nodes;
+DROP TRIGGER IF EXISTS nodes_update_version ON nodes;
+DROP FUNCTION IF EXISTS record_row_version;
+DROP TABLE IF EXISTS application_versions;
+DROP TABLE IF EXISTS queue_versions;
+DROP TABLE IF EXISTS node_versions;
@@ -0,0 +1,75 @@
+-- Create the versions tables, which keep every version of the application, queue and node rows.
+-- A version is valid from valid_from_nano (inclusive) until valid_to_nano (exclusive).
+-- The current version of a row does not have a valid_to_nano.
+CREATE TABLE application_versions(
+    id BIGSERIAL,
+    object_id TEXT NOT NULL, -- id of the application
+    valid_from_nano BIGINT NOT NULL,
+    valid_to_nano BIGINT,
+    data JSONB NOT NULL, -- snapshot of the application row
+    PRIMARY KEY (id)
+);
+CREATE INDEX idx_application_versions_object_id ON application_versions(object_id, valid_from_nano);
+
+CREATE TABLE queue_versions(
+    id BIGSERIAL,
+    object_id TEXT NOT NULL, -- id of the queue
+    valid_from_nano BIGINT NOT NULL,
+    valid_to_nano BIGINT,
+    data JSONB NOT NULL, -- snapshot of the queue row
+    PRIMARY KEY (id)
+);
+CREATE INDEX idx_queue_versions_object_id ON queue_versions(object_id, valid_from_nano);
+
+CREATE TABLE node_versions(
+    id BIGSERIAL,
+    object_id TEXT NOT NULL, -- id of the node
+    valid_from_nano BIGINT NOT NULL,
+    valid_to_nano BIGINT,
+    data JSONB NOT NULL, -- snapshot of the node row
+    PRIMARY KEY (id)
+);
+CREATE INDEX idx_node_versions_object_id ON node_versions(object_id, valid_from_nano);
+
+-- record_row_version closes the current version of the row and records the new one
+-- in the versions table passed as the first trigger argument.
+-- The first version of a row is valid from its creation, the following ones from the time of the update.
+CREATE FUNCTION record_row_version() RETURNS TRIGGER AS $$
+DECLARE
+    valid_from BIGINT;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        valid_from := NEW.created_at_nano;
+    ELSE
+        valid_from := (EXTRACT(EPOCH FROM clock_timestamp()) * 1000000000)::BIGINT;
+        EXECUTE format('UPDATE %I SET valid_to_nano = $1 WHERE object_id = $2 AND valid_to_nano IS NULL', TG_ARGV[0])
+        USING valid_from, OLD.id;
+    END IF;
+    EXECUTE format('INSERT INTO %I (object_id, valid_from_nano, data) VALUES ($1, $2, $3)', TG_ARGV[0])
+    USING NEW.id, valid_from, to_jsonb(NEW);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER applications_insert_version AFTER INSERT ON applications
+    FOR EACH ROW EXECUTE FUNCTION record_row_version('application_versions');
+CREATE TRIGGER applications_update_version AFTER UPDATE ON applications
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*) EXECUTE FUNCTION record_row_version('application_versions');
+
+CREATE TRIGGER queues_insert_version AFTER INSERT ON queues
+    FOR EACH ROW EXECUTE FUNCTION record_row_version('queue_versions');
+CREATE TRIGGER queues_update_version AFTER UPDATE ON queues
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*) EXECUTE FUNCTION record_row_version('queue_versions');
+
+CREATE TRIGGER nodes_insert_version AFTER INSERT ON nodes
+    FOR EACH ROW EXECUTE FUNCTION record_row_version('node_versions');
+CREATE TRIGGER nodes_update_version AFTER UPDATE ON nodes
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*) EXECUTE FUNCTION record_row_version('node_versions');
+
+-- Record the existing rows as their first version
+INSERT INTO application_versions(object_id, valid_from_nano, data)
+SELECT id, created_at_nano, to_jsonb(applications) FROM applications;
+INSERT INTO queue_versions(object_id, valid_from_nano, data)
+SELECT id, created_at_nano, to_jsonb(queues) FROM queues;
+INSERT INTO node_versions(object_id, valid_from_nano, data)
+SELECT id, created_at_nano, to_jsonb(nodes) FROM nodes;
@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS idx_applications_submission_time;
+DROP INDEX IF EXISTS idx_applications_finished_time;
+DROP INDEX IF EXISTS idx_applications_app_id;
+DROP INDEX IF EXISTS idx_applications_user;
+DROP INDEX IF EXISTS idx_applications_state;
+DROP INDEX IF EXISTS idx_applications_used_memory;
+DROP INDEX IF EXISTS idx_applications_used_vcore;
+DROP INDEX IF EXISTS idx_applications_partition_queue_submission_time;
+DROP INDEX IF EXISTS idx_nodes_partition_node_id;
+DROP INDEX IF EXISTS idx_nodes_host_name;
+DROP INDEX IF EXISTS idx_nodes_available_memory;
+DROP INDEX IF EXISTS idx_nodes_available_vcore;
+DROP INDEX IF EXISTS idx_nodes_allocated_memory;
+DROP INDEX IF EXISTS idx_nodes_allocated_vcore;
+DROP INDEX IF EXISTS idx_history_type_timestamp;
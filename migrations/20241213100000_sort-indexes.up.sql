@@ -0,0 +1,21 @@
+-- Indexes for the sort keys of the list endpoints.
+-- The expressions must match the sort expressions of the repository (see repository.SortFields),
+-- otherwise they are not used by the query planner.
+
+CREATE INDEX idx_applications_submission_time ON applications((COALESCE(submission_time, 0)), id);
+CREATE INDEX idx_applications_finished_time ON applications((COALESCE(finished_time, 0)), id);
+CREATE INDEX idx_applications_app_id ON applications(app_id, id);
+CREATE INDEX idx_applications_user ON applications((COALESCE("user", '')), id);
+CREATE INDEX idx_applications_state ON applications((COALESCE(state, '')), id);
+CREATE INDEX idx_applications_used_memory ON applications((COALESCE((used_resource->>'memory')::BIGINT, 0)), id);
+CREATE INDEX idx_applications_used_vcore ON applications((COALESCE((used_resource->>'vcore')::BIGINT, 0)), id);
+CREATE INDEX idx_applications_partition_queue_submission_time ON applications(partition_id, queue_id, (COALESCE(submission_time, 0)), id);
+
+CREATE INDEX idx_nodes_partition_node_id ON nodes(partition_id, node_id, id);
+CREATE INDEX idx_nodes_host_name ON nodes(host_name, id);
+CREATE INDEX idx_nodes_available_memory ON nodes((COALESCE((available->>'memory')::BIGINT, 0)), id);
+CREATE INDEX idx_nodes_available_vcore ON nodes((COALESCE((available->>'vcore')::BIGINT, 0)), id);
+CREATE INDEX idx_nodes_allocated_memory ON nodes((COALESCE((allocated->>'memory')::BIGINT, 0)), id);
+CREATE INDEX idx_nodes_allocated_vcore ON nodes((COALESCE((allocated->>'vcore')::BIGINT, 0)), id);
+
+CREATE INDEX idx_history_type_timestamp ON history(history_type, timestamp, id);
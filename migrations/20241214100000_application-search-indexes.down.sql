@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_applications_app_id_pattern;
+DROP INDEX IF EXISTS idx_applications_queue_name_pattern;
+DROP INDEX IF EXISTS idx_applications_partition;
+DROP INDEX IF EXISTS idx_applications_groups;
@@ -0,0 +1,7 @@
+-- Indexes for the filters of the application search.
+-- text_pattern_ops indexes support the prefix searches (LIKE 'prefix%') of the application ID and the queue subtree.
+
+CREATE INDEX idx_applications_app_id_pattern ON applications(app_id text_pattern_ops);
+CREATE INDEX idx_applications_queue_name_pattern ON applications(queue_name text_pattern_ops);
+CREATE INDEX idx_applications_partition ON applications(partition);
+CREATE INDEX idx_applications_groups ON applications USING GIN (groups);
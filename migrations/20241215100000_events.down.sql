@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_applications_allocations;
+DROP TABLE IF EXISTS events;
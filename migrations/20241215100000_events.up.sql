@@ -0,0 +1,18 @@
+-- Create events table, which stores the events of the YuniKorn event stream
+CREATE TABLE events(
+    id BIGSERIAL,
+    timestamp_nano BIGINT NOT NULL,
+    type TEXT NOT NULL,
+    object_id TEXT NOT NULL,
+    reference_id TEXT,
+    change_type TEXT NOT NULL,
+    change_detail TEXT NOT NULL,
+    message TEXT,
+    resource JSONB,
+    PRIMARY KEY (id)
+);
+CREATE INDEX idx_events_object ON events(type, object_id, timestamp_nano);
+CREATE INDEX idx_events_timestamp ON events(timestamp_nano);
+
+-- Index the allocations of the applications by their nodes, for the running applications of the node timelines.
+CREATE INDEX idx_applications_allocations ON applications USING GIN (allocations jsonb_path_ops);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_nodes_attributes;
+DROP INDEX IF EXISTS idx_nodes_capacity;
+DROP INDEX IF EXISTS idx_nodes_available;
+DROP INDEX IF EXISTS idx_nodes_allocated;
+
+DROP INDEX IF EXISTS idx_applications_used_resource;
+DROP INDEX IF EXISTS idx_applications_pending_resource;
+DROP INDEX IF EXISTS idx_applications_max_used_resource;
@@ -0,0 +1,11 @@
+-- GIN indexes for the resource filters of the list endpoints (see repository.ResourceFilterFields).
+-- They are used to find the rows which have a resource ('?' operator) or an attribute value ('@>' operator).
+
+CREATE INDEX idx_nodes_attributes ON nodes USING GIN (attributes);
+CREATE INDEX idx_nodes_capacity ON nodes USING GIN (capacity);
+CREATE INDEX idx_nodes_available ON nodes USING GIN (available);
+CREATE INDEX idx_nodes_allocated ON nodes USING GIN (allocated);
+
+CREATE INDEX idx_applications_used_resource ON applications USING GIN (used_resource);
+CREATE INDEX idx_applications_pending_resource ON applications USING GIN (pending_resource);
+CREATE INDEX idx_applications_max_used_resource ON applications USING GIN (max_used_resource);
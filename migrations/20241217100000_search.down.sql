@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS idx_applications_app_id_trgm;
+DROP INDEX IF EXISTS idx_applications_user_trgm;
+DROP INDEX IF EXISTS idx_applications_queue_name_trgm;
+DROP INDEX IF EXISTS idx_applications_rejected_message_trgm;
+
+DROP INDEX IF EXISTS idx_queues_queue_name_trgm;
+
+DROP INDEX IF EXISTS idx_nodes_node_id_trgm;
+DROP INDEX IF EXISTS idx_nodes_host_name_trgm;
+DROP INDEX IF EXISTS idx_nodes_rack_name_trgm;
+DROP INDEX IF EXISTS idx_nodes_attributes_trgm;
+
+-- The pg_trgm extension is not dropped, since it may have been installed before or be used by other objects.
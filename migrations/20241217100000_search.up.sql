@@ -0,0 +1,16 @@
+-- Trigram indexes for the search endpoint (see repository.Search).
+-- They support both the substring (ILIKE '%query%') and the word similarity ('<%') conditions of the search queries.
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX idx_applications_app_id_trgm ON applications USING GIN (app_id gin_trgm_ops);
+CREATE INDEX idx_applications_user_trgm ON applications USING GIN ("user" gin_trgm_ops);
+CREATE INDEX idx_applications_queue_name_trgm ON applications USING GIN (queue_name gin_trgm_ops);
+CREATE INDEX idx_applications_rejected_message_trgm ON applications USING GIN (rejected_message gin_trgm_ops);
+
+CREATE INDEX idx_queues_queue_name_trgm ON queues USING GIN (queue_name gin_trgm_ops);
+
+CREATE INDEX idx_nodes_node_id_trgm ON nodes USING GIN (node_id gin_trgm_ops);
+CREATE INDEX idx_nodes_host_name_trgm ON nodes USING GIN (host_name gin_trgm_ops);
+CREATE INDEX idx_nodes_rack_name_trgm ON nodes USING GIN (rack_name gin_trgm_ops);
+CREATE INDEX idx_nodes_attributes_trgm ON nodes USING GIN ((attributes::TEXT) gin_trgm_ops);
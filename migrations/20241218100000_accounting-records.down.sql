@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS accounting_records_immutable ON accounting_records;
+DROP FUNCTION IF EXISTS reject_modification;
+DROP TABLE IF EXISTS accounting_records;
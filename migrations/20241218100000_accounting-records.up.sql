@@ -0,0 +1,40 @@
+-- Create the accounting_records table, which stores an immutable summary of every application
+-- when it completes (see model.AccountingRecord). The id is the id of the application,
+-- so an application is only recorded once.
+CREATE TABLE accounting_records(
+    id TEXT NOT NULL,
+    app_id TEXT NOT NULL,
+    partition_id TEXT NOT NULL,
+    partition TEXT NOT NULL,
+    queue_id TEXT,
+    queue_name TEXT NOT NULL,
+    "user" TEXT,
+    groups TEXT[],
+    state TEXT NOT NULL,
+    rejected_message TEXT,
+    submission_time BIGINT NOT NULL,
+    start_time BIGINT,
+    finished_time BIGINT NOT NULL,
+    wait_time BIGINT NOT NULL,
+    run_time BIGINT NOT NULL,
+    peak_resource JSONB,
+    total_resource JSONB,
+    allocations BIGINT NOT NULL,
+    preemptions BIGINT NOT NULL,
+    recorded_at_nano BIGINT NOT NULL,
+    PRIMARY KEY (id)
+);
+CREATE INDEX idx_accounting_records_finished_time ON accounting_records(finished_time, id);
+CREATE INDEX idx_accounting_records_user ON accounting_records("user", finished_time);
+CREATE INDEX idx_accounting_records_queue_name ON accounting_records(queue_name, finished_time);
+CREATE INDEX idx_accounting_records_groups ON accounting_records USING GIN (groups);
+
+-- reject_modification keeps the accounting records immutable.
+CREATE FUNCTION reject_modification() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'rows of % are immutable', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER accounting_records_immutable BEFORE UPDATE OR DELETE ON accounting_records
+    FOR EACH ROW EXECUTE FUNCTION reject_modification();
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS preemptions;
+ALTER TABLE applications DROP COLUMN IF EXISTS preempted_resource;
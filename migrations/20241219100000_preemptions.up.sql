@@ -0,0 +1,24 @@
+-- Keep the preempted resources of the applications, which are tracked by YuniKorn in resource-seconds per instance type
+ALTER TABLE applications ADD COLUMN preempted_resource JSONB;
+
+-- Create preemptions table, which records every preempted allocation.
+-- A preemption is recorded from the ALLOC_PREEMPT event of the allocation or, if the event was missed,
+-- from the preempted flag of the allocation during a sync, in which case the timestamp is the time of the sync.
+-- The preemptor is inferred from the ask which triggered preemption in the partition of the victim, if any.
+CREATE TABLE preemptions(
+    id BIGSERIAL,
+    timestamp_nano BIGINT NOT NULL,
+    source TEXT NOT NULL, -- event or sync
+    app_id TEXT NOT NULL, -- applicationID of the victim
+    allocation_key TEXT NOT NULL,
+    partition TEXT NOT NULL,
+    queue_name TEXT NOT NULL,
+    "user" TEXT,
+    resource JSONB,
+    preemptor_app_id TEXT,
+    preemptor_allocation_key TEXT,
+    PRIMARY KEY (id),
+    UNIQUE (app_id, allocation_key)
+);
+CREATE INDEX idx_preemptions_timestamp ON preemptions(timestamp_nano);
+CREATE INDEX idx_preemptions_preemptor ON preemptions(preemptor_app_id);
@@ -0,0 +1,19 @@
+-- normalize_message strips the identifiers and numbers of a message, so that the messages of the applications
+-- which failed for the same reason can be grouped (see repository.GetDiagnostics).
+-- UUIDs and words which contain digits (e.g. application IDs or pod names) are replaced by <id>,
+-- and numbers by <n>, before the whitespace is collapsed.
+CREATE FUNCTION normalize_message(message TEXT) RETURNS TEXT AS $$
+    SELECT trim(regexp_replace(
+        regexp_replace(
+            regexp_replace(
+                regexp_replace(
+                    message,
+                    '[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}', '<id>', 'g'
+                ),
+                '\m[0-9]+(\.[0-9]+)?\M', '<n>', 'g'
+            ),
+            '\m[A-Za-z0-9_-]*[0-9][A-Za-z0-9_-]*\M', '<id>', 'g'
+        ),
+        '\s+', ' ', 'g'
+    ))
+$$ LANGUAGE SQL IMMUTABLE;
@@ -0,0 +1,19 @@
+-- Create reservations table, which records every episode during which a node was reserved for an ask of an application.
+-- The episodes are recorded from the reservations of the applications and of the nodes, whichever is updated first.
+-- An episode is open until its reservation is gone, when its outcome is recorded: allocated, unreserved or cancelled.
+CREATE TABLE reservations(
+    id BIGSERIAL,
+    partition_id TEXT NOT NULL,
+    app_id TEXT NOT NULL,
+    allocation_key TEXT NOT NULL,
+    node_id TEXT NOT NULL,
+    start_time_nano BIGINT NOT NULL,
+    end_time_nano BIGINT,
+    outcome TEXT,
+    PRIMARY KEY (id)
+);
+-- There is at most one open episode per reservation.
+CREATE UNIQUE INDEX idx_reservations_open ON reservations(partition_id, app_id, allocation_key, node_id) WHERE end_time_nano IS NULL;
+CREATE INDEX idx_reservations_app_id ON reservations(app_id);
+CREATE INDEX idx_reservations_node_id ON reservations(node_id);
+CREATE INDEX idx_reservations_start_time ON reservations(start_time_nano);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS history_rollups;
+DROP FUNCTION IF EXISTS history_bucket(BIGINT, BIGINT);
@@ -0,0 +1,25 @@
+-- history_bucket returns the start of the bucket of the width in seconds which contains the timestamp,
+-- both in nanoseconds since the epoch. The buckets are aligned to the epoch.
+CREATE FUNCTION history_bucket(timestamp_nano BIGINT, width_seconds BIGINT) RETURNS BIGINT AS $$
+    SELECT (EXTRACT(EPOCH FROM date_bin(
+        make_interval(secs => width_seconds),
+        to_timestamp(timestamp_nano / 1000000000),
+        TIMESTAMPTZ 'epoch'
+    )) * 1000000000)::BIGINT
+$$ LANGUAGE SQL IMMUTABLE;
+
+-- Create history_rollups table, which keeps the hourly and daily aggregates of the history table, so that long
+-- windows of the history are downsampled without scanning its rows. The aggregates are computed incrementally
+-- by the rollups of the server, which record the period they cover.
+CREATE TABLE history_rollups(
+    history_type history_type NOT NULL,
+    width_seconds BIGINT NOT NULL, -- 3600 or 86400
+    bucket BIGINT NOT NULL, -- start of the bucket in nanoseconds since the epoch
+    count BIGINT NOT NULL,
+    sum BIGINT NOT NULL,
+    min BIGINT NOT NULL,
+    max BIGINT NOT NULL,
+    last BIGINT NOT NULL, -- total_number of the most recent row
+    last_timestamp BIGINT NOT NULL,
+    PRIMARY KEY (history_type, width_seconds, bucket)
+);
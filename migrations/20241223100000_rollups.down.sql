@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS node_utilization_rollups;
+DROP TABLE IF EXISTS application_state_rollups;
+DROP TABLE IF EXISTS usage_rollups;
+DROP TABLE IF EXISTS rollup_watermarks;
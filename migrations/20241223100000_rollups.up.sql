@@ -0,0 +1,52 @@
+-- Create rollup_watermarks table, which records the period [start_nano, watermark_nano) covered by the buckets
+-- of a rollup of a width. The buckets before the watermark are computed, the following ones are not yet.
+CREATE TABLE rollup_watermarks(
+    rollup TEXT NOT NULL, -- history, usage, application_states or node_utilization
+    width_seconds BIGINT NOT NULL, -- 3600 or 86400
+    start_nano BIGINT NOT NULL,
+    watermark_nano BIGINT NOT NULL,
+    updated_at_nano BIGINT NOT NULL,
+    PRIMARY KEY (rollup, width_seconds)
+);
+
+-- Create usage_rollups table, which keeps the resources used by the allocations of the applications of a queue
+-- and a user with the same groups during a bucket. resource_seconds maps a resource to the sum of its quantity
+-- multiplied by the seconds it was allocated.
+CREATE TABLE usage_rollups(
+    width_seconds BIGINT NOT NULL,
+    bucket BIGINT NOT NULL, -- start of the bucket in nanoseconds since the epoch
+    partition_id TEXT NOT NULL,
+    partition TEXT NOT NULL,
+    queue_name TEXT NOT NULL,
+    "user" TEXT NOT NULL, -- empty if the applications have no user
+    groups TEXT[] NOT NULL, -- empty if the user has no groups
+    resource_seconds JSONB NOT NULL,
+    PRIMARY KEY (width_seconds, bucket, partition_id, queue_name, "user", groups)
+);
+CREATE INDEX idx_usage_rollups_queue_name ON usage_rollups(queue_name);
+
+-- Create application_state_rollups table, which keeps the numbers of pending and running applications at the start
+-- of a bucket, and the number of applications which completed during the bucket.
+CREATE TABLE application_state_rollups(
+    width_seconds BIGINT NOT NULL,
+    bucket BIGINT NOT NULL,
+    partition TEXT NOT NULL,
+    queue_name TEXT NOT NULL,
+    "user" TEXT NOT NULL,
+    pending BIGINT NOT NULL,
+    running BIGINT NOT NULL,
+    completed BIGINT NOT NULL,
+    PRIMARY KEY (width_seconds, bucket, partition, queue_name, "user")
+);
+
+-- Create node_utilization_rollups table, which keeps the capacity of a node during a bucket and the resources which
+-- were allocated on it, with the same resource_seconds as usage_rollups.
+CREATE TABLE node_utilization_rollups(
+    width_seconds BIGINT NOT NULL,
+    bucket BIGINT NOT NULL,
+    node_id TEXT NOT NULL,
+    partition TEXT NOT NULL,
+    capacity JSONB NOT NULL,
+    allocated_seconds JSONB NOT NULL,
+    PRIMARY KEY (width_seconds, bucket, node_id)
+);